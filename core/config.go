@@ -1,20 +1,78 @@
 package core
 
 import (
+	"fmt"
+	"net"
+	"net/http"
+	neturl "net/url"
 	"strconv"
+	"strings"
+
+	"github.com/eapache/go-resiliency/retrier"
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	proxySchemeHTTP  = "http"
+	proxySchemeHTTPS = "https"
 )
 
 type RetryPolicy struct {
 	Type          string `json:"type,omitempty"`
 	Interval      int64  `json:"interval" validate:"gte=0"`
 	MaxRetryCount int    `json:"maxRetryCount" validate:"gte=0"`
+	// Jitter randomizes every delay of an exponential backoff by up to the given fraction of the delay on every
+	// retry, e.g. 0.5 turns a delay of 100ms into a delay between 50ms and 150ms. It spreads the retries of clients
+	// which failed at the same time.
+	Jitter float64 `json:"jitter,omitempty" validate:"gte=0,lte=1"`
 }
 
 // Registry contains Cloudogu EcoSystem registration details.
 type Registry struct {
-	Type        string      `validate:"eq=etcd"`
-	Endpoints   []string    `validate:"required,min=1"`
+	Type string `validate:"oneof=etcd etcdv3 memory filesystem"`
+	// Endpoints contains the urls of the registry or the directory of the filesystem registry. They are ignored by
+	// the memory registry.
+	Endpoints   []string    `validate:"required_unless=Type memory,omitempty,min=1"`
 	RetryPolicy RetryPolicy `json:"retryPolicy,omitempty"`
+	// ReadRetryPolicy overrides the RetryPolicy for reading requests.
+	ReadRetryPolicy *RetryPolicy `json:"readRetryPolicy,omitempty"`
+	// WriteRetryPolicy overrides the RetryPolicy for requests which create or change keys.
+	WriteRetryPolicy *RetryPolicy `json:"writeRetryPolicy,omitempty"`
+	// DeleteRetryPolicy overrides the RetryPolicy for requests which delete keys.
+	DeleteRetryPolicy *RetryPolicy `json:"deleteRetryPolicy,omitempty"`
+	// RetryClassifier decides which failed requests are retried. If it is nil, transient errors like a lost
+	// connection are retried and permanent errors like a missing permission are not.
+	RetryClassifier retrier.Classifier `json:"-"`
+	// Metrics records the durations and retries of the requests to the registry if it is set.
+	Metrics MetricsRecorder `json:"-"`
+	// TLS configures the encrypted connection to the registry.
+	TLS RegistryTLS `json:"tls,omitempty"`
+	// Username is used to authenticate at a registry with enabled authentication, e.g. etcd with RBAC.
+	Username string `json:"username,omitempty" validate:"required_with=Password"`
+	// Password is the password of the Username.
+	Password string `json:"password,omitempty" validate:"required_with=Username"`
+}
+
+// RegistryTLS contains the certificates of an encrypted connection to the registry. The connection is encrypted if
+// one of the files is configured or an endpoint uses the https scheme.
+type RegistryTLS struct {
+	// CAFile is the path to a PEM encoded certificate authority which is used to verify the certificate of the
+	// registry. The certificate authorities of the system are used if it is empty.
+	CAFile string `json:"caFile,omitempty"`
+	// CertFile is the path to a PEM encoded client certificate which is used to authenticate at the registry.
+	CertFile string `json:"certFile,omitempty" validate:"required_with=KeyFile"`
+	// KeyFile is the path to the PEM encoded private key of the client certificate.
+	KeyFile string `json:"keyFile,omitempty" validate:"required_with=CertFile"`
+	// ServerName overrides the host name which is used to verify the certificate of the registry.
+	ServerName string `json:"serverName,omitempty"`
+	// InsecureSkipVerify disables the verification of the certificate of the registry. It should only be used for
+	// tests.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// IsConfigured returns true if any TLS option is set.
+func (tls RegistryTLS) IsConfigured() bool {
+	return tls != RegistryTLS{}
 }
 
 // Remote contains dogu registry configuration details.
@@ -27,6 +85,9 @@ type Remote struct {
 	AnonymousAccess        bool        `json:",omitempty"`
 	Insecure               bool        `json:",omitempty"`
 	RetryPolicy            RetryPolicy `json:"retryPolicy,omitempty"`
+	// Metrics records the durations and retries of the requests to the remote and the fallbacks to the cache if it
+	// is set.
+	Metrics MetricsRecorder `json:"-"`
 }
 
 // ProxySettings contains the settings for http proxy
@@ -36,11 +97,119 @@ type ProxySettings struct {
 	Port     int    `json:",omitempty"`
 	Username string `json:",omitempty"`
 	Password string `json:",omitempty"`
+	// Scheme is the scheme of the connection to the proxy, either http or https. It is http if it is empty.
+	Scheme string `json:",omitempty"`
+	// NoProxy contains the hosts which are accessed without the proxy. Entries can be host names, domains with a
+	// leading dot, IP addresses and CIDR ranges like in the NO_PROXY environment variable.
+	NoProxy []string `json:",omitempty"`
+}
+
+// Validate checks if the settings describe a usable proxy. Disabled settings are always valid.
+func (proxy ProxySettings) Validate() error {
+	if !proxy.Enabled {
+		return nil
+	}
+
+	if proxy.Scheme != "" && proxy.Scheme != proxySchemeHTTP && proxy.Scheme != proxySchemeHTTPS {
+		return fmt.Errorf("unsupported proxy scheme %s, use %s or %s", proxy.Scheme, proxySchemeHTTP, proxySchemeHTTPS)
+	}
+
+	if proxy.Server == "" {
+		return fmt.Errorf("proxy server is missing")
+	}
+	parsedURL, err := neturl.Parse(proxy.scheme() + "://" + proxy.Server)
+	if err != nil || parsedURL.Host != proxy.Server || parsedURL.Port() != "" || parsedURL.Hostname() == "" {
+		return fmt.Errorf("invalid proxy server %s, expected a host name or ip address without scheme and port", proxy.Server)
+	}
+
+	if proxy.Port < 1 || proxy.Port > 65535 {
+		return fmt.Errorf("invalid proxy port %d, expected a port between 1 and 65535", proxy.Port)
+	}
+
+	if proxy.Password != "" && proxy.Username == "" {
+		return fmt.Errorf("proxy password is set without username")
+	}
+
+	for _, host := range proxy.NoProxy {
+		if host == "" || strings.ContainsAny(host, ", \t\n") {
+			return fmt.Errorf("invalid no proxy host '%s'", host)
+		}
+	}
+
+	return nil
 }
 
-// CreateURL creates a proxy http url
+// CreateURL creates the url of the proxy without the credentials, e.g. for log messages.
 func (proxy ProxySettings) CreateURL() string {
-	return "http://" + proxy.Server + ":" + strconv.Itoa(proxy.Port)
+	return proxy.scheme() + "://" + proxy.hostPort()
+}
+
+// URL creates the url of the proxy including the credentials.
+func (proxy ProxySettings) URL() *neturl.URL {
+	proxyURL := &neturl.URL{
+		Scheme: proxy.scheme(),
+		Host:   proxy.hostPort(),
+	}
+	if proxy.Username != "" {
+		proxyURL.User = neturl.UserPassword(proxy.Username, proxy.Password)
+	}
+	return proxyURL
+}
+
+// EnvironmentVariables converts the settings to the environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY,
+// which are understood by most tools, in upper and lower case. The variables contain the credentials of the proxy.
+// Disabled settings result in no variables.
+func (proxy ProxySettings) EnvironmentVariables() map[string]string {
+	if !proxy.Enabled {
+		return map[string]string{}
+	}
+
+	proxyURL := proxy.URL().String()
+	variables := map[string]string{
+		"HTTP_PROXY":  proxyURL,
+		"HTTPS_PROXY": proxyURL,
+	}
+	if len(proxy.NoProxy) > 0 {
+		variables["NO_PROXY"] = strings.Join(proxy.NoProxy, ",")
+	}
+
+	for name, value := range variables {
+		variables[strings.ToLower(name)] = value
+	}
+	return variables
+}
+
+// ProxyFunc returns a function which selects the proxy of a request like the environment variables of
+// EnvironmentVariables, e.g. for http.Transport. Requests to hosts of NoProxy and to localhost are not proxied.
+func (proxy ProxySettings) ProxyFunc() func(*http.Request) (*neturl.URL, error) {
+	if !proxy.Enabled {
+		return func(*http.Request) (*neturl.URL, error) {
+			return nil, nil
+		}
+	}
+
+	proxyURL := proxy.URL().String()
+	config := &httpproxy.Config{
+		HTTPProxy:  proxyURL,
+		HTTPSProxy: proxyURL,
+		NoProxy:    strings.Join(proxy.NoProxy, ","),
+	}
+	proxyFunc := config.ProxyFunc()
+	return func(request *http.Request) (*neturl.URL, error) {
+		return proxyFunc(request.URL)
+	}
+}
+
+// hostPort joins the server and the port. IPv6 addresses of the server may be enclosed in brackets.
+func (proxy ProxySettings) hostPort() string {
+	return net.JoinHostPort(strings.Trim(proxy.Server, "[]"), strconv.Itoa(proxy.Port))
+}
+
+func (proxy ProxySettings) scheme() string {
+	if proxy.Scheme == "" {
+		return proxySchemeHTTP
+	}
+	return proxy.Scheme
 }
 
 // Credentials for a remote system
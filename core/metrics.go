@@ -0,0 +1,29 @@
+package core
+
+import (
+	"time"
+)
+
+// CacheEvent describes how a cache handled a read.
+type CacheEvent string
+
+const (
+	// CacheHit is used for reads which were answered by the cache.
+	CacheHit CacheEvent = "hit"
+	// CacheMiss is used for reads which were not answered by the cache and passed on to their source.
+	CacheMiss CacheEvent = "miss"
+	// CacheFallback is used for reads which failed at their source and were answered by the cache instead.
+	CacheFallback CacheEvent = "fallback"
+)
+
+// MetricsRecorder records metrics about the requests of the registry and the remote dogu registry. Implementations
+// have to be safe for concurrent use. The package metrics contains an implementation for Prometheus.
+type MetricsRecorder interface {
+	// ObserveRequest records the duration of a finished request, including its retries, and whether it failed. The
+	// component names the source of the request, e.g. etcd or remote, the operation names the request, e.g. get.
+	ObserveRequest(component string, operation string, duration time.Duration, err error)
+	// CountRetry counts a retry of the operation of the component, e.g. a repeated request or a reconnected watch.
+	CountRetry(component string, operation string)
+	// CountCacheEvent counts how the cache of the component handled a read.
+	CountCacheEvent(component string, event CacheEvent)
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"github.com/eapache/go-resiliency/retrier"
+	"math/rand/v2"
 	"time"
 )
 
@@ -13,6 +14,8 @@ const (
 
 var log = GetLogger()
 
+// GetBackoff returns the delays between the retries of the given policy. The delays are not randomized, the jitter of
+// the policy is applied on every retry, e.g. with SetJitter of the retrier and the value of GetJitter.
 func GetBackoff(policy RetryPolicy) ([]time.Duration, error) {
 	if policy.Interval < 0 {
 		return nil, fmt.Errorf("the retry interval needs to be greater or equal to 0: given '%d'", policy.Interval)
@@ -20,6 +23,9 @@ func GetBackoff(policy RetryPolicy) ([]time.Duration, error) {
 	if policy.MaxRetryCount < 0 {
 		return nil, fmt.Errorf("the max retry count needs to be greater or equal to 0: given '%d'", policy.MaxRetryCount)
 	}
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		return nil, fmt.Errorf("the jitter needs to be between 0 and 1: given '%g'", policy.Jitter)
+	}
 	switch policy.Type {
 	case constantPolicyType:
 		return retrier.ConstantBackoff(policy.MaxRetryCount, time.Duration(policy.Interval)*time.Millisecond), nil
@@ -30,3 +36,23 @@ func GetBackoff(policy RetryPolicy) ([]time.Duration, error) {
 		return retrier.ExponentialBackoff(policy.MaxRetryCount, time.Duration(policy.Interval)*time.Millisecond), nil
 	}
 }
+
+// GetJitter returns the fraction by which the delays of the backoff of the given policy are randomized on every
+// retry. The delays of a constant backoff are not randomized.
+func GetJitter(policy RetryPolicy) float64 {
+	if policy.Type == constantPolicyType {
+		return 0
+	}
+	return policy.Jitter
+}
+
+// RandomizeDelay randomizes the delay by up to the given fraction of the delay like a retrier with the jitter, e.g.
+// for retries which are not run by a retrier.
+func RandomizeDelay(delay time.Duration, jitter float64) time.Duration {
+	maxJitter := int64(float64(delay) * jitter)
+	if maxJitter <= 0 {
+		return delay
+	}
+
+	return delay + time.Duration(rand.Int64N(2*maxJitter+1)-maxJitter)
+}
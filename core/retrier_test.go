@@ -80,4 +80,57 @@ func TestGetBackoff(t *testing.T) {
 		assert.ErrorContains(t, err, expectedErrorMessage)
 		assert.Empty(t, backoff)
 	})
+	t.Run("should return an error if the configured jitter is greater than 1", func(t *testing.T) {
+		configWithInvalidJitter := RetryPolicy{
+			Interval:      10,
+			MaxRetryCount: 2,
+			Jitter:        1.5,
+		}
+		backoff, err := GetBackoff(configWithInvalidJitter)
+
+		require.Error(t, err)
+		expectedErrorMessage := "the jitter needs to be between 0 and 1: given '1.5'"
+		assert.ErrorContains(t, err, expectedErrorMessage)
+		assert.Empty(t, backoff)
+	})
+	t.Run("should not randomize the backoff", func(t *testing.T) {
+		configWithJitter := RetryPolicy{
+			Type:          exponentialPolicyType,
+			Interval:      100,
+			MaxRetryCount: 3,
+			Jitter:        0.5,
+		}
+
+		backoff, err := GetBackoff(configWithJitter)
+
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}, backoff)
+	})
+}
+
+func TestGetJitter(t *testing.T) {
+	t.Run("should return jitter of exponential backoff", func(t *testing.T) {
+		assert.Equal(t, 0.5, GetJitter(RetryPolicy{Type: exponentialPolicyType, Jitter: 0.5}))
+		assert.Equal(t, 0.5, GetJitter(RetryPolicy{Jitter: 0.5}))
+	})
+	t.Run("should not randomize constant backoff", func(t *testing.T) {
+		assert.Equal(t, 0.0, GetJitter(RetryPolicy{Type: constantPolicyType, Jitter: 0.5}))
+	})
+}
+
+func TestRandomizeDelay(t *testing.T) {
+	t.Run("should randomize delay on every call", func(t *testing.T) {
+		delays := map[time.Duration]bool{}
+		for i := 0; i < 100; i++ {
+			delay := RandomizeDelay(100*time.Millisecond, 0.5)
+
+			assert.GreaterOrEqual(t, delay, 50*time.Millisecond)
+			assert.LessOrEqual(t, delay, 150*time.Millisecond)
+			delays[delay] = true
+		}
+		assert.Greater(t, len(delays), 1)
+	})
+	t.Run("should not change delay without jitter", func(t *testing.T) {
+		assert.Equal(t, 100*time.Millisecond, RandomizeDelay(100*time.Millisecond, 0))
+	})
 }
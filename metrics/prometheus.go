@@ -0,0 +1,89 @@
+// Package metrics records the metrics of the registry and the remote dogu registry with Prometheus. Only programs
+// which import this package depend on Prometheus.
+package metrics
+
+import (
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "cesapp"
+
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+)
+
+// PrometheusRecorder is a core.MetricsRecorder which records the metrics as Prometheus counters and histograms:
+//   - cesapp_requests_total counts the requests by component, operation and result
+//   - cesapp_request_duration_seconds observes the duration of the requests by component and operation
+//   - cesapp_retries_total counts the retries by component and operation
+//   - cesapp_cache_events_total counts the hits, misses and fallbacks of the caches by component and event
+type PrometheusRecorder struct {
+	requests    *prometheus.CounterVec
+	durations   *prometheus.HistogramVec
+	retries     *prometheus.CounterVec
+	cacheEvents *prometheus.CounterVec
+}
+
+// NewPrometheusRecorder creates the metrics and registers them at the given registerer, e.g.
+// prometheus.DefaultRegisterer. Pass the recorder as Metrics of the core.Registry, core.Remote or
+// registry.CacheOptions to record their metrics.
+func NewPrometheusRecorder(registerer prometheus.Registerer) (*PrometheusRecorder, error) {
+	recorder := &PrometheusRecorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Number of finished requests by component, operation and result.",
+		}, []string{"component", "operation", "result"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the requests including their retries by component and operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"component", "operation"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Number of retried requests and reconnected watches by component and operation.",
+		}, []string{"component", "operation"}),
+		cacheEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_events_total",
+			Help:      "Number of cache hits, misses and fallbacks by component.",
+		}, []string{"component", "event"}),
+	}
+
+	for _, collector := range []prometheus.Collector{recorder.requests, recorder.durations, recorder.retries, recorder.cacheEvents} {
+		err := registerer.Register(collector)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to register metrics")
+		}
+	}
+
+	return recorder, nil
+}
+
+// ObserveRequest counts the request and observes its duration.
+func (recorder *PrometheusRecorder) ObserveRequest(component string, operation string, duration time.Duration, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultError
+	}
+
+	recorder.requests.WithLabelValues(component, operation, result).Inc()
+	recorder.durations.WithLabelValues(component, operation).Observe(duration.Seconds())
+}
+
+// CountRetry counts a retry of the operation.
+func (recorder *PrometheusRecorder) CountRetry(component string, operation string) {
+	recorder.retries.WithLabelValues(component, operation).Inc()
+}
+
+// CountCacheEvent counts how the cache of the component handled a read.
+func (recorder *PrometheusRecorder) CountCacheEvent(component string, event core.CacheEvent) {
+	recorder.cacheEvents.WithLabelValues(component, string(event)).Inc()
+}
@@ -0,0 +1,71 @@
+package metrics_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/cloudogu/cesapp-lib/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPrometheusRecorder(t *testing.T) {
+	t.Run("should register the metrics", func(t *testing.T) {
+		// given
+		registry := prometheus.NewPedanticRegistry()
+
+		// when
+		_, err := metrics.NewPrometheusRecorder(registry)
+
+		// then
+		require.NoError(t, err)
+		count, err := testutil.GatherAndCount(registry)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+	t.Run("should return an error if the metrics are already registered", func(t *testing.T) {
+		// given
+		registry := prometheus.NewRegistry()
+		_, err := metrics.NewPrometheusRecorder(registry)
+		require.NoError(t, err)
+
+		// when
+		_, err = metrics.NewPrometheusRecorder(registry)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to register metrics")
+	})
+}
+
+func TestPrometheusRecorder(t *testing.T) {
+	// given
+	registry := prometheus.NewPedanticRegistry()
+	sut, err := metrics.NewPrometheusRecorder(registry)
+	require.NoError(t, err)
+
+	// when
+	sut.ObserveRequest("etcd", "get", 10*time.Millisecond, nil)
+	sut.ObserveRequest("etcd", "get", 20*time.Millisecond, errors.New("connection refused"))
+	sut.CountRetry("etcd", "get")
+	sut.CountRetry("etcd", "watch")
+	sut.CountCacheEvent("remote", core.CacheFallback)
+
+	// then
+	requests, err := testutil.GatherAndCount(registry, "cesapp_requests_total")
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	durations, err := testutil.GatherAndCount(registry, "cesapp_request_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 1, durations)
+	retries, err := testutil.GatherAndCount(registry, "cesapp_retries_total")
+	require.NoError(t, err)
+	assert.Equal(t, 2, retries)
+	cacheEvents, err := testutil.GatherAndCount(registry, "cesapp_cache_events_total")
+	require.NoError(t, err)
+	assert.Equal(t, 1, cacheEvents)
+}
@@ -0,0 +1,630 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+)
+
+// AuditOperation is the kind of a recorded registry mutation.
+type AuditOperation string
+
+const (
+	// AuditOperationSet is recorded if a key or the state of a dogu was set.
+	AuditOperationSet AuditOperation = "set"
+	// AuditOperationDelete is recorded if a key or the state of a dogu was removed.
+	AuditOperationDelete AuditOperation = "delete"
+	// AuditOperationDeleteRecursive is recorded if a key or directory was removed recursively.
+	AuditOperationDeleteRecursive AuditOperation = "deleteRecursive"
+	// AuditOperationRemoveAll is recorded if all keys of a configuration context were removed.
+	AuditOperationRemoveAll AuditOperation = "removeAll"
+	// AuditOperationRegister is recorded if a dogu was registered.
+	AuditOperationRegister AuditOperation = "register"
+	// AuditOperationEnable is recorded if a version of a dogu was enabled.
+	AuditOperationEnable AuditOperation = "enable"
+	// AuditOperationUnregister is recorded if a dogu was unregistered.
+	AuditOperationUnregister AuditOperation = "unregister"
+	// AuditOperationPruneVersions is recorded if old versions of a dogu were removed.
+	AuditOperationPruneVersions AuditOperation = "pruneVersions"
+	// AuditOperationUnregisterVersion is recorded if a single version of a dogu was removed.
+	AuditOperationUnregisterVersion AuditOperation = "unregisterVersion"
+	// AuditOperationImport is recorded for every key which was created or updated by a snapshot import.
+	AuditOperationImport AuditOperation = "import"
+	// AuditOperationLockAcquire is recorded if a lock was acquired.
+	AuditOperationLockAcquire AuditOperation = "lockAcquire"
+	// AuditOperationLockRelease is recorded if a lock was released.
+	AuditOperationLockRelease AuditOperation = "lockRelease"
+	// AuditOperationLockBreak is recorded if a lock was broken.
+	AuditOperationLockBreak AuditOperation = "lockBreak"
+)
+
+// auditMaskedKeysTimeToLive is the time after which the encrypted global configuration keys of the registered dogus
+// are read again. Changes of the dogu registry through the audited registry invalidate them immediately.
+const auditMaskedKeysTimeToLive = time.Minute
+
+// AuditEntry describes a single mutation of the registry.
+type AuditEntry struct {
+	// Time is the time at which the mutation was finished.
+	Time time.Time `json:"time"`
+	// Actor identifies the caller who changed the registry.
+	Actor string `json:"actor"`
+	// Operation is the kind of the mutation.
+	Operation AuditOperation `json:"operation"`
+	// Key is the full path of the changed key, e.g. /config/_global/fqdn or /dogu_v2/ldap.
+	Key string `json:"key"`
+	// OldValue is the value before the mutation. Values of encrypted configuration fields are masked.
+	OldValue string `json:"oldValue,omitempty"`
+	// NewValue is the value after the mutation. Values of encrypted configuration fields are masked.
+	NewValue string `json:"newValue,omitempty"`
+	// Error is the error message if the mutation failed.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink stores the entries of the audit log.
+type AuditSink interface {
+	// Write stores the entry.
+	Write(entry AuditEntry) error
+}
+
+// AuditOptions configure the audit log of a registry.
+type AuditOptions struct {
+	// Sink stores the entries of the audit log.
+	Sink AuditSink
+	// Actor identifies the caller in all entries. If it is empty, the name of the current operating system user is
+	// used. WithAuditActor overrides the actor for a single request.
+	Actor string
+	// MaskedKeys contains full keys whose values, and the values of all keys below them, are masked in addition to
+	// the encrypted configuration fields of the registered dogus.
+	MaskedKeys []string
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor returns a context which identifies the given caller in the entries of the audit log, e.g. the user
+// of a web request. The context has to be passed to the context-aware variants of the registry operations.
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// auditor records the mutations of a registry in the sink of the options.
+type auditor struct {
+	registry Registry
+	options  AuditOptions
+
+	// globalMaskedKeys caches the encrypted global configuration keys of the registered dogus, so that not every
+	// change of the global configuration reads all dogus.
+	globalMaskedKeysMutex      sync.Mutex
+	globalMaskedKeys           []string
+	globalMaskedKeysExpiration time.Time
+}
+
+func (a *auditor) actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(auditActorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	if a.options.Actor != "" {
+		return a.options.Actor
+	}
+
+	currentUser, err := user.Current()
+	if err != nil {
+		return "unknown"
+	}
+	return currentUser.Username
+}
+
+// record writes the entry to the sink. A failing sink does not fail the already applied mutation, so the error is
+// only logged.
+func (a *auditor) record(ctx context.Context, entry AuditEntry, err error) {
+	entry.Time = time.Now().UTC()
+	entry.Actor = a.actor(ctx)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	if isMaskedKey(entry.Key, a.maskedKeys(ctx, entry.Key)) {
+		entry.OldValue = maskValue(entry.OldValue)
+		entry.NewValue = maskValue(entry.NewValue)
+	}
+
+	sinkErr := a.options.Sink.Write(entry)
+	if sinkErr != nil {
+		core.GetLogger().Warningf("failed to write audit entry for %s of key %s: %v", entry.Operation, entry.Key, sinkErr)
+	}
+}
+
+// maskedKeys returns the masked keys of the options and the keys of the encrypted configuration fields which could
+// contain the given key.
+func (a *auditor) maskedKeys(ctx context.Context, key string) []string {
+	maskedKeys := a.options.MaskedKeys
+
+	if !strings.HasPrefix(key, "/config/") {
+		return maskedKeys
+	}
+
+	contextName := strings.SplitN(strings.TrimPrefix(key, "/config/"), "/", 2)[0]
+	switch contextName {
+	case directoryHost:
+		return maskedKeys
+	case DirectoryGlobal:
+		return append(a.encryptedGlobalKeys(ctx), maskedKeys...)
+	default:
+		dogu, err := a.registry.DoguRegistry().GetContext(ctx, contextName)
+		if err != nil {
+			core.GetLogger().Debugf("could not read dogu %s to mask encrypted configuration: %v", contextName, err)
+			return maskedKeys
+		}
+		return append(EncryptedConfigurationKeys(dogu), maskedKeys...)
+	}
+}
+
+// encryptedGlobalKeys returns the cached encrypted global configuration keys of the registered dogus.
+func (a *auditor) encryptedGlobalKeys(ctx context.Context) []string {
+	a.globalMaskedKeysMutex.Lock()
+	defer a.globalMaskedKeysMutex.Unlock()
+
+	if time.Now().Before(a.globalMaskedKeysExpiration) {
+		return a.globalMaskedKeys
+	}
+
+	dogus, err := a.registry.DoguRegistry().GetAllContext(ctx)
+	if err != nil {
+		core.GetLogger().Warningf("failed to read dogus to mask encrypted global configuration: %v", err)
+		return nil
+	}
+
+	a.globalMaskedKeys = EncryptedConfigurationKeys(dogus...)
+	a.globalMaskedKeysExpiration = time.Now().Add(auditMaskedKeysTimeToLive)
+	return a.globalMaskedKeys
+}
+
+// invalidateEncryptedGlobalKeys makes sure that the encrypted global configuration keys are read again, e.g. after
+// a dogu was registered.
+func (a *auditor) invalidateEncryptedGlobalKeys() {
+	a.globalMaskedKeysMutex.Lock()
+	defer a.globalMaskedKeysMutex.Unlock()
+
+	a.globalMaskedKeysExpiration = time.Time{}
+}
+
+// auditedRegistry records every mutation of the decorated registry in an audit log. Reading operations like GetNode,
+// ExportSnapshot, WaitFor and RootConfig as well as Close are passed through to the decorated registry.
+type auditedRegistry struct {
+	Registry
+	auditor *auditor
+}
+
+// NewAuditedRegistry decorates the registry, so that every mutation of a configuration context, the dogu registry,
+// the state of a dogu, every snapshot import and every acquisition, release and break of a lock is recorded in the
+// sink of the options. Values of encrypted configuration fields of the registered dogus are masked. The entries are
+// written after the mutation, even if it failed. Reading operations, conditional writes which were not applied and
+// the renewals of held locks are not recorded.
+func NewAuditedRegistry(registry Registry, options AuditOptions) Registry {
+	return &auditedRegistry{
+		Registry: registry,
+		auditor:  &auditor{registry: registry, options: options},
+	}
+}
+
+// GlobalConfig returns an audited ConfigurationContext for the global context
+func (ar *auditedRegistry) GlobalConfig() ConfigurationContext {
+	return &auditedConfigurationContext{ar.Registry.GlobalConfig(), "/config/" + DirectoryGlobal, ar.auditor}
+}
+
+// HostConfig returns an audited ConfigurationContext for the host context
+func (ar *auditedRegistry) HostConfig(hostService string) ConfigurationContext {
+	return &auditedConfigurationContext{ar.Registry.HostConfig(hostService), "/config/" + directoryHost + "/" + hostService, ar.auditor}
+}
+
+// DoguConfig returns an audited ConfigurationContext for the given dogu
+func (ar *auditedRegistry) DoguConfig(dogu string) ConfigurationContext {
+	return &auditedConfigurationContext{ar.Registry.DoguConfig(dogu), "/config/" + dogu, ar.auditor}
+}
+
+// BlueprintRegistry returns an audited ConfigurationContext for the blueprint registry
+func (ar *auditedRegistry) BlueprintRegistry() ConfigurationContext {
+	return &auditedConfigurationContext{ar.Registry.BlueprintRegistry(), "/blueprint", ar.auditor}
+}
+
+// State returns an audited state object for the given dogu
+func (ar *auditedRegistry) State(dogu string) State {
+	return &auditedState{ar.Registry.State(dogu), "/state/" + dogu, ar.auditor}
+}
+
+// DoguRegistry returns an audited object which is able to manage dogus
+func (ar *auditedRegistry) DoguRegistry() DoguRegistry {
+	return &auditedDoguRegistry{ar.Registry.DoguRegistry(), "/dogu_v2", ar.auditor}
+}
+
+// Lock returns an audited lock with the given name
+func (ar *auditedRegistry) Lock(name string, options LockOptions) Lock {
+	return &auditedLock{ar.Registry.Lock(name, options), lockKey(name, options), ar.auditor}
+}
+
+// ImportSnapshot imports the snapshot and records every created or updated key
+func (ar *auditedRegistry) ImportSnapshot(snapshot *Snapshot, options SnapshotImportOptions) (*SnapshotImportReport, error) {
+	return ar.ImportSnapshotContext(context.Background(), snapshot, options)
+}
+
+// ImportSnapshotContext imports the snapshot and records every created or updated key
+func (ar *auditedRegistry) ImportSnapshotContext(ctx context.Context, snapshot *Snapshot, options SnapshotImportOptions) (*SnapshotImportReport, error) {
+	report, err := ar.Registry.ImportSnapshotContext(ctx, snapshot, options)
+	if err != nil || report.DryRun {
+		return report, err
+	}
+
+	values := map[string]string{}
+	for _, entry := range snapshot.Entries {
+		values[entry.Key] = entry.Value
+	}
+	for _, key := range append(append([]string{}, report.Created...), report.Updated...) {
+		ar.auditor.record(ctx, AuditEntry{Operation: AuditOperationImport, Key: key, NewValue: values[key]}, nil)
+	}
+
+	return report, nil
+}
+
+// auditedConfigurationContext records the mutations of the decorated configuration context.
+type auditedConfigurationContext struct {
+	ConfigurationContext
+	parent  string
+	auditor *auditor
+}
+
+func (acc *auditedConfigurationContext) path(key string) string {
+	return normalizeKey(acc.parent + "/" + key)
+}
+
+// oldValue returns the value of the key before a mutation. The audit log is best effort, so a failing request
+// results in an empty value.
+func (acc *auditedConfigurationContext) oldValue(ctx context.Context, key string) string {
+	_, value, _ := acc.ConfigurationContext.GetOrFalseContext(ctx, key)
+	return value
+}
+
+// Set sets a configuration value in current context and records the change
+func (acc *auditedConfigurationContext) Set(key, value string) error {
+	return acc.SetContext(context.Background(), key, value)
+}
+
+// SetContext sets a configuration value in current context and records the change
+func (acc *auditedConfigurationContext) SetContext(ctx context.Context, key, value string) error {
+	oldValue := acc.oldValue(ctx, key)
+	err := acc.ConfigurationContext.SetContext(ctx, key, value)
+	acc.auditor.record(ctx, AuditEntry{Operation: AuditOperationSet, Key: acc.path(key), OldValue: oldValue, NewValue: value}, err)
+	return err
+}
+
+// SetWithLifetime sets a configuration value in current context with the given lifetime and records the change
+func (acc *auditedConfigurationContext) SetWithLifetime(key, value string, timeToLiveInSeconds int) error {
+	return acc.SetWithLifetimeContext(context.Background(), key, value, timeToLiveInSeconds)
+}
+
+// SetWithLifetimeContext sets a configuration value in current context with the given lifetime and records the
+// change
+func (acc *auditedConfigurationContext) SetWithLifetimeContext(ctx context.Context, key, value string, timeToLiveInSeconds int) error {
+	oldValue := acc.oldValue(ctx, key)
+	err := acc.ConfigurationContext.SetWithLifetimeContext(ctx, key, value, timeToLiveInSeconds)
+	acc.auditor.record(ctx, AuditEntry{Operation: AuditOperationSet, Key: acc.path(key), OldValue: oldValue, NewValue: value}, err)
+	return err
+}
+
+// Delete removes a configuration key and value from the current context and records the change
+func (acc *auditedConfigurationContext) Delete(key string) error {
+	return acc.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext removes a configuration key and value from the current context and records the change
+func (acc *auditedConfigurationContext) DeleteContext(ctx context.Context, key string) error {
+	oldValue := acc.oldValue(ctx, key)
+	err := acc.ConfigurationContext.DeleteContext(ctx, key)
+	acc.auditor.record(ctx, AuditEntry{Operation: AuditOperationDelete, Key: acc.path(key), OldValue: oldValue}, err)
+	return err
+}
+
+// DeleteRecursive removes a configuration key or directory from the current context and records the change
+func (acc *auditedConfigurationContext) DeleteRecursive(key string) error {
+	return acc.DeleteRecursiveContext(context.Background(), key)
+}
+
+// DeleteRecursiveContext removes a configuration key or directory from the current context and records the change
+func (acc *auditedConfigurationContext) DeleteRecursiveContext(ctx context.Context, key string) error {
+	err := acc.ConfigurationContext.DeleteRecursiveContext(ctx, key)
+	acc.auditor.record(ctx, AuditEntry{Operation: AuditOperationDeleteRecursive, Key: acc.path(key)}, err)
+	return err
+}
+
+// RemoveAll removes all configuration keys and records the change
+func (acc *auditedConfigurationContext) RemoveAll() error {
+	return acc.RemoveAllContext(context.Background())
+}
+
+// RemoveAllContext removes all configuration keys and records the change
+func (acc *auditedConfigurationContext) RemoveAllContext(ctx context.Context) error {
+	err := acc.ConfigurationContext.RemoveAllContext(ctx)
+	acc.auditor.record(ctx, AuditEntry{Operation: AuditOperationRemoveAll, Key: acc.parent}, err)
+	return err
+}
+
+// SetIfAbsent sets a configuration value only if the key does not exist yet and records the change if it was applied
+func (acc *auditedConfigurationContext) SetIfAbsent(key, value string) (bool, error) {
+	return acc.SetIfAbsentContext(context.Background(), key, value)
+}
+
+// SetIfAbsentContext sets a configuration value only if the key does not exist yet and records the change if it was
+// applied
+func (acc *auditedConfigurationContext) SetIfAbsentContext(ctx context.Context, key, value string) (bool, error) {
+	created, err := acc.ConfigurationContext.SetIfAbsentContext(ctx, key, value)
+	if created || err != nil {
+		acc.auditor.record(ctx, AuditEntry{Operation: AuditOperationSet, Key: acc.path(key), NewValue: value}, err)
+	}
+	return created, err
+}
+
+// CompareAndSwap sets the key to the new value only if its current value equals the old value and records the
+// change if it was applied
+func (acc *auditedConfigurationContext) CompareAndSwap(key, oldValue, newValue string) (bool, error) {
+	return acc.CompareAndSwapContext(context.Background(), key, oldValue, newValue)
+}
+
+// CompareAndSwapContext sets the key to the new value only if its current value equals the old value and records
+// the change if it was applied
+func (acc *auditedConfigurationContext) CompareAndSwapContext(ctx context.Context, key, oldValue, newValue string) (bool, error) {
+	swapped, err := acc.ConfigurationContext.CompareAndSwapContext(ctx, key, oldValue, newValue)
+	if swapped || err != nil {
+		acc.auditor.record(ctx, AuditEntry{Operation: AuditOperationSet, Key: acc.path(key), OldValue: oldValue, NewValue: newValue}, err)
+	}
+	return swapped, err
+}
+
+// CompareAndDelete removes the key only if its current value equals the old value and records the change if it was
+// applied
+func (acc *auditedConfigurationContext) CompareAndDelete(key, oldValue string) (bool, error) {
+	return acc.CompareAndDeleteContext(context.Background(), key, oldValue)
+}
+
+// CompareAndDeleteContext removes the key only if its current value equals the old value and records the change if
+// it was applied
+func (acc *auditedConfigurationContext) CompareAndDeleteContext(ctx context.Context, key, oldValue string) (bool, error) {
+	deleted, err := acc.ConfigurationContext.CompareAndDeleteContext(ctx, key, oldValue)
+	if deleted || err != nil {
+		acc.auditor.record(ctx, AuditEntry{Operation: AuditOperationDelete, Key: acc.path(key), OldValue: oldValue}, err)
+	}
+	return deleted, err
+}
+
+// ApplyBatch applies all changes to the current context and records every change
+func (acc *auditedConfigurationContext) ApplyBatch(changes []ConfigurationChange) error {
+	return acc.ApplyBatchContext(context.Background(), changes)
+}
+
+// ApplyBatchContext applies all changes to the current context and records every change
+func (acc *auditedConfigurationContext) ApplyBatchContext(ctx context.Context, changes []ConfigurationChange) error {
+	oldValues := make([]string, len(changes))
+	for i, change := range changes {
+		oldValues[i] = acc.oldValue(ctx, change.Key)
+	}
+
+	err := acc.ConfigurationContext.ApplyBatchContext(ctx, changes)
+	for i, change := range changes {
+		entry := AuditEntry{Operation: AuditOperationSet, Key: acc.path(change.Key), OldValue: oldValues[i], NewValue: change.Value}
+		if change.Delete {
+			entry = AuditEntry{Operation: AuditOperationDelete, Key: acc.path(change.Key), OldValue: oldValues[i]}
+		}
+		acc.auditor.record(ctx, entry, err)
+	}
+	return err
+}
+
+// auditedState records the mutations of the decorated state.
+type auditedState struct {
+	State
+	path    string
+	auditor *auditor
+}
+
+// Set sets the state of the dogu and records the change
+func (as *auditedState) Set(value string) error {
+	return as.SetContext(context.Background(), value)
+}
+
+// SetContext sets the state of the dogu and records the change
+func (as *auditedState) SetContext(ctx context.Context, value string) error {
+	oldValue, _ := as.State.GetContext(ctx)
+	err := as.State.SetContext(ctx, value)
+	as.auditor.record(ctx, AuditEntry{Operation: AuditOperationSet, Key: as.path, OldValue: oldValue, NewValue: value}, err)
+	return err
+}
+
+// Transition changes the state of the dogu and records the change
+func (as *auditedState) Transition(state DoguState) error {
+	return as.TransitionContext(context.Background(), state)
+}
+
+// TransitionContext changes the state of the dogu and records the change
+func (as *auditedState) TransitionContext(ctx context.Context, state DoguState) error {
+	oldValue, _ := as.State.GetContext(ctx)
+	err := as.State.TransitionContext(ctx, state)
+	if oldValue != string(state) || err != nil {
+		as.auditor.record(ctx, AuditEntry{Operation: AuditOperationSet, Key: as.path, OldValue: oldValue, NewValue: string(state)}, err)
+	}
+	return err
+}
+
+// Remove removes the state of the dogu and records the change
+func (as *auditedState) Remove() error {
+	return as.RemoveContext(context.Background())
+}
+
+// RemoveContext removes the state of the dogu and records the change
+func (as *auditedState) RemoveContext(ctx context.Context) error {
+	oldValue, _ := as.State.GetContext(ctx)
+	err := as.State.RemoveContext(ctx)
+	as.auditor.record(ctx, AuditEntry{Operation: AuditOperationDelete, Key: as.path, OldValue: oldValue}, err)
+	return err
+}
+
+// auditedDoguRegistry records the mutations of the decorated dogu registry. The keys of the entries use the path of
+// the v2 dogu registry.
+type auditedDoguRegistry struct {
+	DoguRegistry
+	path    string
+	auditor *auditor
+}
+
+// record invalidates the cached encrypted global configuration keys, because the registered dogus may have changed,
+// and records the entry.
+func (adr *auditedDoguRegistry) record(ctx context.Context, entry AuditEntry, err error) {
+	adr.auditor.invalidateEncryptedGlobalKeys()
+	adr.auditor.record(ctx, entry, err)
+}
+
+func (adr *auditedDoguRegistry) enabledVersion(ctx context.Context, name string) string {
+	dogu, err := adr.DoguRegistry.GetContext(ctx, name)
+	if err != nil {
+		return ""
+	}
+	return dogu.Version
+}
+
+// Enable enables the given dogu and records the change
+func (adr *auditedDoguRegistry) Enable(dogu *core.Dogu) error {
+	return adr.EnableContext(context.Background(), dogu)
+}
+
+// EnableContext enables the given dogu and records the change
+func (adr *auditedDoguRegistry) EnableContext(ctx context.Context, dogu *core.Dogu) error {
+	oldVersion := adr.enabledVersion(ctx, dogu.GetSimpleName())
+	err := adr.DoguRegistry.EnableContext(ctx, dogu)
+	adr.record(ctx, AuditEntry{Operation: AuditOperationEnable, Key: adr.path + "/" + dogu.GetSimpleName(), OldValue: oldVersion, NewValue: dogu.Version}, err)
+	return err
+}
+
+// Register registers the dogu on the registry and records the change
+func (adr *auditedDoguRegistry) Register(dogu *core.Dogu) error {
+	return adr.RegisterContext(context.Background(), dogu)
+}
+
+// RegisterContext registers the dogu on the registry and records the change
+func (adr *auditedDoguRegistry) RegisterContext(ctx context.Context, dogu *core.Dogu) error {
+	err := adr.DoguRegistry.RegisterContext(ctx, dogu)
+	adr.record(ctx, AuditEntry{Operation: AuditOperationRegister, Key: adr.path + "/" + dogu.GetSimpleName(), NewValue: dogu.Version}, err)
+	return err
+}
+
+// Unregister unregisters the dogu on the registry and records the change
+func (adr *auditedDoguRegistry) Unregister(name string) error {
+	return adr.UnregisterContext(context.Background(), name)
+}
+
+// UnregisterContext unregisters the dogu on the registry and records the change
+func (adr *auditedDoguRegistry) UnregisterContext(ctx context.Context, name string) error {
+	oldVersion := adr.enabledVersion(ctx, name)
+	err := adr.DoguRegistry.UnregisterContext(ctx, name)
+	adr.record(ctx, AuditEntry{Operation: AuditOperationUnregister, Key: adr.path + "/" + name, OldValue: oldVersion}, err)
+	return err
+}
+
+// EnableVersion enables an already registered version of the dogu and records the change
+func (adr *auditedDoguRegistry) EnableVersion(name string, version string) error {
+	return adr.EnableVersionContext(context.Background(), name, version)
+}
+
+// EnableVersionContext enables an already registered version of the dogu and records the change
+func (adr *auditedDoguRegistry) EnableVersionContext(ctx context.Context, name string, version string) error {
+	oldVersion := adr.enabledVersion(ctx, name)
+	err := adr.DoguRegistry.EnableVersionContext(ctx, name, version)
+	adr.record(ctx, AuditEntry{Operation: AuditOperationEnable, Key: adr.path + "/" + name, OldValue: oldVersion, NewValue: version}, err)
+	return err
+}
+
+// PruneVersions removes old versions of the dogu and records the number of kept versions
+func (adr *auditedDoguRegistry) PruneVersions(name string, keep int) error {
+	return adr.PruneVersionsContext(context.Background(), name, keep)
+}
+
+// PruneVersionsContext removes old versions of the dogu and records the number of kept versions
+func (adr *auditedDoguRegistry) PruneVersionsContext(ctx context.Context, name string, keep int) error {
+	err := adr.DoguRegistry.PruneVersionsContext(ctx, name, keep)
+	adr.record(ctx, AuditEntry{Operation: AuditOperationPruneVersions, Key: adr.path + "/" + name, NewValue: strconv.Itoa(keep)}, err)
+	return err
+}
+
+// UnregisterVersion removes a version of the dogu and records the change
+func (adr *auditedDoguRegistry) UnregisterVersion(name string, version string) error {
+	return adr.UnregisterVersionContext(context.Background(), name, version)
+}
+
+// UnregisterVersionContext removes a version of the dogu and records the change
+func (adr *auditedDoguRegistry) UnregisterVersionContext(ctx context.Context, name string, version string) error {
+	err := adr.DoguRegistry.UnregisterVersionContext(ctx, name, version)
+	adr.record(ctx, AuditEntry{Operation: AuditOperationUnregisterVersion, Key: adr.path + "/" + name, OldValue: version}, err)
+	return err
+}
+
+// auditedLock records the acquisitions, releases and breaks of the decorated lock. The renewals of the held lock are
+// not recorded.
+type auditedLock struct {
+	Lock
+	key     string
+	auditor *auditor
+}
+
+// holder returns the holder of the lock for the audit log. The audit log is best effort, so a failing request results
+// in an empty value.
+func (al *auditedLock) holder(ctx context.Context) string {
+	info, held, err := al.Lock.Holder(ctx)
+	if err != nil || !held {
+		return ""
+	}
+	return info.Owner
+}
+
+// Acquire blocks until the lock is acquired and records the acquisition. Waiting for the lock until the context is
+// done is not recorded.
+func (al *auditedLock) Acquire(ctx context.Context) error {
+	err := al.Lock.Acquire(ctx)
+	if err == nil || !(errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+		al.recordAcquire(ctx, err)
+	}
+	return err
+}
+
+// TryAcquire acquires the lock if it is free and records the acquisition if it was applied
+func (al *auditedLock) TryAcquire(ctx context.Context) (bool, error) {
+	acquired, err := al.Lock.TryAcquire(ctx)
+	if acquired || err != nil {
+		al.recordAcquire(ctx, err)
+	}
+	return acquired, err
+}
+
+func (al *auditedLock) recordAcquire(ctx context.Context, err error) {
+	entry := AuditEntry{Operation: AuditOperationLockAcquire, Key: al.key}
+	if err == nil {
+		entry.NewValue = al.holder(ctx)
+	}
+	al.auditor.record(ctx, entry, err)
+}
+
+// Release removes the lock and records the change
+func (al *auditedLock) Release(ctx context.Context) error {
+	oldValue := al.holder(ctx)
+	err := al.Lock.Release(ctx)
+	al.auditor.record(ctx, AuditEntry{Operation: AuditOperationLockRelease, Key: al.key, OldValue: oldValue}, err)
+	return err
+}
+
+// Break removes the lock regardless of its holder and records the change
+func (al *auditedLock) Break(ctx context.Context) error {
+	oldValue := al.holder(ctx)
+	err := al.Lock.Break(ctx)
+	al.auditor.record(ctx, AuditEntry{Operation: AuditOperationLockBreak, Key: al.key, OldValue: oldValue}, err)
+	return err
+}
@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FileAuditSink writes the entries of the audit log as JSON lines to a local file. If the file would exceed the
+// maximum size, it is rotated: the file is renamed to <path>.1, older files are renamed to <path>.2 and so on, and
+// files beyond the maximum number of backups are removed.
+type FileAuditSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+// NewFileAuditSink creates a sink which appends the entries to the file at the given path. The file is rotated
+// before it would exceed maxSize bytes. A maxSize of 0 or less disables the rotation. maxBackups is the number of
+// rotated files which are kept.
+func NewFileAuditSink(path string, maxSize int64, maxBackups int) (*FileAuditSink, error) {
+	if maxBackups < 0 {
+		return nil, errors.Errorf("number of audit log backups must not be negative, got %d", maxBackups)
+	}
+
+	sink := &FileAuditSink{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	err := sink.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return sink, nil
+}
+
+func (fas *FileAuditSink) open() error {
+	file, err := os.OpenFile(fas.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open audit log %s", fas.path)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return errors.Wrapf(err, "failed to read size of audit log %s", fas.path)
+	}
+
+	fas.file = file
+	fas.size = info.Size()
+	return nil
+}
+
+// Write appends the entry as a single JSON line to the audit log
+func (fas *FileAuditSink) Write(entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal audit entry")
+	}
+	data = append(data, '\n')
+
+	fas.mutex.Lock()
+	defer fas.mutex.Unlock()
+
+	if fas.file == nil {
+		return errors.Errorf("audit log %s is closed", fas.path)
+	}
+
+	if fas.maxSize > 0 && fas.size > 0 && fas.size+int64(len(data)) > fas.maxSize {
+		err = fas.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	written, err := fas.file.Write(data)
+	fas.size += int64(written)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write audit log %s", fas.path)
+	}
+
+	return nil
+}
+
+func (fas *FileAuditSink) rotate() error {
+	err := fas.file.Close()
+	fas.file = nil
+	if err != nil {
+		return errors.Wrapf(err, "failed to close audit log %s", fas.path)
+	}
+
+	err = os.Remove(fas.backupPath(fas.maxBackups))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove oldest backup of audit log %s", fas.path)
+	}
+
+	for i := fas.maxBackups - 1; i >= 0; i-- {
+		err = os.Rename(fas.backupPath(i), fas.backupPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to rotate audit log %s", fas.path)
+		}
+	}
+
+	return fas.open()
+}
+
+// backupPath returns the path of the backup with the given number. The number 0 is the current audit log.
+func (fas *FileAuditSink) backupPath(number int) string {
+	if number == 0 {
+		return fas.path
+	}
+	return fmt.Sprintf("%s.%d", fas.path, number)
+}
+
+// Close closes the audit log. Entries which are written afterwards are rejected.
+func (fas *FileAuditSink) Close() error {
+	fas.mutex.Lock()
+	defer fas.mutex.Unlock()
+
+	if fas.file == nil {
+		return nil
+	}
+
+	err := fas.file.Close()
+	fas.file = nil
+	return err
+}
@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestAuditLog(t *testing.T, path string) []AuditEntry {
+	t.Helper()
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := AuditEntry{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+
+	return entries
+}
+
+func TestFileAuditSink(t *testing.T) {
+	t.Run("should append entries as json lines", func(t *testing.T) {
+		// given
+		path := filepath.Join(t.TempDir(), "audit.log")
+		sut, err := NewFileAuditSink(path, 0, 0)
+		require.NoError(t, err)
+
+		// when
+		require.NoError(t, sut.Write(AuditEntry{Operation: AuditOperationSet, Key: "/config/_global/fqdn", NewValue: "a"}))
+		require.NoError(t, sut.Close())
+		sut, err = NewFileAuditSink(path, 0, 0)
+		require.NoError(t, err)
+		require.NoError(t, sut.Write(AuditEntry{Operation: AuditOperationDelete, Key: "/config/_global/fqdn", OldValue: "a"}))
+		require.NoError(t, sut.Close())
+
+		// then
+		entries := readTestAuditLog(t, path)
+		require.Len(t, entries, 2)
+		assert.Equal(t, AuditOperationSet, entries[0].Operation)
+		assert.Equal(t, AuditOperationDelete, entries[1].Operation)
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("should rotate files and keep the given number of backups", func(t *testing.T) {
+		// given
+		path := filepath.Join(t.TempDir(), "audit.log")
+		entry := AuditEntry{Operation: AuditOperationSet, Key: "/config/_global/fqdn"}
+		data, err := json.Marshal(entry)
+		require.NoError(t, err)
+		sut, err := NewFileAuditSink(path, int64(len(data)+1)*2, 2)
+		require.NoError(t, err)
+		defer func() { _ = sut.Close() }()
+
+		// when
+		for i := 0; i < 7; i++ {
+			require.NoError(t, sut.Write(entry))
+		}
+
+		// then
+		assert.Len(t, readTestAuditLog(t, path), 1)
+		assert.Len(t, readTestAuditLog(t, path+".1"), 2)
+		assert.Len(t, readTestAuditLog(t, path+".2"), 2)
+		assert.NoFileExists(t, path+".3")
+	})
+
+	t.Run("should truncate file without backups", func(t *testing.T) {
+		// given
+		path := filepath.Join(t.TempDir(), "audit.log")
+		sut, err := NewFileAuditSink(path, 10, 0)
+		require.NoError(t, err)
+		defer func() { _ = sut.Close() }()
+
+		// when
+		require.NoError(t, sut.Write(AuditEntry{Key: "/a"}))
+		require.NoError(t, sut.Write(AuditEntry{Key: "/b"}))
+
+		// then
+		entries := readTestAuditLog(t, path)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "/b", entries[0].Key)
+		assert.NoFileExists(t, path+".1")
+	})
+
+	t.Run("should reject entries after close", func(t *testing.T) {
+		sut, err := NewFileAuditSink(filepath.Join(t.TempDir(), "audit.log"), 0, 0)
+		require.NoError(t, err)
+		require.NoError(t, sut.Close())
+
+		err = sut.Write(AuditEntry{})
+
+		assert.ErrorContains(t, err, "is closed")
+	})
+
+	t.Run("should fail for invalid options and paths", func(t *testing.T) {
+		_, errBackups := NewFileAuditSink(filepath.Join(t.TempDir(), "audit.log"), 0, -1)
+		_, errPath := NewFileAuditSink(filepath.Join(t.TempDir(), "missing", "audit.log"), 0, 0)
+
+		assert.ErrorContains(t, errBackups, "must not be negative")
+		assert.ErrorContains(t, errPath, "failed to open audit log")
+	})
+}
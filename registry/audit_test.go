@@ -0,0 +1,329 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAuditSink struct {
+	entries []AuditEntry
+	err     error
+}
+
+func (ras *recordingAuditSink) Write(entry AuditEntry) error {
+	ras.entries = append(ras.entries, entry)
+	return ras.err
+}
+
+func createTestAuditedRegistry(t *testing.T) (Registry, *recordingAuditSink) {
+	t.Helper()
+
+	inner := NewInMemoryRegistry()
+	redmine := &core.Dogu{
+		Name:    "official/redmine",
+		Version: "5.0.0-1",
+		Configuration: []core.ConfigurationField{
+			{Name: "smtp/password", Encrypted: true},
+			{Name: "license", Encrypted: true, Global: true},
+		},
+	}
+	require.NoError(t, inner.DoguRegistry().Register(redmine))
+	require.NoError(t, inner.DoguRegistry().Enable(redmine))
+
+	sink := &recordingAuditSink{}
+	return NewAuditedRegistry(inner, AuditOptions{Sink: sink, Actor: "admin", MaskedKeys: []string{"/config/_global/admin_password"}}), sink
+}
+
+func TestNewAuditedRegistry_ConfigurationContext(t *testing.T) {
+	t.Run("should record set and delete with old and new values", func(t *testing.T) {
+		// given
+		sut, sink := createTestAuditedRegistry(t)
+		config := sut.GlobalConfig()
+
+		// when
+		require.NoError(t, config.Set("fqdn", "old.example.com"))
+		require.NoError(t, config.Set("fqdn", "new.example.com"))
+		require.NoError(t, config.Delete("fqdn"))
+
+		// then
+		require.Len(t, sink.entries, 3)
+		assert.Equal(t, AuditOperationSet, sink.entries[0].Operation)
+		assert.Equal(t, "/config/_global/fqdn", sink.entries[0].Key)
+		assert.Equal(t, "", sink.entries[0].OldValue)
+		assert.Equal(t, "old.example.com", sink.entries[0].NewValue)
+		assert.Equal(t, "admin", sink.entries[0].Actor)
+		assert.False(t, sink.entries[0].Time.IsZero())
+		assert.Equal(t, "old.example.com", sink.entries[1].OldValue)
+		assert.Equal(t, "new.example.com", sink.entries[1].NewValue)
+		assert.Equal(t, AuditOperationDelete, sink.entries[2].Operation)
+		assert.Equal(t, "new.example.com", sink.entries[2].OldValue)
+	})
+
+	t.Run("should mask encrypted configuration fields and masked keys", func(t *testing.T) {
+		// given
+		sut, sink := createTestAuditedRegistry(t)
+
+		// when
+		require.NoError(t, sut.DoguConfig("redmine").Set("smtp/password", "secret"))
+		require.NoError(t, sut.DoguConfig("redmine").Set("logging/root", "DEBUG"))
+		require.NoError(t, sut.GlobalConfig().Set("license", "secret"))
+		require.NoError(t, sut.GlobalConfig().Set("admin_password", "secret"))
+
+		// then
+		require.Len(t, sink.entries, 4)
+		assert.Equal(t, MaskedValue, sink.entries[0].NewValue)
+		assert.Equal(t, "DEBUG", sink.entries[1].NewValue)
+		assert.Equal(t, MaskedValue, sink.entries[2].NewValue)
+		assert.Equal(t, MaskedValue, sink.entries[3].NewValue)
+	})
+
+	t.Run("should record failed mutations", func(t *testing.T) {
+		// given
+		sut, sink := createTestAuditedRegistry(t)
+
+		// when
+		err := sut.DoguConfig("redmine").Delete("missing")
+
+		// then
+		require.Error(t, err)
+		require.Len(t, sink.entries, 1)
+		assert.Equal(t, AuditOperationDelete, sink.entries[0].Operation)
+		assert.Contains(t, sink.entries[0].Error, "Key not found")
+	})
+
+	t.Run("should only record applied conditional writes", func(t *testing.T) {
+		// given
+		sut, sink := createTestAuditedRegistry(t)
+		config := sut.DoguConfig("redmine")
+
+		// when
+		created, err := config.SetIfAbsent("key", "first")
+		require.NoError(t, err)
+		require.True(t, created)
+		created, err = config.SetIfAbsent("key", "second")
+		require.NoError(t, err)
+		require.False(t, created)
+		swapped, err := config.CompareAndSwap("key", "other", "third")
+		require.NoError(t, err)
+		require.False(t, swapped)
+		deleted, err := config.CompareAndDelete("key", "first")
+		require.NoError(t, err)
+		require.True(t, deleted)
+
+		// then
+		require.Len(t, sink.entries, 2)
+		assert.Equal(t, AuditOperationSet, sink.entries[0].Operation)
+		assert.Equal(t, "first", sink.entries[0].NewValue)
+		assert.Equal(t, AuditOperationDelete, sink.entries[1].Operation)
+		assert.Equal(t, "first", sink.entries[1].OldValue)
+	})
+
+	t.Run("should record every change of a batch and removals", func(t *testing.T) {
+		// given
+		sut, sink := createTestAuditedRegistry(t)
+		config := sut.DoguConfig("redmine")
+		require.NoError(t, config.Set("old", "value"))
+		sink.entries = nil
+
+		// when
+		require.NoError(t, config.ApplyBatch([]ConfigurationChange{{Key: "new", Value: "value"}, {Key: "old", Delete: true}}))
+		require.NoError(t, config.DeleteRecursive("new"))
+		require.NoError(t, config.RemoveAll())
+
+		// then
+		require.Len(t, sink.entries, 4)
+		assert.Equal(t, AuditEntry{Operation: AuditOperationSet, Key: "/config/redmine/new", NewValue: "value"}, withoutTimeAndActor(sink.entries[0]))
+		assert.Equal(t, AuditEntry{Operation: AuditOperationDelete, Key: "/config/redmine/old", OldValue: "value"}, withoutTimeAndActor(sink.entries[1]))
+		assert.Equal(t, AuditEntry{Operation: AuditOperationDeleteRecursive, Key: "/config/redmine/new"}, withoutTimeAndActor(sink.entries[2]))
+		assert.Equal(t, AuditEntry{Operation: AuditOperationRemoveAll, Key: "/config/redmine"}, withoutTimeAndActor(sink.entries[3]))
+	})
+
+	t.Run("should use actor of context", func(t *testing.T) {
+		// given
+		sut, sink := createTestAuditedRegistry(t)
+		ctx := WithAuditActor(context.Background(), "jane")
+
+		// when
+		require.NoError(t, sut.HostConfig("k8s").SetContext(ctx, "key", "value"))
+
+		// then
+		require.Len(t, sink.entries, 1)
+		assert.Equal(t, "jane", sink.entries[0].Actor)
+		assert.Equal(t, "/config/_host/k8s/key", sink.entries[0].Key)
+	})
+
+	t.Run("should not fail mutation if sink fails", func(t *testing.T) {
+		// given
+		sut, sink := createTestAuditedRegistry(t)
+		sink.err = assert.AnError
+
+		// when
+		err := sut.BlueprintRegistry().Set("key", "value")
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, sink.entries, 1)
+	})
+}
+
+func TestNewAuditedRegistry_DoguRegistry(t *testing.T) {
+	// given
+	sut, sink := createTestAuditedRegistry(t)
+	doguRegistry := sut.DoguRegistry()
+	ldap := newTestDogu("ldap", "2.4.48-4")
+	ldapUpgrade := newTestDogu("ldap", "2.6.2-1")
+
+	// when
+	require.NoError(t, doguRegistry.Register(ldap))
+	require.NoError(t, doguRegistry.Enable(ldap))
+	require.NoError(t, doguRegistry.Register(ldapUpgrade))
+	require.NoError(t, doguRegistry.Enable(ldapUpgrade))
+	require.NoError(t, doguRegistry.EnableVersion("ldap", "2.4.48-4"))
+	require.NoError(t, doguRegistry.PruneVersions("ldap", 1))
+	require.NoError(t, doguRegistry.Unregister("ldap"))
+
+	// then
+	var actual []AuditEntry
+	for _, entry := range sink.entries {
+		actual = append(actual, withoutTimeAndActor(entry))
+	}
+	assert.Equal(t, []AuditEntry{
+		{Operation: AuditOperationRegister, Key: "/dogu_v2/ldap", NewValue: "2.4.48-4"},
+		{Operation: AuditOperationEnable, Key: "/dogu_v2/ldap", NewValue: "2.4.48-4"},
+		{Operation: AuditOperationRegister, Key: "/dogu_v2/ldap", NewValue: "2.6.2-1"},
+		{Operation: AuditOperationEnable, Key: "/dogu_v2/ldap", OldValue: "2.4.48-4", NewValue: "2.6.2-1"},
+		{Operation: AuditOperationEnable, Key: "/dogu_v2/ldap", OldValue: "2.6.2-1", NewValue: "2.4.48-4"},
+		{Operation: AuditOperationPruneVersions, Key: "/dogu_v2/ldap", NewValue: "1"},
+		{Operation: AuditOperationUnregister, Key: "/dogu_v2/ldap", OldValue: "2.4.48-4"},
+	}, actual)
+}
+
+func TestNewAuditedRegistry_State(t *testing.T) {
+	// given
+	sut, sink := createTestAuditedRegistry(t)
+	state := sut.State("ldap")
+
+	// when
+	require.NoError(t, state.Set("installing"))
+	require.NoError(t, state.Transition(StateReady))
+	require.NoError(t, state.Transition(StateReady))
+	require.NoError(t, state.Remove())
+
+	// then
+	var actual []AuditEntry
+	for _, entry := range sink.entries {
+		actual = append(actual, withoutTimeAndActor(entry))
+	}
+	assert.Equal(t, []AuditEntry{
+		{Operation: AuditOperationSet, Key: "/state/ldap", NewValue: "installing"},
+		{Operation: AuditOperationSet, Key: "/state/ldap", OldValue: "installing", NewValue: "ready"},
+		{Operation: AuditOperationDelete, Key: "/state/ldap", OldValue: "ready"},
+	}, actual)
+}
+
+func TestNewAuditedRegistry_Lock(t *testing.T) {
+	// given
+	sut, sink := createTestAuditedRegistry(t)
+	lock := sut.Lock("upgrade", LockOptions{Owner: "cesapp"})
+	other := sut.Lock("upgrade", LockOptions{Owner: "other"})
+
+	// when
+	require.NoError(t, lock.Acquire(context.Background()))
+	acquired, err := other.TryAcquire(context.Background())
+	require.NoError(t, err)
+	require.False(t, acquired)
+	require.NoError(t, lock.Release(context.Background()))
+	require.NoError(t, other.Acquire(context.Background()))
+	require.NoError(t, lock.Break(context.Background()))
+	require.True(t, IsLockNotHeldError(other.Release(context.Background())))
+
+	// then
+	var actual []AuditEntry
+	for _, entry := range sink.entries {
+		actual = append(actual, withoutTimeAndActor(entry))
+	}
+	require.Len(t, actual, 5)
+	assert.Equal(t, AuditEntry{Operation: AuditOperationLockAcquire, Key: "/locks/upgrade", NewValue: "cesapp"}, actual[0])
+	assert.Equal(t, AuditEntry{Operation: AuditOperationLockRelease, Key: "/locks/upgrade", OldValue: "cesapp"}, actual[1])
+	assert.Equal(t, AuditEntry{Operation: AuditOperationLockAcquire, Key: "/locks/upgrade", NewValue: "other"}, actual[2])
+	assert.Equal(t, AuditEntry{Operation: AuditOperationLockBreak, Key: "/locks/upgrade", OldValue: "other"}, actual[3])
+	assert.Equal(t, AuditOperationLockRelease, actual[4].Operation)
+	assert.Contains(t, actual[4].Error, "lock is not held")
+}
+
+// countingDoguRegistry counts the requests for all dogus.
+type countingDoguRegistry struct {
+	DoguRegistry
+	getAllCalls int
+}
+
+func (cdr *countingDoguRegistry) GetAllContext(ctx context.Context) ([]*core.Dogu, error) {
+	cdr.getAllCalls++
+	return cdr.DoguRegistry.GetAllContext(ctx)
+}
+
+type countingRegistry struct {
+	Registry
+	doguRegistry *countingDoguRegistry
+}
+
+func (cr *countingRegistry) DoguRegistry() DoguRegistry {
+	return cr.doguRegistry
+}
+
+func TestNewAuditedRegistry_encryptedGlobalKeys(t *testing.T) {
+	// given
+	inner := NewInMemoryRegistry()
+	doguRegistry := &countingDoguRegistry{DoguRegistry: inner.DoguRegistry()}
+	sink := &recordingAuditSink{}
+	sut := NewAuditedRegistry(&countingRegistry{Registry: inner, doguRegistry: doguRegistry}, AuditOptions{Sink: sink, Actor: "admin"})
+	redmine := &core.Dogu{
+		Name:          "official/redmine",
+		Version:       "5.0.0-1",
+		Configuration: []core.ConfigurationField{{Name: "license", Encrypted: true, Global: true}},
+	}
+
+	// when
+	require.NoError(t, sut.GlobalConfig().Set("license", "plain"))
+	require.NoError(t, sut.GlobalConfig().Set("fqdn", "ces.local"))
+	require.NoError(t, sut.DoguRegistry().Register(redmine))
+	require.NoError(t, sut.DoguRegistry().Enable(redmine))
+	require.NoError(t, sut.GlobalConfig().Set("license", "secret"))
+	require.NoError(t, sut.GlobalConfig().Set("fqdn", "example.com"))
+
+	// then
+	assert.Equal(t, 2, doguRegistry.getAllCalls)
+	require.Len(t, sink.entries, 6)
+	assert.Equal(t, "plain", sink.entries[0].NewValue)
+	assert.Equal(t, MaskedValue, sink.entries[4].NewValue)
+	assert.Equal(t, MaskedValue, sink.entries[4].OldValue)
+	assert.Equal(t, "example.com", sink.entries[5].NewValue)
+}
+
+func TestNewAuditedRegistry_ImportSnapshot(t *testing.T) {
+	// given
+	sut, sink := createTestAuditedRegistry(t)
+	snapshot := &Snapshot{Version: SnapshotVersion, Entries: []SnapshotEntry{
+		{Key: "/config/redmine/smtp/password", Value: "secret"},
+		{Key: "/config/redmine/url", Value: "https://example.com"},
+	}}
+
+	// when
+	_, errDryRun := sut.ImportSnapshot(snapshot, SnapshotImportOptions{DryRun: true})
+	_, err := sut.ImportSnapshot(snapshot, SnapshotImportOptions{})
+
+	// then
+	require.NoError(t, errDryRun)
+	require.NoError(t, err)
+	require.Len(t, sink.entries, 2)
+	assert.Equal(t, AuditEntry{Operation: AuditOperationImport, Key: "/config/redmine/smtp/password", NewValue: MaskedValue}, withoutTimeAndActor(sink.entries[0]))
+	assert.Equal(t, AuditEntry{Operation: AuditOperationImport, Key: "/config/redmine/url", NewValue: "https://example.com"}, withoutTimeAndActor(sink.entries[1]))
+}
+
+func withoutTimeAndActor(entry AuditEntry) AuditEntry {
+	return AuditEntry{Operation: entry.Operation, Key: entry.Key, OldValue: entry.OldValue, NewValue: entry.NewValue, Error: entry.Error}
+}
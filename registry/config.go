@@ -28,14 +28,94 @@ type ConfigurationContext interface {
 	// GetOrFalse return false and empty string when the configuration value does not exist.
 	// Otherwise, return true and the configuration value, even when the configuration value is an empty string.
 	GetOrFalse(key string) (bool, string, error)
+
+	// SetContext sets a configuration value in current context. The given context limits the time of the request.
+	SetContext(ctx context.Context, key, value string) error
+	// SetWithLifetimeContext sets a configuration value in current context with the given lifetime. The given context
+	// limits the time of the request.
+	SetWithLifetimeContext(ctx context.Context, key, value string, timeToLiveInSeconds int) error
+	// RefreshContext resets the time to live of a key. The given context limits the time of the request.
+	RefreshContext(ctx context.Context, key string, timeToLiveInSeconds int) error
+	// GetContext returns a configuration value from the current context. The given context limits the time of the
+	// request.
+	GetContext(ctx context.Context, key string) (string, error)
+	// GetAllContext returns a map of key value pairs. The given context limits the time of the request.
+	GetAllContext(ctx context.Context) (map[string]string, error)
+	// DeleteContext removes a configuration key and value from the current context. The given context limits the
+	// time of the request.
+	DeleteContext(ctx context.Context, key string) error
+	// DeleteRecursiveContext removes a configuration key or directory from the current context. The given context
+	// limits the time of the request.
+	DeleteRecursiveContext(ctx context.Context, key string) error
+	// ExistsContext returns true if configuration key exists in the current context. The given context limits the
+	// time of the request.
+	ExistsContext(ctx context.Context, key string) (bool, error)
+	// RemoveAllContext remove all configuration keys. The given context limits the time of the request.
+	RemoveAllContext(ctx context.Context) error
+	// GetOrFalseContext works like GetOrFalse. The given context limits the time of the request.
+	GetOrFalseContext(ctx context.Context, key string) (bool, string, error)
+
+	// SetIfAbsent sets a configuration value in current context only if the key does not exist yet. It returns false
+	// if the key already exists.
+	SetIfAbsent(key, value string) (bool, error)
+	// CompareAndSwap sets the key to the new value only if its current value equals the old value. It returns false
+	// if the key does not exist or has another value.
+	CompareAndSwap(key, oldValue, newValue string) (bool, error)
+	// CompareAndDelete removes the key only if its current value equals the old value. It returns false if the key
+	// does not exist or has another value.
+	CompareAndDelete(key, oldValue string) (bool, error)
+	// ApplyBatch applies all changes to the current context. With etcd v3, the memory and the filesystem registry,
+	// either all changes are applied or none of them. With etcd v2, the batch is not atomic: other clients may read a
+	// partially applied batch, and a failed batch is only reverted for keys which were not changed by other clients in
+	// the meantime.
+	ApplyBatch(changes []ConfigurationChange) error
+
+	// SetIfAbsentContext works like SetIfAbsent. The given context limits the time of the request.
+	SetIfAbsentContext(ctx context.Context, key, value string) (bool, error)
+	// CompareAndSwapContext works like CompareAndSwap. The given context limits the time of the request.
+	CompareAndSwapContext(ctx context.Context, key, oldValue, newValue string) (bool, error)
+	// CompareAndDeleteContext works like CompareAndDelete. The given context limits the time of the request.
+	CompareAndDeleteContext(ctx context.Context, key, oldValue string) (bool, error)
+	// ApplyBatchContext works like ApplyBatch. The given context limits the time of the request.
+	ApplyBatchContext(ctx context.Context, changes []ConfigurationChange) error
+}
+
+// ConfigurationChange describes the change of a single configuration key within a batch.
+type ConfigurationChange struct {
+	// Key is the configuration key relative to the configuration context.
+	Key string
+	// Value is the new value of the key. It is ignored if Delete is true.
+	Value string
+	// Delete removes the key instead of setting it. Removing a key which does not exist is not an error.
+	Delete bool
 }
 
 // WatchConfigurationContext is just able to watch and query the configuration of a single context
 type WatchConfigurationContext interface {
-	// Watch watches for changes of the provided key and sends the event through the channel
+	// Watch watches for changes of the provided key and sends the event through the channel. The call blocks until
+	// the given context is done.
+	//
+	// Deprecated: Watch exposes the responses of the etcd v2 client. Use WatchEvents instead.
 	Watch(ctx context.Context, key string, recursive bool, eventChannel chan *client.Response)
+	// WatchEvents watches for changes of the provided key until the given context is done. The changes are sent
+	// through the returned event channel. If the watch is interrupted, the error is sent through the returned error
+	// channel and the watch is reconnected after the last received event. Both channels have to be consumed and are
+	// closed when the context is done.
+	WatchEvents(ctx context.Context, key string, recursive bool) (<-chan WatchEvent, <-chan error)
+	// WatchEventsAfter works like WatchEvents, but sends only the changes after the given index of the registry, e.g.
+	// the index returned by GetCurrentIndexContext. The watch starts at the current index if the given index is 0.
+	WatchEventsAfter(ctx context.Context, key string, recursive bool, afterIndex uint64) (<-chan WatchEvent, <-chan error)
+	// GetCurrentIndexContext returns the current index of the registry. A watch which starts after it misses no
+	// change of keys which are read afterwards. The given key is used for the request and is not required to exist.
+	GetCurrentIndexContext(ctx context.Context, key string) (uint64, error)
 	// Get returns a configuration value from the current context
 	Get(key string) (string, error)
 	// GetChildrenPaths returns an array of all children keys of the given key
 	GetChildrenPaths(key string) ([]string, error)
+	// GetContext returns a configuration value from the current context. The given context limits the time of the
+	// request.
+	GetContext(ctx context.Context, key string) (string, error)
+	// GetChildrenPathsContext returns an array of all children keys of the given key. The given context limits the
+	// time of the request.
+	GetChildrenPathsContext(ctx context.Context, key string) ([]string, error)
 }
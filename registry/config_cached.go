@@ -0,0 +1,601 @@
+package registry
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/client/v2"
+)
+
+// CachedConfigurationContext is a ConfigurationContext which serves its reads from memory.
+type CachedConfigurationContext interface {
+	ConfigurationContext
+	// RefreshCache loads all keys of the context again with a single request.
+	RefreshCache() error
+	// RefreshCacheContext works like RefreshCache. The given context limits the time of the request.
+	RefreshCacheContext(ctx context.Context) error
+}
+
+// CacheOptions configure a cached configuration context.
+type CacheOptions struct {
+	// TimeToLive is the maximum age of the cached keys. The first read after it ran out loads all keys again. The
+	// cached keys never expire if it is 0.
+	TimeToLive time.Duration
+	// Metrics counts the hits and misses of the cache if it is set.
+	Metrics core.MetricsRecorder
+}
+
+// cacheMetricsComponent is the name of the cached configuration contexts in the recorded metrics.
+const cacheMetricsComponent = "configuration"
+
+// cachedConfigurationContext caches all keys of the decorated configuration context. The cache is updated by its
+// own writes and by the watch of the context, so that it only has to be loaded again if the watch missed changes or
+// the time to live ran out.
+type cachedConfigurationContext struct {
+	ConfigurationContext
+	path    string
+	options CacheOptions
+
+	mutex    sync.RWMutex
+	values   map[string]string
+	loadedAt time.Time
+	// generation is incremented on every change of the cached keys, so that a load which overlaps with a change
+	// can be detected.
+	generation uint64
+	// eventCounts counts the watch events of every key and of every directory above it, so that a write can detect
+	// whether the watch reported a change of its keys while the decorated context was written.
+	eventCounts map[string]uint64
+	stale       bool
+	watching    bool
+}
+
+// cachedChange is a change of a single key, which is applied to the cache after a successful write.
+type cachedChange struct {
+	key    string
+	value  string
+	delete bool
+}
+
+// NewCachedConfigurationContext decorates the configuration context with the given path, e.g. /config/redmine, with
+// a read-through cache. All keys of the context are loaded with a single recursive request, afterwards Get, GetAll,
+// Exists and GetOrFalse are served from memory. The cache is kept up to date by watching the path with the watcher,
+// e.g. the RootConfig of the registry, until the given context is done. Afterwards all reads are passed to the
+// decorated context. If the watch missed changes, the cache is loaded again on the next read.
+//
+// The watch starts after the index of the registry which is read before the keys are loaded, so that no change is
+// missed. Empty directories and hidden keys are not cached, reads of them are passed to the decorated context.
+func NewCachedConfigurationContext(ctx context.Context, configuration ConfigurationContext, path string,
+	watcher WatchConfigurationContext, options CacheOptions) (CachedConfigurationContext, error) {
+	ccc := &cachedConfigurationContext{
+		ConfigurationContext: configuration,
+		path:                 normalizeKey(path),
+		options:              options,
+		eventCounts:          map[string]uint64{},
+		watching:             true,
+	}
+
+	// every change after the index is sent by the watch, the keys are loaded afterwards and contain all changes before
+	index, err := watcher.GetCurrentIndexContext(ctx, ccc.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to start watch for cache of %s", ccc.path)
+	}
+
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	events, errs := watcher.WatchEventsAfter(watchCtx, ccc.path, true, index)
+	go ccc.watch(stopWatch, events, errs)
+
+	err = ccc.RefreshCacheContext(ctx)
+	if err != nil {
+		stopWatch()
+		return nil, err
+	}
+
+	return ccc, nil
+}
+
+func (ccc *cachedConfigurationContext) watch(stopWatch context.CancelFunc, events <-chan WatchEvent, errs <-chan error) {
+	defer stopWatch()
+
+	for events != nil || errs != nil {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			ccc.applyEvent(event)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			// the watch resumes after the last received event, only a cleared event history loses changes
+			if IsEventIndexClearedError(err) {
+				core.GetLogger().Infof("cache of %s missed changes and is loaded again on the next read", ccc.path)
+				ccc.markStale()
+			}
+		}
+	}
+
+	ccc.mutex.Lock()
+	defer ccc.mutex.Unlock()
+	ccc.watching = false
+	ccc.values = nil
+	ccc.eventCounts = map[string]uint64{}
+}
+
+func (ccc *cachedConfigurationContext) applyEvent(event WatchEvent) {
+	key, ok := ccc.relativeKey(event.Key)
+	if !ok {
+		return
+	}
+
+	ccc.mutex.Lock()
+	defer ccc.mutex.Unlock()
+
+	ccc.generation++
+	ccc.countEvent(key)
+	if ccc.values == nil {
+		return
+	}
+
+	switch event.Action {
+	case WatchActionDelete, WatchActionExpire:
+		removeCachedKeys(ccc.values, key)
+	default:
+		if !event.IsDir {
+			ccc.values[key] = event.NewValue
+		}
+	}
+}
+
+// countEvent counts an event of the key for the key itself and for all directories above it up to the empty key of
+// the context.
+func (ccc *cachedConfigurationContext) countEvent(key string) {
+	for {
+		ccc.eventCounts[key]++
+		if key == "" {
+			return
+		}
+
+		index := strings.LastIndex(key, "/")
+		if index < 0 {
+			key = ""
+		} else {
+			key = key[:index]
+		}
+	}
+}
+
+// relativeKey returns the key relative to the path of the context. It returns false for keys outside the context
+// and for hidden keys.
+func (ccc *cachedConfigurationContext) relativeKey(fullKey string) (string, bool) {
+	fullKey = normalizeKey(fullKey)
+	if fullKey == ccc.path {
+		return "", true
+	}
+
+	prefix := strings.TrimSuffix(ccc.path, "/") + "/"
+	if !strings.HasPrefix(fullKey, prefix) {
+		return "", false
+	}
+
+	key := strings.TrimPrefix(fullKey, prefix)
+	return key, !isHiddenCacheKey(key)
+}
+
+func normalizeCacheKey(key string) string {
+	return strings.Trim(normalizeKey(key), "/")
+}
+
+func isHiddenCacheKey(key string) bool {
+	for _, segment := range strings.Split(key, "/") {
+		if strings.HasPrefix(segment, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+// removeCachedKeys removes the key and all keys below it. The empty key removes all keys.
+func removeCachedKeys(values map[string]string, key string) {
+	for cachedKey := range values {
+		if key == "" || cachedKey == key || strings.HasPrefix(cachedKey, key+"/") {
+			delete(values, cachedKey)
+		}
+	}
+}
+
+// markStale loads the cache again on the next read, e.g. if the watch missed changes.
+func (ccc *cachedConfigurationContext) markStale() {
+	ccc.mutex.Lock()
+	defer ccc.mutex.Unlock()
+
+	ccc.generation++
+	ccc.stale = true
+}
+
+// RefreshCache loads all keys of the context again with a single request
+func (ccc *cachedConfigurationContext) RefreshCache() error {
+	return ccc.RefreshCacheContext(context.Background())
+}
+
+// RefreshCacheContext loads all keys of the context again with a single request
+func (ccc *cachedConfigurationContext) RefreshCacheContext(ctx context.Context) error {
+	ccc.mutex.RLock()
+	generation := ccc.generation
+	ccc.mutex.RUnlock()
+
+	core.GetLogger().Debugf("load cache of %s", ccc.path)
+	values, err := ccc.ConfigurationContext.GetAllContext(ctx)
+	if IsKeyNotFoundError(err) {
+		values = map[string]string{}
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to load cache of %s", ccc.path)
+	}
+
+	ccc.mutex.Lock()
+	defer ccc.mutex.Unlock()
+
+	ccc.values = values
+	ccc.loadedAt = time.Now()
+	// a change during the load may be missing in the loaded keys
+	ccc.stale = ccc.generation != generation
+	return nil
+}
+
+// ensureLoaded loads the cache again if it is stale or its time to live ran out. It returns false if the cache can
+// no longer be used, because the watch was stopped.
+func (ccc *cachedConfigurationContext) ensureLoaded(ctx context.Context) (bool, error) {
+	ccc.mutex.RLock()
+	watching := ccc.watching
+	expired := ccc.stale || (ccc.options.TimeToLive > 0 && time.Since(ccc.loadedAt) > ccc.options.TimeToLive)
+	ccc.mutex.RUnlock()
+
+	if !watching {
+		return false, nil
+	}
+	if expired {
+		err := ccc.RefreshCacheContext(ctx)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// lookup returns the cached value of the key. It returns false if the key has to be read from the decorated
+// context, because it is not cached or the cache can no longer be used.
+func (ccc *cachedConfigurationContext) lookup(ctx context.Context, key string) (value string, exists bool, cached bool, err error) {
+	defer func() {
+		if err == nil {
+			ccc.countRead(cached)
+		}
+	}()
+
+	if isHiddenCacheKey(normalizeCacheKey(key)) {
+		return "", false, false, nil
+	}
+
+	usable, err := ccc.ensureLoaded(ctx)
+	if err != nil || !usable {
+		return "", false, false, err
+	}
+
+	key = normalizeCacheKey(key)
+	ccc.mutex.RLock()
+	defer ccc.mutex.RUnlock()
+
+	if ccc.values == nil {
+		return "", false, false, nil
+	}
+	if value, ok := ccc.values[key]; ok {
+		return value, true, true, nil
+	}
+	for cachedKey := range ccc.values {
+		// directories are read from the decorated context
+		if key == "" || strings.HasPrefix(cachedKey, key+"/") {
+			return "", true, false, nil
+		}
+	}
+
+	return "", false, true, nil
+}
+
+// Get returns a configuration value from the cache
+func (ccc *cachedConfigurationContext) Get(key string) (string, error) {
+	return ccc.GetContext(context.Background(), key)
+}
+
+// GetContext returns a configuration value from the cache
+func (ccc *cachedConfigurationContext) GetContext(ctx context.Context, key string) (string, error) {
+	value, exists, cached, err := ccc.lookup(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !cached {
+		return ccc.ConfigurationContext.GetContext(ctx, key)
+	}
+	if !exists {
+		path := ccc.path + "/" + normalizeCacheKey(key)
+		return "", errors.Wrapf(client.Error{Code: client.ErrorCodeKeyNotFound, Message: "Key not found", Cause: path},
+			"could not get value %s", path)
+	}
+
+	return value, nil
+}
+
+// GetAll returns a map of the cached key value pairs
+func (ccc *cachedConfigurationContext) GetAll() (map[string]string, error) {
+	return ccc.GetAllContext(context.Background())
+}
+
+// GetAllContext returns a map of the cached key value pairs
+func (ccc *cachedConfigurationContext) GetAllContext(ctx context.Context) (map[string]string, error) {
+	usable, err := ccc.ensureLoaded(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if usable {
+		keyValuePairs, ok := ccc.copyValues()
+		if ok {
+			ccc.countRead(true)
+			return keyValuePairs, nil
+		}
+	}
+
+	ccc.countRead(false)
+	return ccc.ConfigurationContext.GetAllContext(ctx)
+}
+
+// countRead counts whether a read was answered by the cache or passed to the decorated context.
+func (ccc *cachedConfigurationContext) countRead(cached bool) {
+	if ccc.options.Metrics == nil {
+		return
+	}
+
+	event := core.CacheMiss
+	if cached {
+		event = core.CacheHit
+	}
+	ccc.options.Metrics.CountCacheEvent(cacheMetricsComponent, event)
+}
+
+func (ccc *cachedConfigurationContext) copyValues() (map[string]string, bool) {
+	ccc.mutex.RLock()
+	defer ccc.mutex.RUnlock()
+
+	if ccc.values == nil {
+		return nil, false
+	}
+
+	keyValuePairs := make(map[string]string, len(ccc.values))
+	for key, value := range ccc.values {
+		keyValuePairs[key] = value
+	}
+	return keyValuePairs, true
+}
+
+// Exists returns true if the configuration key exists in the cache
+func (ccc *cachedConfigurationContext) Exists(key string) (bool, error) {
+	return ccc.ExistsContext(context.Background(), key)
+}
+
+// ExistsContext returns true if the configuration key exists in the cache
+func (ccc *cachedConfigurationContext) ExistsContext(ctx context.Context, key string) (bool, error) {
+	_, exists, cached, err := ccc.lookup(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if !cached && !exists {
+		return ccc.ConfigurationContext.ExistsContext(ctx, key)
+	}
+
+	return exists, nil
+}
+
+// GetOrFalse returns false and an empty string if the configuration key does not exist in the cache. Otherwise, it
+// returns true and the cached value.
+func (ccc *cachedConfigurationContext) GetOrFalse(key string) (bool, string, error) {
+	return ccc.GetOrFalseContext(context.Background(), key)
+}
+
+// GetOrFalseContext works like GetOrFalse. The given context limits the time of the request.
+func (ccc *cachedConfigurationContext) GetOrFalseContext(ctx context.Context, key string) (bool, string, error) {
+	value, exists, cached, err := ccc.lookup(ctx, key)
+	if err != nil {
+		return false, "", err
+	}
+	if !cached {
+		return ccc.ConfigurationContext.GetOrFalseContext(ctx, key)
+	}
+
+	return exists, value, nil
+}
+
+// beforeWrite returns the number of watch events of the changed keys before the decorated context is written.
+func (ccc *cachedConfigurationContext) beforeWrite(changes []cachedChange) []uint64 {
+	ccc.mutex.RLock()
+	defer ccc.mutex.RUnlock()
+
+	counts := make([]uint64, len(changes))
+	for i, change := range changes {
+		counts[i] = ccc.eventCounts[change.key]
+	}
+	return counts
+}
+
+// afterWrite updates the cache after a write to the decorated context. The result of a failed write is unknown, so
+// the cache is loaded again on the next read. A change is not applied if the watch reported an event of its key
+// during the write, because the event contains the own write or a newer change of another client. An older event
+// is replaced by the event of the own write, which the watch reports later.
+func (ccc *cachedConfigurationContext) afterWrite(err error, changes []cachedChange, counts []uint64) {
+	ccc.mutex.Lock()
+	defer ccc.mutex.Unlock()
+
+	ccc.generation++
+	if err != nil {
+		ccc.stale = true
+		return
+	}
+	if ccc.values == nil {
+		return
+	}
+
+	for i, change := range changes {
+		if ccc.eventCounts[change.key] != counts[i] {
+			continue
+		}
+		if change.delete {
+			removeCachedKeys(ccc.values, change.key)
+		} else {
+			ccc.values[change.key] = change.value
+		}
+	}
+}
+
+// write passes the write to the decorated context and applies the changes to the cache afterwards. The changes are
+// only applied if the write returns true, e.g. if a compare and swap succeeded, or fails.
+func (ccc *cachedConfigurationContext) write(changes []cachedChange, decorated func() (bool, error)) (bool, error) {
+	counts := ccc.beforeWrite(changes)
+	written, err := decorated()
+	if written || err != nil {
+		ccc.afterWrite(err, changes, counts)
+	}
+	return written, err
+}
+
+func setCached(key, value string) []cachedChange {
+	return []cachedChange{{key: normalizeCacheKey(key), value: value}}
+}
+
+func removeCached(key string) []cachedChange {
+	return []cachedChange{{key: normalizeCacheKey(key), delete: true}}
+}
+
+// Set sets a configuration value in current context and in the cache
+func (ccc *cachedConfigurationContext) Set(key, value string) error {
+	return ccc.SetContext(context.Background(), key, value)
+}
+
+// SetContext sets a configuration value in current context and in the cache
+func (ccc *cachedConfigurationContext) SetContext(ctx context.Context, key, value string) error {
+	_, err := ccc.write(setCached(key, value), func() (bool, error) {
+		return true, ccc.ConfigurationContext.SetContext(ctx, key, value)
+	})
+	return err
+}
+
+// SetWithLifetime sets a configuration value in current context and in the cache with the given lifetime
+func (ccc *cachedConfigurationContext) SetWithLifetime(key, value string, timeToLiveInSeconds int) error {
+	return ccc.SetWithLifetimeContext(context.Background(), key, value, timeToLiveInSeconds)
+}
+
+// SetWithLifetimeContext sets a configuration value in current context and in the cache with the given lifetime
+func (ccc *cachedConfigurationContext) SetWithLifetimeContext(ctx context.Context, key, value string, timeToLiveInSeconds int) error {
+	_, err := ccc.write(setCached(key, value), func() (bool, error) {
+		return true, ccc.ConfigurationContext.SetWithLifetimeContext(ctx, key, value, timeToLiveInSeconds)
+	})
+	return err
+}
+
+// Delete removes a configuration key and value from the current context and from the cache
+func (ccc *cachedConfigurationContext) Delete(key string) error {
+	return ccc.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext removes a configuration key and value from the current context and from the cache
+func (ccc *cachedConfigurationContext) DeleteContext(ctx context.Context, key string) error {
+	_, err := ccc.write(removeCached(key), func() (bool, error) {
+		return true, ccc.ConfigurationContext.DeleteContext(ctx, key)
+	})
+	return err
+}
+
+// DeleteRecursive removes a configuration key or directory from the current context and from the cache
+func (ccc *cachedConfigurationContext) DeleteRecursive(key string) error {
+	return ccc.DeleteRecursiveContext(context.Background(), key)
+}
+
+// DeleteRecursiveContext removes a configuration key or directory from the current context and from the cache
+func (ccc *cachedConfigurationContext) DeleteRecursiveContext(ctx context.Context, key string) error {
+	_, err := ccc.write(removeCached(key), func() (bool, error) {
+		return true, ccc.ConfigurationContext.DeleteRecursiveContext(ctx, key)
+	})
+	return err
+}
+
+// RemoveAll removes all configuration keys from the current context and from the cache
+func (ccc *cachedConfigurationContext) RemoveAll() error {
+	return ccc.RemoveAllContext(context.Background())
+}
+
+// RemoveAllContext removes all configuration keys from the current context and from the cache
+func (ccc *cachedConfigurationContext) RemoveAllContext(ctx context.Context) error {
+	_, err := ccc.write(removeCached(""), func() (bool, error) {
+		return true, ccc.ConfigurationContext.RemoveAllContext(ctx)
+	})
+	return err
+}
+
+// SetIfAbsent sets a configuration value in current context and in the cache only if the key does not exist yet
+func (ccc *cachedConfigurationContext) SetIfAbsent(key, value string) (bool, error) {
+	return ccc.SetIfAbsentContext(context.Background(), key, value)
+}
+
+// SetIfAbsentContext works like SetIfAbsent. The given context limits the time of the request.
+func (ccc *cachedConfigurationContext) SetIfAbsentContext(ctx context.Context, key, value string) (bool, error) {
+	return ccc.write(setCached(key, value), func() (bool, error) {
+		return ccc.ConfigurationContext.SetIfAbsentContext(ctx, key, value)
+	})
+}
+
+// CompareAndSwap sets the key in the current context and in the cache to the new value only if its current value
+// equals the old value
+func (ccc *cachedConfigurationContext) CompareAndSwap(key, oldValue, newValue string) (bool, error) {
+	return ccc.CompareAndSwapContext(context.Background(), key, oldValue, newValue)
+}
+
+// CompareAndSwapContext works like CompareAndSwap. The given context limits the time of the request.
+func (ccc *cachedConfigurationContext) CompareAndSwapContext(ctx context.Context, key, oldValue, newValue string) (bool, error) {
+	return ccc.write(setCached(key, newValue), func() (bool, error) {
+		return ccc.ConfigurationContext.CompareAndSwapContext(ctx, key, oldValue, newValue)
+	})
+}
+
+// CompareAndDelete removes the key from the current context and from the cache only if its current value equals the
+// old value
+func (ccc *cachedConfigurationContext) CompareAndDelete(key, oldValue string) (bool, error) {
+	return ccc.CompareAndDeleteContext(context.Background(), key, oldValue)
+}
+
+// CompareAndDeleteContext works like CompareAndDelete. The given context limits the time of the request.
+func (ccc *cachedConfigurationContext) CompareAndDeleteContext(ctx context.Context, key, oldValue string) (bool, error) {
+	return ccc.write(removeCached(key), func() (bool, error) {
+		return ccc.ConfigurationContext.CompareAndDeleteContext(ctx, key, oldValue)
+	})
+}
+
+// ApplyBatch applies all changes to the current context and to the cache
+func (ccc *cachedConfigurationContext) ApplyBatch(changes []ConfigurationChange) error {
+	return ccc.ApplyBatchContext(context.Background(), changes)
+}
+
+// ApplyBatchContext works like ApplyBatch. The given context limits the time of the request.
+func (ccc *cachedConfigurationContext) ApplyBatchContext(ctx context.Context, changes []ConfigurationChange) error {
+	cachedChanges := make([]cachedChange, len(changes))
+	for i, change := range changes {
+		cachedChanges[i] = cachedChange{key: normalizeCacheKey(change.Key), value: change.Value, delete: change.Delete}
+	}
+
+	_, err := ccc.write(cachedChanges, func() (bool, error) {
+		return true, ccc.ConfigurationContext.ApplyBatchContext(ctx, changes)
+	})
+	return err
+}
@@ -0,0 +1,336 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/client/v2"
+)
+
+type testCachedContext struct {
+	sut    CachedConfigurationContext
+	inner  *MockConfigurationContext
+	events chan WatchEvent
+	errs   chan error
+}
+
+// stopWatch closes the channels of the watch like a watch whose context is done.
+func (tcc *testCachedContext) stopWatch() {
+	close(tcc.events)
+	close(tcc.errs)
+}
+
+func createTestCachedContext(t *testing.T, options CacheOptions, values map[string]string) *testCachedContext {
+	t.Helper()
+
+	tcc := &testCachedContext{
+		inner:  NewMockConfigurationContext(t),
+		events: make(chan WatchEvent),
+		errs:   make(chan error),
+	}
+	watcher := NewMockWatchConfigurationContext(t)
+	watcher.EXPECT().GetCurrentIndexContext(mock.Anything, "/config/redmine").Return(uint64(42), nil)
+	watcher.EXPECT().WatchEventsAfter(mock.Anything, "/config/redmine", true, uint64(42)).
+		Return((<-chan WatchEvent)(tcc.events), (<-chan error)(tcc.errs))
+	tcc.inner.EXPECT().GetAllContext(mock.Anything).Return(values, nil).Once()
+
+	var err error
+	tcc.sut, err = NewCachedConfigurationContext(context.Background(), tcc.inner, "/config/redmine/", watcher, options)
+	require.NoError(t, err)
+
+	return tcc
+}
+
+func TestNewCachedConfigurationContext(t *testing.T) {
+	t.Run("should serve reads from memory after a single load", func(t *testing.T) {
+		// given
+		tcc := createTestCachedContext(t, CacheOptions{}, map[string]string{"url": "https://example.com", "smtp/host": "mail"})
+		defer tcc.stopWatch()
+
+		// when
+		value, errGet := tcc.sut.Get("url")
+		exists, errExists := tcc.sut.Exists("/smtp/host")
+		dirExists, errDirExists := tcc.sut.Exists("smtp")
+		found, foundValue, errGetOrFalse := tcc.sut.GetOrFalse("smtp/host")
+		notFound, _, errGetOrFalseMissing := tcc.sut.GetOrFalse("missing")
+		all, errGetAll := tcc.sut.GetAll()
+		_, errMissing := tcc.sut.Get("missing")
+
+		// then
+		require.NoError(t, errGet)
+		require.NoError(t, errExists)
+		require.NoError(t, errDirExists)
+		require.NoError(t, errGetOrFalse)
+		require.NoError(t, errGetOrFalseMissing)
+		require.NoError(t, errGetAll)
+		assert.Equal(t, "https://example.com", value)
+		assert.True(t, exists)
+		assert.True(t, dirExists)
+		assert.True(t, found)
+		assert.Equal(t, "mail", foundValue)
+		assert.False(t, notFound)
+		assert.Equal(t, map[string]string{"url": "https://example.com", "smtp/host": "mail"}, all)
+		require.Error(t, errMissing)
+		assert.True(t, IsKeyNotFoundError(errMissing))
+		assert.Contains(t, errMissing.Error(), "could not get value /config/redmine/missing")
+	})
+
+	t.Run("should count hits and misses", func(t *testing.T) {
+		// given
+		recorder := &recordingMetricsRecorder{}
+		tcc := createTestCachedContext(t, CacheOptions{Metrics: recorder}, map[string]string{"url": "https://example.com", "smtp/host": "mail"})
+		defer tcc.stopWatch()
+		tcc.inner.EXPECT().GetContext(mock.Anything, "smtp").Return("", nil)
+
+		// when
+		_, errGet := tcc.sut.Get("url")
+		_, errGetAll := tcc.sut.GetAll()
+		_, errGetDir := tcc.sut.Get("smtp")
+
+		// then
+		require.NoError(t, errGet)
+		require.NoError(t, errGetAll)
+		require.NoError(t, errGetDir)
+		assert.Equal(t, []string{"configuration hit", "configuration hit", "configuration miss"}, recorder.getCacheEvents())
+	})
+	t.Run("should pass reads of directories and hidden keys to the decorated context", func(t *testing.T) {
+		// given
+		tcc := createTestCachedContext(t, CacheOptions{}, map[string]string{"smtp/host": "mail"})
+		defer tcc.stopWatch()
+		tcc.inner.EXPECT().GetContext(mock.Anything, "smtp").Return("", nil)
+		tcc.inner.EXPECT().ExistsContext(mock.Anything, "_hidden").Return(true, nil)
+
+		// when
+		_, errDir := tcc.sut.Get("smtp")
+		exists, errHidden := tcc.sut.Exists("_hidden")
+
+		// then
+		require.NoError(t, errDir)
+		require.NoError(t, errHidden)
+		assert.True(t, exists)
+	})
+
+	t.Run("should update cache with watch events", func(t *testing.T) {
+		// given
+		tcc := createTestCachedContext(t, CacheOptions{}, map[string]string{"url": "https://example.com", "smtp/host": "mail", "smtp/port": "25"})
+		defer tcc.stopWatch()
+
+		// when
+		tcc.events <- WatchEvent{Key: "/config/redmine/url", NewValue: "https://new.example.com", Action: WatchActionUpdate}
+		tcc.events <- WatchEvent{Key: "/config/redmine/smtp", Action: WatchActionDelete, IsDir: true}
+		tcc.events <- WatchEvent{Key: "/config/redmine/logging/root", NewValue: "DEBUG", Action: WatchActionCreate}
+		tcc.events <- WatchEvent{Key: "/config/redmine/_hidden", NewValue: "value", Action: WatchActionCreate}
+
+		// then
+		assert.Eventually(t, func() bool {
+			all, err := tcc.sut.GetAll()
+			return err == nil && assert.ObjectsAreEqual(map[string]string{"url": "https://new.example.com", "logging/root": "DEBUG"}, all)
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should load cache again if the watch missed events", func(t *testing.T) {
+		// given
+		tcc := createTestCachedContext(t, CacheOptions{}, map[string]string{"url": "https://example.com"})
+		defer tcc.stopWatch()
+		tcc.inner.EXPECT().GetAllContext(mock.Anything).Return(map[string]string{"url": "https://new.example.com"}, nil).Once()
+
+		// when
+		tcc.errs <- client.Error{Code: client.ErrorCodeEventIndexCleared}
+
+		// then
+		assert.Eventually(t, func() bool {
+			value, err := tcc.sut.Get("url")
+			return err == nil && value == "https://new.example.com"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should load cache again after time to live", func(t *testing.T) {
+		// given
+		tcc := createTestCachedContext(t, CacheOptions{TimeToLive: 20 * time.Millisecond}, map[string]string{"url": "https://example.com"})
+		defer tcc.stopWatch()
+		tcc.inner.EXPECT().GetAllContext(mock.Anything).Return(map[string]string{"url": "https://new.example.com"}, nil).Once()
+		time.Sleep(30 * time.Millisecond)
+
+		// when
+		value, err := tcc.sut.Get("url")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "https://new.example.com", value)
+	})
+
+	t.Run("should load cache again on refresh", func(t *testing.T) {
+		// given
+		tcc := createTestCachedContext(t, CacheOptions{}, map[string]string{"url": "https://example.com"})
+		defer tcc.stopWatch()
+		tcc.inner.EXPECT().GetAllContext(mock.Anything).Return(nil, assert.AnError).Once()
+		tcc.inner.EXPECT().GetAllContext(mock.Anything).Return(map[string]string{"url": "https://new.example.com"}, nil).Once()
+
+		// when
+		errFailed := tcc.sut.RefreshCache()
+		err := tcc.sut.RefreshCache()
+
+		// then
+		require.Error(t, errFailed)
+		assert.ErrorIs(t, errFailed, assert.AnError)
+		assert.Contains(t, errFailed.Error(), "failed to load cache of /config/redmine")
+		require.NoError(t, err)
+		value, err := tcc.sut.Get("url")
+		require.NoError(t, err)
+		assert.Equal(t, "https://new.example.com", value)
+	})
+
+	t.Run("should update cache with own writes", func(t *testing.T) {
+		// given
+		tcc := createTestCachedContext(t, CacheOptions{}, map[string]string{"url": "https://example.com", "smtp/host": "mail"})
+		defer tcc.stopWatch()
+		tcc.inner.EXPECT().SetContext(mock.Anything, "url", "https://new.example.com").Return(nil)
+		tcc.inner.EXPECT().DeleteRecursiveContext(mock.Anything, "smtp").Return(nil)
+		tcc.inner.EXPECT().CompareAndSwapContext(mock.Anything, "url", "other", "value").Return(false, nil)
+		tcc.inner.EXPECT().ApplyBatchContext(mock.Anything, mock.Anything).Return(nil)
+
+		// when
+		require.NoError(t, tcc.sut.Set("url", "https://new.example.com"))
+		require.NoError(t, tcc.sut.DeleteRecursive("smtp"))
+		swapped, err := tcc.sut.CompareAndSwap("url", "other", "value")
+		require.NoError(t, err)
+		require.False(t, swapped)
+		require.NoError(t, tcc.sut.ApplyBatch([]ConfigurationChange{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}, {Key: "a", Delete: true}}))
+
+		// then
+		all, err := tcc.sut.GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"url": "https://new.example.com", "b": "2"}, all)
+	})
+
+	t.Run("should not overwrite newer changes of the watch with own writes", func(t *testing.T) {
+		// given
+		tcc := createTestCachedContext(t, CacheOptions{}, map[string]string{"url": "https://example.com", "smtp/host": "mail"})
+		defer tcc.stopWatch()
+		tcc.inner.EXPECT().SetContext(mock.Anything, "url", "https://own.example.com").
+			Run(func(context.Context, string, string) {
+				tcc.events <- WatchEvent{Key: "/config/redmine/url", NewValue: "https://other.example.com", Action: WatchActionUpdate}
+				tcc.events <- WatchEvent{Key: "/config/redmine/smtp/host", NewValue: "other", Action: WatchActionUpdate}
+			}).Return(nil)
+		tcc.inner.EXPECT().DeleteRecursiveContext(mock.Anything, "smtp").Return(nil)
+
+		// when
+		errSet := tcc.sut.Set("url", "https://own.example.com")
+		errDelete := tcc.sut.DeleteRecursive("smtp")
+
+		// then
+		require.NoError(t, errSet)
+		require.NoError(t, errDelete)
+		all, err := tcc.sut.GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"url": "https://other.example.com"}, all)
+	})
+
+	t.Run("should load cache again after failed write", func(t *testing.T) {
+		// given
+		tcc := createTestCachedContext(t, CacheOptions{}, map[string]string{"url": "https://example.com"})
+		defer tcc.stopWatch()
+		tcc.inner.EXPECT().SetContext(mock.Anything, "url", "https://new.example.com").Return(assert.AnError)
+		tcc.inner.EXPECT().GetAllContext(mock.Anything).Return(map[string]string{"url": "https://new.example.com"}, nil).Once()
+
+		// when
+		errSet := tcc.sut.Set("url", "https://new.example.com")
+		value, err := tcc.sut.Get("url")
+
+		// then
+		require.ErrorIs(t, errSet, assert.AnError)
+		require.NoError(t, err)
+		assert.Equal(t, "https://new.example.com", value)
+	})
+
+	t.Run("should pass reads to the decorated context after the watch stopped", func(t *testing.T) {
+		// given
+		tcc := createTestCachedContext(t, CacheOptions{}, map[string]string{"url": "https://example.com"})
+		tcc.inner.EXPECT().GetContext(mock.Anything, "url").Return("https://new.example.com", nil)
+
+		// when
+		tcc.stopWatch()
+
+		// then
+		assert.Eventually(t, func() bool {
+			value, err := tcc.sut.Get("url")
+			return err == nil && value == "https://new.example.com"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should fail if the context cannot be loaded", func(t *testing.T) {
+		// given
+		inner := NewMockConfigurationContext(t)
+		inner.EXPECT().GetAllContext(mock.Anything).Return(nil, assert.AnError)
+		watcher := NewMockWatchConfigurationContext(t)
+		events := make(chan WatchEvent)
+		errs := make(chan error)
+		var watchCtx context.Context
+		watcher.EXPECT().GetCurrentIndexContext(mock.Anything, "/config/redmine").Return(uint64(42), nil)
+		watcher.EXPECT().WatchEventsAfter(mock.Anything, "/config/redmine", true, uint64(42)).
+			Run(func(ctx context.Context, _ string, _ bool, _ uint64) { watchCtx = ctx }).
+			Return((<-chan WatchEvent)(events), (<-chan error)(errs))
+		defer close(events)
+		defer close(errs)
+
+		// when
+		_, err := NewCachedConfigurationContext(context.Background(), inner, "/config/redmine", watcher, CacheOptions{})
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Error(t, watchCtx.Err())
+	})
+
+	t.Run("should fail if the index of the registry cannot be read", func(t *testing.T) {
+		// given
+		watcher := NewMockWatchConfigurationContext(t)
+		watcher.EXPECT().GetCurrentIndexContext(mock.Anything, "/config/redmine").Return(uint64(0), assert.AnError)
+
+		// when
+		_, err := NewCachedConfigurationContext(context.Background(), NewMockConfigurationContext(t), "/config/redmine", watcher, CacheOptions{})
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, err.Error(), "failed to start watch for cache of /config/redmine")
+	})
+
+	t.Run("should cache a context which does not exist yet", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		sut, err := NewCachedConfigurationContext(context.Background(), reg.DoguConfig("redmine"), "/config/redmine", reg.RootConfig(), CacheOptions{})
+		require.NoError(t, err)
+
+		// when
+		exists, err := sut.Exists("url")
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestNewCachedConfigurationContext_withInMemoryRegistry(t *testing.T) {
+	// given
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reg := NewInMemoryRegistry()
+	require.NoError(t, reg.DoguConfig("redmine").Set("url", "https://example.com"))
+	sut, err := NewCachedConfigurationContext(ctx, reg.DoguConfig("redmine"), "/config/redmine", reg.RootConfig(), CacheOptions{})
+	require.NoError(t, err)
+
+	// when
+	require.NoError(t, reg.DoguConfig("ldap").Set("url", "ldap://example.com"))
+	// changes directly after the creation are not missed, although the watch is started asynchronously
+	require.NoError(t, reg.DoguConfig("redmine").Set("url", "https://new.example.com"))
+	require.NoError(t, reg.DoguConfig("redmine").Set("logging/root", "DEBUG"))
+
+	// then
+	assert.Eventually(t, func() bool {
+		all, err := sut.GetAll()
+		return err == nil && assert.ObjectsAreEqual(map[string]string{"url": "https://new.example.com", "logging/root": "DEBUG"}, all)
+	}, time.Second, 10*time.Millisecond)
+}
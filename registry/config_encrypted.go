@@ -0,0 +1,273 @@
+package registry
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/cloudogu/cesapp-lib/keys"
+	"github.com/pkg/errors"
+)
+
+// encryptedConfigurationContext decorates the configuration context of a dogu. It encrypts the values of the
+// encrypted configuration fields of the dogu before they are written and decrypts them after they are read.
+// All other keys and operations are passed unchanged to the decorated context.
+type encryptedConfigurationContext struct {
+	ConfigurationContext
+	encryptedKeys map[string]bool
+	publicKey     *keys.PublicKey
+	privateKey    *keys.PrivateKey
+}
+
+// NewEncryptedConfigurationContext creates a configuration context which transparently encrypts and decrypts the
+// values of the encrypted configuration fields of the dogu, so that the caller never handles ciphertext. The values
+// are encrypted with the public key and decrypted with the private key of the key pair, e.g. the key pair of the
+// dogu. Global configuration fields are ignored, because they are not part of the configuration of the dogu. Empty
+// values are neither encrypted nor decrypted.
+func NewEncryptedConfigurationContext(configuration ConfigurationContext, dogu *core.Dogu, keyPair *keys.KeyPair) ConfigurationContext {
+	encryptedKeys := map[string]bool{}
+	for _, field := range dogu.Configuration {
+		if field.Encrypted && !field.Global {
+			encryptedKeys[normalizeEncryptedKey(field.Name)] = true
+		}
+	}
+
+	return &encryptedConfigurationContext{
+		ConfigurationContext: configuration,
+		encryptedKeys:        encryptedKeys,
+		publicKey:            keyPair.Public(),
+		privateKey:           keyPair.Private(),
+	}
+}
+
+func normalizeEncryptedKey(key string) string {
+	return strings.Trim(key, "/")
+}
+
+func (ecc *encryptedConfigurationContext) isEncrypted(key string) bool {
+	return ecc.encryptedKeys[normalizeEncryptedKey(key)]
+}
+
+func (ecc *encryptedConfigurationContext) encrypt(key, value string) (string, error) {
+	if value == "" || !ecc.isEncrypted(key) {
+		return value, nil
+	}
+
+	encryptedValue, err := ecc.publicKey.Encrypt(value)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to encrypt value of key %s", key)
+	}
+
+	return encryptedValue, nil
+}
+
+func (ecc *encryptedConfigurationContext) decrypt(key, value string) (string, error) {
+	if value == "" || !ecc.isEncrypted(key) {
+		return value, nil
+	}
+
+	decryptedValue, err := ecc.privateKey.Decrypt(value)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decrypt value of key %s", key)
+	}
+
+	return decryptedValue, nil
+}
+
+// Set encrypts the value if the key belongs to an encrypted configuration field and sets it in the current context
+func (ecc *encryptedConfigurationContext) Set(key, value string) error {
+	return ecc.SetContext(context.Background(), key, value)
+}
+
+// SetContext encrypts the value if the key belongs to an encrypted configuration field and sets it in the current
+// context
+func (ecc *encryptedConfigurationContext) SetContext(ctx context.Context, key, value string) error {
+	encryptedValue, err := ecc.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+
+	return ecc.ConfigurationContext.SetContext(ctx, key, encryptedValue)
+}
+
+// SetWithLifetime encrypts the value if the key belongs to an encrypted configuration field and sets it in the
+// current context with the given lifetime
+func (ecc *encryptedConfigurationContext) SetWithLifetime(key, value string, timeToLiveInSeconds int) error {
+	return ecc.SetWithLifetimeContext(context.Background(), key, value, timeToLiveInSeconds)
+}
+
+// SetWithLifetimeContext encrypts the value if the key belongs to an encrypted configuration field and sets it in
+// the current context with the given lifetime
+func (ecc *encryptedConfigurationContext) SetWithLifetimeContext(ctx context.Context, key, value string, timeToLiveInSeconds int) error {
+	encryptedValue, err := ecc.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+
+	return ecc.ConfigurationContext.SetWithLifetimeContext(ctx, key, encryptedValue, timeToLiveInSeconds)
+}
+
+// Get returns a configuration value from the current context and decrypts it if the key belongs to an encrypted
+// configuration field
+func (ecc *encryptedConfigurationContext) Get(key string) (string, error) {
+	return ecc.GetContext(context.Background(), key)
+}
+
+// GetContext returns a configuration value from the current context and decrypts it if the key belongs to an
+// encrypted configuration field
+func (ecc *encryptedConfigurationContext) GetContext(ctx context.Context, key string) (string, error) {
+	value, err := ecc.ConfigurationContext.GetContext(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return ecc.decrypt(key, value)
+}
+
+// GetAll returns a map of key value pairs with decrypted values of the encrypted configuration fields
+func (ecc *encryptedConfigurationContext) GetAll() (map[string]string, error) {
+	return ecc.GetAllContext(context.Background())
+}
+
+// GetAllContext returns a map of key value pairs with decrypted values of the encrypted configuration fields
+func (ecc *encryptedConfigurationContext) GetAllContext(ctx context.Context) (map[string]string, error) {
+	keyValuePairs, err := ecc.ConfigurationContext.GetAllContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range keyValuePairs {
+		keyValuePairs[key], err = ecc.decrypt(key, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return keyValuePairs, nil
+}
+
+// GetOrFalse works like GetOrFalse of the decorated context and decrypts the value if the key belongs to an
+// encrypted configuration field
+func (ecc *encryptedConfigurationContext) GetOrFalse(key string) (bool, string, error) {
+	return ecc.GetOrFalseContext(context.Background(), key)
+}
+
+// GetOrFalseContext works like GetOrFalseContext of the decorated context and decrypts the value if the key belongs
+// to an encrypted configuration field
+func (ecc *encryptedConfigurationContext) GetOrFalseContext(ctx context.Context, key string) (bool, string, error) {
+	exists, value, err := ecc.ConfigurationContext.GetOrFalseContext(ctx, key)
+	if err != nil || !exists {
+		return exists, value, err
+	}
+
+	decryptedValue, err := ecc.decrypt(key, value)
+	if err != nil {
+		return false, "", err
+	}
+
+	return true, decryptedValue, nil
+}
+
+// SetIfAbsent encrypts the value if the key belongs to an encrypted configuration field and sets it only if the key
+// does not exist yet
+func (ecc *encryptedConfigurationContext) SetIfAbsent(key, value string) (bool, error) {
+	return ecc.SetIfAbsentContext(context.Background(), key, value)
+}
+
+// SetIfAbsentContext encrypts the value if the key belongs to an encrypted configuration field and sets it only if
+// the key does not exist yet
+func (ecc *encryptedConfigurationContext) SetIfAbsentContext(ctx context.Context, key, value string) (bool, error) {
+	encryptedValue, err := ecc.encrypt(key, value)
+	if err != nil {
+		return false, err
+	}
+
+	return ecc.ConfigurationContext.SetIfAbsentContext(ctx, key, encryptedValue)
+}
+
+// CompareAndSwap sets the key to the new value only if its current value equals the old value. The values of
+// encrypted configuration fields are compared in plain text.
+func (ecc *encryptedConfigurationContext) CompareAndSwap(key, oldValue, newValue string) (bool, error) {
+	return ecc.CompareAndSwapContext(context.Background(), key, oldValue, newValue)
+}
+
+// CompareAndSwapContext sets the key to the new value only if its current value equals the old value. The values of
+// encrypted configuration fields are compared in plain text.
+func (ecc *encryptedConfigurationContext) CompareAndSwapContext(ctx context.Context, key, oldValue, newValue string) (bool, error) {
+	if !ecc.isEncrypted(key) {
+		return ecc.ConfigurationContext.CompareAndSwapContext(ctx, key, oldValue, newValue)
+	}
+
+	// the encryption is not deterministic, so the stored ciphertext is compared instead of the encrypted old value
+	storedValue, matches, err := ecc.storedValueIfMatches(ctx, key, oldValue)
+	if err != nil || !matches {
+		return false, err
+	}
+
+	encryptedValue, err := ecc.encrypt(key, newValue)
+	if err != nil {
+		return false, err
+	}
+
+	return ecc.ConfigurationContext.CompareAndSwapContext(ctx, key, storedValue, encryptedValue)
+}
+
+// CompareAndDelete removes the key only if its current value equals the old value. The values of encrypted
+// configuration fields are compared in plain text.
+func (ecc *encryptedConfigurationContext) CompareAndDelete(key, oldValue string) (bool, error) {
+	return ecc.CompareAndDeleteContext(context.Background(), key, oldValue)
+}
+
+// CompareAndDeleteContext removes the key only if its current value equals the old value. The values of encrypted
+// configuration fields are compared in plain text.
+func (ecc *encryptedConfigurationContext) CompareAndDeleteContext(ctx context.Context, key, oldValue string) (bool, error) {
+	if !ecc.isEncrypted(key) {
+		return ecc.ConfigurationContext.CompareAndDeleteContext(ctx, key, oldValue)
+	}
+
+	storedValue, matches, err := ecc.storedValueIfMatches(ctx, key, oldValue)
+	if err != nil || !matches {
+		return false, err
+	}
+
+	return ecc.ConfigurationContext.CompareAndDeleteContext(ctx, key, storedValue)
+}
+
+// storedValueIfMatches returns the stored value of the key if its decrypted value equals the expected value.
+func (ecc *encryptedConfigurationContext) storedValueIfMatches(ctx context.Context, key, expectedValue string) (string, bool, error) {
+	exists, storedValue, err := ecc.ConfigurationContext.GetOrFalseContext(ctx, key)
+	if err != nil || !exists {
+		return "", false, err
+	}
+
+	decryptedValue, err := ecc.decrypt(key, storedValue)
+	if err != nil {
+		return "", false, err
+	}
+
+	return storedValue, decryptedValue == expectedValue, nil
+}
+
+// ApplyBatch encrypts the values of the encrypted configuration fields and applies all changes to the current
+// context. Either all changes are applied or none of them.
+func (ecc *encryptedConfigurationContext) ApplyBatch(changes []ConfigurationChange) error {
+	return ecc.ApplyBatchContext(context.Background(), changes)
+}
+
+// ApplyBatchContext encrypts the values of the encrypted configuration fields and applies all changes to the current
+// context. Either all changes are applied or none of them.
+func (ecc *encryptedConfigurationContext) ApplyBatchContext(ctx context.Context, changes []ConfigurationChange) error {
+	encryptedChanges := make([]ConfigurationChange, 0, len(changes))
+	for _, change := range changes {
+		if !change.Delete {
+			encryptedValue, err := ecc.encrypt(change.Key, change.Value)
+			if err != nil {
+				return err
+			}
+			change.Value = encryptedValue
+		}
+		encryptedChanges = append(encryptedChanges, change)
+	}
+
+	return ecc.ConfigurationContext.ApplyBatchContext(ctx, encryptedChanges)
+}
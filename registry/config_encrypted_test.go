@@ -0,0 +1,192 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/cloudogu/cesapp-lib/keys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEncryptedDogu = &core.Dogu{
+	Name: "official/redmine",
+	Configuration: []core.ConfigurationField{
+		{Name: "logging/root"},
+		{Name: "smtp/password", Encrypted: true},
+		{Name: "license", Encrypted: true, Global: true},
+	},
+}
+
+func createTestEncryptedConfigurationContext(t *testing.T) (ConfigurationContext, ConfigurationContext, *keys.KeyPair) {
+	t.Helper()
+
+	provider, err := keys.NewKeyProvider("")
+	require.NoError(t, err)
+	keyPair, err := provider.Generate()
+	require.NoError(t, err)
+
+	configuration := NewInMemoryRegistry().DoguConfig("redmine")
+	return NewEncryptedConfigurationContext(configuration, testEncryptedDogu, keyPair), configuration, keyPair
+}
+
+func TestEncryptedConfigurationContext_Set(t *testing.T) {
+	t.Run("should encrypt values of encrypted fields", func(t *testing.T) {
+		// given
+		sut, configuration, keyPair := createTestEncryptedConfigurationContext(t)
+
+		// when
+		err := sut.Set("smtp/password", "secret")
+
+		// then
+		require.NoError(t, err)
+		storedValue, err := configuration.Get("smtp/password")
+		require.NoError(t, err)
+		assert.NotEqual(t, "secret", storedValue)
+		decryptedValue, err := keyPair.Private().Decrypt(storedValue)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", decryptedValue)
+	})
+
+	t.Run("should not encrypt other keys, global fields and empty values", func(t *testing.T) {
+		// given
+		sut, configuration, _ := createTestEncryptedConfigurationContext(t)
+
+		// when
+		require.NoError(t, sut.Set("logging/root", "DEBUG"))
+		require.NoError(t, sut.Set("license", "public"))
+		require.NoError(t, sut.SetWithLifetime("/smtp/password/", "", 60))
+
+		// then
+		all, err := configuration.GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"logging/root": "DEBUG", "license": "public", "smtp/password": ""}, all)
+	})
+}
+
+func TestEncryptedConfigurationContext_Get(t *testing.T) {
+	t.Run("should decrypt values of encrypted fields", func(t *testing.T) {
+		// given
+		sut, configuration, keyPair := createTestEncryptedConfigurationContext(t)
+		encryptedValue, err := keyPair.Public().Encrypt("secret")
+		require.NoError(t, err)
+		require.NoError(t, configuration.Set("smtp/password", encryptedValue))
+		require.NoError(t, configuration.Set("logging/root", "DEBUG"))
+
+		// when
+		value, err := sut.Get("smtp/password")
+		exists, valueOrFalse, errOrFalse := sut.GetOrFalse("smtp/password")
+		all, errAll := sut.GetAll()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "secret", value)
+		require.NoError(t, errOrFalse)
+		assert.True(t, exists)
+		assert.Equal(t, "secret", valueOrFalse)
+		require.NoError(t, errAll)
+		assert.Equal(t, map[string]string{"smtp/password": "secret", "logging/root": "DEBUG"}, all)
+	})
+
+	t.Run("should return false for missing keys", func(t *testing.T) {
+		sut, _, _ := createTestEncryptedConfigurationContext(t)
+
+		exists, value, err := sut.GetOrFalse("smtp/password")
+
+		require.NoError(t, err)
+		assert.False(t, exists)
+		assert.Empty(t, value)
+	})
+
+	t.Run("should fail if value cannot be decrypted", func(t *testing.T) {
+		// given
+		sut, configuration, _ := createTestEncryptedConfigurationContext(t)
+		require.NoError(t, configuration.Set("smtp/password", "plain"))
+
+		// when
+		_, err := sut.Get("smtp/password")
+		_, errAll := sut.GetAll()
+
+		// then
+		assert.ErrorContains(t, err, "failed to decrypt value of key smtp/password")
+		assert.ErrorContains(t, errAll, "failed to decrypt value of key smtp/password")
+	})
+}
+
+func TestEncryptedConfigurationContext_ConditionalWrites(t *testing.T) {
+	t.Run("should compare encrypted values in plain text", func(t *testing.T) {
+		// given
+		sut, _, _ := createTestEncryptedConfigurationContext(t)
+		created, err := sut.SetIfAbsent("smtp/password", "first")
+		require.NoError(t, err)
+		require.True(t, created)
+
+		// when
+		swappedWrongValue, errWrongValue := sut.CompareAndSwap("smtp/password", "other", "second")
+		swapped, errSwap := sut.CompareAndSwap("smtp/password", "first", "second")
+
+		// then
+		require.NoError(t, errWrongValue)
+		assert.False(t, swappedWrongValue)
+		require.NoError(t, errSwap)
+		assert.True(t, swapped)
+		value, err := sut.Get("smtp/password")
+		require.NoError(t, err)
+		assert.Equal(t, "second", value)
+	})
+
+	t.Run("should delete encrypted values compared in plain text", func(t *testing.T) {
+		// given
+		sut, configuration, _ := createTestEncryptedConfigurationContext(t)
+		require.NoError(t, sut.Set("smtp/password", "secret"))
+
+		// when
+		deletedWrongValue, errWrongValue := sut.CompareAndDelete("smtp/password", "other")
+		deleted, errDelete := sut.CompareAndDelete("smtp/password", "secret")
+		deletedMissing, errMissing := sut.CompareAndDelete("smtp/password", "secret")
+
+		// then
+		require.NoError(t, errWrongValue)
+		assert.False(t, deletedWrongValue)
+		require.NoError(t, errDelete)
+		assert.True(t, deleted)
+		require.NoError(t, errMissing)
+		assert.False(t, deletedMissing)
+		exists, err := configuration.Exists("smtp/password")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should pass other keys to the decorated context", func(t *testing.T) {
+		sut, _, _ := createTestEncryptedConfigurationContext(t)
+		require.NoError(t, sut.Set("logging/root", "INFO"))
+
+		swapped, err := sut.CompareAndSwap("logging/root", "INFO", "DEBUG")
+
+		require.NoError(t, err)
+		assert.True(t, swapped)
+	})
+}
+
+func TestEncryptedConfigurationContext_ApplyBatch(t *testing.T) {
+	// given
+	sut, configuration, keyPair := createTestEncryptedConfigurationContext(t)
+	require.NoError(t, sut.Set("old", "value"))
+
+	// when
+	err := sut.ApplyBatch([]ConfigurationChange{
+		{Key: "smtp/password", Value: "secret"},
+		{Key: "logging/root", Value: "DEBUG"},
+		{Key: "old", Delete: true},
+	})
+
+	// then
+	require.NoError(t, err)
+	all, err := configuration.GetAll()
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	assert.Equal(t, "DEBUG", all["logging/root"])
+	decryptedValue, err := keyPair.Private().Decrypt(all["smtp/password"])
+	require.NoError(t, err)
+	assert.Equal(t, "secret", decryptedValue)
+}
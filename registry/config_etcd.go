@@ -12,14 +12,26 @@ import (
 )
 
 type etcdClient interface {
-	Exists(key string) (bool, error)
-	Get(key string) (string, error)
-	GetRecursive(key string) (map[string]string, error)
-	GetChildrenPaths(key string) ([]string, error)
-	Set(key string, value string, options *client.SetOptions) (string, error)
-	Delete(key string, options *client.DeleteOptions) error
-	DeleteRecursive(key string) error
-	Watch(ctx context.Context, key string, recursive bool, eventChannel chan *client.Response)
+	Exists(ctx context.Context, key string) (bool, error)
+	Get(ctx context.Context, key string) (string, error)
+	GetRecursive(ctx context.Context, key string) (map[string]string, error)
+	GetChildrenPaths(ctx context.Context, key string) ([]string, error)
+	Set(ctx context.Context, key string, value string, options *client.SetOptions) (string, error)
+	Delete(ctx context.Context, key string, options *client.DeleteOptions) error
+	DeleteRecursive(ctx context.Context, key string) error
+	Watch(ctx context.Context, key string, recursive bool, afterIndex uint64, eventChannel chan<- *client.Response) error
+	GetMainNode(ctx context.Context) (*client.Node, error)
+	GetTree(ctx context.Context, key string) (*client.Node, error)
+	GetWithIndex(ctx context.Context, key string) (string, uint64, error)
+	GetCurrentIndex(ctx context.Context, key string) (uint64, error)
+	ApplyBatch(ctx context.Context, changes []keyChange) error
+}
+
+// keyChange is a single change of a batch which is applied by an etcdClient.
+type keyChange struct {
+	key    string
+	value  string
+	delete bool
 }
 
 type etcdConfigurationContext struct {
@@ -28,44 +40,62 @@ type etcdConfigurationContext struct {
 }
 
 type etcdWatchConfigurationContext struct {
-	client etcdClient
+	client           etcdClient
+	reconnectBackoff []time.Duration
+	reconnectJitter  float64
+	metrics          core.MetricsRecorder
 }
 
 // Set sets a configuration value in current context
 func (ecc *etcdConfigurationContext) Set(key, value string) error {
-	return ecc.set(key, value, nil)
+	return ecc.SetContext(context.Background(), key, value)
+}
+
+// SetContext sets a configuration value in current context
+func (ecc *etcdConfigurationContext) SetContext(ctx context.Context, key, value string) error {
+	return ecc.set(ctx, key, value, nil)
 }
 
 // SetWithLifetime sets a configuration value in current context with the given lifetime
 func (ecc *etcdConfigurationContext) SetWithLifetime(key, value string, timeToLiveInSeconds int) error {
+	return ecc.SetWithLifetimeContext(context.Background(), key, value, timeToLiveInSeconds)
+}
+
+// SetWithLifetimeContext sets a configuration value in current context with the given lifetime
+func (ecc *etcdConfigurationContext) SetWithLifetimeContext(ctx context.Context, key, value string, timeToLiveInSeconds int) error {
 	duration, err := time.ParseDuration(fmt.Sprintf("%ds", timeToLiveInSeconds))
 	if err != nil {
 		return errors.Wrapf(err, "could not create the curation '%d'", timeToLiveInSeconds)
 	}
-	return ecc.set(key, value, &client.SetOptions{
+	return ecc.set(ctx, key, value, &client.SetOptions{
 		TTL: duration,
 	})
 }
 
 // Refresh will refresh the ttl of a key
 func (ecc *etcdConfigurationContext) Refresh(key string, timeToLiveInSeconds int) error {
+	return ecc.RefreshContext(context.Background(), key, timeToLiveInSeconds)
+}
+
+// RefreshContext will refresh the ttl of a key
+func (ecc *etcdConfigurationContext) RefreshContext(ctx context.Context, key string, timeToLiveInSeconds int) error {
 	duration, err := time.ParseDuration(fmt.Sprintf("%ds", timeToLiveInSeconds))
 	if err != nil {
 		return errors.Wrapf(err, "could not create the curation '%d'", timeToLiveInSeconds)
 	}
 
-	err = ecc.set(key, "", &client.SetOptions{
+	err = ecc.set(ctx, key, "", &client.SetOptions{
 		TTL:     duration,
 		Refresh: true,
 	})
 	return err
 }
 
-func (ecc *etcdConfigurationContext) set(key, value string, options *client.SetOptions) error {
+func (ecc *etcdConfigurationContext) set(ctx context.Context, key, value string, options *client.SetOptions) error {
 	path := ecc.parent + "/" + key
 	core.GetLogger().Debug("try to set config key", path)
 
-	_, err := ecc.client.Set(path, value, options)
+	_, err := ecc.client.Set(ctx, path, value, options)
 	if err != nil {
 		return errors.Wrapf(err, "could not set value %s", path)
 	}
@@ -73,15 +103,26 @@ func (ecc *etcdConfigurationContext) set(key, value string, options *client.SetO
 	return err
 }
 
+// Get returns a configuration value from the current context
 func (ecc *etcdConfigurationContext) Get(key string) (string, error) {
-	return Get(ecc.parent, key, ecc.client)
+	return ecc.GetContext(context.Background(), key)
+}
+
+// GetContext returns a configuration value from the current context
+func (ecc *etcdConfigurationContext) GetContext(ctx context.Context, key string) (string, error) {
+	return get(ctx, ecc.parent, key, ecc.client)
 }
 
 // GetAll returns a map of key value pairs
 func (ecc *etcdConfigurationContext) GetAll() (map[string]string, error) {
+	return ecc.GetAllContext(context.Background())
+}
+
+// GetAllContext returns a map of key value pairs
+func (ecc *etcdConfigurationContext) GetAllContext(ctx context.Context) (map[string]string, error) {
 	core.GetLogger().Debugf("try to get all configuration keys and values from %s", ecc.parent)
 
-	keyValuePairs, err := ecc.client.GetRecursive(ecc.parent)
+	keyValuePairs, err := ecc.client.GetRecursive(ctx, ecc.parent)
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not get key value pairs recursive from %s", ecc.parent)
 	}
@@ -91,10 +132,15 @@ func (ecc *etcdConfigurationContext) GetAll() (map[string]string, error) {
 
 // Delete removes a configuration key and value from the current context
 func (ecc *etcdConfigurationContext) Delete(key string) error {
+	return ecc.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext removes a configuration key and value from the current context
+func (ecc *etcdConfigurationContext) DeleteContext(ctx context.Context, key string) error {
 	path := ecc.parent + "/" + key
 	core.GetLogger().Debug("try to delete config key", path)
 
-	err := ecc.client.Delete(path, nil)
+	err := ecc.client.Delete(ctx, path, nil)
 	if err != nil {
 		return errors.Wrapf(err, "could not delete value at %s", path)
 	}
@@ -103,10 +149,15 @@ func (ecc *etcdConfigurationContext) Delete(key string) error {
 
 // DeleteRecursive deletes a configuration key from the current context recursively.
 func (ecc *etcdConfigurationContext) DeleteRecursive(key string) error {
+	return ecc.DeleteRecursiveContext(context.Background(), key)
+}
+
+// DeleteRecursiveContext deletes a configuration key from the current context recursively.
+func (ecc *etcdConfigurationContext) DeleteRecursiveContext(ctx context.Context, key string) error {
 	path := ecc.parent + "/" + key
 	core.GetLogger().Debugf("try to delete config key '%s' recursive", path)
 
-	err := ecc.client.DeleteRecursive(path)
+	err := ecc.client.DeleteRecursive(ctx, path)
 	if err != nil {
 		return errors.Wrapf(err, "could not delete value at %s", path)
 	}
@@ -115,10 +166,15 @@ func (ecc *etcdConfigurationContext) DeleteRecursive(key string) error {
 
 // Exists returns true if configuration key exists in the current context
 func (ecc *etcdConfigurationContext) Exists(key string) (bool, error) {
+	return ecc.ExistsContext(context.Background(), key)
+}
+
+// ExistsContext returns true if configuration key exists in the current context
+func (ecc *etcdConfigurationContext) ExistsContext(ctx context.Context, key string) (bool, error) {
 	path := ecc.parent + "/" + key
 	core.GetLogger().Debugf("try to check if config key %s exists", path)
 
-	exists, err := ecc.client.Exists(path)
+	exists, err := ecc.client.Exists(ctx, path)
 	if err != nil {
 		return false, errors.Wrapf(err, "failed to check if key %s exists", path)
 	}
@@ -128,7 +184,12 @@ func (ecc *etcdConfigurationContext) Exists(key string) (bool, error) {
 
 // RemoveAll removes all configuration key
 func (ecc *etcdConfigurationContext) RemoveAll() error {
-	err := ecc.client.DeleteRecursive(ecc.parent)
+	return ecc.RemoveAllContext(context.Background())
+}
+
+// RemoveAllContext removes all configuration key
+func (ecc *etcdConfigurationContext) RemoveAllContext(ctx context.Context) error {
+	err := ecc.client.DeleteRecursive(ctx, ecc.parent)
 	if err != nil {
 		return errors.Wrapf(err, "could not remove all configuration keys from %s", ecc.parent)
 	}
@@ -138,7 +199,13 @@ func (ecc *etcdConfigurationContext) RemoveAll() error {
 // GetOrFalse return false and empty string when the configuration value does not exist.
 // Otherwise return true and the configuration value, even when the configuration value is an empty string.
 func (ecc *etcdConfigurationContext) GetOrFalse(key string) (bool, string, error) {
-	exists, err := ecc.Exists(key)
+	return ecc.GetOrFalseContext(context.Background(), key)
+}
+
+// GetOrFalseContext return false and empty string when the configuration value does not exist.
+// Otherwise return true and the configuration value, even when the configuration value is an empty string.
+func (ecc *etcdConfigurationContext) GetOrFalseContext(ctx context.Context, key string) (bool, string, error) {
+	exists, err := ecc.ExistsContext(ctx, key)
 	if err != nil {
 		return false, "", err
 	}
@@ -147,7 +214,7 @@ func (ecc *etcdConfigurationContext) GetOrFalse(key string) (bool, string, error
 		return false, "", nil
 	}
 
-	value, err := ecc.Get(key)
+	value, err := ecc.GetContext(ctx, key)
 	if err != nil {
 		return false, "", err
 	}
@@ -155,19 +222,218 @@ func (ecc *etcdConfigurationContext) GetOrFalse(key string) (bool, string, error
 	return true, value, nil
 }
 
-// Watch watches for changes of the provided key and sends the event through the channel
+// SetIfAbsent sets a configuration value in current context only if the key does not exist yet. It returns false
+// if the key already exists.
+func (ecc *etcdConfigurationContext) SetIfAbsent(key, value string) (bool, error) {
+	return ecc.SetIfAbsentContext(context.Background(), key, value)
+}
+
+// SetIfAbsentContext sets a configuration value in current context only if the key does not exist yet. It returns
+// false if the key already exists.
+func (ecc *etcdConfigurationContext) SetIfAbsentContext(ctx context.Context, key, value string) (bool, error) {
+	err := ecc.set(ctx, key, value, &client.SetOptions{PrevExist: client.PrevNoExist})
+	if hasErrorCode(err, client.ErrorCodeNodeExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CompareAndSwap sets the key to the new value only if its current value equals the old value. It returns false if
+// the key does not exist or has another value.
+func (ecc *etcdConfigurationContext) CompareAndSwap(key, oldValue, newValue string) (bool, error) {
+	return ecc.CompareAndSwapContext(context.Background(), key, oldValue, newValue)
+}
+
+// CompareAndSwapContext sets the key to the new value only if its current value equals the old value. It returns
+// false if the key does not exist or has another value.
+func (ecc *etcdConfigurationContext) CompareAndSwapContext(ctx context.Context, key, oldValue, newValue string) (bool, error) {
+	options := &client.SetOptions{PrevExist: client.PrevExist, PrevValue: oldValue}
+	if oldValue == "" {
+		index, matches, err := ecc.indexOfValue(ctx, key, oldValue)
+		if err != nil || !matches {
+			return false, err
+		}
+		options = &client.SetOptions{PrevIndex: index}
+	}
+
+	err := ecc.set(ctx, key, newValue, options)
+	if IsKeyNotFoundError(err) || hasErrorCode(err, client.ErrorCodeTestFailed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CompareAndDelete removes the key only if its current value equals the old value. It returns false if the key does
+// not exist or has another value.
+func (ecc *etcdConfigurationContext) CompareAndDelete(key, oldValue string) (bool, error) {
+	return ecc.CompareAndDeleteContext(context.Background(), key, oldValue)
+}
+
+// CompareAndDeleteContext removes the key only if its current value equals the old value. It returns false if the
+// key does not exist or has another value.
+func (ecc *etcdConfigurationContext) CompareAndDeleteContext(ctx context.Context, key, oldValue string) (bool, error) {
+	options := &client.DeleteOptions{PrevValue: oldValue}
+	if oldValue == "" {
+		index, matches, err := ecc.indexOfValue(ctx, key, oldValue)
+		if err != nil || !matches {
+			return false, err
+		}
+		options = &client.DeleteOptions{PrevIndex: index}
+	}
+
+	path := ecc.parent + "/" + key
+	core.GetLogger().Debug("try to compare and delete config key", path)
+
+	err := ecc.client.Delete(ctx, path, options)
+	if IsKeyNotFoundError(err) || hasErrorCode(err, client.ErrorCodeTestFailed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "could not delete value at %s", path)
+	}
+
+	return true, nil
+}
+
+// indexOfValue returns the modified index of the key if it has the expected value. etcd ignores an empty previous
+// value in conditional requests, so empty values are compared by their index instead.
+func (ecc *etcdConfigurationContext) indexOfValue(ctx context.Context, key, expectedValue string) (uint64, bool, error) {
+	path := ecc.parent + "/" + key
+
+	value, index, err := ecc.client.GetWithIndex(ctx, path)
+	if IsKeyNotFoundError(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "could not get value %s", path)
+	}
+
+	return index, value == expectedValue, nil
+}
+
+// ApplyBatch applies all changes to the current context. The batch is only atomic if the client supports
+// transactions, see ConfigurationContext.ApplyBatch.
+func (ecc *etcdConfigurationContext) ApplyBatch(changes []ConfigurationChange) error {
+	return ecc.ApplyBatchContext(context.Background(), changes)
+}
+
+// ApplyBatchContext works like ApplyBatch. The given context limits the time of the request.
+func (ecc *etcdConfigurationContext) ApplyBatchContext(ctx context.Context, changes []ConfigurationChange) error {
+	core.GetLogger().Debugf("try to apply %d changes to %s", len(changes), ecc.parent)
+
+	keyChanges := make([]keyChange, 0, len(changes))
+	paths := map[string]bool{}
+	for _, change := range changes {
+		if change.Key == "" {
+			return errors.Errorf("could not apply batch to %s: change without key", ecc.parent)
+		}
+
+		path := normalizeKey(ecc.parent + "/" + change.Key)
+		if paths[path] {
+			return errors.Errorf("could not apply batch to %s: key %s is changed more than once", ecc.parent, path)
+		}
+		paths[path] = true
+
+		keyChanges = append(keyChanges, keyChange{key: path, value: change.Value, delete: change.Delete})
+	}
+
+	if len(keyChanges) == 0 {
+		return nil
+	}
+
+	err := ecc.client.ApplyBatch(ctx, keyChanges)
+	if err != nil {
+		return errors.Wrapf(err, "could not apply batch to %s", ecc.parent)
+	}
+
+	return nil
+}
+
+// Watch watches for changes of the provided key and sends the event through the channel. The call blocks until the
+// given context is done.
+//
+// Deprecated: Watch exposes the responses of the etcd v2 client. Use WatchEvents instead.
 func (ewcc *etcdWatchConfigurationContext) Watch(ctx context.Context, key string, recursive bool, eventChannel chan *client.Response) {
 	core.GetLogger().Debugf("starting watcher on key %s", key)
-	ewcc.client.Watch(ctx, key, recursive, eventChannel)
+	watchWithReconnect(ctx, ewcc.client, key, recursive, 0, ewcc.reconnectBackoff, ewcc.reconnectJitter, ewcc.metrics,
+		func(response *client.Response) {
+			select {
+			case eventChannel <- response:
+			case <-ctx.Done():
+			}
+		},
+		func(error) {
+			// the error is already logged and the deprecated api has no way to report it
+		})
 }
 
+// WatchEvents watches for changes of the provided key until the given context is done. The changes are sent through
+// the returned event channel. If the watch is interrupted, the error is sent through the returned error channel and
+// the watch is reconnected after the last received event. Both channels have to be consumed and are closed when the
+// context is done.
+func (ewcc *etcdWatchConfigurationContext) WatchEvents(ctx context.Context, key string, recursive bool) (<-chan WatchEvent, <-chan error) {
+	return ewcc.WatchEventsAfter(ctx, key, recursive, 0)
+}
+
+// WatchEventsAfter works like WatchEvents, but sends only the changes after the given index of the registry. The
+// watch starts at the current index if the given index is 0.
+func (ewcc *etcdWatchConfigurationContext) WatchEventsAfter(ctx context.Context, key string, recursive bool, afterIndex uint64) (<-chan WatchEvent, <-chan error) {
+	core.GetLogger().Debugf("starting watcher on key %s after index %d", key, afterIndex)
+
+	events := make(chan WatchEvent)
+	errs := make(chan error)
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		watchWithReconnect(ctx, ewcc.client, key, recursive, afterIndex, ewcc.reconnectBackoff, ewcc.reconnectJitter, ewcc.metrics,
+			func(response *client.Response) {
+				select {
+				case events <- newWatchEvent(response):
+				case <-ctx.Done():
+				}
+			},
+			func(err error) {
+				select {
+				case errs <- err:
+				case <-ctx.Done():
+				}
+			})
+	}()
+
+	return events, errs
+}
+
+// GetCurrentIndexContext returns the current index of the registry
+func (ewcc *etcdWatchConfigurationContext) GetCurrentIndexContext(ctx context.Context, key string) (uint64, error) {
+	return ewcc.client.GetCurrentIndex(ctx, key)
+}
+
+// Get returns a configuration value from the root context
 func (ewcc *etcdWatchConfigurationContext) Get(key string) (string, error) {
-	return Get("", key, ewcc.client)
+	return ewcc.GetContext(context.Background(), key)
+}
+
+// GetContext returns a configuration value from the root context
+func (ewcc *etcdWatchConfigurationContext) GetContext(ctx context.Context, key string) (string, error) {
+	return get(ctx, "", key, ewcc.client)
 }
 
 // Get returns a configuration value from the current context, otherwise it returns an error. If the given key cannot be
 // found a KeyNotFoundError is returned.
 func Get(parent string, key string, client etcdClient) (string, error) {
+	return get(context.Background(), parent, key, client)
+}
+
+func get(ctx context.Context, parent string, key string, client etcdClient) (string, error) {
 	path := parent + "/" + key
 	if parent == "" {
 		if strings.HasPrefix(key, "/") {
@@ -177,7 +443,7 @@ func Get(parent string, key string, client etcdClient) (string, error) {
 
 	core.GetLogger().Debug("try to get config key", path)
 
-	value, err := client.Get(path)
+	value, err := client.Get(ctx, path)
 	if err != nil {
 		return "", errors.Wrapf(err, "could not get value %s", path)
 	}
@@ -186,5 +452,10 @@ func Get(parent string, key string, client etcdClient) (string, error) {
 
 // GetChildrenPaths returns an array of all children keys of the given key
 func (ewcc *etcdWatchConfigurationContext) GetChildrenPaths(key string) ([]string, error) {
-	return ewcc.client.GetChildrenPaths(key)
+	return ewcc.GetChildrenPathsContext(context.Background(), key)
+}
+
+// GetChildrenPathsContext returns an array of all children keys of the given key
+func (ewcc *etcdWatchConfigurationContext) GetChildrenPathsContext(ctx context.Context, key string) ([]string, error) {
+	return ewcc.client.GetChildrenPaths(ctx, key)
 }
@@ -14,7 +14,7 @@ func TestGet(t *testing.T) {
 	t.Run("successfully get key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Get", "test/testKey").Return("testValue", nil)
+		etcdClientMock.On("Get", mock.Anything, "test/testKey").Return("testValue", nil)
 
 		sut := &etcdConfigurationContext{
 			parent: "test",
@@ -33,7 +33,7 @@ func TestGet(t *testing.T) {
 	t.Run("error on getting key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Get", "test/testKey").Return("", assert.AnError)
+		etcdClientMock.On("Get", mock.Anything, "test/testKey").Return("", assert.AnError)
 
 		sut := &etcdConfigurationContext{
 			parent: "test",
@@ -53,7 +53,7 @@ func Test_etcdConfigurationContext_Delete(t *testing.T) {
 	t.Run("Successfully delete key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Delete", "test/testKey", mock.Anything).Return(nil)
+		etcdClientMock.On("Delete", mock.Anything, "test/testKey", mock.Anything).Return(nil)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -71,7 +71,7 @@ func Test_etcdConfigurationContext_Delete(t *testing.T) {
 	t.Run("error on deleting key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Delete", "test/testKey", mock.Anything).Return(assert.AnError)
+		etcdClientMock.On("Delete", mock.Anything, "test/testKey", mock.Anything).Return(assert.AnError)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -91,7 +91,7 @@ func Test_etcdConfigurationContext_DeleteRecursive(t *testing.T) {
 	t.Run("Successfully delete key recursively from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("DeleteRecursive", "test/testKey").Return(nil)
+		etcdClientMock.On("DeleteRecursive", mock.Anything, "test/testKey").Return(nil)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -109,7 +109,7 @@ func Test_etcdConfigurationContext_DeleteRecursive(t *testing.T) {
 	t.Run("error on deleting key recursively from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("DeleteRecursive", "test/testKey").Return(assert.AnError)
+		etcdClientMock.On("DeleteRecursive", mock.Anything, "test/testKey").Return(assert.AnError)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -129,7 +129,7 @@ func Test_etcdConfigurationContext_Exists(t *testing.T) {
 	t.Run("Successfully checking key existence in registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Exists", "test/testKey", mock.Anything).Return(true, nil)
+		etcdClientMock.On("Exists", mock.Anything, "test/testKey").Return(true, nil)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -148,7 +148,7 @@ func Test_etcdConfigurationContext_Exists(t *testing.T) {
 	t.Run("error on checking key existence in registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Exists", "test/testKey", mock.Anything).Return(false, assert.AnError)
+		etcdClientMock.On("Exists", mock.Anything, "test/testKey").Return(false, assert.AnError)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -169,7 +169,7 @@ func Test_etcdConfigurationContext_Get(t *testing.T) {
 	t.Run("Successfully getting key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Get", "test/testKey").Return("testValue", nil)
+		etcdClientMock.On("Get", mock.Anything, "test/testKey").Return("testValue", nil)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -184,6 +184,27 @@ func Test_etcdConfigurationContext_Get(t *testing.T) {
 		assert.Equal(t, "testValue", value)
 		mock.AssertExpectationsForObjects(t, etcdClientMock)
 	})
+
+	t.Run("should pass the given context to the client", func(t *testing.T) {
+		// given
+		type ctxKey string
+		ctx := context.WithValue(context.Background(), ctxKey("request"), "42")
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("Get", ctx, "test/testKey").Return("testValue", nil)
+
+		sut := etcdConfigurationContext{
+			parent: "test",
+			client: etcdClientMock,
+		}
+
+		// when
+		value, err := sut.GetContext(ctx, "testKey")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "testValue", value)
+		mock.AssertExpectationsForObjects(t, etcdClientMock)
+	})
 }
 
 func Test_etcdConfigurationContext_GetAll(t *testing.T) {
@@ -195,7 +216,7 @@ func Test_etcdConfigurationContext_GetAll(t *testing.T) {
 		}
 
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("GetRecursive", "test").Return(testKeys, nil)
+		etcdClientMock.On("GetRecursive", mock.Anything, "test").Return(testKeys, nil)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -215,7 +236,7 @@ func Test_etcdConfigurationContext_GetAll(t *testing.T) {
 	t.Run("error on getting all keys in registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("GetRecursive", "test").Return(map[string]string{}, assert.AnError)
+		etcdClientMock.On("GetRecursive", mock.Anything, "test").Return(map[string]string{}, assert.AnError)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -234,8 +255,8 @@ func Test_etcdConfigurationContext_GetOrFalse(t *testing.T) {
 	t.Run("Successfully getting key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Exists", "test/testKey").Return(true, nil)
-		etcdClientMock.On("Get", "test/testKey").Return("testValue", nil)
+		etcdClientMock.On("Exists", mock.Anything, "test/testKey").Return(true, nil)
+		etcdClientMock.On("Get", mock.Anything, "test/testKey").Return("testValue", nil)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -255,7 +276,7 @@ func Test_etcdConfigurationContext_GetOrFalse(t *testing.T) {
 	t.Run("error on checking existence of key", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Exists", "test/testKey").Return(false, assert.AnError)
+		etcdClientMock.On("Exists", mock.Anything, "test/testKey").Return(false, assert.AnError)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -273,7 +294,7 @@ func Test_etcdConfigurationContext_GetOrFalse(t *testing.T) {
 	t.Run("return false if key does not exist", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Exists", "test/testKey").Return(false, nil)
+		etcdClientMock.On("Exists", mock.Anything, "test/testKey").Return(false, nil)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -291,8 +312,8 @@ func Test_etcdConfigurationContext_GetOrFalse(t *testing.T) {
 	t.Run("error when getting key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Exists", "test/testKey").Return(true, nil)
-		etcdClientMock.On("Get", "test/testKey").Return("", assert.AnError)
+		etcdClientMock.On("Exists", mock.Anything, "test/testKey").Return(true, nil)
+		etcdClientMock.On("Get", mock.Anything, "test/testKey").Return("", assert.AnError)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -317,7 +338,7 @@ func Test_etcdConfigurationContext_Refresh(t *testing.T) {
 		}
 
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Set", "test/testKey", "", options).Return("", nil)
+		etcdClientMock.On("Set", mock.Anything, "test/testKey", "", options).Return("", nil)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -337,7 +358,7 @@ func Test_etcdConfigurationContext_RemoveAll(t *testing.T) {
 	t.Run("successfully delete all keys recursively key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("DeleteRecursive", "test").Return(nil)
+		etcdClientMock.On("DeleteRecursive", mock.Anything, "test").Return(nil)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -354,7 +375,7 @@ func Test_etcdConfigurationContext_RemoveAll(t *testing.T) {
 	t.Run("error on deleting all keys recursively key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("DeleteRecursive", "test").Return(assert.AnError)
+		etcdClientMock.On("DeleteRecursive", mock.Anything, "test").Return(assert.AnError)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -374,7 +395,7 @@ func Test_etcdConfigurationContext_Set(t *testing.T) {
 	t.Run("Successfully getting key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Set", "test/testKey", "testValue", mock.Anything).Return("", nil)
+		etcdClientMock.On("Set", mock.Anything, "test/testKey", "testValue", mock.Anything).Return("", nil)
 
 		sut := etcdConfigurationContext{
 			parent: "test",
@@ -394,7 +415,7 @@ func Test_etcdConfigurationContext_SetWithLifetime(t *testing.T) {
 	t.Run("Successfully getting key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Set", "test/testKey", "testValue", &client.SetOptions{
+		etcdClientMock.On("Set", mock.Anything, "test/testKey", "testValue", &client.SetOptions{
 			TTL: time.Second * 500,
 		}).Return("", nil)
 
@@ -412,11 +433,288 @@ func Test_etcdConfigurationContext_SetWithLifetime(t *testing.T) {
 	})
 }
 
+func Test_etcdConfigurationContext_SetIfAbsent(t *testing.T) {
+	t.Run("should set key if it does not exist", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("Set", mock.Anything, "test/testKey", "testValue", &client.SetOptions{PrevExist: client.PrevNoExist}).Return("testValue", nil)
+
+		sut := etcdConfigurationContext{
+			parent: "test",
+			client: etcdClientMock,
+		}
+
+		// when
+		actual, err := sut.SetIfAbsent("testKey", "testValue")
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, actual)
+	})
+
+	t.Run("should return false if key already exists", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("Set", mock.Anything, "test/testKey", "testValue", mock.Anything).Return("", client.Error{Code: client.ErrorCodeNodeExist})
+
+		sut := etcdConfigurationContext{
+			parent: "test",
+			client: etcdClientMock,
+		}
+
+		// when
+		actual, err := sut.SetIfAbsent("testKey", "testValue")
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, actual)
+	})
+
+	t.Run("should return other errors", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("Set", mock.Anything, "test/testKey", "testValue", mock.Anything).Return("", assert.AnError)
+
+		sut := etcdConfigurationContext{
+			parent: "test",
+			client: etcdClientMock,
+		}
+
+		// when
+		_, err := sut.SetIfAbsent("testKey", "testValue")
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func Test_etcdConfigurationContext_CompareAndSwap(t *testing.T) {
+	t.Run("should compare with previous value", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		options := &client.SetOptions{PrevExist: client.PrevExist, PrevValue: "old"}
+		etcdClientMock.On("Set", mock.Anything, "test/testKey", "new", options).Return("new", nil)
+
+		sut := etcdConfigurationContext{
+			parent: "test",
+			client: etcdClientMock,
+		}
+
+		// when
+		actual, err := sut.CompareAndSwap("testKey", "old", "new")
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, actual)
+	})
+
+	t.Run("should return false if comparison fails", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("Set", mock.Anything, "test/testKey", "new", mock.Anything).Return("", client.Error{Code: client.ErrorCodeTestFailed})
+
+		sut := etcdConfigurationContext{
+			parent: "test",
+			client: etcdClientMock,
+		}
+
+		// when
+		actual, err := sut.CompareAndSwap("testKey", "old", "new")
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, actual)
+	})
+
+	t.Run("should compare empty previous value by index", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("GetWithIndex", mock.Anything, "test/testKey").Return("", uint64(42), nil)
+		etcdClientMock.On("Set", mock.Anything, "test/testKey", "new", &client.SetOptions{PrevIndex: 42}).Return("new", nil)
+
+		sut := etcdConfigurationContext{
+			parent: "test",
+			client: etcdClientMock,
+		}
+
+		// when
+		actual, err := sut.CompareAndSwap("testKey", "", "new")
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, actual)
+	})
+
+	t.Run("should not set value if empty previous value does not match", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("GetWithIndex", mock.Anything, "test/testKey").Return("other", uint64(42), nil)
+
+		sut := etcdConfigurationContext{
+			parent: "test",
+			client: etcdClientMock,
+		}
+
+		// when
+		actual, err := sut.CompareAndSwap("testKey", "", "new")
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, actual)
+	})
+}
+
+func Test_etcdConfigurationContext_CompareAndDelete(t *testing.T) {
+	t.Run("should compare with previous value", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("Delete", mock.Anything, "test/testKey", &client.DeleteOptions{PrevValue: "old"}).Return(nil)
+
+		sut := etcdConfigurationContext{
+			parent: "test",
+			client: etcdClientMock,
+		}
+
+		// when
+		actual, err := sut.CompareAndDelete("testKey", "old")
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, actual)
+	})
+
+	t.Run("should return false if key does not exist", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("Delete", mock.Anything, "test/testKey", mock.Anything).Return(client.Error{Code: client.ErrorCodeKeyNotFound})
+
+		sut := etcdConfigurationContext{
+			parent: "test",
+			client: etcdClientMock,
+		}
+
+		// when
+		actual, err := sut.CompareAndDelete("testKey", "old")
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, actual)
+	})
+
+	t.Run("should return other errors", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("Delete", mock.Anything, "test/testKey", mock.Anything).Return(assert.AnError)
+
+		sut := etcdConfigurationContext{
+			parent: "test",
+			client: etcdClientMock,
+		}
+
+		// when
+		_, err := sut.CompareAndDelete("testKey", "old")
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func Test_etcdConfigurationContext_ApplyBatch(t *testing.T) {
+	t.Run("should pass all changes with full path to the client", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		expected := []keyChange{
+			{key: "/config/redmine/logging/root", value: "INFO"},
+			{key: "/config/redmine/obsolete", delete: true},
+		}
+		etcdClientMock.On("ApplyBatch", mock.Anything, expected).Return(nil)
+
+		sut := etcdConfigurationContext{
+			parent: "/config/redmine",
+			client: etcdClientMock,
+		}
+
+		// when
+		err := sut.ApplyBatch([]ConfigurationChange{
+			{Key: "logging/root", Value: "INFO"},
+			{Key: "obsolete", Delete: true},
+		})
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should not call client for empty batch", func(t *testing.T) {
+		// given
+		sut := etcdConfigurationContext{
+			parent: "/config/redmine",
+			client: newMockEtcdClient(t),
+		}
+
+		// when
+		err := sut.ApplyBatch(nil)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail if a key is changed more than once", func(t *testing.T) {
+		// given
+		sut := etcdConfigurationContext{
+			parent: "/config/redmine",
+			client: newMockEtcdClient(t),
+		}
+
+		// when
+		err := sut.ApplyBatch([]ConfigurationChange{
+			{Key: "logging/root", Value: "INFO"},
+			{Key: "logging//root", Delete: true},
+		})
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "key /config/redmine/logging/root is changed more than once")
+	})
+
+	t.Run("should fail for change without key", func(t *testing.T) {
+		// given
+		sut := etcdConfigurationContext{
+			parent: "/config/redmine",
+			client: newMockEtcdClient(t),
+		}
+
+		// when
+		err := sut.ApplyBatch([]ConfigurationChange{{Value: "INFO"}})
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "change without key")
+	})
+
+	t.Run("should return error of client", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("ApplyBatch", mock.Anything, mock.Anything).Return(assert.AnError)
+
+		sut := etcdConfigurationContext{
+			parent: "/config/redmine",
+			client: etcdClientMock,
+		}
+
+		// when
+		err := sut.ApplyBatch([]ConfigurationChange{{Key: "logging/root", Value: "INFO"}})
+
+		// then
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, err.Error(), "could not apply batch to /config/redmine")
+	})
+}
+
 func Test_etcdWatchConfigurationContext_Get(t *testing.T) {
 	t.Run("Successfully getting key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Get", "/testKey").Return("testValue", nil)
+		etcdClientMock.On("Get", mock.Anything, "/testKey").Return("testValue", nil)
 
 		sut := etcdWatchConfigurationContext{
 			client: etcdClientMock,
@@ -436,7 +734,7 @@ func Test_etcdWatchConfigurationContext_GetChildrenPaths(t *testing.T) {
 	t.Run("Successfully getting key from registry", func(t *testing.T) {
 		// given
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("GetChildrenPaths", "testKey").Return([]string{"test1", "test2"}, nil)
+		etcdClientMock.On("GetChildrenPaths", mock.Anything, "testKey").Return([]string{"test1", "test2"}, nil)
 
 		sut := etcdWatchConfigurationContext{
 			client: etcdClientMock,
@@ -454,21 +752,119 @@ func Test_etcdWatchConfigurationContext_GetChildrenPaths(t *testing.T) {
 }
 
 func Test_etcdWatchConfigurationContext_Watch(t *testing.T) {
-	t.Run("Successfully getting key from registry", func(t *testing.T) {
+	t.Run("should send events through the channel", func(t *testing.T) {
 		// given
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 		eventChannel := make(chan *client.Response)
+		response := &client.Response{Action: "set", Node: &client.Node{Key: "/testKey", Value: "value", ModifiedIndex: 3}}
 
 		etcdClientMock := newMockEtcdClient(t)
-		etcdClientMock.On("Watch", context.Background(), "testKey", true, eventChannel).Return()
+		etcdClientMock.On("GetCurrentIndex", mock.Anything, "testKey").Return(uint64(2), nil)
+		etcdClientMock.On("Watch", mock.Anything, "testKey", true, uint64(2), mock.Anything).
+			Run(func(args mock.Arguments) {
+				args.Get(4).(chan<- *client.Response) <- response
+				<-ctx.Done()
+			}).
+			Return(context.Canceled)
 
 		sut := etcdWatchConfigurationContext{
 			client: etcdClientMock,
 		}
 
 		// when
-		sut.Watch(context.Background(), "testKey", true, eventChannel)
+		go sut.Watch(ctx, "testKey", true, eventChannel)
 
 		// then
-		mock.AssertExpectationsForObjects(t, etcdClientMock)
+		select {
+		case actual := <-eventChannel:
+			assert.Same(t, response, actual)
+		case <-time.After(time.Second):
+			t.Fatal("expected watch event")
+		}
+	})
+}
+
+func Test_etcdWatchConfigurationContext_WatchEvents(t *testing.T) {
+	t.Run("should report error and resume after last received event", func(t *testing.T) {
+		// given
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("GetCurrentIndex", mock.Anything, "/config").Return(uint64(5), nil).Once()
+		etcdClientMock.On("Watch", mock.Anything, "/config", true, uint64(5), mock.Anything).
+			Run(func(args mock.Arguments) {
+				args.Get(4).(chan<- *client.Response) <- &client.Response{Action: "create", Node: &client.Node{Key: "/config/key", Value: "v1", ModifiedIndex: 7}}
+			}).
+			Return(assert.AnError).Once()
+		etcdClientMock.On("Watch", mock.Anything, "/config", true, uint64(7), mock.Anything).
+			Run(func(args mock.Arguments) {
+				args.Get(4).(chan<- *client.Response) <- &client.Response{
+					Action:   "delete",
+					Node:     &client.Node{Key: "/config/key", ModifiedIndex: 9},
+					PrevNode: &client.Node{Key: "/config/key", Value: "v1", ModifiedIndex: 7},
+				}
+				<-ctx.Done()
+			}).
+			Return(context.Canceled).Once()
+
+		sut := etcdWatchConfigurationContext{
+			client:           etcdClientMock,
+			reconnectBackoff: []time.Duration{time.Millisecond},
+		}
+
+		// when
+		events, errs := sut.WatchEvents(ctx, "/config", true)
+
+		// then
+		assert.Equal(t, WatchEvent{Key: "/config/key", NewValue: "v1", Action: WatchActionCreate, Revision: 7}, <-events)
+		assert.ErrorIs(t, <-errs, assert.AnError)
+		assert.Equal(t, WatchEvent{Key: "/config/key", OldValue: "v1", Action: WatchActionDelete, Revision: 9}, <-events)
+
+		cancel()
+		_, eventsOpen := <-events
+		_, errsOpen := <-errs
+		assert.False(t, eventsOpen)
+		assert.False(t, errsOpen)
+	})
+
+	t.Run("should resume at current index if events were cleared", func(t *testing.T) {
+		// given
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.On("GetCurrentIndex", mock.Anything, "/config").Return(uint64(5), nil).Once()
+		etcdClientMock.On("Watch", mock.Anything, "/config", false, uint64(5), mock.Anything).
+			Run(func(args mock.Arguments) {
+				args.Get(4).(chan<- *client.Response) <- &client.Response{Action: "set", Node: &client.Node{Key: "/config", ModifiedIndex: 7}}
+			}).
+			Return(client.Error{Code: client.ErrorCodeEventIndexCleared, Index: 1500}).Once()
+		resumed := make(chan struct{})
+		etcdClientMock.On("Watch", mock.Anything, "/config", false, uint64(1500), mock.Anything).
+			Run(func(args mock.Arguments) {
+				close(resumed)
+				<-ctx.Done()
+			}).
+			Return(context.Canceled).Once()
+
+		sut := etcdWatchConfigurationContext{
+			client:           etcdClientMock,
+			reconnectBackoff: []time.Duration{time.Millisecond},
+		}
+
+		// when
+		events, errs := sut.WatchEvents(ctx, "/config", false)
+
+		// then
+		<-events
+		err := <-errs
+		assert.True(t, IsEventIndexClearedError(err))
+		select {
+		case <-resumed:
+		case <-time.After(time.Second):
+			t.Fatal("expected watch to be resumed")
+		}
 	})
 }
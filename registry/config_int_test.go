@@ -4,6 +4,7 @@
 package registry_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -79,6 +80,148 @@ func TestEtcdDoguConfigGetAll_inttest(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestDoguConfigCompareAndSwap_inttest(t *testing.T) {
+	testCompareAndSwap(t, reg.DoguConfig("unit-test-cas"))
+}
+
+func TestDoguConfigApplyBatch_inttest(t *testing.T) {
+	testApplyBatch(t, reg.DoguConfig("unit-test-batch"))
+}
+
+func TestRootConfigWatchEvents_inttest(t *testing.T) {
+	cc := reg.DoguConfig("unit-test-watch-events")
+	defer cc.RemoveAll()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := reg.RootConfig().WatchEvents(ctx, "/config/unit-test-watch-events", true)
+	time.Sleep(time.Second)
+
+	require.NoError(t, cc.Set("key", "value"))
+	require.NoError(t, cc.Set("key", "changed"))
+	require.NoError(t, cc.Delete("key"))
+
+	var actual []registry.WatchEvent
+	for len(actual) < 3 {
+		select {
+		case event := <-events:
+			event.Revision = 0
+			actual = append(actual, event)
+		case err := <-errs:
+			t.Fatalf("unexpected watch error: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected watch event")
+		}
+	}
+	key := "/config/unit-test-watch-events/key"
+	assert.Equal(t, []registry.WatchEvent{
+		{Key: key, NewValue: "value", Action: registry.WatchActionCreate},
+		{Key: key, OldValue: "value", NewValue: "changed", Action: registry.WatchActionUpdate},
+		{Key: key, OldValue: "changed", Action: registry.WatchActionDelete},
+	}, actual)
+}
+
+func TestDoguConfigCached_inttest(t *testing.T) {
+	cc := reg.DoguConfig("unit-test-cached")
+	defer cc.RemoveAll()
+	require.NoError(t, cc.Set("key", "value"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cached, err := registry.NewCachedConfigurationContext(ctx, cc, "/config/unit-test-cached", reg.RootConfig(), registry.CacheOptions{})
+	require.NoError(t, err)
+	time.Sleep(time.Second)
+
+	value, err := cached.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	require.NoError(t, cc.Set("key", "changed"))
+	require.NoError(t, cc.Set("dir/key", "new"))
+	assert.Eventually(t, func() bool {
+		keyValuePairs, err := cached.GetAll()
+		return err == nil && assert.ObjectsAreEqual(map[string]string{"key": "changed", "dir/key": "new"}, keyValuePairs)
+	}, 5*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, cc.DeleteRecursive("dir"))
+	assert.Eventually(t, func() bool {
+		exists, err := cached.Exists("dir/key")
+		return err == nil && !exists
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func testCompareAndSwap(t *testing.T, cc registry.ConfigurationContext) {
+	t.Helper()
+	defer cc.RemoveAll()
+
+	created, err := cc.SetIfAbsent("lock", "first")
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	created, err = cc.SetIfAbsent("lock", "second")
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	swapped, err := cc.CompareAndSwap("lock", "second", "third")
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	swapped, err = cc.CompareAndSwap("lock", "first", "")
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	swapped, err = cc.CompareAndSwap("lock", "", "fourth")
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	swapped, err = cc.CompareAndSwap("missing", "", "value")
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	deleted, err := cc.CompareAndDelete("lock", "first")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = cc.CompareAndDelete("lock", "fourth")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	exists, err := cc.Exists("lock")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testApplyBatch(t *testing.T, cc registry.ConfigurationContext) {
+	t.Helper()
+	defer cc.RemoveAll()
+
+	require.NoError(t, cc.Set("dir/key", "value-1"))
+	require.NoError(t, cc.Set("obsolete", "value-2"))
+
+	err := cc.ApplyBatch([]registry.ConfigurationChange{
+		{Key: "dir/key", Value: "changed"},
+		{Key: "new", Value: "value-3"},
+		{Key: "obsolete", Delete: true},
+		{Key: "missing", Delete: true},
+	})
+	require.NoError(t, err)
+
+	keyValuePairs, err := cc.GetAll()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"dir/key": "changed", "new": "value-3"}, keyValuePairs)
+
+	err = cc.ApplyBatch([]registry.ConfigurationChange{
+		{Key: "new", Value: "should be reverted"},
+		{Key: "other", Value: "should be reverted"},
+		{Key: "dir", Value: "directories cannot be set"},
+	})
+	require.Error(t, err)
+
+	keyValuePairs, err = cc.GetAll()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"dir/key": "changed", "new": "value-3"}, keyValuePairs)
+}
+
 func testConfigurationContext(t *testing.T, cc registry.ConfigurationContext) {
 	t.Helper()
 	defer cc.RemoveAll()
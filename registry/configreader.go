@@ -1,11 +1,26 @@
 package registry
 
 import (
+	"encoding/json"
+	"math"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
+// binaryMeasurementRegex matches the values of the doguConf BINARY_MEASUREMENT validator, e.g. 1024k.
+var binaryMeasurementRegex = regexp.MustCompile(`^(\d{1,19})([bkmg])$`)
+
+var binaryMeasurementFactors = map[string]int64{
+	"b": 1,
+	"k": 1024,
+	"m": 1024 * 1024,
+	"g": 1024 * 1024 * 1024,
+}
+
 // NewConfigurationReader creates a new ConfigurationReader
 func NewConfigurationReader(configuration ConfigurationContext) *ConfigurationReader {
 	return &ConfigurationReader{configuration}
@@ -24,6 +39,21 @@ func (configReader *ConfigurationReader) GetBool(key string) (bool, error) {
 		return false, err
 	}
 
+	return parseBool(key, stringValue)
+}
+
+// GetBoolOrDefault reads the configuration value and converts it to a boolean. If the key could not be found, the
+// function will return the default value.
+func (configReader *ConfigurationReader) GetBoolOrDefault(key string, defaultValue bool) (bool, error) {
+	stringValue, found, err := configReader.getIfFound(key)
+	if err != nil || !found {
+		return defaultValue, err
+	}
+
+	return parseBool(key, stringValue)
+}
+
+func parseBool(key string, stringValue string) (bool, error) {
 	if stringValue == "" {
 		return false, nil
 	}
@@ -44,6 +74,21 @@ func (configReader *ConfigurationReader) GetInt(key string) (int, error) {
 		return -1, err
 	}
 
+	return parseInt(key, stringValue)
+}
+
+// GetIntOrDefault reads the configuration value and converts it to an integer. If the key could not be found, the
+// function will return the default value.
+func (configReader *ConfigurationReader) GetIntOrDefault(key string, defaultValue int) (int, error) {
+	stringValue, found, err := configReader.getIfFound(key)
+	if err != nil || !found {
+		return defaultValue, err
+	}
+
+	return parseInt(key, stringValue)
+}
+
+func parseInt(key string, stringValue string) (int, error) {
 	if stringValue == "" {
 		return 0, nil
 	}
@@ -56,6 +101,172 @@ func (configReader *ConfigurationReader) GetInt(key string) (int, error) {
 	return value, nil
 }
 
+// GetFloat reads the configuration value and converts it to a float. If the key could not be found, the function
+// will return 0.
+func (configReader *ConfigurationReader) GetFloat(key string) (float64, error) {
+	stringValue, err := configReader.GetString(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseFloat(key, stringValue)
+}
+
+// GetFloatOrDefault reads the configuration value and converts it to a float. If the key could not be found, the
+// function will return the default value.
+func (configReader *ConfigurationReader) GetFloatOrDefault(key string, defaultValue float64) (float64, error) {
+	stringValue, found, err := configReader.getIfFound(key)
+	if err != nil || !found {
+		return defaultValue, err
+	}
+
+	return parseFloat(key, stringValue)
+}
+
+func parseFloat(key string, stringValue string) (float64, error) {
+	if stringValue == "" {
+		return 0, nil
+	}
+
+	value, err := strconv.ParseFloat(stringValue, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse %s as float from registry key %s", stringValue, key)
+	}
+
+	return value, nil
+}
+
+// GetDuration reads the configuration value and converts it to a duration, e.g. "1h30m" or "500ms". If the key could
+// not be found, the function will return 0.
+func (configReader *ConfigurationReader) GetDuration(key string) (time.Duration, error) {
+	stringValue, err := configReader.GetString(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseDuration(key, stringValue)
+}
+
+// GetDurationOrDefault reads the configuration value and converts it to a duration, e.g. "1h30m" or "500ms". If the
+// key could not be found, the function will return the default value.
+func (configReader *ConfigurationReader) GetDurationOrDefault(key string, defaultValue time.Duration) (time.Duration, error) {
+	stringValue, found, err := configReader.getIfFound(key)
+	if err != nil || !found {
+		return defaultValue, err
+	}
+
+	return parseDuration(key, stringValue)
+}
+
+func parseDuration(key string, stringValue string) (time.Duration, error) {
+	if stringValue == "" {
+		return 0, nil
+	}
+
+	value, err := time.ParseDuration(stringValue)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse %s as duration from registry key %s", stringValue, key)
+	}
+
+	return value, nil
+}
+
+// GetBinaryMeasurement reads the configuration value and converts it to a number of bytes. The value must be an
+// integer followed by one of the binary units b, k, m or g like it is required by the BINARY_MEASUREMENT validator of
+// dogu configuration fields, e.g. "512m". If the key could not be found, the function will return 0.
+func (configReader *ConfigurationReader) GetBinaryMeasurement(key string) (int64, error) {
+	stringValue, err := configReader.GetString(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseBinaryMeasurement(key, stringValue)
+}
+
+// GetBinaryMeasurementOrDefault reads the configuration value and converts it to a number of bytes, see
+// GetBinaryMeasurement. If the key could not be found, the function will return the default value.
+func (configReader *ConfigurationReader) GetBinaryMeasurementOrDefault(key string, defaultValue int64) (int64, error) {
+	stringValue, found, err := configReader.getIfFound(key)
+	if err != nil || !found {
+		return defaultValue, err
+	}
+
+	return parseBinaryMeasurement(key, stringValue)
+}
+
+func parseBinaryMeasurement(key string, stringValue string) (int64, error) {
+	if stringValue == "" {
+		return 0, nil
+	}
+
+	matches := binaryMeasurementRegex.FindStringSubmatch(stringValue)
+	if matches == nil {
+		return 0, errors.Errorf("failed to parse %s as binary measurement from registry key %s: value must be an integer followed by one of the units b, k, m or g", stringValue, key)
+	}
+
+	value, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse %s as binary measurement from registry key %s", stringValue, key)
+	}
+
+	factor := binaryMeasurementFactors[matches[2]]
+	if value > math.MaxInt64/factor {
+		return 0, errors.Errorf("failed to parse %s as binary measurement from registry key %s: value is too large", stringValue, key)
+	}
+
+	return value * factor, nil
+}
+
+// GetStringSlice reads the configuration value and splits it at commas. Whitespace around the elements and empty
+// elements are removed. If the key could not be found, the function will return an empty slice.
+func (configReader *ConfigurationReader) GetStringSlice(key string) ([]string, error) {
+	stringValue, err := configReader.GetString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return splitStringSlice(stringValue), nil
+}
+
+// GetStringSliceOrDefault reads the configuration value and splits it at commas, see GetStringSlice. If the key could
+// not be found, the function will return the default value.
+func (configReader *ConfigurationReader) GetStringSliceOrDefault(key string, defaultValue []string) ([]string, error) {
+	stringValue, found, err := configReader.getIfFound(key)
+	if err != nil || !found {
+		return defaultValue, err
+	}
+
+	return splitStringSlice(stringValue), nil
+}
+
+func splitStringSlice(stringValue string) []string {
+	values := []string{}
+	for _, value := range strings.Split(stringValue, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// GetJSON reads the configuration value and unmarshals it as JSON into the given value. If the key could not be found
+// or is empty, the given value is not modified, so that it can be prefilled with defaults.
+func (configReader *ConfigurationReader) GetJSON(key string, into interface{}) error {
+	stringValue, found, err := configReader.getIfFound(key)
+	if err != nil || !found || stringValue == "" {
+		return err
+	}
+
+	err = json.Unmarshal([]byte(stringValue), into)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse value of registry key %s as json", key)
+	}
+
+	return nil
+}
+
 // GetString reads a string from registry
 func (configReader *ConfigurationReader) GetString(key string) (string, error) {
 	exists, err := configReader.Configuration.Exists(key)
@@ -74,3 +285,27 @@ func (configReader *ConfigurationReader) GetString(key string) (string, error) {
 
 	return value, nil
 }
+
+// GetStringOrDefault reads a string from registry. If the key could not be found, the function will return the
+// default value.
+func (configReader *ConfigurationReader) GetStringOrDefault(key string, defaultValue string) (string, error) {
+	value, found, err := configReader.getIfFound(key)
+	if err != nil || !found {
+		return defaultValue, err
+	}
+
+	return value, nil
+}
+
+// getIfFound reads the key with a single request and returns false if the key could not be found.
+func (configReader *ConfigurationReader) getIfFound(key string) (string, bool, error) {
+	value, err := configReader.Configuration.Get(key)
+	if IsKeyNotFoundError(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to read %s from registry", key)
+	}
+
+	return value, true, nil
+}
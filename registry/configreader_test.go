@@ -2,10 +2,15 @@ package registry
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/client/v2"
 )
 
+var testKeyNotFoundError = client.Error{Code: client.ErrorCodeKeyNotFound}
+
 func TestGetString(t *testing.T) {
 	configuration := NewMockConfigurationContext(t)
 	configuration.EXPECT().Exists("hello").Return(true, nil)
@@ -86,3 +91,235 @@ func TestGetIntWithInvalidValue(t *testing.T) {
 	_, err := configReader.GetInt("nan")
 	assert.NotNil(t, err)
 }
+
+func TestGetFloat(t *testing.T) {
+	configuration := NewMockConfigurationContext(t)
+	configuration.EXPECT().Exists("ratio").Return(true, nil).Once()
+	configuration.EXPECT().Get("ratio").Return("0.75", nil).Once()
+	configuration.EXPECT().Exists("nan").Return(true, nil).Once()
+	configuration.EXPECT().Get("nan").Return("abc", nil).Once()
+
+	configReader := NewConfigurationReader(configuration)
+	ratio, err := configReader.GetFloat("ratio")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.75, ratio)
+
+	_, err = configReader.GetFloat("nan")
+	assert.ErrorContains(t, err, "failed to parse abc as float from registry key nan")
+}
+
+func TestGetDuration(t *testing.T) {
+	configuration := NewMockConfigurationContext(t)
+	configuration.EXPECT().Exists("timeout").Return(true, nil).Once()
+	configuration.EXPECT().Get("timeout").Return("1m30s", nil).Once()
+	configuration.EXPECT().Exists("nonexisting").Return(false, nil).Once()
+	configuration.EXPECT().Exists("invalid").Return(true, nil).Once()
+	configuration.EXPECT().Get("invalid").Return("30", nil).Once()
+
+	configReader := NewConfigurationReader(configuration)
+	timeout, err := configReader.GetDuration("timeout")
+	assert.Nil(t, err)
+	assert.Equal(t, 90*time.Second, timeout)
+
+	nonexisting, err := configReader.GetDuration("nonexisting")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), nonexisting)
+
+	_, err = configReader.GetDuration("invalid")
+	assert.ErrorContains(t, err, "failed to parse 30 as duration from registry key invalid")
+}
+
+func TestGetBinaryMeasurement(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr string
+	}{
+		{value: "", want: 0},
+		{value: "0b", want: 0},
+		{value: "100b", want: 100},
+		{value: "2k", want: 2048},
+		{value: "512m", want: 512 * 1024 * 1024},
+		{value: "3g", want: 3 * 1024 * 1024 * 1024},
+		{value: "2K", wantErr: "value must be an integer followed by one of the units b, k, m or g"},
+		{value: "2", wantErr: "value must be an integer followed by one of the units b, k, m or g"},
+		{value: "-2k", wantErr: "value must be an integer followed by one of the units b, k, m or g"},
+		{value: "1.5g", wantErr: "value must be an integer followed by one of the units b, k, m or g"},
+		{value: "9999999999999999999b", wantErr: "failed to parse 9999999999999999999b as binary measurement"},
+		{value: "9000000000000000g", wantErr: "value is too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			configuration := NewMockConfigurationContext(t)
+			configuration.EXPECT().Exists("memory").Return(true, nil)
+			configuration.EXPECT().Get("memory").Return(tt.value, nil)
+
+			configReader := NewConfigurationReader(configuration)
+			actual, err := configReader.GetBinaryMeasurement("memory")
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, actual)
+		})
+	}
+}
+
+func TestGetStringSlice(t *testing.T) {
+	configuration := NewMockConfigurationContext(t)
+	configuration.EXPECT().Exists("hosts").Return(true, nil).Once()
+	configuration.EXPECT().Get("hosts").Return(" a.example.com, b.example.com,,c.example.com ", nil).Once()
+	configuration.EXPECT().Exists("nonexisting").Return(false, nil).Once()
+
+	configReader := NewConfigurationReader(configuration)
+	hosts, err := configReader.GetStringSlice("hosts")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a.example.com", "b.example.com", "c.example.com"}, hosts)
+
+	nonexisting, err := configReader.GetStringSlice("nonexisting")
+	assert.Nil(t, err)
+	assert.Empty(t, nonexisting)
+}
+
+func TestGetJSON(t *testing.T) {
+	type settings struct {
+		Name    string `json:"name"`
+		Retries int    `json:"retries"`
+	}
+
+	t.Run("should unmarshal value", func(t *testing.T) {
+		configuration := NewMockConfigurationContext(t)
+		configuration.EXPECT().Get("settings").Return(`{"name": "redmine"}`, nil)
+
+		configReader := NewConfigurationReader(configuration)
+		actual := settings{Retries: 3}
+		err := configReader.GetJSON("settings", &actual)
+
+		require.NoError(t, err)
+		assert.Equal(t, settings{Name: "redmine", Retries: 3}, actual)
+	})
+
+	t.Run("should keep value if key could not be found", func(t *testing.T) {
+		configuration := NewMockConfigurationContext(t)
+		configuration.EXPECT().Get("settings").Return("", testKeyNotFoundError)
+
+		configReader := NewConfigurationReader(configuration)
+		actual := settings{Retries: 3}
+		err := configReader.GetJSON("settings", &actual)
+
+		require.NoError(t, err)
+		assert.Equal(t, settings{Retries: 3}, actual)
+	})
+
+	t.Run("should fail on invalid json", func(t *testing.T) {
+		configuration := NewMockConfigurationContext(t)
+		configuration.EXPECT().Get("settings").Return("{", nil)
+
+		configReader := NewConfigurationReader(configuration)
+		err := configReader.GetJSON("settings", &settings{})
+
+		assert.ErrorContains(t, err, "failed to parse value of registry key settings as json")
+	})
+
+	t.Run("should fail on registry error", func(t *testing.T) {
+		configuration := NewMockConfigurationContext(t)
+		configuration.EXPECT().Get("settings").Return("", assert.AnError)
+
+		configReader := NewConfigurationReader(configuration)
+		err := configReader.GetJSON("settings", &settings{})
+
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestGetOrDefault(t *testing.T) {
+	t.Run("should return defaults if keys could not be found", func(t *testing.T) {
+		configuration := NewMockConfigurationContext(t)
+		configuration.EXPECT().Get("nonexisting").Return("", testKeyNotFoundError)
+
+		configReader := NewConfigurationReader(configuration)
+
+		stringValue, err := configReader.GetStringOrDefault("nonexisting", "default")
+		assert.Nil(t, err)
+		assert.Equal(t, "default", stringValue)
+
+		boolValue, err := configReader.GetBoolOrDefault("nonexisting", true)
+		assert.Nil(t, err)
+		assert.True(t, boolValue)
+
+		intValue, err := configReader.GetIntOrDefault("nonexisting", 42)
+		assert.Nil(t, err)
+		assert.Equal(t, 42, intValue)
+
+		floatValue, err := configReader.GetFloatOrDefault("nonexisting", 0.5)
+		assert.Nil(t, err)
+		assert.Equal(t, 0.5, floatValue)
+
+		durationValue, err := configReader.GetDurationOrDefault("nonexisting", time.Minute)
+		assert.Nil(t, err)
+		assert.Equal(t, time.Minute, durationValue)
+
+		sizeValue, err := configReader.GetBinaryMeasurementOrDefault("nonexisting", 1024)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1024), sizeValue)
+
+		sliceValue, err := configReader.GetStringSliceOrDefault("nonexisting", []string{"a"})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a"}, sliceValue)
+	})
+
+	t.Run("should return existing values", func(t *testing.T) {
+		configuration := NewMockConfigurationContext(t)
+		configuration.EXPECT().Get("string").Return("value", nil)
+		configuration.EXPECT().Get("bool").Return("false", nil)
+		configuration.EXPECT().Get("int").Return("7", nil)
+		configuration.EXPECT().Get("float").Return("1.5", nil)
+		configuration.EXPECT().Get("duration").Return("5s", nil)
+		configuration.EXPECT().Get("size").Return("1m", nil)
+		configuration.EXPECT().Get("slice").Return("b,c", nil)
+
+		configReader := NewConfigurationReader(configuration)
+
+		stringValue, err := configReader.GetStringOrDefault("string", "default")
+		assert.Nil(t, err)
+		assert.Equal(t, "value", stringValue)
+
+		boolValue, err := configReader.GetBoolOrDefault("bool", true)
+		assert.Nil(t, err)
+		assert.False(t, boolValue)
+
+		intValue, err := configReader.GetIntOrDefault("int", 42)
+		assert.Nil(t, err)
+		assert.Equal(t, 7, intValue)
+
+		floatValue, err := configReader.GetFloatOrDefault("float", 0.5)
+		assert.Nil(t, err)
+		assert.Equal(t, 1.5, floatValue)
+
+		durationValue, err := configReader.GetDurationOrDefault("duration", time.Minute)
+		assert.Nil(t, err)
+		assert.Equal(t, 5*time.Second, durationValue)
+
+		sizeValue, err := configReader.GetBinaryMeasurementOrDefault("size", 1024)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1024*1024), sizeValue)
+
+		sliceValue, err := configReader.GetStringSliceOrDefault("slice", []string{"a"})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"b", "c"}, sliceValue)
+	})
+
+	t.Run("should return registry errors", func(t *testing.T) {
+		configuration := NewMockConfigurationContext(t)
+		configuration.EXPECT().Get("key").Return("", assert.AnError)
+
+		configReader := NewConfigurationReader(configuration)
+		value, err := configReader.GetIntOrDefault("key", 42)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to read key from registry")
+		assert.Equal(t, 42, value)
+	})
+}
@@ -1,6 +1,9 @@
 package registry
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/cloudogu/cesapp-lib/core"
 )
 
@@ -16,6 +19,76 @@ type DoguRegistry interface {
 	Get(name string) (*core.Dogu, error)
 	// GetAll returns all installed dogus
 	GetAll() ([]*core.Dogu, error)
+	// GetAllWithFailures returns all installed dogus and the installed dogus which could not be read
+	GetAllWithFailures() (*DoguList, error)
 	// IsEnabled returns true if the dogu is installed
 	IsEnabled(name string) (bool, error)
+
+	// EnableContext enables the given dogu. The given context limits the time of the request.
+	EnableContext(ctx context.Context, dogu *core.Dogu) error
+	// RegisterContext registers the dogu on the registry. The given context limits the time of the request.
+	RegisterContext(ctx context.Context, dogu *core.Dogu) error
+	// UnregisterContext unregisters the dogu on the registry. The given context limits the time of the request.
+	UnregisterContext(ctx context.Context, name string) error
+	// GetContext returns the dogu which the given name. The given context limits the time of the request.
+	GetContext(ctx context.Context, name string) (*core.Dogu, error)
+	// GetAllContext returns all installed dogus. The given context limits the time of the request.
+	GetAllContext(ctx context.Context) ([]*core.Dogu, error)
+	// GetAllWithFailuresContext returns all installed dogus and the installed dogus which could not be read. The
+	// given context limits the time of the request.
+	GetAllWithFailuresContext(ctx context.Context) (*DoguList, error)
+	// IsEnabledContext returns true if the dogu is installed. The given context limits the time of the request.
+	IsEnabledContext(ctx context.Context, name string) (bool, error)
+
+	// GetVersions returns the registered versions of the dogu ordered from the newest to the oldest
+	GetVersions(name string) ([]core.Version, error)
+	// GetVersion returns the registered dogu with the given version
+	GetVersion(name string, version string) (*core.Dogu, error)
+	// EnableVersion enables an already registered version of the dogu, e.g. to roll back to an earlier version
+	EnableVersion(name string, version string) error
+	// PruneVersions removes the registered versions of the dogu except of the given number of newest versions and
+	// the enabled version
+	PruneVersions(name string, keep int) error
+	// UnregisterVersion removes a registered version of the dogu which is not enabled, e.g. to roll back the
+	// registration of a failed upgrade. It does not fail if the version is not registered.
+	UnregisterVersion(name string, version string) error
+
+	// GetVersionsContext works like GetVersions. The given context limits the time of the request.
+	GetVersionsContext(ctx context.Context, name string) ([]core.Version, error)
+	// GetVersionContext works like GetVersion. The given context limits the time of the request.
+	GetVersionContext(ctx context.Context, name string, version string) (*core.Dogu, error)
+	// EnableVersionContext works like EnableVersion. The given context limits the time of the request.
+	EnableVersionContext(ctx context.Context, name string, version string) error
+	// PruneVersionsContext works like PruneVersions. The given context limits the time of the request.
+	PruneVersionsContext(ctx context.Context, name string, keep int) error
+	// UnregisterVersionContext works like UnregisterVersion. The given context limits the time of the request.
+	UnregisterVersionContext(ctx context.Context, name string, version string) error
+}
+
+// DoguList contains the installed dogus of a registry.
+type DoguList struct {
+	// Dogus contains the enabled versions of the installed dogus ordered by their simple name.
+	Dogus []*core.Dogu
+	// Failures contains the installed dogus whose enabled version could not be read.
+	Failures []DoguFailure
+}
+
+// DoguFailure describes an installed dogu which could not be read.
+type DoguFailure struct {
+	// Name is the simple name of the dogu, e.g. ldap.
+	Name string
+	// Version is the enabled version of the dogu.
+	Version string
+	// Err is the reason why the dogu could not be read.
+	Err error
+}
+
+// Error returns the reason of the failure including the name and version of the dogu.
+func (df DoguFailure) Error() string {
+	return fmt.Sprintf("could not read version %s of dogu %s: %v", df.Version, df.Name, df.Err)
+}
+
+// Unwrap returns the reason of the failure.
+func (df DoguFailure) Unwrap() error {
+	return df.Err
 }
@@ -1,6 +1,11 @@
 package registry
 
 import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
 	"github.com/cloudogu/cesapp-lib/core"
 	"github.com/pkg/errors"
 )
@@ -13,7 +18,7 @@ type combinedEtcdDoguRegistry struct {
 	v2DoguRegistry DoguRegistry
 }
 
-func newCombinedEtcdDoguRegistry(client *resilentEtcdClient, pathV1 string, pathV2 string) *combinedEtcdDoguRegistry {
+func newCombinedEtcdDoguRegistry(client etcdClient, pathV1 string, pathV2 string) *combinedEtcdDoguRegistry {
 	formatProviderV1 := &core.DoguJsonV1FormatProvider{}
 	formatProviderV2 := &core.DoguJsonV2FormatProvider{}
 
@@ -35,12 +40,19 @@ func newCombinedEtcdDoguRegistry(client *resilentEtcdClient, pathV1 string, path
 // current key to the dogu path
 // Enables the dogu in v1 as well as in v2 registry.
 func (reg *combinedEtcdDoguRegistry) Enable(dogu *core.Dogu) error {
-	err := reg.v1DoguRegistry.Enable(dogu)
+	return reg.EnableContext(context.Background(), dogu)
+}
+
+// EnableContext enables a specific dogu in the backend. The method will create the
+// current key to the dogu path
+// Enables the dogu in v1 as well as in v2 registry.
+func (reg *combinedEtcdDoguRegistry) EnableContext(ctx context.Context, dogu *core.Dogu) error {
+	err := reg.v1DoguRegistry.EnableContext(ctx, dogu)
 	if err != nil {
 		return errors.Wrap(err, "could not write to v1 registry")
 	}
 
-	err = reg.v2DoguRegistry.Enable(dogu)
+	err = reg.v2DoguRegistry.EnableContext(ctx, dogu)
 	if err != nil {
 		return errors.Wrap(err, "could not write to v2 registry")
 	}
@@ -51,12 +63,18 @@ func (reg *combinedEtcdDoguRegistry) Enable(dogu *core.Dogu) error {
 // Register registers the dogu at the registry backend.
 // Registers the dogu in v1 as well as in v2 registry.
 func (reg *combinedEtcdDoguRegistry) Register(dogu *core.Dogu) error {
-	err := reg.v1DoguRegistry.Register(dogu)
+	return reg.RegisterContext(context.Background(), dogu)
+}
+
+// RegisterContext registers the dogu at the registry backend.
+// Registers the dogu in v1 as well as in v2 registry.
+func (reg *combinedEtcdDoguRegistry) RegisterContext(ctx context.Context, dogu *core.Dogu) error {
+	err := reg.v1DoguRegistry.RegisterContext(ctx, dogu)
 	if err != nil {
 		return errors.Wrap(err, "could not write to v1 registry")
 	}
 
-	err = reg.v2DoguRegistry.Register(dogu)
+	err = reg.v2DoguRegistry.RegisterContext(ctx, dogu)
 	if err != nil {
 		return errors.Wrap(err, "could not write to v2 registry")
 	}
@@ -67,13 +85,19 @@ func (reg *combinedEtcdDoguRegistry) Register(dogu *core.Dogu) error {
 // Get returns a dogu from the registry.
 // Gets the dogu from v2 registry. If dogu is not installed in v2 registry, v1 registry is used.
 func (reg *combinedEtcdDoguRegistry) Get(name string) (*core.Dogu, error) {
-	v2dogu, err := reg.v2DoguRegistry.Get(name)
+	return reg.GetContext(context.Background(), name)
+}
+
+// GetContext returns a dogu from the registry.
+// Gets the dogu from v2 registry. If dogu is not installed in v2 registry, v1 registry is used.
+func (reg *combinedEtcdDoguRegistry) GetContext(ctx context.Context, name string) (*core.Dogu, error) {
+	v2dogu, err := reg.v2DoguRegistry.GetContext(ctx, name)
 	if err != nil {
 		if !IsKeyNotFoundError(err) {
 			return nil, errors.Wrap(err, "could not get dogu from v2 registry")
 		}
 
-		return reg.v1DoguRegistry.Get(name)
+		return reg.v1DoguRegistry.GetContext(ctx, name)
 	}
 
 	return v2dogu, nil
@@ -82,38 +106,96 @@ func (reg *combinedEtcdDoguRegistry) Get(name string) (*core.Dogu, error) {
 // GetAll returns all registered dogus.
 // Collects all dogus in v1 as well as in v2 registry.
 func (reg *combinedEtcdDoguRegistry) GetAll() ([]*core.Dogu, error) {
-	var allDogus []*core.Dogu
+	return reg.GetAllContext(context.Background())
+}
 
-	v2dogus, err := reg.v2DoguRegistry.GetAll()
+// GetAllContext returns all registered dogus.
+// Collects all dogus in v1 as well as in v2 registry.
+func (reg *combinedEtcdDoguRegistry) GetAllContext(ctx context.Context) ([]*core.Dogu, error) {
+	doguList, err := reg.GetAllWithFailuresContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logDoguFailures(doguList)
+	return doguList.Dogus, nil
+}
+
+// GetAllWithFailures returns all registered dogus and the dogus which could not be read.
+// Collects all dogus in v1 as well as in v2 registry. A dogu which could not be read from one registry is only
+// reported as failure if it could not be read from the other registry either.
+func (reg *combinedEtcdDoguRegistry) GetAllWithFailures() (*DoguList, error) {
+	return reg.GetAllWithFailuresContext(context.Background())
+}
+
+// GetAllWithFailuresContext returns all registered dogus and the dogus which could not be read.
+// Collects all dogus in v1 as well as in v2 registry. A dogu which could not be read from one registry is only
+// reported as failure if it could not be read from the other registry either.
+func (reg *combinedEtcdDoguRegistry) GetAllWithFailuresContext(ctx context.Context) (*DoguList, error) {
+	v2list, err := reg.v2DoguRegistry.GetAllWithFailuresContext(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get all v2 dogus")
 	}
 
-	v1dogus, err := reg.v1DoguRegistry.GetAll()
+	v1list, err := reg.v1DoguRegistry.GetAllWithFailuresContext(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get all v1 dogus")
 	}
 
-	allDogus = append(allDogus, v2dogus...)
+	names := map[string]bool{}
+	allDogus := &DoguList{Dogus: []*core.Dogu{}, Failures: []DoguFailure{}}
+	for _, dogu := range v2list.Dogus {
+		names[dogu.GetSimpleName()] = true
+		allDogus.Dogus = append(allDogus.Dogus, dogu)
+	}
+	for _, dogu := range v1list.Dogus {
+		if !names[dogu.GetSimpleName()] {
+			names[dogu.GetSimpleName()] = true
+			allDogus.Dogus = append(allDogus.Dogus, dogu)
+		}
+	}
 
-	for _, v1dogu := range v1dogus {
-		if !core.ContainsDoguWithName(v2dogus, v1dogu.Name) {
-			allDogus = append(allDogus, v1dogu)
+	for _, failure := range append(v2list.Failures, v1list.Failures...) {
+		if !names[failure.Name] {
+			names[failure.Name] = true
+			allDogus.Failures = append(allDogus.Failures, failure)
 		}
 	}
 
+	sortDoguList(allDogus)
 	return allDogus, nil
 }
 
+func sortDoguList(doguList *DoguList) {
+	sort.Slice(doguList.Dogus, func(i, j int) bool {
+		return doguList.Dogus[i].GetSimpleName() < doguList.Dogus[j].GetSimpleName()
+	})
+	sort.Slice(doguList.Failures, func(i, j int) bool {
+		return doguList.Failures[i].Name < doguList.Failures[j].Name
+	})
+}
+
+func logDoguFailures(doguList *DoguList) {
+	for _, failure := range doguList.Failures {
+		core.GetLogger().Warningf("%v", failure)
+	}
+}
+
 // Unregister removes a dogu from the registry.
 // Removes in v1 as well as in v2 registry.
 func (reg *combinedEtcdDoguRegistry) Unregister(name string) error {
-	err := reg.v1DoguRegistry.Unregister(name)
+	return reg.UnregisterContext(context.Background(), name)
+}
+
+// UnregisterContext removes a dogu from the registry.
+// Removes in v1 as well as in v2 registry.
+func (reg *combinedEtcdDoguRegistry) UnregisterContext(ctx context.Context, name string) error {
+	err := reg.v1DoguRegistry.UnregisterContext(ctx, name)
 	if err != nil {
 		return errors.Wrap(err, "could not unregister v1 dogu")
 	}
 
-	err = reg.v2DoguRegistry.Unregister(name)
+	err = reg.v2DoguRegistry.UnregisterContext(ctx, name)
 	if err != nil && !IsKeyNotFoundError(err) {
 		return errors.Wrap(err, "could not unregister v2 dogu")
 	}
@@ -124,28 +206,180 @@ func (reg *combinedEtcdDoguRegistry) Unregister(name string) error {
 // IsEnabled returns true if the dogu is installed and enabled.
 // Use v1 registry as it contains v1 as well as converted v2 dogus.
 func (reg *combinedEtcdDoguRegistry) IsEnabled(name string) (bool, error) {
-	return reg.v1DoguRegistry.IsEnabled(name)
+	return reg.IsEnabledContext(context.Background(), name)
+}
+
+// IsEnabledContext returns true if the dogu is installed and enabled.
+// Use v1 registry as it contains v1 as well as converted v2 dogus.
+func (reg *combinedEtcdDoguRegistry) IsEnabledContext(ctx context.Context, name string) (bool, error) {
+	return reg.v1DoguRegistry.IsEnabledContext(ctx, name)
+}
+
+// GetVersions returns the registered versions of the dogu ordered from the newest to the oldest.
+// Collects the versions of the v1 as well as of the v2 registry.
+func (reg *combinedEtcdDoguRegistry) GetVersions(name string) ([]core.Version, error) {
+	return reg.GetVersionsContext(context.Background(), name)
+}
+
+// GetVersionsContext returns the registered versions of the dogu ordered from the newest to the oldest.
+// Collects the versions of the v1 as well as of the v2 registry.
+func (reg *combinedEtcdDoguRegistry) GetVersionsContext(ctx context.Context, name string) ([]core.Version, error) {
+	v2versions, err := reg.v2DoguRegistry.GetVersionsContext(ctx, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get versions from v2 registry")
+	}
+
+	v1versions, err := reg.v1DoguRegistry.GetVersionsContext(ctx, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get versions from v1 registry")
+	}
+
+	versions := v2versions
+	for _, v1version := range v1versions {
+		if !containsVersion(versions, v1version) {
+			versions = append(versions, v1version)
+		}
+	}
+	sort.Sort(core.ByVersion(versions))
+
+	return versions, nil
+}
+
+func containsVersion(versions []core.Version, version core.Version) bool {
+	for _, v := range versions {
+		if v.Raw == version.Raw {
+			return true
+		}
+	}
+	return false
+}
+
+// GetVersion returns the registered dogu with the given version.
+// Gets the dogu from v2 registry. If the version is not registered in v2 registry, v1 registry is used.
+func (reg *combinedEtcdDoguRegistry) GetVersion(name string, version string) (*core.Dogu, error) {
+	return reg.GetVersionContext(context.Background(), name, version)
+}
+
+// GetVersionContext returns the registered dogu with the given version.
+// Gets the dogu from v2 registry. If the version is not registered in v2 registry, v1 registry is used.
+func (reg *combinedEtcdDoguRegistry) GetVersionContext(ctx context.Context, name string, version string) (*core.Dogu, error) {
+	v2dogu, err := reg.v2DoguRegistry.GetVersionContext(ctx, name, version)
+	if err != nil {
+		if !IsKeyNotFoundError(err) {
+			return nil, errors.Wrap(err, "could not get dogu version from v2 registry")
+		}
+
+		return reg.v1DoguRegistry.GetVersionContext(ctx, name, version)
+	}
+
+	return v2dogu, nil
+}
+
+// EnableVersion enables an already registered version of the dogu in v1 as well as in v2 registry.
+// If the version was registered before the v2 registry existed, the dogu is disabled in v2 registry, so that
+// reading actions use the enabled version of the v1 registry.
+func (reg *combinedEtcdDoguRegistry) EnableVersion(name string, version string) error {
+	return reg.EnableVersionContext(context.Background(), name, version)
+}
+
+// EnableVersionContext enables an already registered version of the dogu in v1 as well as in v2 registry.
+// If the version was registered before the v2 registry existed, the dogu is disabled in v2 registry, so that
+// reading actions use the enabled version of the v1 registry.
+func (reg *combinedEtcdDoguRegistry) EnableVersionContext(ctx context.Context, name string, version string) error {
+	err := reg.v1DoguRegistry.EnableVersionContext(ctx, name, version)
+	if err != nil {
+		return errors.Wrap(err, "could not write to v1 registry")
+	}
+
+	err = reg.v2DoguRegistry.EnableVersionContext(ctx, name, version)
+	if IsKeyNotFoundError(err) {
+		err = reg.v2DoguRegistry.UnregisterContext(ctx, name)
+		if err != nil && !IsKeyNotFoundError(err) {
+			return errors.Wrap(err, "could not unregister v2 dogu")
+		}
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not write to v2 registry")
+	}
+
+	return nil
+}
+
+// PruneVersions removes the registered versions of the dogu except of the given number of newest versions and the
+// enabled version. Removes the versions in v1 as well as in v2 registry.
+func (reg *combinedEtcdDoguRegistry) PruneVersions(name string, keep int) error {
+	return reg.PruneVersionsContext(context.Background(), name, keep)
+}
+
+// PruneVersionsContext removes the registered versions of the dogu except of the given number of newest versions
+// and the enabled version. Removes the versions in v1 as well as in v2 registry.
+func (reg *combinedEtcdDoguRegistry) PruneVersionsContext(ctx context.Context, name string, keep int) error {
+	err := reg.v1DoguRegistry.PruneVersionsContext(ctx, name, keep)
+	if err != nil {
+		return errors.Wrap(err, "could not prune v1 dogu versions")
+	}
+
+	err = reg.v2DoguRegistry.PruneVersionsContext(ctx, name, keep)
+	if err != nil {
+		return errors.Wrap(err, "could not prune v2 dogu versions")
+	}
+
+	return nil
+}
+
+// UnregisterVersion removes a registered version of the dogu which is not enabled.
+// Removes the version in v1 as well as in v2 registry.
+func (reg *combinedEtcdDoguRegistry) UnregisterVersion(name string, version string) error {
+	return reg.UnregisterVersionContext(context.Background(), name, version)
+}
+
+// UnregisterVersionContext removes a registered version of the dogu which is not enabled.
+// Removes the version in v1 as well as in v2 registry.
+func (reg *combinedEtcdDoguRegistry) UnregisterVersionContext(ctx context.Context, name string, version string) error {
+	err := reg.v1DoguRegistry.UnregisterVersionContext(ctx, name, version)
+	if err != nil {
+		return errors.Wrap(err, "could not unregister v1 dogu version")
+	}
+
+	err = reg.v2DoguRegistry.UnregisterVersionContext(ctx, name, version)
+	if err != nil {
+		return errors.Wrap(err, "could not unregister v2 dogu version")
+	}
+
+	return nil
 }
 
 type etcdDoguRegistry struct {
 	path           string
-	client         *resilentEtcdClient
+	client         etcdClient
 	formatProvider core.DoguFormatProvider
 }
 
 // Enable enables a specific dogu in the backend. The method will create the
 // current key to the dogu path
 func (reg *etcdDoguRegistry) Enable(dogu *core.Dogu) error {
+	return reg.EnableContext(context.Background(), dogu)
+}
+
+// EnableContext enables a specific dogu in the backend. The method will create the
+// current key to the dogu path
+func (reg *etcdDoguRegistry) EnableContext(ctx context.Context, dogu *core.Dogu) error {
 	core.GetLogger().Infof("enable dogu %s:%s", dogu.GetSimpleName(), dogu.Version)
 
 	path := reg.path + "/" + dogu.GetSimpleName() + "/current"
 	core.GetLogger().Debug("set etcd value at", path)
-	_, err := reg.client.Set(path, dogu.Version, nil)
+	_, err := reg.client.Set(ctx, path, dogu.Version, nil)
 	return err
 }
 
 // Register registers the dogu at the registry backend
 func (reg *etcdDoguRegistry) Register(dogu *core.Dogu) error {
+	return reg.RegisterContext(context.Background(), dogu)
+}
+
+// RegisterContext registers the dogu at the registry backend
+func (reg *etcdDoguRegistry) RegisterContext(ctx context.Context, dogu *core.Dogu) error {
 	// convert dogu to json
 	data, err := reg.formatProvider.WriteDoguToString(dogu)
 	if err != nil {
@@ -157,13 +391,18 @@ func (reg *etcdDoguRegistry) Register(dogu *core.Dogu) error {
 	// register dogu as json on etcd
 	path := reg.path + "/" + dogu.GetSimpleName() + "/" + dogu.Version
 	core.GetLogger().Debug("set etcd value on", path)
-	_, err = reg.client.Set(path, data, nil)
+	_, err = reg.client.Set(ctx, path, data, nil)
 	return err
 }
 
 // Get returns a dogu from the registry
 func (reg *etcdDoguRegistry) Get(name string) (*core.Dogu, error) {
-	value, err := getCurrentValue(reg.client, reg.path+"/"+name)
+	return reg.GetContext(context.Background(), name)
+}
+
+// GetContext returns a dogu from the registry
+func (reg *etcdDoguRegistry) GetContext(ctx context.Context, name string) (*core.Dogu, error) {
+	value, err := getCurrentValue(ctx, reg.client, reg.path+"/"+name)
 	if err != nil && IsKeyNotFoundError(err) {
 		return nil, err
 	}
@@ -175,11 +414,11 @@ func (reg *etcdDoguRegistry) Get(name string) (*core.Dogu, error) {
 	return dogu, nil
 }
 
-func getCurrentValue(client *resilentEtcdClient, parent string) (string, error) {
+func getCurrentValue(ctx context.Context, client etcdClient, parent string) (string, error) {
 	path := parent + "/current"
 	core.GetLogger().Debug("get etcd value from", path)
 
-	version, err := client.Get(path)
+	version, err := client.Get(ctx, path)
 	if err != nil {
 		core.GetLogger().Debug("could not get current version of", parent)
 		return "", err
@@ -188,7 +427,7 @@ func getCurrentValue(client *resilentEtcdClient, parent string) (string, error)
 	path = parent + "/" + version
 	core.GetLogger().Debug("get etcd value from", path)
 
-	doguJSON, err := client.Get(path)
+	doguJSON, err := client.Get(ctx, path)
 	if err != nil {
 		core.GetLogger().Warningf("could not read version %s of %s", version, parent)
 		return "", err
@@ -199,43 +438,236 @@ func getCurrentValue(client *resilentEtcdClient, parent string) (string, error)
 
 // GetAll returns all registered dogus
 func (reg *etcdDoguRegistry) GetAll() ([]*core.Dogu, error) {
-	path := reg.path
-	core.GetLogger().Debug("get etcd values from", path)
+	return reg.GetAllContext(context.Background())
+}
 
-	children, err := reg.client.GetChildrenPaths(path)
+// GetAllContext returns all registered dogus
+func (reg *etcdDoguRegistry) GetAllContext(ctx context.Context) ([]*core.Dogu, error) {
+	doguList, err := reg.GetAllWithFailuresContext(ctx)
 	if err != nil {
+		return nil, err
+	}
+
+	logDoguFailures(doguList)
+	return doguList.Dogus, nil
+}
+
+// GetAllWithFailures returns all registered dogus and the dogus which could not be read
+func (reg *etcdDoguRegistry) GetAllWithFailures() (*DoguList, error) {
+	return reg.GetAllWithFailuresContext(context.Background())
+}
+
+// GetAllWithFailuresContext returns all registered dogus and the dogus which could not be read. All descriptors are
+// read with a single recursive request.
+func (reg *etcdDoguRegistry) GetAllWithFailuresContext(ctx context.Context) (*DoguList, error) {
+	path := reg.path
+	core.GetLogger().Debug("get etcd values recursive from", path)
+
+	keyValuePairs, err := reg.client.GetRecursive(ctx, path)
+	if err != nil && !IsKeyNotFoundError(err) {
 		return nil, errors.Wrapf(err, "failed to get dogus from path %s", path)
 	}
 
-	var dogus []*core.Dogu
-	for _, child := range children {
-		value, err := getCurrentValue(reg.client, child)
-		if err == nil {
-			dogu, err := reg.formatProvider.ReadDoguFromString(value)
-			if err != nil {
-				core.GetLogger().Warningf("could not unmarshal dogu %s: %v", child, err)
-			} else {
-				dogus = append(dogus, dogu)
-			}
+	doguList := &DoguList{Dogus: []*core.Dogu{}, Failures: []DoguFailure{}}
+	for key, version := range keyValuePairs {
+		name, isCurrent := strings.CutSuffix(key, "/current")
+		// keys of nested directories are no dogus
+		if !isCurrent || strings.Contains(name, "/") {
+			continue
+		}
+
+		doguJSON, ok := keyValuePairs[name+"/"+version]
+		if !ok {
+			err = errors.Errorf("descriptor %s/%s/%s does not exist", path, name, version)
+			doguList.Failures = append(doguList.Failures, DoguFailure{Name: name, Version: version, Err: err})
+			continue
 		}
+
+		dogu, err := reg.formatProvider.ReadDoguFromString(doguJSON)
+		if err != nil {
+			err = errors.Wrap(err, "failed to read dogu json")
+			doguList.Failures = append(doguList.Failures, DoguFailure{Name: name, Version: version, Err: err})
+			continue
+		}
+		doguList.Dogus = append(doguList.Dogus, dogu)
 	}
 
-	return dogus, nil
+	sortDoguList(doguList)
+	return doguList, nil
 }
 
 // Unregister removes a dogu from the registry
 func (reg *etcdDoguRegistry) Unregister(name string) error {
+	return reg.UnregisterContext(context.Background(), name)
+}
+
+// UnregisterContext removes a dogu from the registry
+func (reg *etcdDoguRegistry) UnregisterContext(ctx context.Context, name string) error {
 	core.GetLogger().Info("unregister dogu", name)
 	path := reg.path + "/" + name + "/current"
 
 	core.GetLogger().Debug("delete etcd key", path)
-	return reg.client.Delete(path, nil)
+	return reg.client.Delete(ctx, path, nil)
 }
 
 // IsEnabled returns true if the dogu is installed and enabled
 func (reg *etcdDoguRegistry) IsEnabled(name string) (bool, error) {
+	return reg.IsEnabledContext(context.Background(), name)
+}
+
+// IsEnabledContext returns true if the dogu is installed and enabled
+func (reg *etcdDoguRegistry) IsEnabledContext(ctx context.Context, name string) (bool, error) {
 	core.GetLogger().Debugf("check if dogu %s is installed", name)
 	path := reg.path + "/" + name + "/current"
 
-	return reg.client.Exists(path)
+	return reg.client.Exists(ctx, path)
+}
+
+// GetVersions returns the registered versions of the dogu ordered from the newest to the oldest
+func (reg *etcdDoguRegistry) GetVersions(name string) ([]core.Version, error) {
+	return reg.GetVersionsContext(context.Background(), name)
+}
+
+// GetVersionsContext returns the registered versions of the dogu ordered from the newest to the oldest
+func (reg *etcdDoguRegistry) GetVersionsContext(ctx context.Context, name string) ([]core.Version, error) {
+	parent := reg.path + "/" + name
+	core.GetLogger().Debug("get etcd children from", parent)
+
+	children, err := reg.client.GetChildrenPaths(ctx, parent)
+	if IsKeyNotFoundError(err) {
+		return []core.Version{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get versions of dogu %s", name)
+	}
+
+	versions := []core.Version{}
+	for _, child := range children {
+		rawVersion := path.Base(child)
+		if rawVersion == "current" {
+			continue
+		}
+
+		version, err := core.ParseVersion(rawVersion)
+		if err != nil {
+			core.GetLogger().Warningf("ignore invalid version %s of dogu %s: %v", rawVersion, name, err)
+			continue
+		}
+		versions = append(versions, version)
+	}
+	sort.Sort(core.ByVersion(versions))
+
+	return versions, nil
+}
+
+// GetVersion returns the registered dogu with the given version
+func (reg *etcdDoguRegistry) GetVersion(name string, version string) (*core.Dogu, error) {
+	return reg.GetVersionContext(context.Background(), name, version)
+}
+
+// GetVersionContext returns the registered dogu with the given version
+func (reg *etcdDoguRegistry) GetVersionContext(ctx context.Context, name string, version string) (*core.Dogu, error) {
+	if version == "" || version == "current" {
+		return nil, errors.Errorf("invalid version '%s' of dogu %s", version, name)
+	}
+
+	versionPath := reg.path + "/" + name + "/" + version
+	core.GetLogger().Debug("get etcd value from", versionPath)
+
+	value, err := reg.client.Get(ctx, versionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dogu, err := reg.formatProvider.ReadDoguFromString(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read dogu json")
+	}
+	return dogu, nil
+}
+
+// EnableVersion enables an already registered version of the dogu, e.g. to roll back to an earlier version
+func (reg *etcdDoguRegistry) EnableVersion(name string, version string) error {
+	return reg.EnableVersionContext(context.Background(), name, version)
+}
+
+// EnableVersionContext enables an already registered version of the dogu, e.g. to roll back to an earlier version
+func (reg *etcdDoguRegistry) EnableVersionContext(ctx context.Context, name string, version string) error {
+	dogu, err := reg.GetVersionContext(ctx, name, version)
+	if err != nil {
+		return errors.Wrapf(err, "failed to enable version %s of dogu %s", version, name)
+	}
+
+	return reg.EnableContext(ctx, dogu)
+}
+
+// PruneVersions removes the registered versions of the dogu except of the given number of newest versions and the
+// enabled version
+func (reg *etcdDoguRegistry) PruneVersions(name string, keep int) error {
+	return reg.PruneVersionsContext(context.Background(), name, keep)
+}
+
+// PruneVersionsContext removes the registered versions of the dogu except of the given number of newest versions
+// and the enabled version
+func (reg *etcdDoguRegistry) PruneVersionsContext(ctx context.Context, name string, keep int) error {
+	if keep < 0 {
+		return errors.Errorf("number of versions to keep must not be negative, got %d", keep)
+	}
+
+	versions, err := reg.GetVersionsContext(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(versions) <= keep {
+		return nil
+	}
+
+	currentVersion, err := reg.client.Get(ctx, reg.path+"/"+name+"/current")
+	if err != nil && !IsKeyNotFoundError(err) {
+		return errors.Wrapf(err, "failed to get enabled version of dogu %s", name)
+	}
+
+	for _, version := range versions[keep:] {
+		if version.Raw == currentVersion {
+			continue
+		}
+
+		versionPath := reg.path + "/" + name + "/" + version.Raw
+		core.GetLogger().Infof("remove version %s of dogu %s", version.Raw, name)
+		err = reg.client.Delete(ctx, versionPath, nil)
+		if err != nil && !IsKeyNotFoundError(err) {
+			return errors.Wrapf(err, "failed to remove version %s of dogu %s", version.Raw, name)
+		}
+	}
+
+	return nil
+}
+
+// UnregisterVersion removes a registered version of the dogu which is not enabled
+func (reg *etcdDoguRegistry) UnregisterVersion(name string, version string) error {
+	return reg.UnregisterVersionContext(context.Background(), name, version)
+}
+
+// UnregisterVersionContext removes a registered version of the dogu which is not enabled
+func (reg *etcdDoguRegistry) UnregisterVersionContext(ctx context.Context, name string, version string) error {
+	if version == "" || version == "current" {
+		return errors.Errorf("invalid version '%s' of dogu %s", version, name)
+	}
+
+	currentVersion, err := reg.client.Get(ctx, reg.path+"/"+name+"/current")
+	if err != nil && !IsKeyNotFoundError(err) {
+		return errors.Wrapf(err, "failed to get enabled version of dogu %s", name)
+	}
+	if currentVersion == version {
+		return errors.Errorf("cannot unregister version %s of dogu %s, because it is enabled", version, name)
+	}
+
+	versionPath := reg.path + "/" + name + "/" + version
+	core.GetLogger().Infof("remove version %s of dogu %s", version, name)
+	err = reg.client.Delete(ctx, versionPath, nil)
+	if err != nil && !IsKeyNotFoundError(err) {
+		return errors.Wrapf(err, "failed to remove version %s of dogu %s", version, name)
+	}
+
+	return nil
 }
@@ -4,6 +4,7 @@
 package registry
 
 import (
+	"context"
 	"testing"
 
 	"github.com/cloudogu/cesapp-lib/core"
@@ -19,7 +20,7 @@ func TestGetAllReturnsV1AsWellAsV2_inttest(t *testing.T) {
 	v1Format := &core.DoguJsonV1FormatProvider{}
 	v2Format := &core.DoguJsonV2FormatProvider{}
 
-	cl, err := newResilientEtcdClient([]string{server.URL}, core.RetryPolicy{Interval: 100})
+	cl, err := newResilientEtcdClient([]string{server.URL}, core.Registry{RetryPolicy: core.RetryPolicy{Interval: 100}})
 	require.Nil(t, err)
 
 	defer func() {
@@ -70,7 +71,7 @@ func TestCombinedEtcdDoguRegistryWritesToV1AndV2_inttest(t *testing.T) {
 	v1Format := &core.DoguJsonV1FormatProvider{}
 	v2Format := &core.DoguJsonV2FormatProvider{}
 
-	cl, err := newResilientEtcdClient([]string{server.URL}, core.RetryPolicy{Interval: 100})
+	cl, err := newResilientEtcdClient([]string{server.URL}, core.Registry{RetryPolicy: core.RetryPolicy{Interval: 100}})
 	require.Nil(t, err)
 
 	defer func() {
@@ -142,19 +143,19 @@ func createTestDogu(t *testing.T, name string, version string) *core.Dogu {
 }
 
 func cleanupRegistry(t *testing.T, cl *resilentEtcdClient) {
-	exists, err := cl.Exists("dogu")
+	exists, err := cl.Exists(context.Background(), "dogu")
 	assert.Nil(t, err)
 
 	if exists {
-		err = cl.DeleteRecursive("dogu")
+		err = cl.DeleteRecursive(context.Background(), "dogu")
 		assert.Nil(t, err)
 	}
 
-	exists, err = cl.Exists("dogu_v2")
+	exists, err = cl.Exists(context.Background(), "dogu_v2")
 	assert.Nil(t, err)
 
 	if exists {
-		err = cl.DeleteRecursive("dogu_v2")
+		err = cl.DeleteRecursive(context.Background(), "dogu_v2")
 		assert.Nil(t, err)
 	}
 }
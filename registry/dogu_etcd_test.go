@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/client/v2"
+)
+
+func Test_combinedEtcdDoguRegistry_GetAllWithFailures(t *testing.T) {
+	t.Run("should read all dogus with a single request per registry", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.EXPECT().GetRecursive(mock.Anything, "/dogu_v2").Return(map[string]string{
+			"ldap/current":  "2.4.48-4",
+			"ldap/2.4.48-4": `{"Name": "official/ldap", "Version": "2.4.48-4"}`,
+			"ldap/2.4.48-3": `{"Name": "official/ldap", "Version": "2.4.48-3"}`,
+			"cas/current":   "6.5.0-1",
+			"cas/6.5.0-1":   `{"Name": "official/cas", "Version": "6.5.0-1"}`,
+		}, nil).Once()
+		etcdClientMock.EXPECT().GetRecursive(mock.Anything, "/dogu").Return(map[string]string{
+			"ldap/current":       "2.4.48-4",
+			"ldap/2.4.48-4":      `{"Name": "official/ldap", "Version": "2.4.48-4"}`,
+			"nginx/current":      "1.17.10-9",
+			"nginx/1.17.10-9":    `{"Name": "official/nginx", "Version": "1.17.10-9"}`,
+			"unregistered/1.0.0": `{"Name": "official/unregistered", "Version": "1.0.0"}`,
+		}, nil).Once()
+		sut := newCombinedEtcdDoguRegistry(etcdClientMock, "/dogu", "/dogu_v2")
+
+		// when
+		actual, err := sut.GetAllWithFailures()
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, actual.Failures)
+		require.Len(t, actual.Dogus, 3)
+		assert.Equal(t, "official/cas", actual.Dogus[0].Name)
+		assert.Equal(t, "official/ldap", actual.Dogus[1].Name)
+		assert.Equal(t, "2.4.48-4", actual.Dogus[1].Version)
+		assert.Equal(t, "official/nginx", actual.Dogus[2].Name)
+	})
+
+	t.Run("should report dogus which could not be read", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.EXPECT().GetRecursive(mock.Anything, "/dogu_v2").Return(map[string]string{
+			"ldap/current":  "2.4.48-4",
+			"ldap/2.4.48-4": `{"Name": "official/ldap", "Version": "2.4.48-4"`,
+			"cas/current":   "6.5.0-1",
+			"cas/6.5.0-1":   `{invalid`,
+		}, nil).Once()
+		etcdClientMock.EXPECT().GetRecursive(mock.Anything, "/dogu").Return(map[string]string{
+			"ldap/current":  "2.4.48-4",
+			"ldap/2.4.48-4": `{"Name": "official/ldap", "Version": "2.4.48-4"}`,
+			"nginx/current": "1.17.10-9",
+		}, nil).Once()
+		sut := newCombinedEtcdDoguRegistry(etcdClientMock, "/dogu", "/dogu_v2")
+
+		// when
+		actual, err := sut.GetAllWithFailures()
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, actual.Dogus, 1)
+		assert.Equal(t, "official/ldap", actual.Dogus[0].Name)
+		require.Len(t, actual.Failures, 2)
+		assert.Equal(t, "cas", actual.Failures[0].Name)
+		assert.Equal(t, "6.5.0-1", actual.Failures[0].Version)
+		assert.ErrorContains(t, actual.Failures[0], "could not read version 6.5.0-1 of dogu cas: failed to read dogu json")
+		assert.Equal(t, "nginx", actual.Failures[1].Name)
+		assert.ErrorContains(t, actual.Failures[1], "descriptor /dogu/nginx/1.17.10-9 does not exist")
+	})
+
+	t.Run("should return no dogus if registries do not exist", func(t *testing.T) {
+		// given
+		keyNotFound := client.Error{Code: client.ErrorCodeKeyNotFound}
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.EXPECT().GetRecursive(mock.Anything, "/dogu_v2").Return(nil, keyNotFound).Once()
+		etcdClientMock.EXPECT().GetRecursive(mock.Anything, "/dogu").Return(nil, keyNotFound).Once()
+		sut := newCombinedEtcdDoguRegistry(etcdClientMock, "/dogu", "/dogu_v2")
+
+		// when
+		actual, err := sut.GetAll()
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("should fail on registry error", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.EXPECT().GetRecursive(mock.Anything, "/dogu_v2").Return(nil, assert.AnError).Once()
+		sut := newCombinedEtcdDoguRegistry(etcdClientMock, "/dogu", "/dogu_v2")
+
+		// when
+		_, err := sut.GetAllWithFailures()
+
+		// then
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "could not get all v2 dogus: failed to get dogus from path /dogu_v2")
+	})
+}
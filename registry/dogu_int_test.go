@@ -67,3 +67,56 @@ func TestDogus_inttest(t *testing.T) {
 	err = doguReg.Unregister("test-2")
 	assert.Nil(t, err)
 }
+
+func TestDoguVersions_inttest(t *testing.T) {
+	doguReg := reg.DoguRegistry()
+	defer func() {
+		_ = doguReg.Unregister("test-versions")
+		_ = doguReg.PruneVersions("test-versions", 0)
+	}()
+
+	versions, err := doguReg.GetVersions("test-versions")
+	assert.Nil(t, err)
+	assert.Empty(t, versions)
+
+	for _, version := range []string{"1.0.0-1", "1.0.0-2", "1.1.0-1"} {
+		dogu := &core.Dogu{Name: "official/test-versions", Version: version}
+		assert.Nil(t, doguReg.Register(dogu))
+		assert.Nil(t, doguReg.Enable(dogu))
+	}
+
+	versions, err = doguReg.GetVersions("test-versions")
+	assert.Nil(t, err)
+	assert.Len(t, versions, 3)
+	assert.Equal(t, "1.1.0-1", versions[0].Raw)
+
+	err = doguReg.EnableVersion("test-versions", "1.0.0-1")
+	assert.Nil(t, err)
+
+	dogu, err := doguReg.Get("test-versions")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.0.0-1", dogu.Version)
+
+	err = doguReg.PruneVersions("test-versions", 1)
+	assert.Nil(t, err)
+
+	versions, err = doguReg.GetVersions("test-versions")
+	assert.Nil(t, err)
+	assert.Len(t, versions, 2)
+	assert.Equal(t, "1.1.0-1", versions[0].Raw)
+	assert.Equal(t, "1.0.0-1", versions[1].Raw)
+
+	_, err = doguReg.GetVersion("test-versions", "1.0.0-2")
+	assert.NotNil(t, err)
+
+	err = doguReg.UnregisterVersion("test-versions", "1.0.0-1")
+	assert.NotNil(t, err)
+
+	err = doguReg.UnregisterVersion("test-versions", "1.1.0-1")
+	assert.Nil(t, err)
+
+	versions, err = doguReg.GetVersions("test-versions")
+	assert.Nil(t, err)
+	assert.Len(t, versions, 1)
+	assert.Equal(t, "1.0.0-1", versions[0].Raw)
+}
@@ -1,10 +1,49 @@
 package registry
 
 import (
+	"context"
+
 	"github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/v2"
 )
 
+// permanentErrorCodes contains the error codes of the v2 api of etcd which are caused by the request itself. Retrying
+// the request will not change its result.
+var permanentErrorCodes = []int{
+	client.ErrorCodeNotFile,
+	client.ErrorCodeNotDir,
+	client.ErrorCodeRootROnly,
+	client.ErrorCodeDirNotEmpty,
+	client.ErrorCodeUnauthorized,
+	client.ErrorCodePrevValueRequired,
+	client.ErrorCodeTTLNaN,
+	client.ErrorCodeIndexNaN,
+	client.ErrorCodeInvalidField,
+	client.ErrorCodeInvalidForm,
+	client.ErrorCodeWatcherCleared,
+	client.ErrorCodeEventIndexCleared,
+}
+
+// permanentV3Errors contains the errors of the v3 api of etcd which are caused by the request itself or the
+// configuration of the client. Retrying the request will not change its result.
+var permanentV3Errors = []error{
+	rpctypes.ErrEmptyKey,
+	rpctypes.ErrValueProvided,
+	rpctypes.ErrLeaseProvided,
+	rpctypes.ErrTooManyOps,
+	rpctypes.ErrDuplicateKey,
+	rpctypes.ErrCompacted,
+	rpctypes.ErrFutureRev,
+	rpctypes.ErrLeaseNotFound,
+	rpctypes.ErrLeaseTTLTooLarge,
+	rpctypes.ErrRequestTooLarge,
+	rpctypes.ErrUserEmpty,
+	rpctypes.ErrAuthFailed,
+	rpctypes.ErrAuthNotEnabled,
+	rpctypes.ErrPermissionDenied,
+}
+
 // IsKeyNotFoundError returns true if the given error is a or contains a registry keyNotFoundError, otherwise false.
 // It returns false if the given error is nil.
 func IsKeyNotFoundError(err error) bool {
@@ -16,9 +55,65 @@ func IsKeyNotFoundError(err error) bool {
 	return foundNaturally || foundAsCause
 }
 
+// IsEventIndexClearedError returns true if the given error is or contains a registry error which signals that the
+// events of a watch are no longer available, because they were removed from the event history of the registry.
+// Watches which receive such an error missed events and resume at the current index of the registry.
+func IsEventIndexClearedError(err error) bool {
+	return hasErrorCode(err, client.ErrorCodeEventIndexCleared)
+}
+
 func isKeyNotFound(err error) bool {
 	if cErr, ok := err.(client.Error); ok {
 		return cErr.Code == client.ErrorCodeKeyNotFound
 	}
 	return false
 }
+
+// hasErrorCode returns true if the given error is or contains a registry error with the given etcd error code.
+func hasErrorCode(err error, code int) bool {
+	for _, candidate := range []error{err, errors.Cause(err)} {
+		if cErr, ok := candidate.(client.Error); ok && cErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isPreconditionFailed returns true if a conditional request failed because the key has another value or index or
+// already exists. Retrying such a request will not change its result.
+func isPreconditionFailed(err error) bool {
+	return hasErrorCode(err, client.ErrorCodeTestFailed) || hasErrorCode(err, client.ErrorCodeNodeExist)
+}
+
+// errorIndex returns the index of the registry at the time of the error or 0 if the error is no registry error.
+func errorIndex(err error) uint64 {
+	for _, candidate := range []error{err, errors.Cause(err)} {
+		if cErr, ok := candidate.(client.Error); ok {
+			return cErr.Index
+		}
+	}
+	return 0
+}
+
+// isPermanentError returns true if the given error will occur again if the failed request is retried, e.g. because
+// the client has no permission or the request is invalid. Errors of the connection or the cluster, e.g. a lost leader,
+// are transient.
+func isPermanentError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	for _, code := range permanentErrorCodes {
+		if hasErrorCode(err, code) {
+			return true
+		}
+	}
+
+	for _, permanentErr := range permanentV3Errors {
+		if errors.Is(err, permanentErr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,7 +1,9 @@
 package registry
 
 import (
+	"context"
 	"errors"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/v2"
 	"strconv"
 	"testing"
@@ -42,3 +44,53 @@ func TestIsKeyNotFoundError(t *testing.T) {
 		require.True(t, actual)
 	})
 }
+
+func Test_isPreconditionFailed(t *testing.T) {
+	t.Run("should return true for wrapped compare failed error", func(t *testing.T) {
+		err := errors2.Wrap(client.Error{Code: client.ErrorCodeTestFailed}, "oh noe")
+
+		require.True(t, isPreconditionFailed(err))
+	})
+	t.Run("should return true for node exists error", func(t *testing.T) {
+		require.True(t, isPreconditionFailed(client.Error{Code: client.ErrorCodeNodeExist}))
+	})
+	t.Run("should return false for other errors", func(t *testing.T) {
+		require.False(t, isPreconditionFailed(nil))
+		require.False(t, isPreconditionFailed(errors.New("oh noez")))
+		require.False(t, isPreconditionFailed(client.Error{Code: client.ErrorCodeKeyNotFound}))
+	})
+}
+
+func Test_errorIndex(t *testing.T) {
+	t.Run("should return index of wrapped registry error", func(t *testing.T) {
+		err := errors2.Wrap(client.Error{Code: client.ErrorCodeKeyNotFound, Index: 42}, "oh noe")
+
+		require.Equal(t, uint64(42), errorIndex(err))
+	})
+	t.Run("should return 0 for other errors", func(t *testing.T) {
+		require.Equal(t, uint64(0), errorIndex(nil))
+		require.Equal(t, uint64(0), errorIndex(errors.New("oh noez")))
+	})
+}
+
+func Test_isPermanentError(t *testing.T) {
+	t.Run("should return true for wrapped permanent registry error", func(t *testing.T) {
+		err := errors2.Wrap(client.Error{Code: client.ErrorCodeNotFile}, "oh noe")
+
+		require.True(t, isPermanentError(err))
+	})
+	t.Run("should return true for permission error of v3 api", func(t *testing.T) {
+		err := errors2.Wrap(rpctypes.ErrPermissionDenied, "oh noe")
+
+		require.True(t, isPermanentError(err))
+	})
+	t.Run("should return true for cancelled context", func(t *testing.T) {
+		require.True(t, isPermanentError(errors2.Wrap(context.Canceled, "oh noe")))
+	})
+	t.Run("should return false for transient errors", func(t *testing.T) {
+		require.False(t, isPermanentError(nil))
+		require.False(t, isPermanentError(errors.New("connection refused")))
+		require.False(t, isPermanentError(client.Error{Code: client.ErrorCodeLeaderElect}))
+		require.False(t, isPermanentError(rpctypes.ErrNoLeader))
+	})
+}
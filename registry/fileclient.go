@@ -0,0 +1,607 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/client/v2"
+)
+
+const (
+	// fileReservedPrefix marks the files in the directory of the file client which are no keys, e.g. the expirations
+	// of the keys and temporary files.
+	fileReservedPrefix = "."
+	// fileExpirationsName is the name of the file which contains the expirations of keys with a time to live.
+	fileExpirationsName = ".expirations.json"
+	// fileLockName is the name of the file which is locked during every request, so that the requests of all
+	// processes which use the directory are serialized.
+	fileLockName = ".lock"
+	// fileSyncInterval is the interval in which watches check the directory for expired keys and for changes which
+	// were missed by the file watch.
+	fileSyncInterval = time.Second
+)
+
+// fileEntry is a key or a directory of the key space.
+type fileEntry struct {
+	value string
+	dir   bool
+}
+
+// fileClient is an etcdClient which stores the key space in a directory. Every key is a file which contains the
+// value of the key and every directory of the key space is a directory. The expirations of keys with a time to live
+// are stored in the file .expirations.json of the directory. Names which start with a dot are reserved for such files
+// and cannot be used as keys.
+//
+// The client keeps a copy of the key space in a memoryClient, which provides the semantics of etcd. Before every
+// request the copy is synchronized with the directory, so that changes of other processes or of manual edits are
+// visible, and afterwards the changes of the request are written to the directory. Every request holds the lock of
+// the file .lock, so that requests of other processes which use the directory cannot interleave with it and
+// conditional changes are atomic across these processes. Manual edits do not take the lock. Watches notice changes
+// of the directory with a file watch. The indexes of keys and events are only valid within one process.
+type fileClient struct {
+	mutex     sync.Mutex
+	directory string
+	memory    *memoryClient
+}
+
+func newFileClient(directory string) (*fileClient, error) {
+	err := os.MkdirAll(directory, 0700)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create registry directory %s", directory)
+	}
+
+	fc := &fileClient{directory: directory, memory: newMemoryClient()}
+	err = fc.do(func() error { return nil })
+	if err != nil {
+		return nil, err
+	}
+
+	return fc, nil
+}
+
+// Exists returns true if the key exists
+func (fc *fileClient) Exists(ctx context.Context, key string) (bool, error) {
+	var exists bool
+	err := fc.do(func() error {
+		var err error
+		exists, err = fc.memory.Exists(ctx, key)
+		return err
+	})
+	return exists, err
+}
+
+// Get returns the value of the given node, otherwise it returns an error. If the given key cannot be found a
+// KeyNotFoundError is returned.
+func (fc *fileClient) Get(ctx context.Context, key string) (string, error) {
+	var value string
+	err := fc.do(func() error {
+		var err error
+		value, err = fc.memory.Get(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+// GetRecursive returns a map of key Value pairs below the given key
+func (fc *fileClient) GetRecursive(ctx context.Context, key string) (map[string]string, error) {
+	var result map[string]string
+	err := fc.do(func() error {
+		var err error
+		result, err = fc.memory.GetRecursive(ctx, key)
+		return err
+	})
+	return result, err
+}
+
+// GetChildrenPaths returns an array of all children keys of the given key
+func (fc *fileClient) GetChildrenPaths(ctx context.Context, key string) ([]string, error) {
+	var children []string
+	err := fc.do(func() error {
+		var err error
+		children, err = fc.memory.GetChildrenPaths(ctx, key)
+		return err
+	})
+	return children, err
+}
+
+// GetMainNode returns the root node including all its children recursively.
+func (fc *fileClient) GetMainNode(ctx context.Context) (*client.Node, error) {
+	var mainNode *client.Node
+	err := fc.do(func() error {
+		var err error
+		mainNode, err = fc.memory.GetMainNode(ctx)
+		return err
+	})
+	return mainNode, err
+}
+
+// GetTree returns the node of the given key including all its children recursively. Unlike GetMainNode, hidden keys
+// are included.
+func (fc *fileClient) GetTree(ctx context.Context, key string) (*client.Node, error) {
+	var tree *client.Node
+	err := fc.do(func() error {
+		var err error
+		tree, err = fc.memory.GetTree(ctx, key)
+		return err
+	})
+	return tree, err
+}
+
+// Set sets the key to the given value
+func (fc *fileClient) Set(ctx context.Context, key string, value string, options *client.SetOptions) (string, error) {
+	err := checkFileKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	err = fc.do(func() error {
+		var err error
+		result, err = fc.memory.Set(ctx, key, value, options)
+		return err
+	})
+	return result, err
+}
+
+// Delete deletes the given key or directory
+func (fc *fileClient) Delete(ctx context.Context, key string, options *client.DeleteOptions) error {
+	return fc.do(func() error {
+		return fc.memory.Delete(ctx, key, options)
+	})
+}
+
+// DeleteRecursive deletes the given key and all its children
+func (fc *fileClient) DeleteRecursive(ctx context.Context, key string) error {
+	return fc.Delete(ctx, key, &client.DeleteOptions{Recursive: true})
+}
+
+// GetWithIndex returns the value and the modified index of the given key. If the given key cannot be found a
+// KeyNotFoundError is returned.
+func (fc *fileClient) GetWithIndex(ctx context.Context, key string) (string, uint64, error) {
+	var value string
+	var index uint64
+	err := fc.do(func() error {
+		var err error
+		value, index, err = fc.memory.GetWithIndex(ctx, key)
+		return err
+	})
+	return value, index, err
+}
+
+// GetCurrentIndex returns the index of the last change of the key space after the directory was read. The given key
+// is not required to exist.
+func (fc *fileClient) GetCurrentIndex(ctx context.Context, key string) (uint64, error) {
+	var index uint64
+	err := fc.do(func() error {
+		var err error
+		index, err = fc.memory.GetCurrentIndex(ctx, key)
+		return err
+	})
+	return index, err
+}
+
+// ApplyBatch applies all changes at once. No change is applied if one of them fails.
+func (fc *fileClient) ApplyBatch(ctx context.Context, changes []keyChange) error {
+	for _, change := range changes {
+		err := checkFileKey(change.key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return fc.do(func() error {
+		return fc.memory.ApplyBatch(ctx, changes)
+	})
+}
+
+// Watch watches for changes of the provided key which happened after the given index and sends the event through
+// the channel. An index of 0 starts the watch at the current index. Changes of the directory by other processes are
+// noticed with a file watch. The call blocks until the given context is done or the file watch fails.
+func (fc *fileClient) Watch(ctx context.Context, key string, recursive bool, afterIndex uint64, eventChannel chan<- *client.Response) error {
+	// changes which happened before the watch started must not be reported as events
+	err := fc.do(func() error {
+		if afterIndex == 0 {
+			afterIndex = fc.memoryIndex()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	directoryWatchErr := make(chan error, 1)
+	go func() {
+		directoryWatchErr <- fc.watchDirectory(watchCtx)
+	}()
+
+	memoryWatchErr := make(chan error, 1)
+	go func() {
+		memoryWatchErr <- fc.memory.Watch(watchCtx, key, recursive, afterIndex, eventChannel)
+	}()
+
+	select {
+	case err = <-memoryWatchErr:
+		return err
+	case err = <-directoryWatchErr:
+		cancel()
+		<-memoryWatchErr
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+}
+
+// watchDirectory synchronizes the copy of the key space with the directory whenever the file watch notices a change
+// and once per sync interval, so that watches receive the changes of other processes and the expirations of keys.
+func (fc *fileClient) watchDirectory(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to create file watch of registry directory")
+	}
+	defer func() { _ = watcher.Close() }()
+
+	err = addDirectoryWatches(watcher, fc.directory)
+	if err != nil {
+		return errors.Wrapf(err, "failed to watch registry directory %s", fc.directory)
+	}
+
+	ticker := time.NewTicker(fileSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return errors.New("file watch of registry directory was closed")
+			}
+			if event.Has(fsnotify.Create) {
+				// new directories have to be watched as well, they may disappear again at any time
+				_ = addDirectoryWatches(watcher, event.Name)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return errors.New("file watch of registry directory was closed")
+			}
+			return errors.Wrap(err, "failed to watch registry directory")
+		case <-ticker.C:
+		}
+
+		err = fc.do(func() error {
+			fc.memory.mutex.Lock()
+			defer fc.memory.mutex.Unlock()
+			fc.memory.removeExpiredNodes()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func addDirectoryWatches(watcher *fsnotify.Watcher, directory string) error {
+	return filepath.WalkDir(directory, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if filePath != directory && strings.HasPrefix(entry.Name(), fileReservedPrefix) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(filePath)
+	})
+}
+
+// do locks the directory, synchronizes the copy of the key space with the directory, runs the request on the copy
+// and writes the changes of the copy to the directory. The directory is not written if the request did not change
+// the copy, e.g. if it only read keys.
+func (fc *fileClient) do(request func() error) error {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	unlock, err := lockFile(filepath.Join(fc.directory, fileLockName))
+	if err != nil {
+		return errors.Wrapf(err, "failed to lock registry directory %s", fc.directory)
+	}
+	defer unlock()
+
+	entries, expirations, err := fc.read()
+	if err != nil {
+		return err
+	}
+
+	err = fc.applyToMemory(entries, expirations)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read registry directory %s", fc.directory)
+	}
+
+	synchronizedIndex := fc.memoryIndex()
+	requestErr := request()
+	if fc.memoryIndex() == synchronizedIndex {
+		return requestErr
+	}
+
+	err = fc.write(entries, expirations)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write registry directory %s", fc.directory)
+	}
+
+	return requestErr
+}
+
+// memoryIndex returns the index of the copy of the key space, which is incremented by every change.
+func (fc *fileClient) memoryIndex() uint64 {
+	fc.memory.mutex.Lock()
+	defer fc.memory.mutex.Unlock()
+	return fc.memory.index
+}
+
+// read reads the keys and the expirations from the directory.
+func (fc *fileClient) read() (map[string]fileEntry, map[string]time.Time, error) {
+	entries := map[string]fileEntry{}
+	err := filepath.WalkDir(fc.directory, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// other processes may remove keys while the directory is read
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if filePath == fc.directory {
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), fileReservedPrefix) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(fc.directory, filePath)
+		if err != nil {
+			return err
+		}
+		key := "/" + filepath.ToSlash(relativePath)
+
+		if entry.IsDir() {
+			entries[key] = fileEntry{dir: true}
+			return nil
+		}
+
+		value, err := os.ReadFile(filePath)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read key %s", key)
+		}
+		entries[key] = fileEntry{value: string(value)}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to read registry directory %s", fc.directory)
+	}
+
+	expirations := map[string]time.Time{}
+	data, err := os.ReadFile(filepath.Join(fc.directory, fileExpirationsName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, errors.Wrap(err, "failed to read expirations of registry keys")
+	}
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &expirations)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to parse expirations of registry keys")
+		}
+	}
+
+	return entries, expirations, nil
+}
+
+// applyToMemory changes the copy of the key space, so that it matches the keys of the directory. Every change is
+// recorded as event for the watches.
+func (fc *fileClient) applyToMemory(entries map[string]fileEntry, expirations map[string]time.Time) error {
+	mc := fc.memory
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	current, _ := collectMemoryEntries(mc.root)
+	for _, key := range sortedEntryKeys(current) {
+		entry, ok := entries[key]
+		if ok && entry.dir == current[key].dir {
+			continue
+		}
+
+		node := mc.lookup(key)
+		if node == nil {
+			// the parent directory was already removed
+			continue
+		}
+		delete(mc.lookup(path.Dir(key)).children, path.Base(key))
+		mc.index++
+		mc.recordEvent("delete", &memoryNode{key: key, dir: node.dir, modifiedIndex: mc.index}, node)
+	}
+
+	for _, key := range sortedEntryKeys(entries) {
+		entry := entries[key]
+		node := mc.lookup(key)
+		expiration, hasExpiration := expirations[key]
+		if node == nil && hasExpiration && !expiration.After(mc.now()) {
+			// the key expired already and is removed from the directory afterwards
+			continue
+		}
+		if node == nil || (!entry.dir && node.value != entry.value) {
+			_, err := mc.set(key, entry.value, &client.SetOptions{Dir: entry.dir})
+			if err != nil {
+				return err
+			}
+			node = mc.lookup(key)
+		}
+		node.expiration = expiration
+	}
+
+	return nil
+}
+
+// write changes the directory, so that it matches the copy of the key space. The given entries and expirations are
+// the current content of the directory.
+func (fc *fileClient) write(diskEntries map[string]fileEntry, diskExpirations map[string]time.Time) error {
+	fc.memory.mutex.Lock()
+	entries, expirations := collectMemoryEntries(fc.memory.root)
+	fc.memory.mutex.Unlock()
+
+	for _, key := range sortedEntryKeys(diskEntries) {
+		entry, ok := entries[key]
+		if ok && entry.dir == diskEntries[key].dir {
+			continue
+		}
+
+		err := os.RemoveAll(fc.filePath(key))
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove key %s", key)
+		}
+	}
+
+	for _, key := range sortedEntryKeys(entries) {
+		entry := entries[key]
+		if diskEntry, ok := diskEntries[key]; ok && diskEntry == entry {
+			continue
+		}
+
+		var err error
+		if entry.dir {
+			err = os.MkdirAll(fc.filePath(key), 0700)
+		} else {
+			err = writeFileAtomically(fc.filePath(key), []byte(entry.value))
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to write key %s", key)
+		}
+	}
+
+	if equalExpirations(expirations, diskExpirations) {
+		return nil
+	}
+
+	expirationsPath := filepath.Join(fc.directory, fileExpirationsName)
+	if len(expirations) == 0 {
+		err := os.Remove(expirationsPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return errors.Wrap(err, "failed to remove expirations of registry keys")
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(expirations)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize expirations of registry keys")
+	}
+	err = writeFileAtomically(expirationsPath, data)
+	if err != nil {
+		return errors.Wrap(err, "failed to write expirations of registry keys")
+	}
+
+	return nil
+}
+
+func (fc *fileClient) filePath(key string) string {
+	return filepath.Join(fc.directory, filepath.FromSlash(key))
+}
+
+// writeFileAtomically replaces the file with a temporary file, so that readers never see a partially written file.
+func writeFileAtomically(filePath string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(filePath), 0700)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(filePath), fileReservedPrefix+"tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	_, err = file.Write(data)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), filePath)
+}
+
+// collectMemoryEntries returns all keys and directories below the given node, including hidden keys, and the
+// expirations of the keys with a time to live.
+func collectMemoryEntries(node *memoryNode) (map[string]fileEntry, map[string]time.Time) {
+	entries := map[string]fileEntry{}
+	expirations := map[string]time.Time{}
+
+	var collect func(node *memoryNode)
+	collect = func(node *memoryNode) {
+		for _, child := range node.children {
+			entries[child.key] = fileEntry{value: child.value, dir: child.dir}
+			if !child.expiration.IsZero() {
+				expirations[child.key] = child.expiration
+			}
+			if child.dir {
+				collect(child)
+			}
+		}
+	}
+	collect(node)
+
+	return entries, expirations
+}
+
+// sortedEntryKeys returns the keys ordered by their name, so that directories come before their children.
+func sortedEntryKeys(entries map[string]fileEntry) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func equalExpirations(first map[string]time.Time, second map[string]time.Time) bool {
+	if len(first) != len(second) {
+		return false
+	}
+	for key, expiration := range first {
+		if !expiration.Equal(second[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkFileKey returns an error if a segment of the key starts with a dot, because such names are reserved for the
+// files of the file client.
+func checkFileKey(key string) error {
+	for _, segment := range strings.Split(normalizeKey(key), "/") {
+		if strings.HasPrefix(segment, fileReservedPrefix) {
+			return errors.Errorf("key %s is not supported, names starting with %q are reserved", key, fileReservedPrefix)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+//go:build unix
+// +build unix
+
+package registry
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// lockFile locks the given file exclusively with flock and blocks until the lock is acquired. The file is created if
+// it does not exist. The returned function releases the lock. The lock is released as well if the process ends.
+func lockFile(filePath string) (func(), error) {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+		if !errors.Is(err, syscall.EINTR) {
+			break
+		}
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, errors.Wrapf(err, "failed to lock %s", filePath)
+	}
+
+	return func() {
+		// closing the file releases the lock
+		_ = file.Close()
+	}, nil
+}
@@ -0,0 +1,397 @@
+package registry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/client/v2"
+)
+
+const (
+	// lockDirectory contains the keys of the locks of the registry.
+	lockDirectory = "/locks"
+
+	defaultLockTTL           = 60 * time.Second
+	defaultLockRetryInterval = time.Second
+)
+
+// errLockNotHeld signals that a lock is not held by the caller.
+var errLockNotHeld = errors.New("lock is not held")
+
+// Lock is a distributed lock which is stored in the registry, e.g. to make sure that only one process upgrades the
+// ecosystem at a time. The lock is held with a lease, which expires after the time to live of the lock if the holder
+// crashes, and which is renewed automatically while the lock is held. A Lock is safe for concurrent use, but it can
+// only be held once at a time.
+type Lock interface {
+	// Acquire blocks until the lock is acquired, the acquire timeout of the lock is over or the given context is done.
+	Acquire(ctx context.Context) error
+	// TryAcquire acquires the lock if it is free. It returns false if the lock is held by another owner.
+	TryAcquire(ctx context.Context) (bool, error)
+	// Release stops the renewal of the lease and removes the lock. The lock is only removed if it is still held by
+	// the caller, otherwise an error is returned which can be checked with IsLockNotHeldError.
+	Release(ctx context.Context) error
+	// Token returns the fencing token of the held lock. The token is increased with every acquisition of the lock
+	// and stays the same while its lease is renewed, so that resources which are protected by the lock can reject
+	// requests of former holders. It is 0 if the lock is not held.
+	Token() uint64
+	// Lost returns a channel which is closed if the held lock is lost before it is released, e.g. because its lease
+	// could not be renewed in time. It returns nil if the lock was never acquired.
+	Lost() <-chan struct{}
+	// Holder returns the current holder of the lock. It returns false if the lock is free.
+	Holder(ctx context.Context) (*LockInfo, bool, error)
+	// Break removes the lock regardless of its holder, e.g. if the holder crashed and its lease did not expire yet.
+	// The former holder notices the loss of the lock when it renews its lease.
+	Break(ctx context.Context) error
+}
+
+// LockOptions configure a lock of the registry.
+type LockOptions struct {
+	// Owner describes the holder of the lock, e.g. the name of the process. It defaults to the host name and the
+	// process id.
+	Owner string
+	// Metadata is stored together with the owner, e.g. to describe why the lock is held.
+	Metadata map[string]string
+	// TTL is the time after which the lock expires if its lease is not renewed. It is rounded up to whole seconds
+	// and defaults to 60 seconds.
+	TTL time.Duration
+	// RenewInterval is the interval in which the lease of a held lock is renewed. It defaults to a third of the TTL.
+	RenewInterval time.Duration
+	// AcquireTimeout limits the time in which Acquire waits for the lock. Acquire waits until its context is done if
+	// the timeout is 0.
+	AcquireTimeout time.Duration
+	// RetryInterval is the interval in which Acquire checks if a held lock was released. It defaults to one second.
+	RetryInterval time.Duration
+	// Key is the registry key of the lock. It defaults to the name of the lock below /locks and can be set to keep
+	// the key of an existing lock.
+	Key string
+	// OnRenewError is called if the lease of the held lock could not be renewed, including the failure after which
+	// the lock is lost. It must not block.
+	OnRenewError func(err error)
+}
+
+// LockInfo describes the holder of a lock.
+type LockInfo struct {
+	// Owner describes the holder of the lock.
+	Owner string `json:"owner"`
+	// Metadata contains the metadata of the holder.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// AcquiredAt is the time at which the lock was acquired.
+	AcquiredAt time.Time `json:"acquiredAt"`
+	// Session identifies a single acquisition of the lock, so that holders with the same owner can be distinguished.
+	Session string `json:"session"`
+}
+
+// IsLockNotHeldError returns true if the given error signals that a lock is not held by the caller.
+func IsLockNotHeldError(err error) bool {
+	return errors.Is(err, errLockNotHeld)
+}
+
+type etcdLock struct {
+	client  etcdClient
+	name    string
+	key     string
+	options LockOptions
+
+	mutex   sync.Mutex
+	session *lockSession
+	lost    chan struct{}
+}
+
+// lockSession is a single acquisition of a lock.
+type lockSession struct {
+	value string
+	token uint64
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// Lock returns the lock with the given name. The lock is stored below the key /locks unless another key is
+// configured.
+func (er *etcdRegistry) Lock(name string, options LockOptions) Lock {
+	return &etcdLock{
+		client:  er.client,
+		name:    name,
+		key:     lockKey(name, options),
+		options: options,
+	}
+}
+
+// lockKey returns the registry key of the lock with the given name and options.
+func lockKey(name string, options LockOptions) string {
+	if options.Key != "" {
+		return options.Key
+	}
+	return lockDirectory + "/" + name
+}
+
+// Acquire blocks until the lock is acquired, the acquire timeout of the lock is over or the given context is done.
+func (el *etcdLock) Acquire(ctx context.Context) error {
+	if el.options.AcquireTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, el.options.AcquireTimeout)
+		defer cancel()
+	}
+
+	for {
+		acquired, err := el.TryAcquire(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "failed to acquire lock %s", el.name)
+		case <-time.After(el.retryInterval()):
+		}
+	}
+}
+
+// TryAcquire acquires the lock if it is free. It returns false if the lock is held by another owner.
+func (el *etcdLock) TryAcquire(ctx context.Context) (bool, error) {
+	err := el.validateOptions()
+	if err != nil {
+		return false, err
+	}
+
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	if el.session != nil {
+		return false, errors.Errorf("lock %s is already held", el.name)
+	}
+
+	value, err := el.newLockValue()
+	if err != nil {
+		return false, err
+	}
+
+	_, err = el.client.Set(ctx, el.key, value, &client.SetOptions{PrevExist: client.PrevNoExist, TTL: el.ttl()})
+	// a retried request fails if the lost response of a former attempt already created the lock, so the value of
+	// the existing lock decides whether it is held by the caller
+	exists := hasErrorCode(err, client.ErrorCodeNodeExist)
+	if err != nil && !exists {
+		return false, errors.Wrapf(err, "failed to acquire lock %s", el.name)
+	}
+
+	// the created index of the key is increased by every acquisition and kept by renewals, so that it can be used
+	// as fencing token
+	node, err := el.client.GetTree(ctx, el.key)
+	if exists && IsKeyNotFoundError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get fencing token of lock %s", el.name)
+	}
+	if node.Value != value {
+		if exists {
+			return false, nil
+		}
+		return false, errors.Errorf("lock %s was lost while it was acquired", el.name)
+	}
+
+	session := &lockSession{value: value, token: node.CreatedIndex, stop: make(chan struct{}), done: make(chan struct{})}
+	el.session = session
+	el.lost = make(chan struct{})
+	go el.renew(session, el.lost)
+
+	return true, nil
+}
+
+// renew renews the lease of the lock until the session is stopped or the lock is lost.
+func (el *etcdLock) renew(session *lockSession, lost chan struct{}) {
+	defer close(session.done)
+
+	ticker := time.NewTicker(el.renewInterval())
+	defer ticker.Stop()
+
+	lastRenewal := time.Now()
+	for {
+		select {
+		case <-session.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), el.renewInterval())
+		// the value is compared, so that the lease of another holder is never renewed
+		_, err := el.client.Set(ctx, el.key, "", &client.SetOptions{Refresh: true, PrevExist: client.PrevExist, PrevValue: session.value, TTL: el.ttl()})
+		cancel()
+		if err == nil {
+			lastRenewal = time.Now()
+			continue
+		}
+
+		if el.options.OnRenewError != nil {
+			el.options.OnRenewError(errors.Wrapf(err, "failed to renew lock %s", el.name))
+		}
+		if IsKeyNotFoundError(err) || isPreconditionFailed(err) || time.Since(lastRenewal) >= el.ttl() {
+			core.GetLogger().Errorf("lost lock %s: %v", el.name, err)
+			el.loseSession(session, lost)
+			return
+		}
+		core.GetLogger().Warningf("failed to renew lock %s, retrying: %v", el.name, err)
+	}
+}
+
+func (el *etcdLock) loseSession(session *lockSession, lost chan struct{}) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	if el.session == session {
+		el.session = nil
+	}
+	close(lost)
+}
+
+// Release stops the renewal of the lease and removes the lock.
+func (el *etcdLock) Release(ctx context.Context) error {
+	el.mutex.Lock()
+	session := el.session
+	el.session = nil
+	el.mutex.Unlock()
+
+	if session == nil {
+		return errors.Wrapf(errLockNotHeld, "failed to release lock %s", el.name)
+	}
+
+	close(session.stop)
+	<-session.done
+
+	err := el.client.Delete(ctx, el.key, &client.DeleteOptions{PrevValue: session.value})
+	if IsKeyNotFoundError(err) || isPreconditionFailed(err) {
+		return errors.Wrapf(errLockNotHeld, "failed to release lock %s, it expired or was taken over", el.name)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to release lock %s", el.name)
+	}
+
+	return nil
+}
+
+// Token returns the fencing token of the held lock.
+func (el *etcdLock) Token() uint64 {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	if el.session == nil {
+		return 0
+	}
+	return el.session.token
+}
+
+// Lost returns a channel which is closed if the held lock is lost before it is released.
+func (el *etcdLock) Lost() <-chan struct{} {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	return el.lost
+}
+
+// Holder returns the current holder of the lock. It returns false if the lock is free.
+func (el *etcdLock) Holder(ctx context.Context) (*LockInfo, bool, error) {
+	value, err := el.client.Get(ctx, el.key)
+	if IsKeyNotFoundError(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to get holder of lock %s", el.name)
+	}
+
+	info := &LockInfo{}
+	err = json.Unmarshal([]byte(value), info)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to parse holder of lock %s", el.name)
+	}
+
+	return info, true, nil
+}
+
+// Break removes the lock regardless of its holder.
+func (el *etcdLock) Break(ctx context.Context) error {
+	err := el.client.Delete(ctx, el.key, nil)
+	if err != nil && !IsKeyNotFoundError(err) {
+		return errors.Wrapf(err, "failed to break lock %s", el.name)
+	}
+
+	return nil
+}
+
+func (el *etcdLock) newLockValue() (string, error) {
+	session := make([]byte, 16)
+	_, err := rand.Read(session)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create lock session")
+	}
+
+	info := LockInfo{
+		Owner:      el.owner(),
+		Metadata:   el.options.Metadata,
+		AcquiredAt: time.Now().UTC(),
+		Session:    hex.EncodeToString(session),
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to serialize holder of lock %s", el.name)
+	}
+
+	return string(data), nil
+}
+
+func (el *etcdLock) validateOptions() error {
+	if el.name == "" {
+		return errors.New("the name of a lock must not be empty")
+	}
+	if el.options.TTL < 0 || el.options.RenewInterval < 0 || el.options.AcquireTimeout < 0 || el.options.RetryInterval < 0 {
+		return errors.Errorf("the durations of lock %s must not be negative", el.name)
+	}
+	if el.renewInterval() >= el.ttl() {
+		return errors.Errorf("the renew interval of lock %s has to be shorter than its time to live", el.name)
+	}
+	return nil
+}
+
+func (el *etcdLock) owner() string {
+	if el.options.Owner != "" {
+		return el.options.Owner
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// ttl returns the time to live of the lock in whole seconds, because etcd does not support shorter lifetimes.
+func (el *etcdLock) ttl() time.Duration {
+	if el.options.TTL == 0 {
+		return defaultLockTTL
+	}
+	return ((el.options.TTL + time.Second - 1) / time.Second) * time.Second
+}
+
+func (el *etcdLock) renewInterval() time.Duration {
+	if el.options.RenewInterval == 0 {
+		return el.ttl() / 3
+	}
+	return el.options.RenewInterval
+}
+
+func (el *etcdLock) retryInterval() time.Duration {
+	if el.options.RetryInterval == 0 {
+		return defaultLockRetryInterval
+	}
+	return el.options.RetryInterval
+}
@@ -0,0 +1,54 @@
+//go:build integration
+// +build integration
+
+package registry_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtcdLock_inttest(t *testing.T) {
+	testLock(t, reg)
+}
+
+func TestEtcdV3Lock_inttest(t *testing.T) {
+	testLock(t, createEtcdV3TestRegistry(t))
+}
+
+func testLock(t *testing.T, sut registry.Registry) {
+	t.Helper()
+	ctx := context.Background()
+
+	t.Run("should hold the lock until it is released", func(t *testing.T) {
+		// given
+		first := sut.Lock("unit-test", registry.LockOptions{Owner: "first", TTL: time.Second, RenewInterval: 200 * time.Millisecond})
+		second := sut.Lock("unit-test", registry.LockOptions{Owner: "second"})
+		require.NoError(t, first.Acquire(ctx))
+		firstToken := first.Token()
+
+		// when
+		time.Sleep(2500 * time.Millisecond)
+		acquiredWhileHeld, err := second.TryAcquire(ctx)
+		require.NoError(t, err)
+		require.NoError(t, first.Release(ctx))
+		acquiredAfterRelease, err := second.TryAcquire(ctx)
+		require.NoError(t, err)
+		defer func() { _ = second.Release(ctx) }()
+
+		// then
+		assert.False(t, acquiredWhileHeld)
+		assert.True(t, acquiredAfterRelease)
+		assert.Greater(t, second.Token(), firstToken)
+		select {
+		case <-first.Lost():
+			t.Fatal("released lock must not be reported as lost")
+		default:
+		}
+	})
+}
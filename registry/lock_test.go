@@ -0,0 +1,340 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/client/v2"
+)
+
+func TestEtcdLock_TryAcquire(t *testing.T) {
+	t.Run("should acquire free lock only once", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		first := reg.Lock("upgrade", LockOptions{Owner: "first", Metadata: map[string]string{"dogu": "redmine"}})
+		second := reg.Lock("upgrade", LockOptions{Owner: "second"})
+
+		// when
+		firstAcquired, err := first.TryAcquire(context.Background())
+		require.NoError(t, err)
+		defer func() { _ = first.Release(context.Background()) }()
+		secondAcquired, err := second.TryAcquire(context.Background())
+		require.NoError(t, err)
+
+		// then
+		assert.True(t, firstAcquired)
+		assert.False(t, secondAcquired)
+		assert.NotZero(t, first.Token())
+		assert.Zero(t, second.Token())
+		holder, held, err := second.Holder(context.Background())
+		require.NoError(t, err)
+		assert.True(t, held)
+		assert.Equal(t, "first", holder.Owner)
+		assert.Equal(t, map[string]string{"dogu": "redmine"}, holder.Metadata)
+		assert.NotEmpty(t, holder.Session)
+	})
+
+	t.Run("should increase fencing token with every acquisition", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		first := reg.Lock("upgrade", LockOptions{Owner: "first"})
+		second := reg.Lock("upgrade", LockOptions{Owner: "second"})
+		_, err := first.TryAcquire(context.Background())
+		require.NoError(t, err)
+		firstToken := first.Token()
+		require.NoError(t, first.Release(context.Background()))
+
+		// when
+		acquired, err := second.TryAcquire(context.Background())
+		require.NoError(t, err)
+		defer func() { _ = second.Release(context.Background()) }()
+
+		// then
+		assert.True(t, acquired)
+		assert.Greater(t, second.Token(), firstToken)
+		assert.Zero(t, first.Token())
+	})
+
+	t.Run("should acquire lock which was created by a retried request", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		var value string
+		etcdClientMock.EXPECT().Set(mock.Anything, "/locks/upgrade", mock.Anything, &client.SetOptions{PrevExist: client.PrevNoExist, TTL: time.Minute}).
+			Run(func(_ context.Context, _ string, setValue string, _ *client.SetOptions) { value = setValue }).
+			Return("", client.Error{Code: client.ErrorCodeNodeExist})
+		etcdClientMock.EXPECT().GetTree(mock.Anything, "/locks/upgrade").
+			RunAndReturn(func(context.Context, string) (*client.Node, error) {
+				return &client.Node{Key: "/locks/upgrade", Value: value, CreatedIndex: 42, ModifiedIndex: 42}, nil
+			})
+		etcdClientMock.EXPECT().Delete(mock.Anything, "/locks/upgrade", mock.Anything).Return(nil)
+		sut := (&etcdRegistry{client: etcdClientMock}).Lock("upgrade", LockOptions{})
+
+		// when
+		acquired, err := sut.TryAcquire(context.Background())
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, acquired)
+		assert.Equal(t, uint64(42), sut.Token())
+		require.NoError(t, sut.Release(context.Background()))
+	})
+
+	t.Run("should not acquire lock of another owner which exists already", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.EXPECT().Set(mock.Anything, "/locks/upgrade", mock.Anything, mock.Anything).
+			Return("", client.Error{Code: client.ErrorCodeNodeExist})
+		etcdClientMock.EXPECT().GetTree(mock.Anything, "/locks/upgrade").
+			Return(&client.Node{Key: "/locks/upgrade", Value: `{"owner":"other"}`, CreatedIndex: 42}, nil)
+		sut := (&etcdRegistry{client: etcdClientMock}).Lock("upgrade", LockOptions{})
+
+		// when
+		acquired, err := sut.TryAcquire(context.Background())
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, acquired)
+		assert.Zero(t, sut.Token())
+	})
+
+	t.Run("should fail if the lock is already held by the caller", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().Lock("upgrade", LockOptions{})
+		_, err := sut.TryAcquire(context.Background())
+		require.NoError(t, err)
+		defer func() { _ = sut.Release(context.Background()) }()
+
+		// when
+		_, err = sut.TryAcquire(context.Background())
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "lock upgrade is already held")
+	})
+
+	t.Run("should fail for invalid options", func(t *testing.T) {
+		reg := NewInMemoryRegistry()
+
+		_, errName := reg.Lock("", LockOptions{}).TryAcquire(context.Background())
+		_, errInterval := reg.Lock("upgrade", LockOptions{TTL: time.Second, RenewInterval: 2 * time.Second}).TryAcquire(context.Background())
+
+		require.Error(t, errName)
+		assert.Contains(t, errName.Error(), "the name of a lock must not be empty")
+		require.Error(t, errInterval)
+		assert.Contains(t, errInterval.Error(), "has to be shorter than its time to live")
+	})
+}
+
+func TestEtcdLock_Acquire(t *testing.T) {
+	t.Run("should wait until the lock is released", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		first := reg.Lock("upgrade", LockOptions{Owner: "first"})
+		second := reg.Lock("upgrade", LockOptions{Owner: "second", RetryInterval: 10 * time.Millisecond})
+		require.NoError(t, first.Acquire(context.Background()))
+		time.AfterFunc(50*time.Millisecond, func() { _ = first.Release(context.Background()) })
+
+		// when
+		err := second.Acquire(context.Background())
+
+		// then
+		require.NoError(t, err)
+		holder, _, err := second.Holder(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "second", holder.Owner)
+		require.NoError(t, second.Release(context.Background()))
+	})
+
+	t.Run("should fail after the acquire timeout", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		first := reg.Lock("upgrade", LockOptions{Owner: "first"})
+		second := reg.Lock("upgrade", LockOptions{Owner: "second", AcquireTimeout: 50 * time.Millisecond, RetryInterval: 10 * time.Millisecond})
+		require.NoError(t, first.Acquire(context.Background()))
+		defer func() { _ = first.Release(context.Background()) }()
+
+		// when
+		err := second.Acquire(context.Background())
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "failed to acquire lock upgrade")
+	})
+}
+
+func TestEtcdLock_Renew(t *testing.T) {
+	t.Run("should renew the lease while the lock is held", func(t *testing.T) {
+		// given
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		var nowMutex sync.Mutex
+		advance := func(duration time.Duration) {
+			nowMutex.Lock()
+			defer nowMutex.Unlock()
+			now = now.Add(duration)
+		}
+		memClient := newMemoryClient()
+		memClient.now = func() time.Time {
+			nowMutex.Lock()
+			defer nowMutex.Unlock()
+			return now
+		}
+		reg := &etcdRegistry{client: memClient}
+		sut := reg.Lock("upgrade", LockOptions{TTL: time.Second, RenewInterval: 10 * time.Millisecond})
+		require.NoError(t, sut.Acquire(context.Background()))
+		defer func() { _ = sut.Release(context.Background()) }()
+
+		// when
+		time.Sleep(50 * time.Millisecond)
+		advance(500 * time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		advance(700 * time.Millisecond)
+
+		// then
+		_, held, err := sut.Holder(context.Background())
+		require.NoError(t, err)
+		assert.True(t, held)
+		node, err := memClient.GetTree(context.Background(), "/locks/upgrade")
+		require.NoError(t, err)
+		assert.Greater(t, node.ModifiedIndex, node.CreatedIndex)
+		assert.Equal(t, node.CreatedIndex, sut.Token())
+	})
+
+	t.Run("should notify if the lock is lost", func(t *testing.T) {
+		// given
+		memClient := newMemoryClient()
+		reg := &etcdRegistry{client: memClient}
+		sut := reg.Lock("upgrade", LockOptions{TTL: time.Second, RenewInterval: 10 * time.Millisecond})
+		assert.Nil(t, sut.Lost())
+		require.NoError(t, sut.Acquire(context.Background()))
+
+		// when
+		err := memClient.Delete(context.Background(), "/locks/upgrade", &client.DeleteOptions{})
+		require.NoError(t, err)
+
+		// then
+		select {
+		case <-sut.Lost():
+		case <-time.After(time.Second):
+			t.Fatal("lost lock was not notified")
+		}
+		assert.Zero(t, sut.Token())
+		err = sut.Release(context.Background())
+		require.Error(t, err)
+		assert.True(t, IsLockNotHeldError(err))
+	})
+}
+
+func TestEtcdLock_Break(t *testing.T) {
+	t.Run("should remove the lock of another holder", func(t *testing.T) {
+		// given
+		var renewErrors []error
+		var renewErrorsMutex sync.Mutex
+		reg := NewInMemoryRegistry()
+		holder := reg.Lock("upgrade", LockOptions{
+			Owner:         "crashed",
+			TTL:           time.Second,
+			RenewInterval: 10 * time.Millisecond,
+			OnRenewError: func(err error) {
+				renewErrorsMutex.Lock()
+				defer renewErrorsMutex.Unlock()
+				renewErrors = append(renewErrors, err)
+			},
+		})
+		require.NoError(t, holder.Acquire(context.Background()))
+		sut := reg.Lock("upgrade", LockOptions{Owner: "takeover"})
+
+		// when
+		err := sut.Break(context.Background())
+		require.NoError(t, err)
+		acquired, err := sut.TryAcquire(context.Background())
+		require.NoError(t, err)
+		defer func() { _ = sut.Release(context.Background()) }()
+
+		// then
+		assert.True(t, acquired)
+		select {
+		case <-holder.Lost():
+		case <-time.After(time.Second):
+			t.Fatal("lost lock was not notified")
+		}
+		renewErrorsMutex.Lock()
+		defer renewErrorsMutex.Unlock()
+		require.Len(t, renewErrors, 1)
+		assert.Contains(t, renewErrors[0].Error(), "failed to renew lock upgrade")
+	})
+
+	t.Run("should ignore free lock", func(t *testing.T) {
+		err := NewInMemoryRegistry().Lock("upgrade", LockOptions{}).Break(context.Background())
+
+		require.NoError(t, err)
+	})
+}
+
+func TestEtcdLock_Key(t *testing.T) {
+	// given
+	reg := NewInMemoryRegistry()
+	sut := reg.Lock("critical", LockOptions{Owner: "backup", Key: "/config/_global/critical"})
+
+	// when
+	err := sut.Acquire(context.Background())
+	require.NoError(t, err)
+	defer func() { _ = sut.Release(context.Background()) }()
+
+	// then
+	value, err := reg.GlobalConfig().Get("critical")
+	require.NoError(t, err)
+	assert.Contains(t, value, `"owner":"backup"`)
+}
+
+func TestEtcdLock_Release(t *testing.T) {
+	t.Run("should fail if the lock was never acquired", func(t *testing.T) {
+		sut := NewInMemoryRegistry().Lock("upgrade", LockOptions{})
+
+		err := sut.Release(context.Background())
+
+		require.Error(t, err)
+		assert.True(t, IsLockNotHeldError(err))
+	})
+
+	t.Run("should not remove the lock of another owner", func(t *testing.T) {
+		// given
+		memClient := newMemoryClient()
+		reg := &etcdRegistry{client: memClient}
+		sut := reg.Lock("upgrade", LockOptions{Owner: "first"})
+		require.NoError(t, sut.Acquire(context.Background()))
+		_, err := memClient.Set(context.Background(), "/locks/upgrade", `{"owner":"second"}`, nil)
+		require.NoError(t, err)
+
+		// when
+		err = sut.Release(context.Background())
+
+		// then
+		require.Error(t, err)
+		assert.True(t, IsLockNotHeldError(err))
+		holder, held, err := sut.Holder(context.Background())
+		require.NoError(t, err)
+		assert.True(t, held)
+		assert.Equal(t, "second", holder.Owner)
+	})
+
+	t.Run("should free the lock", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().Lock("upgrade", LockOptions{})
+		require.NoError(t, sut.Acquire(context.Background()))
+
+		// when
+		err := sut.Release(context.Background())
+
+		// then
+		require.NoError(t, err)
+		_, held, err := sut.Holder(context.Background())
+		require.NoError(t, err)
+		assert.False(t, held)
+	})
+}
@@ -0,0 +1,690 @@
+package registry
+
+import (
+	"context"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/client/v2"
+)
+
+// maxMemoryEventHistory is the number of events the in-memory client keeps for watchers. It equals the event history
+// size of etcd v2.
+const maxMemoryEventHistory = 1000
+
+type memoryNode struct {
+	key           string
+	value         string
+	dir           bool
+	children      map[string]*memoryNode
+	expiration    time.Time
+	createdIndex  uint64
+	modifiedIndex uint64
+}
+
+// memoryClient is an etcdClient which keeps the whole key space in memory. It mimics the semantics of the etcd v2
+// key space, including the error codes, hidden keys, time to live of keys and the event history for watchers.
+// Expired keys are removed lazily on the next access of the client.
+type memoryClient struct {
+	mutex  sync.Mutex
+	root   *memoryNode
+	index  uint64
+	events []*client.Response
+	// clearedIndex is the index of the latest event which was removed from the event history
+	clearedIndex uint64
+	changed      chan struct{}
+	now          func() time.Time
+}
+
+func newMemoryClient() *memoryClient {
+	return &memoryClient{
+		root:    &memoryNode{key: "/", dir: true, children: map[string]*memoryNode{}},
+		changed: make(chan struct{}),
+		now:     time.Now,
+	}
+}
+
+// Exists returns true if the key exists
+func (mc *memoryClient) Exists(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	return mc.lookup(normalizeKey(key)) != nil, nil
+}
+
+// Get returns the value of the given node, otherwise it returns an error. If the given key cannot be found a
+// KeyNotFoundError is returned.
+func (mc *memoryClient) Get(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	key = normalizeKey(key)
+	node := mc.lookup(key)
+	if node == nil {
+		return "", mc.newError(client.ErrorCodeKeyNotFound, "Key not found", key)
+	}
+
+	return node.value, nil
+}
+
+// GetRecursive returns a map of key Value pairs below the given key
+func (mc *memoryClient) GetRecursive(ctx context.Context, key string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	key = normalizeKey(key)
+	node := mc.lookup(key)
+	if node == nil {
+		return nil, mc.newError(client.ErrorCodeKeyNotFound, "Key not found", key)
+	}
+
+	result := map[string]string{}
+	addMemoryNodeValuesToMap(result, node, "")
+	return result, nil
+}
+
+func addMemoryNodeValuesToMap(keyValuePairs map[string]string, node *memoryNode, parent string) {
+	for _, child := range visibleChildren(node) {
+		childKey := path.Base(child.key)
+		if parent != "" {
+			childKey = parent + "/" + childKey
+		}
+
+		if child.dir {
+			addMemoryNodeValuesToMap(keyValuePairs, child, childKey)
+		} else {
+			keyValuePairs[childKey] = child.value
+		}
+	}
+}
+
+// GetChildrenPaths returns an array of all children keys of the given key
+func (mc *memoryClient) GetChildrenPaths(ctx context.Context, key string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	children := []string{}
+	node := mc.lookup(normalizeKey(key))
+	if node == nil {
+		return children, nil
+	}
+
+	for _, child := range visibleChildren(node) {
+		children = append(children, child.key)
+	}
+
+	return children, nil
+}
+
+// GetMainNode returns the root node including all its children recursively.
+func (mc *memoryClient) GetMainNode(ctx context.Context) (*client.Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	mainNode := mc.toClientNode(mc.root, true)
+
+	// like in etcd `config/_global` is a hidden directory and has to be added explicitly.
+	for _, node := range mainNode.Nodes {
+		if node.Key == "/config" {
+			globalConfig := mc.lookup("/config/" + DirectoryGlobal)
+			if globalConfig == nil {
+				log.Warning("Key '/config/_global' not found.")
+				continue
+			}
+			node.Nodes = append(node.Nodes, mc.toClientNode(globalConfig, true))
+		}
+	}
+
+	return mainNode, nil
+}
+
+// GetTree returns the node of the given key including all its children recursively. Unlike GetMainNode, hidden keys
+// are included.
+func (mc *memoryClient) GetTree(ctx context.Context, key string) (*client.Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	key = normalizeKey(key)
+	node := mc.lookup(key)
+	if node == nil {
+		return nil, mc.newError(client.ErrorCodeKeyNotFound, "Key not found", key)
+	}
+
+	return mc.toClientTree(node), nil
+}
+
+// Set sets the key to the given value
+func (mc *memoryClient) Set(ctx context.Context, key string, value string, options *client.SetOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	return mc.set(key, value, options)
+}
+
+func (mc *memoryClient) set(key string, value string, options *client.SetOptions) (string, error) {
+	if options == nil {
+		options = &client.SetOptions{}
+	}
+
+	key = normalizeKey(key)
+	if key == "/" {
+		return "", mc.newError(client.ErrorCodeRootROnly, "Root is read only", key)
+	}
+
+	existing := mc.lookup(key)
+	if options.Refresh {
+		return mc.refresh(key, existing, options)
+	}
+
+	action, err := mc.checkSetPreconditions(key, existing, options)
+	if err != nil {
+		return "", err
+	}
+
+	parent, err := mc.createParentDirectories(key)
+	if err != nil {
+		return "", err
+	}
+
+	mc.index++
+	node := &memoryNode{
+		key:           key,
+		value:         value,
+		dir:           options.Dir,
+		createdIndex:  mc.index,
+		modifiedIndex: mc.index,
+	}
+	if options.Dir {
+		node.value = ""
+		node.children = map[string]*memoryNode{}
+	}
+	if existing != nil {
+		node.createdIndex = existing.createdIndex
+	}
+	if options.TTL > 0 {
+		node.expiration = mc.now().Add(options.TTL)
+	}
+
+	parent.children[path.Base(key)] = node
+	mc.recordEvent(action, node, existing)
+
+	return node.value, nil
+}
+
+func (mc *memoryClient) checkSetPreconditions(key string, existing *memoryNode, options *client.SetOptions) (string, error) {
+	action := "set"
+
+	// like in etcd, creating a key fails for existing directories as well
+	if options.PrevExist == client.PrevNoExist && existing != nil {
+		return "", mc.newError(client.ErrorCodeNodeExist, "Key already exists", key)
+	}
+
+	if existing != nil && existing.dir {
+		return "", mc.newError(client.ErrorCodeNotFile, "Not a file", key)
+	}
+
+	switch options.PrevExist {
+	case client.PrevNoExist:
+		action = "create"
+	case client.PrevExist:
+		if existing == nil {
+			return "", mc.newError(client.ErrorCodeKeyNotFound, "Key not found", key)
+		}
+		action = "update"
+	}
+
+	if options.PrevValue != "" || options.PrevIndex != 0 {
+		if existing == nil {
+			return "", mc.newError(client.ErrorCodeKeyNotFound, "Key not found", key)
+		}
+		if options.PrevValue != "" && options.PrevValue != existing.value {
+			return "", mc.newError(client.ErrorCodeTestFailed, "Compare failed", key)
+		}
+		if options.PrevIndex != 0 && options.PrevIndex != existing.modifiedIndex {
+			return "", mc.newError(client.ErrorCodeTestFailed, "Compare failed", key)
+		}
+		action = "compareAndSwap"
+	}
+
+	return action, nil
+}
+
+func (mc *memoryClient) refresh(key string, existing *memoryNode, options *client.SetOptions) (string, error) {
+	if existing == nil {
+		return "", mc.newError(client.ErrorCodeKeyNotFound, "Key not found", key)
+	}
+	_, err := mc.checkSetPreconditions(key, existing, options)
+	if err != nil {
+		return "", err
+	}
+
+	// like in etcd, a refresh increases the modified index, but does neither change the value nor notify any watcher
+	mc.index++
+	existing.modifiedIndex = mc.index
+	existing.expiration = time.Time{}
+	if options.TTL > 0 {
+		existing.expiration = mc.now().Add(options.TTL)
+	}
+
+	return existing.value, nil
+}
+
+func (mc *memoryClient) createParentDirectories(key string) (*memoryNode, error) {
+	current := mc.root
+	for _, segment := range strings.Split(strings.TrimPrefix(path.Dir(key), "/"), "/") {
+		if segment == "" {
+			continue
+		}
+
+		child, ok := current.children[segment]
+		if !ok {
+			mc.index++
+			child = &memoryNode{
+				key:           path.Join(current.key, segment),
+				dir:           true,
+				children:      map[string]*memoryNode{},
+				createdIndex:  mc.index,
+				modifiedIndex: mc.index,
+			}
+			current.children[segment] = child
+		} else if !child.dir {
+			return nil, mc.newError(client.ErrorCodeNotDir, "Not a directory", child.key)
+		}
+
+		current = child
+	}
+
+	return current, nil
+}
+
+// Delete deletes the given key or directory
+func (mc *memoryClient) Delete(ctx context.Context, key string, options *client.DeleteOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	return mc.delete(key, options)
+}
+
+func (mc *memoryClient) delete(key string, options *client.DeleteOptions) error {
+	if options == nil {
+		options = &client.DeleteOptions{}
+	}
+
+	key = normalizeKey(key)
+	if key == "/" {
+		return mc.newError(client.ErrorCodeRootROnly, "Root is read only", key)
+	}
+
+	existing := mc.lookup(key)
+	if existing == nil {
+		return mc.newError(client.ErrorCodeKeyNotFound, "Key not found", key)
+	}
+
+	action := "delete"
+	if existing.dir {
+		if !options.Recursive && !options.Dir {
+			return mc.newError(client.ErrorCodeNotFile, "Not a file", key)
+		}
+		if !options.Recursive && len(existing.children) > 0 {
+			return mc.newError(client.ErrorCodeDirNotEmpty, "Directory not empty", key)
+		}
+	}
+
+	if options.PrevValue != "" || options.PrevIndex != 0 {
+		if options.PrevValue != "" && options.PrevValue != existing.value {
+			return mc.newError(client.ErrorCodeTestFailed, "Compare failed", key)
+		}
+		if options.PrevIndex != 0 && options.PrevIndex != existing.modifiedIndex {
+			return mc.newError(client.ErrorCodeTestFailed, "Compare failed", key)
+		}
+		action = "compareAndDelete"
+	}
+
+	parent := mc.lookup(path.Dir(key))
+	delete(parent.children, path.Base(key))
+
+	mc.index++
+	mc.recordEvent(action, &memoryNode{key: key, dir: existing.dir, modifiedIndex: mc.index}, existing)
+
+	return nil
+}
+
+// DeleteRecursive deletes the given key and all its children
+func (mc *memoryClient) DeleteRecursive(ctx context.Context, key string) error {
+	return mc.Delete(ctx, key, &client.DeleteOptions{Recursive: true})
+}
+
+// GetCurrentIndex returns the index of the last change of the key space. The given key is not required to exist.
+func (mc *memoryClient) GetCurrentIndex(ctx context.Context, _ string) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	return mc.index, nil
+}
+
+// GetWithIndex returns the value and the modified index of the given key. If the given key cannot be found a
+// KeyNotFoundError is returned.
+func (mc *memoryClient) GetWithIndex(ctx context.Context, key string) (string, uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	key = normalizeKey(key)
+	node := mc.lookup(key)
+	if node == nil {
+		return "", 0, mc.newError(client.ErrorCodeKeyNotFound, "Key not found", key)
+	}
+
+	return node.value, node.modifiedIndex, nil
+}
+
+// ApplyBatch applies all changes at once. The changes are applied to a copy of the key space first, so that no
+// change is applied if one of them fails.
+func (mc *memoryClient) ApplyBatch(ctx context.Context, changes []keyChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	dryRun := &memoryClient{
+		root:    mc.root.clone(),
+		index:   mc.index,
+		changed: make(chan struct{}),
+		now:     mc.now,
+	}
+	err := dryRun.applyChanges(changes)
+	if err != nil {
+		return err
+	}
+
+	return mc.applyChanges(changes)
+}
+
+func (mc *memoryClient) applyChanges(changes []keyChange) error {
+	for _, change := range changes {
+		if change.delete {
+			err := mc.delete(change.key, nil)
+			if err != nil && !IsKeyNotFoundError(err) {
+				return err
+			}
+			continue
+		}
+
+		_, err := mc.set(change.key, change.value, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Watch watches for changes of the provided key which happened after the given index and sends the event through
+// the channel. An index of 0 starts the watch at the current index. The call blocks until the given context is done.
+// If the events after the given index were already removed from the event history, an EventIndexCleared error is
+// returned.
+func (mc *memoryClient) Watch(ctx context.Context, key string, recursive bool, afterIndex uint64, eventChannel chan<- *client.Response) error {
+	key = normalizeKey(key)
+
+	if afterIndex == 0 {
+		mc.mutex.Lock()
+		afterIndex = mc.index
+		mc.mutex.Unlock()
+	}
+
+	for {
+		events, changed, err := mc.eventsAfter(afterIndex, key, recursive)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			select {
+			case eventChannel <- event:
+				afterIndex = event.Index
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (mc *memoryClient) eventsAfter(afterIndex uint64, key string, recursive bool) ([]*client.Response, chan struct{}, error) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.removeExpiredNodes()
+
+	if afterIndex < mc.clearedIndex {
+		return nil, nil, mc.newError(client.ErrorCodeEventIndexCleared, "The event in requested index is outdated and cleared", key)
+	}
+
+	var events []*client.Response
+	for _, event := range mc.events {
+		if event.Index > afterIndex && eventMatchesKey(event, key, recursive) {
+			events = append(events, event)
+		}
+	}
+
+	return events, mc.changed, nil
+}
+
+func eventMatchesKey(event *client.Response, key string, recursive bool) bool {
+	eventKey := event.Node.Key
+	if eventKey == key {
+		return true
+	}
+
+	if recursive && (key == "/" || strings.HasPrefix(eventKey, key+"/")) {
+		return true
+	}
+
+	// removing a directory removes all watched keys below it as well
+	isRemoval := event.Action == "delete" || event.Action == "expire" || event.Action == "compareAndDelete"
+	return isRemoval && strings.HasPrefix(key, eventKey+"/")
+}
+
+func (mc *memoryClient) recordEvent(action string, node *memoryNode, prevNode *memoryNode) {
+	event := &client.Response{
+		Action: action,
+		Node:   mc.toClientNode(node, false),
+		Index:  mc.index,
+	}
+	if prevNode != nil {
+		event.PrevNode = mc.toClientNode(prevNode, false)
+	}
+
+	mc.events = append(mc.events, event)
+	if len(mc.events) > maxMemoryEventHistory {
+		removed := len(mc.events) - maxMemoryEventHistory
+		mc.clearedIndex = mc.events[removed-1].Index
+		mc.events = mc.events[removed:]
+	}
+
+	close(mc.changed)
+	mc.changed = make(chan struct{})
+}
+
+func (mc *memoryClient) removeExpiredNodes() {
+	mc.removeExpiredChildren(mc.root, mc.now())
+}
+
+func (mc *memoryClient) removeExpiredChildren(node *memoryNode, now time.Time) {
+	for _, name := range sortedChildNames(node) {
+		child := node.children[name]
+		if !child.expiration.IsZero() && !child.expiration.After(now) {
+			delete(node.children, name)
+			mc.index++
+			mc.recordEvent("expire", &memoryNode{key: child.key, dir: child.dir, modifiedIndex: mc.index}, child)
+			continue
+		}
+
+		if child.dir {
+			mc.removeExpiredChildren(child, now)
+		}
+	}
+}
+
+func (node *memoryNode) clone() *memoryNode {
+	result := *node
+	if node.children != nil {
+		result.children = make(map[string]*memoryNode, len(node.children))
+		for name, child := range node.children {
+			result.children[name] = child.clone()
+		}
+	}
+	return &result
+}
+
+func (mc *memoryClient) lookup(key string) *memoryNode {
+	current := mc.root
+	for _, segment := range strings.Split(strings.TrimPrefix(key, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+
+		if !current.dir {
+			return nil
+		}
+
+		child, ok := current.children[segment]
+		if !ok {
+			return nil
+		}
+		current = child
+	}
+
+	return current
+}
+
+func (mc *memoryClient) toClientNode(node *memoryNode, recursive bool) *client.Node {
+	result := &client.Node{
+		Key:           node.key,
+		Value:         node.value,
+		Dir:           node.dir,
+		CreatedIndex:  node.createdIndex,
+		ModifiedIndex: node.modifiedIndex,
+	}
+
+	if node == mc.root {
+		// etcd uses an empty key for the root node
+		result.Key = ""
+	}
+
+	if !node.expiration.IsZero() {
+		expiration := node.expiration
+		result.Expiration = &expiration
+		result.TTL = int64(math.Ceil(node.expiration.Sub(mc.now()).Seconds()))
+	}
+
+	if recursive && node.dir {
+		result.Nodes = client.Nodes{}
+		for _, child := range visibleChildren(node) {
+			result.Nodes = append(result.Nodes, mc.toClientNode(child, true))
+		}
+	}
+
+	return result
+}
+
+// toClientTree converts the node and all its children including the hidden ones.
+func (mc *memoryClient) toClientTree(node *memoryNode) *client.Node {
+	result := mc.toClientNode(node, false)
+	if node.dir {
+		result.Nodes = client.Nodes{}
+		for _, name := range sortedChildNames(node) {
+			result.Nodes = append(result.Nodes, mc.toClientTree(node.children[name]))
+		}
+	}
+	return result
+}
+
+func (mc *memoryClient) newError(code int, message string, key string) error {
+	return client.Error{Code: code, Message: message, Cause: key, Index: mc.index}
+}
+
+// visibleChildren returns the children of the given node ordered by their name. Like in etcd, keys starting with an
+// underscore are hidden and can only be accessed directly.
+func visibleChildren(node *memoryNode) []*memoryNode {
+	var children []*memoryNode
+	for _, name := range sortedChildNames(node) {
+		if !strings.HasPrefix(name, "_") {
+			children = append(children, node.children[name])
+		}
+	}
+	return children
+}
+
+func sortedChildNames(node *memoryNode) []string {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func normalizeKey(key string) string {
+	return path.Clean("/" + key)
+}
@@ -2,7 +2,11 @@
 
 package registry
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // MockConfigurationContext is an autogenerated mock type for the ConfigurationContext type
 type MockConfigurationContext struct {
@@ -17,13 +21,13 @@ func (_m *MockConfigurationContext) EXPECT() *MockConfigurationContext_Expecter
 	return &MockConfigurationContext_Expecter{mock: &_m.Mock}
 }
 
-// Delete provides a mock function with given fields: key
-func (_m *MockConfigurationContext) Delete(key string) error {
-	ret := _m.Called(key)
+// ApplyBatch provides a mock function with given fields: changes
+func (_m *MockConfigurationContext) ApplyBatch(changes []ConfigurationChange) error {
+	ret := _m.Called(changes)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(key)
+	if rf, ok := ret.Get(0).(func([]ConfigurationChange) error); ok {
+		r0 = rf(changes)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -31,41 +35,41 @@ func (_m *MockConfigurationContext) Delete(key string) error {
 	return r0
 }
 
-// MockConfigurationContext_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
-type MockConfigurationContext_Delete_Call struct {
+// MockConfigurationContext_ApplyBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ApplyBatch'
+type MockConfigurationContext_ApplyBatch_Call struct {
 	*mock.Call
 }
 
-// Delete is a helper method to define mock.On call
-//  - key string
-func (_e *MockConfigurationContext_Expecter) Delete(key interface{}) *MockConfigurationContext_Delete_Call {
-	return &MockConfigurationContext_Delete_Call{Call: _e.mock.On("Delete", key)}
+// ApplyBatch is a helper method to define mock.On call
+//  - changes []ConfigurationChange
+func (_e *MockConfigurationContext_Expecter) ApplyBatch(changes interface{}) *MockConfigurationContext_ApplyBatch_Call {
+	return &MockConfigurationContext_ApplyBatch_Call{Call: _e.mock.On("ApplyBatch", changes)}
 }
 
-func (_c *MockConfigurationContext_Delete_Call) Run(run func(key string)) *MockConfigurationContext_Delete_Call {
+func (_c *MockConfigurationContext_ApplyBatch_Call) Run(run func(changes []ConfigurationChange)) *MockConfigurationContext_ApplyBatch_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].([]ConfigurationChange))
 	})
 	return _c
 }
 
-func (_c *MockConfigurationContext_Delete_Call) Return(_a0 error) *MockConfigurationContext_Delete_Call {
+func (_c *MockConfigurationContext_ApplyBatch_Call) Return(_a0 error) *MockConfigurationContext_ApplyBatch_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockConfigurationContext_Delete_Call) RunAndReturn(run func(string) error) *MockConfigurationContext_Delete_Call {
+func (_c *MockConfigurationContext_ApplyBatch_Call) RunAndReturn(run func([]ConfigurationChange) error) *MockConfigurationContext_ApplyBatch_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// DeleteRecursive provides a mock function with given fields: key
-func (_m *MockConfigurationContext) DeleteRecursive(key string) error {
-	ret := _m.Called(key)
+// ApplyBatchContext provides a mock function with given fields: ctx, changes
+func (_m *MockConfigurationContext) ApplyBatchContext(ctx context.Context, changes []ConfigurationChange) error {
+	ret := _m.Called(ctx, changes)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, []ConfigurationChange) error); ok {
+		r0 = rf(ctx, changes)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -73,51 +77,52 @@ func (_m *MockConfigurationContext) DeleteRecursive(key string) error {
 	return r0
 }
 
-// MockConfigurationContext_DeleteRecursive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteRecursive'
-type MockConfigurationContext_DeleteRecursive_Call struct {
+// MockConfigurationContext_ApplyBatchContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ApplyBatchContext'
+type MockConfigurationContext_ApplyBatchContext_Call struct {
 	*mock.Call
 }
 
-// DeleteRecursive is a helper method to define mock.On call
-//  - key string
-func (_e *MockConfigurationContext_Expecter) DeleteRecursive(key interface{}) *MockConfigurationContext_DeleteRecursive_Call {
-	return &MockConfigurationContext_DeleteRecursive_Call{Call: _e.mock.On("DeleteRecursive", key)}
+// ApplyBatchContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - changes []ConfigurationChange
+func (_e *MockConfigurationContext_Expecter) ApplyBatchContext(ctx interface{}, changes interface{}) *MockConfigurationContext_ApplyBatchContext_Call {
+	return &MockConfigurationContext_ApplyBatchContext_Call{Call: _e.mock.On("ApplyBatchContext", ctx, changes)}
 }
 
-func (_c *MockConfigurationContext_DeleteRecursive_Call) Run(run func(key string)) *MockConfigurationContext_DeleteRecursive_Call {
+func (_c *MockConfigurationContext_ApplyBatchContext_Call) Run(run func(ctx context.Context, changes []ConfigurationChange)) *MockConfigurationContext_ApplyBatchContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].([]ConfigurationChange))
 	})
 	return _c
 }
 
-func (_c *MockConfigurationContext_DeleteRecursive_Call) Return(_a0 error) *MockConfigurationContext_DeleteRecursive_Call {
+func (_c *MockConfigurationContext_ApplyBatchContext_Call) Return(_a0 error) *MockConfigurationContext_ApplyBatchContext_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockConfigurationContext_DeleteRecursive_Call) RunAndReturn(run func(string) error) *MockConfigurationContext_DeleteRecursive_Call {
+func (_c *MockConfigurationContext_ApplyBatchContext_Call) RunAndReturn(run func(context.Context, []ConfigurationChange) error) *MockConfigurationContext_ApplyBatchContext_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Exists provides a mock function with given fields: key
-func (_m *MockConfigurationContext) Exists(key string) (bool, error) {
-	ret := _m.Called(key)
+// CompareAndDelete provides a mock function with given fields: key, oldValue
+func (_m *MockConfigurationContext) CompareAndDelete(key string, oldValue string) (bool, error) {
+	ret := _m.Called(key, oldValue)
 
 	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
-		return rf(key)
+	if rf, ok := ret.Get(0).(func(string, string) (bool, error)); ok {
+		return rf(key, oldValue)
 	}
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
-		r0 = rf(key)
+	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = rf(key, oldValue)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(key)
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(key, oldValue)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -125,51 +130,52 @@ func (_m *MockConfigurationContext) Exists(key string) (bool, error) {
 	return r0, r1
 }
 
-// MockConfigurationContext_Exists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Exists'
-type MockConfigurationContext_Exists_Call struct {
+// MockConfigurationContext_CompareAndDelete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CompareAndDelete'
+type MockConfigurationContext_CompareAndDelete_Call struct {
 	*mock.Call
 }
 
-// Exists is a helper method to define mock.On call
+// CompareAndDelete is a helper method to define mock.On call
 //  - key string
-func (_e *MockConfigurationContext_Expecter) Exists(key interface{}) *MockConfigurationContext_Exists_Call {
-	return &MockConfigurationContext_Exists_Call{Call: _e.mock.On("Exists", key)}
+//  - oldValue string
+func (_e *MockConfigurationContext_Expecter) CompareAndDelete(key interface{}, oldValue interface{}) *MockConfigurationContext_CompareAndDelete_Call {
+	return &MockConfigurationContext_CompareAndDelete_Call{Call: _e.mock.On("CompareAndDelete", key, oldValue)}
 }
 
-func (_c *MockConfigurationContext_Exists_Call) Run(run func(key string)) *MockConfigurationContext_Exists_Call {
+func (_c *MockConfigurationContext_CompareAndDelete_Call) Run(run func(key string, oldValue string)) *MockConfigurationContext_CompareAndDelete_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(string), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockConfigurationContext_Exists_Call) Return(_a0 bool, _a1 error) *MockConfigurationContext_Exists_Call {
+func (_c *MockConfigurationContext_CompareAndDelete_Call) Return(_a0 bool, _a1 error) *MockConfigurationContext_CompareAndDelete_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockConfigurationContext_Exists_Call) RunAndReturn(run func(string) (bool, error)) *MockConfigurationContext_Exists_Call {
+func (_c *MockConfigurationContext_CompareAndDelete_Call) RunAndReturn(run func(string, string) (bool, error)) *MockConfigurationContext_CompareAndDelete_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Get provides a mock function with given fields: key
-func (_m *MockConfigurationContext) Get(key string) (string, error) {
-	ret := _m.Called(key)
+// CompareAndDeleteContext provides a mock function with given fields: ctx, key, oldValue
+func (_m *MockConfigurationContext) CompareAndDeleteContext(ctx context.Context, key string, oldValue string) (bool, error) {
+	ret := _m.Called(ctx, key, oldValue)
 
-	var r0 string
+	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return rf(ctx, key, oldValue)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(ctx, key, oldValue)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(key)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, key, oldValue)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -177,53 +183,53 @@ func (_m *MockConfigurationContext) Get(key string) (string, error) {
 	return r0, r1
 }
 
-// MockConfigurationContext_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
-type MockConfigurationContext_Get_Call struct {
+// MockConfigurationContext_CompareAndDeleteContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CompareAndDeleteContext'
+type MockConfigurationContext_CompareAndDeleteContext_Call struct {
 	*mock.Call
 }
 
-// Get is a helper method to define mock.On call
+// CompareAndDeleteContext is a helper method to define mock.On call
+//  - ctx context.Context
 //  - key string
-func (_e *MockConfigurationContext_Expecter) Get(key interface{}) *MockConfigurationContext_Get_Call {
-	return &MockConfigurationContext_Get_Call{Call: _e.mock.On("Get", key)}
+//  - oldValue string
+func (_e *MockConfigurationContext_Expecter) CompareAndDeleteContext(ctx interface{}, key interface{}, oldValue interface{}) *MockConfigurationContext_CompareAndDeleteContext_Call {
+	return &MockConfigurationContext_CompareAndDeleteContext_Call{Call: _e.mock.On("CompareAndDeleteContext", ctx, key, oldValue)}
 }
 
-func (_c *MockConfigurationContext_Get_Call) Run(run func(key string)) *MockConfigurationContext_Get_Call {
+func (_c *MockConfigurationContext_CompareAndDeleteContext_Call) Run(run func(ctx context.Context, key string, oldValue string)) *MockConfigurationContext_CompareAndDeleteContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
 	})
 	return _c
 }
 
-func (_c *MockConfigurationContext_Get_Call) Return(_a0 string, _a1 error) *MockConfigurationContext_Get_Call {
+func (_c *MockConfigurationContext_CompareAndDeleteContext_Call) Return(_a0 bool, _a1 error) *MockConfigurationContext_CompareAndDeleteContext_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockConfigurationContext_Get_Call) RunAndReturn(run func(string) (string, error)) *MockConfigurationContext_Get_Call {
+func (_c *MockConfigurationContext_CompareAndDeleteContext_Call) RunAndReturn(run func(context.Context, string, string) (bool, error)) *MockConfigurationContext_CompareAndDeleteContext_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetAll provides a mock function with given fields:
-func (_m *MockConfigurationContext) GetAll() (map[string]string, error) {
-	ret := _m.Called()
+// CompareAndSwap provides a mock function with given fields: key, oldValue, newValue
+func (_m *MockConfigurationContext) CompareAndSwap(key string, oldValue string, newValue string) (bool, error) {
+	ret := _m.Called(key, oldValue, newValue)
 
-	var r0 map[string]string
+	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func() (map[string]string, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(string, string, string) (bool, error)); ok {
+		return rf(key, oldValue, newValue)
 	}
-	if rf, ok := ret.Get(0).(func() map[string]string); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(string, string, string) bool); ok {
+		r0 = rf(key, oldValue, newValue)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(map[string]string)
-		}
+		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(key, oldValue, newValue)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -231,99 +237,98 @@ func (_m *MockConfigurationContext) GetAll() (map[string]string, error) {
 	return r0, r1
 }
 
-// MockConfigurationContext_GetAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAll'
-type MockConfigurationContext_GetAll_Call struct {
+// MockConfigurationContext_CompareAndSwap_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CompareAndSwap'
+type MockConfigurationContext_CompareAndSwap_Call struct {
 	*mock.Call
 }
 
-// GetAll is a helper method to define mock.On call
-func (_e *MockConfigurationContext_Expecter) GetAll() *MockConfigurationContext_GetAll_Call {
-	return &MockConfigurationContext_GetAll_Call{Call: _e.mock.On("GetAll")}
+// CompareAndSwap is a helper method to define mock.On call
+//  - key string
+//  - oldValue string
+//  - newValue string
+func (_e *MockConfigurationContext_Expecter) CompareAndSwap(key interface{}, oldValue interface{}, newValue interface{}) *MockConfigurationContext_CompareAndSwap_Call {
+	return &MockConfigurationContext_CompareAndSwap_Call{Call: _e.mock.On("CompareAndSwap", key, oldValue, newValue)}
 }
 
-func (_c *MockConfigurationContext_GetAll_Call) Run(run func()) *MockConfigurationContext_GetAll_Call {
+func (_c *MockConfigurationContext_CompareAndSwap_Call) Run(run func(key string, oldValue string, newValue string)) *MockConfigurationContext_CompareAndSwap_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		run(args[0].(string), args[1].(string), args[2].(string))
 	})
 	return _c
 }
 
-func (_c *MockConfigurationContext_GetAll_Call) Return(_a0 map[string]string, _a1 error) *MockConfigurationContext_GetAll_Call {
+func (_c *MockConfigurationContext_CompareAndSwap_Call) Return(_a0 bool, _a1 error) *MockConfigurationContext_CompareAndSwap_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockConfigurationContext_GetAll_Call) RunAndReturn(run func() (map[string]string, error)) *MockConfigurationContext_GetAll_Call {
+func (_c *MockConfigurationContext_CompareAndSwap_Call) RunAndReturn(run func(string, string, string) (bool, error)) *MockConfigurationContext_CompareAndSwap_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetOrFalse provides a mock function with given fields: key
-func (_m *MockConfigurationContext) GetOrFalse(key string) (bool, string, error) {
-	ret := _m.Called(key)
+// CompareAndSwapContext provides a mock function with given fields: ctx, key, oldValue, newValue
+func (_m *MockConfigurationContext) CompareAndSwapContext(ctx context.Context, key string, oldValue string, newValue string) (bool, error) {
+	ret := _m.Called(ctx, key, oldValue, newValue)
 
 	var r0 bool
-	var r1 string
-	var r2 error
-	if rf, ok := ret.Get(0).(func(string) (bool, string, error)); ok {
-		return rf(key)
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (bool, error)); ok {
+		return rf(ctx, key, oldValue, newValue)
 	}
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
-		r0 = rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) bool); ok {
+		r0 = rf(ctx, key, oldValue, newValue)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) string); ok {
-		r1 = rf(key)
-	} else {
-		r1 = ret.Get(1).(string)
-	}
-
-	if rf, ok := ret.Get(2).(func(string) error); ok {
-		r2 = rf(key)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, key, oldValue, newValue)
 	} else {
-		r2 = ret.Error(2)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2
+	return r0, r1
 }
 
-// MockConfigurationContext_GetOrFalse_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrFalse'
-type MockConfigurationContext_GetOrFalse_Call struct {
+// MockConfigurationContext_CompareAndSwapContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CompareAndSwapContext'
+type MockConfigurationContext_CompareAndSwapContext_Call struct {
 	*mock.Call
 }
 
-// GetOrFalse is a helper method to define mock.On call
+// CompareAndSwapContext is a helper method to define mock.On call
+//  - ctx context.Context
 //  - key string
-func (_e *MockConfigurationContext_Expecter) GetOrFalse(key interface{}) *MockConfigurationContext_GetOrFalse_Call {
-	return &MockConfigurationContext_GetOrFalse_Call{Call: _e.mock.On("GetOrFalse", key)}
+//  - oldValue string
+//  - newValue string
+func (_e *MockConfigurationContext_Expecter) CompareAndSwapContext(ctx interface{}, key interface{}, oldValue interface{}, newValue interface{}) *MockConfigurationContext_CompareAndSwapContext_Call {
+	return &MockConfigurationContext_CompareAndSwapContext_Call{Call: _e.mock.On("CompareAndSwapContext", ctx, key, oldValue, newValue)}
 }
 
-func (_c *MockConfigurationContext_GetOrFalse_Call) Run(run func(key string)) *MockConfigurationContext_GetOrFalse_Call {
+func (_c *MockConfigurationContext_CompareAndSwapContext_Call) Run(run func(ctx context.Context, key string, oldValue string, newValue string)) *MockConfigurationContext_CompareAndSwapContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string))
 	})
 	return _c
 }
 
-func (_c *MockConfigurationContext_GetOrFalse_Call) Return(_a0 bool, _a1 string, _a2 error) *MockConfigurationContext_GetOrFalse_Call {
-	_c.Call.Return(_a0, _a1, _a2)
+func (_c *MockConfigurationContext_CompareAndSwapContext_Call) Return(_a0 bool, _a1 error) *MockConfigurationContext_CompareAndSwapContext_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockConfigurationContext_GetOrFalse_Call) RunAndReturn(run func(string) (bool, string, error)) *MockConfigurationContext_GetOrFalse_Call {
+func (_c *MockConfigurationContext_CompareAndSwapContext_Call) RunAndReturn(run func(context.Context, string, string, string) (bool, error)) *MockConfigurationContext_CompareAndSwapContext_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Refresh provides a mock function with given fields: key, timeToLiveInSeconds
-func (_m *MockConfigurationContext) Refresh(key string, timeToLiveInSeconds int) error {
-	ret := _m.Called(key, timeToLiveInSeconds)
+// Delete provides a mock function with given fields: key
+func (_m *MockConfigurationContext) Delete(key string) error {
+	ret := _m.Called(key)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, int) error); ok {
-		r0 = rf(key, timeToLiveInSeconds)
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(key)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -331,42 +336,41 @@ func (_m *MockConfigurationContext) Refresh(key string, timeToLiveInSeconds int)
 	return r0
 }
 
-// MockConfigurationContext_Refresh_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Refresh'
-type MockConfigurationContext_Refresh_Call struct {
+// MockConfigurationContext_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockConfigurationContext_Delete_Call struct {
 	*mock.Call
 }
 
-// Refresh is a helper method to define mock.On call
+// Delete is a helper method to define mock.On call
 //  - key string
-//  - timeToLiveInSeconds int
-func (_e *MockConfigurationContext_Expecter) Refresh(key interface{}, timeToLiveInSeconds interface{}) *MockConfigurationContext_Refresh_Call {
-	return &MockConfigurationContext_Refresh_Call{Call: _e.mock.On("Refresh", key, timeToLiveInSeconds)}
+func (_e *MockConfigurationContext_Expecter) Delete(key interface{}) *MockConfigurationContext_Delete_Call {
+	return &MockConfigurationContext_Delete_Call{Call: _e.mock.On("Delete", key)}
 }
 
-func (_c *MockConfigurationContext_Refresh_Call) Run(run func(key string, timeToLiveInSeconds int)) *MockConfigurationContext_Refresh_Call {
+func (_c *MockConfigurationContext_Delete_Call) Run(run func(key string)) *MockConfigurationContext_Delete_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(int))
+		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *MockConfigurationContext_Refresh_Call) Return(_a0 error) *MockConfigurationContext_Refresh_Call {
+func (_c *MockConfigurationContext_Delete_Call) Return(_a0 error) *MockConfigurationContext_Delete_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockConfigurationContext_Refresh_Call) RunAndReturn(run func(string, int) error) *MockConfigurationContext_Refresh_Call {
+func (_c *MockConfigurationContext_Delete_Call) RunAndReturn(run func(string) error) *MockConfigurationContext_Delete_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RemoveAll provides a mock function with given fields:
-func (_m *MockConfigurationContext) RemoveAll() error {
-	ret := _m.Called()
+// DeleteContext provides a mock function with given fields: ctx, key
+func (_m *MockConfigurationContext) DeleteContext(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -374,40 +378,42 @@ func (_m *MockConfigurationContext) RemoveAll() error {
 	return r0
 }
 
-// MockConfigurationContext_RemoveAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveAll'
-type MockConfigurationContext_RemoveAll_Call struct {
+// MockConfigurationContext_DeleteContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteContext'
+type MockConfigurationContext_DeleteContext_Call struct {
 	*mock.Call
 }
 
-// RemoveAll is a helper method to define mock.On call
-func (_e *MockConfigurationContext_Expecter) RemoveAll() *MockConfigurationContext_RemoveAll_Call {
-	return &MockConfigurationContext_RemoveAll_Call{Call: _e.mock.On("RemoveAll")}
+// DeleteContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+func (_e *MockConfigurationContext_Expecter) DeleteContext(ctx interface{}, key interface{}) *MockConfigurationContext_DeleteContext_Call {
+	return &MockConfigurationContext_DeleteContext_Call{Call: _e.mock.On("DeleteContext", ctx, key)}
 }
 
-func (_c *MockConfigurationContext_RemoveAll_Call) Run(run func()) *MockConfigurationContext_RemoveAll_Call {
+func (_c *MockConfigurationContext_DeleteContext_Call) Run(run func(ctx context.Context, key string)) *MockConfigurationContext_DeleteContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockConfigurationContext_RemoveAll_Call) Return(_a0 error) *MockConfigurationContext_RemoveAll_Call {
+func (_c *MockConfigurationContext_DeleteContext_Call) Return(_a0 error) *MockConfigurationContext_DeleteContext_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockConfigurationContext_RemoveAll_Call) RunAndReturn(run func() error) *MockConfigurationContext_RemoveAll_Call {
+func (_c *MockConfigurationContext_DeleteContext_Call) RunAndReturn(run func(context.Context, string) error) *MockConfigurationContext_DeleteContext_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Set provides a mock function with given fields: key, value
-func (_m *MockConfigurationContext) Set(key string, value string) error {
-	ret := _m.Called(key, value)
+// DeleteRecursive provides a mock function with given fields: key
+func (_m *MockConfigurationContext) DeleteRecursive(key string) error {
+	ret := _m.Called(key)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(key, value)
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(key)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -415,42 +421,41 @@ func (_m *MockConfigurationContext) Set(key string, value string) error {
 	return r0
 }
 
-// MockConfigurationContext_Set_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Set'
-type MockConfigurationContext_Set_Call struct {
+// MockConfigurationContext_DeleteRecursive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteRecursive'
+type MockConfigurationContext_DeleteRecursive_Call struct {
 	*mock.Call
 }
 
-// Set is a helper method to define mock.On call
+// DeleteRecursive is a helper method to define mock.On call
 //  - key string
-//  - value string
-func (_e *MockConfigurationContext_Expecter) Set(key interface{}, value interface{}) *MockConfigurationContext_Set_Call {
-	return &MockConfigurationContext_Set_Call{Call: _e.mock.On("Set", key, value)}
+func (_e *MockConfigurationContext_Expecter) DeleteRecursive(key interface{}) *MockConfigurationContext_DeleteRecursive_Call {
+	return &MockConfigurationContext_DeleteRecursive_Call{Call: _e.mock.On("DeleteRecursive", key)}
 }
 
-func (_c *MockConfigurationContext_Set_Call) Run(run func(key string, value string)) *MockConfigurationContext_Set_Call {
+func (_c *MockConfigurationContext_DeleteRecursive_Call) Run(run func(key string)) *MockConfigurationContext_DeleteRecursive_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *MockConfigurationContext_Set_Call) Return(_a0 error) *MockConfigurationContext_Set_Call {
+func (_c *MockConfigurationContext_DeleteRecursive_Call) Return(_a0 error) *MockConfigurationContext_DeleteRecursive_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockConfigurationContext_Set_Call) RunAndReturn(run func(string, string) error) *MockConfigurationContext_Set_Call {
+func (_c *MockConfigurationContext_DeleteRecursive_Call) RunAndReturn(run func(string) error) *MockConfigurationContext_DeleteRecursive_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SetWithLifetime provides a mock function with given fields: key, value, timeToLiveInSeconds
-func (_m *MockConfigurationContext) SetWithLifetime(key string, value string, timeToLiveInSeconds int) error {
-	ret := _m.Called(key, value, timeToLiveInSeconds)
+// DeleteRecursiveContext provides a mock function with given fields: ctx, key
+func (_m *MockConfigurationContext) DeleteRecursiveContext(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, int) error); ok {
-		r0 = rf(key, value, timeToLiveInSeconds)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -458,32 +463,920 @@ func (_m *MockConfigurationContext) SetWithLifetime(key string, value string, ti
 	return r0
 }
 
-// MockConfigurationContext_SetWithLifetime_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWithLifetime'
-type MockConfigurationContext_SetWithLifetime_Call struct {
+// MockConfigurationContext_DeleteRecursiveContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteRecursiveContext'
+type MockConfigurationContext_DeleteRecursiveContext_Call struct {
 	*mock.Call
 }
 
-// SetWithLifetime is a helper method to define mock.On call
+// DeleteRecursiveContext is a helper method to define mock.On call
+//  - ctx context.Context
 //  - key string
-//  - value string
-//  - timeToLiveInSeconds int
-func (_e *MockConfigurationContext_Expecter) SetWithLifetime(key interface{}, value interface{}, timeToLiveInSeconds interface{}) *MockConfigurationContext_SetWithLifetime_Call {
-	return &MockConfigurationContext_SetWithLifetime_Call{Call: _e.mock.On("SetWithLifetime", key, value, timeToLiveInSeconds)}
+func (_e *MockConfigurationContext_Expecter) DeleteRecursiveContext(ctx interface{}, key interface{}) *MockConfigurationContext_DeleteRecursiveContext_Call {
+	return &MockConfigurationContext_DeleteRecursiveContext_Call{Call: _e.mock.On("DeleteRecursiveContext", ctx, key)}
 }
 
-func (_c *MockConfigurationContext_SetWithLifetime_Call) Run(run func(key string, value string, timeToLiveInSeconds int)) *MockConfigurationContext_SetWithLifetime_Call {
+func (_c *MockConfigurationContext_DeleteRecursiveContext_Call) Run(run func(ctx context.Context, key string)) *MockConfigurationContext_DeleteRecursiveContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string), args[2].(int))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockConfigurationContext_SetWithLifetime_Call) Return(_a0 error) *MockConfigurationContext_SetWithLifetime_Call {
+func (_c *MockConfigurationContext_DeleteRecursiveContext_Call) Return(_a0 error) *MockConfigurationContext_DeleteRecursiveContext_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockConfigurationContext_SetWithLifetime_Call) RunAndReturn(run func(string, string, int) error) *MockConfigurationContext_SetWithLifetime_Call {
+func (_c *MockConfigurationContext_DeleteRecursiveContext_Call) RunAndReturn(run func(context.Context, string) error) *MockConfigurationContext_DeleteRecursiveContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Exists provides a mock function with given fields: key
+func (_m *MockConfigurationContext) Exists(key string) (bool, error) {
+	ret := _m.Called(key)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(key)
+	}
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConfigurationContext_Exists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Exists'
+type MockConfigurationContext_Exists_Call struct {
+	*mock.Call
+}
+
+// Exists is a helper method to define mock.On call
+//  - key string
+func (_e *MockConfigurationContext_Expecter) Exists(key interface{}) *MockConfigurationContext_Exists_Call {
+	return &MockConfigurationContext_Exists_Call{Call: _e.mock.On("Exists", key)}
+}
+
+func (_c *MockConfigurationContext_Exists_Call) Run(run func(key string)) *MockConfigurationContext_Exists_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_Exists_Call) Return(_a0 bool, _a1 error) *MockConfigurationContext_Exists_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConfigurationContext_Exists_Call) RunAndReturn(run func(string) (bool, error)) *MockConfigurationContext_Exists_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ExistsContext provides a mock function with given fields: ctx, key
+func (_m *MockConfigurationContext) ExistsContext(ctx context.Context, key string) (bool, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConfigurationContext_ExistsContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExistsContext'
+type MockConfigurationContext_ExistsContext_Call struct {
+	*mock.Call
+}
+
+// ExistsContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+func (_e *MockConfigurationContext_Expecter) ExistsContext(ctx interface{}, key interface{}) *MockConfigurationContext_ExistsContext_Call {
+	return &MockConfigurationContext_ExistsContext_Call{Call: _e.mock.On("ExistsContext", ctx, key)}
+}
+
+func (_c *MockConfigurationContext_ExistsContext_Call) Run(run func(ctx context.Context, key string)) *MockConfigurationContext_ExistsContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_ExistsContext_Call) Return(_a0 bool, _a1 error) *MockConfigurationContext_ExistsContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConfigurationContext_ExistsContext_Call) RunAndReturn(run func(context.Context, string) (bool, error)) *MockConfigurationContext_ExistsContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function with given fields: key
+func (_m *MockConfigurationContext) Get(key string) (string, error) {
+	ret := _m.Called(key)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(key)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConfigurationContext_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type MockConfigurationContext_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//  - key string
+func (_e *MockConfigurationContext_Expecter) Get(key interface{}) *MockConfigurationContext_Get_Call {
+	return &MockConfigurationContext_Get_Call{Call: _e.mock.On("Get", key)}
+}
+
+func (_c *MockConfigurationContext_Get_Call) Run(run func(key string)) *MockConfigurationContext_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_Get_Call) Return(_a0 string, _a1 error) *MockConfigurationContext_Get_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConfigurationContext_Get_Call) RunAndReturn(run func(string) (string, error)) *MockConfigurationContext_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAll provides a mock function with given fields:
+func (_m *MockConfigurationContext) GetAll() (map[string]string, error) {
+	ret := _m.Called()
+
+	var r0 map[string]string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (map[string]string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() map[string]string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConfigurationContext_GetAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAll'
+type MockConfigurationContext_GetAll_Call struct {
+	*mock.Call
+}
+
+// GetAll is a helper method to define mock.On call
+func (_e *MockConfigurationContext_Expecter) GetAll() *MockConfigurationContext_GetAll_Call {
+	return &MockConfigurationContext_GetAll_Call{Call: _e.mock.On("GetAll")}
+}
+
+func (_c *MockConfigurationContext_GetAll_Call) Run(run func()) *MockConfigurationContext_GetAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_GetAll_Call) Return(_a0 map[string]string, _a1 error) *MockConfigurationContext_GetAll_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConfigurationContext_GetAll_Call) RunAndReturn(run func() (map[string]string, error)) *MockConfigurationContext_GetAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAllContext provides a mock function with given fields: ctx
+func (_m *MockConfigurationContext) GetAllContext(ctx context.Context) (map[string]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[string]string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (map[string]string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) map[string]string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConfigurationContext_GetAllContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllContext'
+type MockConfigurationContext_GetAllContext_Call struct {
+	*mock.Call
+}
+
+// GetAllContext is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockConfigurationContext_Expecter) GetAllContext(ctx interface{}) *MockConfigurationContext_GetAllContext_Call {
+	return &MockConfigurationContext_GetAllContext_Call{Call: _e.mock.On("GetAllContext", ctx)}
+}
+
+func (_c *MockConfigurationContext_GetAllContext_Call) Run(run func(ctx context.Context)) *MockConfigurationContext_GetAllContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_GetAllContext_Call) Return(_a0 map[string]string, _a1 error) *MockConfigurationContext_GetAllContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConfigurationContext_GetAllContext_Call) RunAndReturn(run func(context.Context) (map[string]string, error)) *MockConfigurationContext_GetAllContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetContext provides a mock function with given fields: ctx, key
+func (_m *MockConfigurationContext) GetContext(ctx context.Context, key string) (string, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConfigurationContext_GetContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetContext'
+type MockConfigurationContext_GetContext_Call struct {
+	*mock.Call
+}
+
+// GetContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+func (_e *MockConfigurationContext_Expecter) GetContext(ctx interface{}, key interface{}) *MockConfigurationContext_GetContext_Call {
+	return &MockConfigurationContext_GetContext_Call{Call: _e.mock.On("GetContext", ctx, key)}
+}
+
+func (_c *MockConfigurationContext_GetContext_Call) Run(run func(ctx context.Context, key string)) *MockConfigurationContext_GetContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_GetContext_Call) Return(_a0 string, _a1 error) *MockConfigurationContext_GetContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConfigurationContext_GetContext_Call) RunAndReturn(run func(context.Context, string) (string, error)) *MockConfigurationContext_GetContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetOrFalse provides a mock function with given fields: key
+func (_m *MockConfigurationContext) GetOrFalse(key string) (bool, string, error) {
+	ret := _m.Called(key)
+
+	var r0 bool
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string) (bool, string, error)); ok {
+		return rf(key)
+	}
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) string); ok {
+		r1 = rf(key)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(key)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockConfigurationContext_GetOrFalse_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrFalse'
+type MockConfigurationContext_GetOrFalse_Call struct {
+	*mock.Call
+}
+
+// GetOrFalse is a helper method to define mock.On call
+//  - key string
+func (_e *MockConfigurationContext_Expecter) GetOrFalse(key interface{}) *MockConfigurationContext_GetOrFalse_Call {
+	return &MockConfigurationContext_GetOrFalse_Call{Call: _e.mock.On("GetOrFalse", key)}
+}
+
+func (_c *MockConfigurationContext_GetOrFalse_Call) Run(run func(key string)) *MockConfigurationContext_GetOrFalse_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_GetOrFalse_Call) Return(_a0 bool, _a1 string, _a2 error) *MockConfigurationContext_GetOrFalse_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockConfigurationContext_GetOrFalse_Call) RunAndReturn(run func(string) (bool, string, error)) *MockConfigurationContext_GetOrFalse_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetOrFalseContext provides a mock function with given fields: ctx, key
+func (_m *MockConfigurationContext) GetOrFalseContext(ctx context.Context, key string) (bool, string, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 bool
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, string, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) string); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, key)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockConfigurationContext_GetOrFalseContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrFalseContext'
+type MockConfigurationContext_GetOrFalseContext_Call struct {
+	*mock.Call
+}
+
+// GetOrFalseContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+func (_e *MockConfigurationContext_Expecter) GetOrFalseContext(ctx interface{}, key interface{}) *MockConfigurationContext_GetOrFalseContext_Call {
+	return &MockConfigurationContext_GetOrFalseContext_Call{Call: _e.mock.On("GetOrFalseContext", ctx, key)}
+}
+
+func (_c *MockConfigurationContext_GetOrFalseContext_Call) Run(run func(ctx context.Context, key string)) *MockConfigurationContext_GetOrFalseContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_GetOrFalseContext_Call) Return(_a0 bool, _a1 string, _a2 error) *MockConfigurationContext_GetOrFalseContext_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockConfigurationContext_GetOrFalseContext_Call) RunAndReturn(run func(context.Context, string) (bool, string, error)) *MockConfigurationContext_GetOrFalseContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Refresh provides a mock function with given fields: key, timeToLiveInSeconds
+func (_m *MockConfigurationContext) Refresh(key string, timeToLiveInSeconds int) error {
+	ret := _m.Called(key, timeToLiveInSeconds)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int) error); ok {
+		r0 = rf(key, timeToLiveInSeconds)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConfigurationContext_Refresh_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Refresh'
+type MockConfigurationContext_Refresh_Call struct {
+	*mock.Call
+}
+
+// Refresh is a helper method to define mock.On call
+//  - key string
+//  - timeToLiveInSeconds int
+func (_e *MockConfigurationContext_Expecter) Refresh(key interface{}, timeToLiveInSeconds interface{}) *MockConfigurationContext_Refresh_Call {
+	return &MockConfigurationContext_Refresh_Call{Call: _e.mock.On("Refresh", key, timeToLiveInSeconds)}
+}
+
+func (_c *MockConfigurationContext_Refresh_Call) Run(run func(key string, timeToLiveInSeconds int)) *MockConfigurationContext_Refresh_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_Refresh_Call) Return(_a0 error) *MockConfigurationContext_Refresh_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConfigurationContext_Refresh_Call) RunAndReturn(run func(string, int) error) *MockConfigurationContext_Refresh_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RefreshContext provides a mock function with given fields: ctx, key, timeToLiveInSeconds
+func (_m *MockConfigurationContext) RefreshContext(ctx context.Context, key string, timeToLiveInSeconds int) error {
+	ret := _m.Called(ctx, key, timeToLiveInSeconds)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, key, timeToLiveInSeconds)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConfigurationContext_RefreshContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RefreshContext'
+type MockConfigurationContext_RefreshContext_Call struct {
+	*mock.Call
+}
+
+// RefreshContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+//  - timeToLiveInSeconds int
+func (_e *MockConfigurationContext_Expecter) RefreshContext(ctx interface{}, key interface{}, timeToLiveInSeconds interface{}) *MockConfigurationContext_RefreshContext_Call {
+	return &MockConfigurationContext_RefreshContext_Call{Call: _e.mock.On("RefreshContext", ctx, key, timeToLiveInSeconds)}
+}
+
+func (_c *MockConfigurationContext_RefreshContext_Call) Run(run func(ctx context.Context, key string, timeToLiveInSeconds int)) *MockConfigurationContext_RefreshContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_RefreshContext_Call) Return(_a0 error) *MockConfigurationContext_RefreshContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConfigurationContext_RefreshContext_Call) RunAndReturn(run func(context.Context, string, int) error) *MockConfigurationContext_RefreshContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveAll provides a mock function with given fields:
+func (_m *MockConfigurationContext) RemoveAll() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConfigurationContext_RemoveAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveAll'
+type MockConfigurationContext_RemoveAll_Call struct {
+	*mock.Call
+}
+
+// RemoveAll is a helper method to define mock.On call
+func (_e *MockConfigurationContext_Expecter) RemoveAll() *MockConfigurationContext_RemoveAll_Call {
+	return &MockConfigurationContext_RemoveAll_Call{Call: _e.mock.On("RemoveAll")}
+}
+
+func (_c *MockConfigurationContext_RemoveAll_Call) Run(run func()) *MockConfigurationContext_RemoveAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_RemoveAll_Call) Return(_a0 error) *MockConfigurationContext_RemoveAll_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConfigurationContext_RemoveAll_Call) RunAndReturn(run func() error) *MockConfigurationContext_RemoveAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveAllContext provides a mock function with given fields: ctx
+func (_m *MockConfigurationContext) RemoveAllContext(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConfigurationContext_RemoveAllContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveAllContext'
+type MockConfigurationContext_RemoveAllContext_Call struct {
+	*mock.Call
+}
+
+// RemoveAllContext is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockConfigurationContext_Expecter) RemoveAllContext(ctx interface{}) *MockConfigurationContext_RemoveAllContext_Call {
+	return &MockConfigurationContext_RemoveAllContext_Call{Call: _e.mock.On("RemoveAllContext", ctx)}
+}
+
+func (_c *MockConfigurationContext_RemoveAllContext_Call) Run(run func(ctx context.Context)) *MockConfigurationContext_RemoveAllContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_RemoveAllContext_Call) Return(_a0 error) *MockConfigurationContext_RemoveAllContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConfigurationContext_RemoveAllContext_Call) RunAndReturn(run func(context.Context) error) *MockConfigurationContext_RemoveAllContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Set provides a mock function with given fields: key, value
+func (_m *MockConfigurationContext) Set(key string, value string) error {
+	ret := _m.Called(key, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(key, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConfigurationContext_Set_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Set'
+type MockConfigurationContext_Set_Call struct {
+	*mock.Call
+}
+
+// Set is a helper method to define mock.On call
+//  - key string
+//  - value string
+func (_e *MockConfigurationContext_Expecter) Set(key interface{}, value interface{}) *MockConfigurationContext_Set_Call {
+	return &MockConfigurationContext_Set_Call{Call: _e.mock.On("Set", key, value)}
+}
+
+func (_c *MockConfigurationContext_Set_Call) Run(run func(key string, value string)) *MockConfigurationContext_Set_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_Set_Call) Return(_a0 error) *MockConfigurationContext_Set_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConfigurationContext_Set_Call) RunAndReturn(run func(string, string) error) *MockConfigurationContext_Set_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetContext provides a mock function with given fields: ctx, key, value
+func (_m *MockConfigurationContext) SetContext(ctx context.Context, key string, value string) error {
+	ret := _m.Called(ctx, key, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, key, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConfigurationContext_SetContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetContext'
+type MockConfigurationContext_SetContext_Call struct {
+	*mock.Call
+}
+
+// SetContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+//  - value string
+func (_e *MockConfigurationContext_Expecter) SetContext(ctx interface{}, key interface{}, value interface{}) *MockConfigurationContext_SetContext_Call {
+	return &MockConfigurationContext_SetContext_Call{Call: _e.mock.On("SetContext", ctx, key, value)}
+}
+
+func (_c *MockConfigurationContext_SetContext_Call) Run(run func(ctx context.Context, key string, value string)) *MockConfigurationContext_SetContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_SetContext_Call) Return(_a0 error) *MockConfigurationContext_SetContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConfigurationContext_SetContext_Call) RunAndReturn(run func(context.Context, string, string) error) *MockConfigurationContext_SetContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetIfAbsent provides a mock function with given fields: key, value
+func (_m *MockConfigurationContext) SetIfAbsent(key string, value string) (bool, error) {
+	ret := _m.Called(key, value)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (bool, error)); ok {
+		return rf(key, value)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = rf(key, value)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(key, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConfigurationContext_SetIfAbsent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetIfAbsent'
+type MockConfigurationContext_SetIfAbsent_Call struct {
+	*mock.Call
+}
+
+// SetIfAbsent is a helper method to define mock.On call
+//  - key string
+//  - value string
+func (_e *MockConfigurationContext_Expecter) SetIfAbsent(key interface{}, value interface{}) *MockConfigurationContext_SetIfAbsent_Call {
+	return &MockConfigurationContext_SetIfAbsent_Call{Call: _e.mock.On("SetIfAbsent", key, value)}
+}
+
+func (_c *MockConfigurationContext_SetIfAbsent_Call) Run(run func(key string, value string)) *MockConfigurationContext_SetIfAbsent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_SetIfAbsent_Call) Return(_a0 bool, _a1 error) *MockConfigurationContext_SetIfAbsent_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConfigurationContext_SetIfAbsent_Call) RunAndReturn(run func(string, string) (bool, error)) *MockConfigurationContext_SetIfAbsent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetIfAbsentContext provides a mock function with given fields: ctx, key, value
+func (_m *MockConfigurationContext) SetIfAbsentContext(ctx context.Context, key string, value string) (bool, error) {
+	ret := _m.Called(ctx, key, value)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return rf(ctx, key, value)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(ctx, key, value)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, key, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConfigurationContext_SetIfAbsentContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetIfAbsentContext'
+type MockConfigurationContext_SetIfAbsentContext_Call struct {
+	*mock.Call
+}
+
+// SetIfAbsentContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+//  - value string
+func (_e *MockConfigurationContext_Expecter) SetIfAbsentContext(ctx interface{}, key interface{}, value interface{}) *MockConfigurationContext_SetIfAbsentContext_Call {
+	return &MockConfigurationContext_SetIfAbsentContext_Call{Call: _e.mock.On("SetIfAbsentContext", ctx, key, value)}
+}
+
+func (_c *MockConfigurationContext_SetIfAbsentContext_Call) Run(run func(ctx context.Context, key string, value string)) *MockConfigurationContext_SetIfAbsentContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_SetIfAbsentContext_Call) Return(_a0 bool, _a1 error) *MockConfigurationContext_SetIfAbsentContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConfigurationContext_SetIfAbsentContext_Call) RunAndReturn(run func(context.Context, string, string) (bool, error)) *MockConfigurationContext_SetIfAbsentContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetWithLifetime provides a mock function with given fields: key, value, timeToLiveInSeconds
+func (_m *MockConfigurationContext) SetWithLifetime(key string, value string, timeToLiveInSeconds int) error {
+	ret := _m.Called(key, value, timeToLiveInSeconds)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, int) error); ok {
+		r0 = rf(key, value, timeToLiveInSeconds)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConfigurationContext_SetWithLifetime_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWithLifetime'
+type MockConfigurationContext_SetWithLifetime_Call struct {
+	*mock.Call
+}
+
+// SetWithLifetime is a helper method to define mock.On call
+//  - key string
+//  - value string
+//  - timeToLiveInSeconds int
+func (_e *MockConfigurationContext_Expecter) SetWithLifetime(key interface{}, value interface{}, timeToLiveInSeconds interface{}) *MockConfigurationContext_SetWithLifetime_Call {
+	return &MockConfigurationContext_SetWithLifetime_Call{Call: _e.mock.On("SetWithLifetime", key, value, timeToLiveInSeconds)}
+}
+
+func (_c *MockConfigurationContext_SetWithLifetime_Call) Run(run func(key string, value string, timeToLiveInSeconds int)) *MockConfigurationContext_SetWithLifetime_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_SetWithLifetime_Call) Return(_a0 error) *MockConfigurationContext_SetWithLifetime_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConfigurationContext_SetWithLifetime_Call) RunAndReturn(run func(string, string, int) error) *MockConfigurationContext_SetWithLifetime_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetWithLifetimeContext provides a mock function with given fields: ctx, key, value, timeToLiveInSeconds
+func (_m *MockConfigurationContext) SetWithLifetimeContext(ctx context.Context, key string, value string, timeToLiveInSeconds int) error {
+	ret := _m.Called(ctx, key, value, timeToLiveInSeconds)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) error); ok {
+		r0 = rf(ctx, key, value, timeToLiveInSeconds)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConfigurationContext_SetWithLifetimeContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWithLifetimeContext'
+type MockConfigurationContext_SetWithLifetimeContext_Call struct {
+	*mock.Call
+}
+
+// SetWithLifetimeContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+//  - value string
+//  - timeToLiveInSeconds int
+func (_e *MockConfigurationContext_Expecter) SetWithLifetimeContext(ctx interface{}, key interface{}, value interface{}, timeToLiveInSeconds interface{}) *MockConfigurationContext_SetWithLifetimeContext_Call {
+	return &MockConfigurationContext_SetWithLifetimeContext_Call{Call: _e.mock.On("SetWithLifetimeContext", ctx, key, value, timeToLiveInSeconds)}
+}
+
+func (_c *MockConfigurationContext_SetWithLifetimeContext_Call) Run(run func(ctx context.Context, key string, value string, timeToLiveInSeconds int)) *MockConfigurationContext_SetWithLifetimeContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *MockConfigurationContext_SetWithLifetimeContext_Call) Return(_a0 error) *MockConfigurationContext_SetWithLifetimeContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConfigurationContext_SetWithLifetimeContext_Call) RunAndReturn(run func(context.Context, string, string, int) error) *MockConfigurationContext_SetWithLifetimeContext_Call {
 	_c.Call.Return(run)
 	return _c
 }
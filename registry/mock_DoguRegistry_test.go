@@ -3,6 +3,8 @@
 package registry
 
 import (
+	context "context"
+
 	core "github.com/cloudogu/cesapp-lib/core"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -62,6 +64,136 @@ func (_c *MockDoguRegistry_Enable_Call) RunAndReturn(run func(*core.Dogu) error)
 	return _c
 }
 
+// EnableContext provides a mock function with given fields: ctx, dogu
+func (_m *MockDoguRegistry) EnableContext(ctx context.Context, dogu *core.Dogu) error {
+	ret := _m.Called(ctx, dogu)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *core.Dogu) error); ok {
+		r0 = rf(ctx, dogu)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDoguRegistry_EnableContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableContext'
+type MockDoguRegistry_EnableContext_Call struct {
+	*mock.Call
+}
+
+// EnableContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - dogu *core.Dogu
+func (_e *MockDoguRegistry_Expecter) EnableContext(ctx interface{}, dogu interface{}) *MockDoguRegistry_EnableContext_Call {
+	return &MockDoguRegistry_EnableContext_Call{Call: _e.mock.On("EnableContext", ctx, dogu)}
+}
+
+func (_c *MockDoguRegistry_EnableContext_Call) Run(run func(ctx context.Context, dogu *core.Dogu)) *MockDoguRegistry_EnableContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*core.Dogu))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_EnableContext_Call) Return(_a0 error) *MockDoguRegistry_EnableContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDoguRegistry_EnableContext_Call) RunAndReturn(run func(context.Context, *core.Dogu) error) *MockDoguRegistry_EnableContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// EnableVersion provides a mock function with given fields: name, version
+func (_m *MockDoguRegistry) EnableVersion(name string, version string) error {
+	ret := _m.Called(name, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(name, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDoguRegistry_EnableVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableVersion'
+type MockDoguRegistry_EnableVersion_Call struct {
+	*mock.Call
+}
+
+// EnableVersion is a helper method to define mock.On call
+//  - name string
+//  - version string
+func (_e *MockDoguRegistry_Expecter) EnableVersion(name interface{}, version interface{}) *MockDoguRegistry_EnableVersion_Call {
+	return &MockDoguRegistry_EnableVersion_Call{Call: _e.mock.On("EnableVersion", name, version)}
+}
+
+func (_c *MockDoguRegistry_EnableVersion_Call) Run(run func(name string, version string)) *MockDoguRegistry_EnableVersion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_EnableVersion_Call) Return(_a0 error) *MockDoguRegistry_EnableVersion_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDoguRegistry_EnableVersion_Call) RunAndReturn(run func(string, string) error) *MockDoguRegistry_EnableVersion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// EnableVersionContext provides a mock function with given fields: ctx, name, version
+func (_m *MockDoguRegistry) EnableVersionContext(ctx context.Context, name string, version string) error {
+	ret := _m.Called(ctx, name, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, name, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDoguRegistry_EnableVersionContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableVersionContext'
+type MockDoguRegistry_EnableVersionContext_Call struct {
+	*mock.Call
+}
+
+// EnableVersionContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - name string
+//  - version string
+func (_e *MockDoguRegistry_Expecter) EnableVersionContext(ctx interface{}, name interface{}, version interface{}) *MockDoguRegistry_EnableVersionContext_Call {
+	return &MockDoguRegistry_EnableVersionContext_Call{Call: _e.mock.On("EnableVersionContext", ctx, name, version)}
+}
+
+func (_c *MockDoguRegistry_EnableVersionContext_Call) Run(run func(ctx context.Context, name string, version string)) *MockDoguRegistry_EnableVersionContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_EnableVersionContext_Call) Return(_a0 error) *MockDoguRegistry_EnableVersionContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDoguRegistry_EnableVersionContext_Call) RunAndReturn(run func(context.Context, string, string) error) *MockDoguRegistry_EnableVersionContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Get provides a mock function with given fields: name
 func (_m *MockDoguRegistry) Get(name string) (*core.Dogu, error) {
 	ret := _m.Called(name)
@@ -169,23 +301,25 @@ func (_c *MockDoguRegistry_GetAll_Call) RunAndReturn(run func() ([]*core.Dogu, e
 	return _c
 }
 
-// IsEnabled provides a mock function with given fields: name
-func (_m *MockDoguRegistry) IsEnabled(name string) (bool, error) {
-	ret := _m.Called(name)
+// GetAllContext provides a mock function with given fields: ctx
+func (_m *MockDoguRegistry) GetAllContext(ctx context.Context) ([]*core.Dogu, error) {
+	ret := _m.Called(ctx)
 
-	var r0 bool
+	var r0 []*core.Dogu
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
-		return rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*core.Dogu, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
-		r0 = rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context) []*core.Dogu); ok {
+		r0 = rf(ctx)
 	} else {
-		r0 = ret.Get(0).(bool)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*core.Dogu)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(name)
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -193,114 +327,861 @@ func (_m *MockDoguRegistry) IsEnabled(name string) (bool, error) {
 	return r0, r1
 }
 
-// MockDoguRegistry_IsEnabled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsEnabled'
-type MockDoguRegistry_IsEnabled_Call struct {
+// MockDoguRegistry_GetAllContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllContext'
+type MockDoguRegistry_GetAllContext_Call struct {
 	*mock.Call
 }
 
-// IsEnabled is a helper method to define mock.On call
-//  - name string
-func (_e *MockDoguRegistry_Expecter) IsEnabled(name interface{}) *MockDoguRegistry_IsEnabled_Call {
-	return &MockDoguRegistry_IsEnabled_Call{Call: _e.mock.On("IsEnabled", name)}
+// GetAllContext is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockDoguRegistry_Expecter) GetAllContext(ctx interface{}) *MockDoguRegistry_GetAllContext_Call {
+	return &MockDoguRegistry_GetAllContext_Call{Call: _e.mock.On("GetAllContext", ctx)}
 }
 
-func (_c *MockDoguRegistry_IsEnabled_Call) Run(run func(name string)) *MockDoguRegistry_IsEnabled_Call {
+func (_c *MockDoguRegistry_GetAllContext_Call) Run(run func(ctx context.Context)) *MockDoguRegistry_GetAllContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *MockDoguRegistry_IsEnabled_Call) Return(_a0 bool, _a1 error) *MockDoguRegistry_IsEnabled_Call {
+func (_c *MockDoguRegistry_GetAllContext_Call) Return(_a0 []*core.Dogu, _a1 error) *MockDoguRegistry_GetAllContext_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockDoguRegistry_IsEnabled_Call) RunAndReturn(run func(string) (bool, error)) *MockDoguRegistry_IsEnabled_Call {
+func (_c *MockDoguRegistry_GetAllContext_Call) RunAndReturn(run func(context.Context) ([]*core.Dogu, error)) *MockDoguRegistry_GetAllContext_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Register provides a mock function with given fields: dogu
-func (_m *MockDoguRegistry) Register(dogu *core.Dogu) error {
-	ret := _m.Called(dogu)
+// GetAllWithFailures provides a mock function with given fields:
+func (_m *MockDoguRegistry) GetAllWithFailures() (*DoguList, error) {
+	ret := _m.Called()
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(*core.Dogu) error); ok {
-		r0 = rf(dogu)
+	var r0 *DoguList
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*DoguList, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *DoguList); ok {
+		r0 = rf()
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*DoguList)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockDoguRegistry_Register_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Register'
-type MockDoguRegistry_Register_Call struct {
+// MockDoguRegistry_GetAllWithFailures_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllWithFailures'
+type MockDoguRegistry_GetAllWithFailures_Call struct {
 	*mock.Call
 }
 
-// Register is a helper method to define mock.On call
-//  - dogu *core.Dogu
-func (_e *MockDoguRegistry_Expecter) Register(dogu interface{}) *MockDoguRegistry_Register_Call {
-	return &MockDoguRegistry_Register_Call{Call: _e.mock.On("Register", dogu)}
+// GetAllWithFailures is a helper method to define mock.On call
+func (_e *MockDoguRegistry_Expecter) GetAllWithFailures() *MockDoguRegistry_GetAllWithFailures_Call {
+	return &MockDoguRegistry_GetAllWithFailures_Call{Call: _e.mock.On("GetAllWithFailures")}
 }
 
-func (_c *MockDoguRegistry_Register_Call) Run(run func(dogu *core.Dogu)) *MockDoguRegistry_Register_Call {
+func (_c *MockDoguRegistry_GetAllWithFailures_Call) Run(run func()) *MockDoguRegistry_GetAllWithFailures_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*core.Dogu))
+		run()
 	})
 	return _c
 }
 
-func (_c *MockDoguRegistry_Register_Call) Return(_a0 error) *MockDoguRegistry_Register_Call {
-	_c.Call.Return(_a0)
+func (_c *MockDoguRegistry_GetAllWithFailures_Call) Return(_a0 *DoguList, _a1 error) *MockDoguRegistry_GetAllWithFailures_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockDoguRegistry_Register_Call) RunAndReturn(run func(*core.Dogu) error) *MockDoguRegistry_Register_Call {
+func (_c *MockDoguRegistry_GetAllWithFailures_Call) RunAndReturn(run func() (*DoguList, error)) *MockDoguRegistry_GetAllWithFailures_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Unregister provides a mock function with given fields: name
-func (_m *MockDoguRegistry) Unregister(name string) error {
-	ret := _m.Called(name)
+// GetAllWithFailuresContext provides a mock function with given fields: ctx
+func (_m *MockDoguRegistry) GetAllWithFailuresContext(ctx context.Context) (*DoguList, error) {
+	ret := _m.Called(ctx)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(name)
+	var r0 *DoguList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*DoguList, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *DoguList); ok {
+		r0 = rf(ctx)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*DoguList)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockDoguRegistry_Unregister_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unregister'
-type MockDoguRegistry_Unregister_Call struct {
+// MockDoguRegistry_GetAllWithFailuresContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllWithFailuresContext'
+type MockDoguRegistry_GetAllWithFailuresContext_Call struct {
 	*mock.Call
 }
 
-// Unregister is a helper method to define mock.On call
+// GetAllWithFailuresContext is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockDoguRegistry_Expecter) GetAllWithFailuresContext(ctx interface{}) *MockDoguRegistry_GetAllWithFailuresContext_Call {
+	return &MockDoguRegistry_GetAllWithFailuresContext_Call{Call: _e.mock.On("GetAllWithFailuresContext", ctx)}
+}
+
+func (_c *MockDoguRegistry_GetAllWithFailuresContext_Call) Run(run func(ctx context.Context)) *MockDoguRegistry_GetAllWithFailuresContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_GetAllWithFailuresContext_Call) Return(_a0 *DoguList, _a1 error) *MockDoguRegistry_GetAllWithFailuresContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDoguRegistry_GetAllWithFailuresContext_Call) RunAndReturn(run func(context.Context) (*DoguList, error)) *MockDoguRegistry_GetAllWithFailuresContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetContext provides a mock function with given fields: ctx, name
+func (_m *MockDoguRegistry) GetContext(ctx context.Context, name string) (*core.Dogu, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *core.Dogu
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*core.Dogu, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *core.Dogu); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*core.Dogu)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDoguRegistry_GetContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetContext'
+type MockDoguRegistry_GetContext_Call struct {
+	*mock.Call
+}
+
+// GetContext is a helper method to define mock.On call
+//  - ctx context.Context
 //  - name string
-func (_e *MockDoguRegistry_Expecter) Unregister(name interface{}) *MockDoguRegistry_Unregister_Call {
-	return &MockDoguRegistry_Unregister_Call{Call: _e.mock.On("Unregister", name)}
+func (_e *MockDoguRegistry_Expecter) GetContext(ctx interface{}, name interface{}) *MockDoguRegistry_GetContext_Call {
+	return &MockDoguRegistry_GetContext_Call{Call: _e.mock.On("GetContext", ctx, name)}
 }
 
-func (_c *MockDoguRegistry_Unregister_Call) Run(run func(name string)) *MockDoguRegistry_Unregister_Call {
+func (_c *MockDoguRegistry_GetContext_Call) Run(run func(ctx context.Context, name string)) *MockDoguRegistry_GetContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockDoguRegistry_Unregister_Call) Return(_a0 error) *MockDoguRegistry_Unregister_Call {
-	_c.Call.Return(_a0)
+func (_c *MockDoguRegistry_GetContext_Call) Return(_a0 *core.Dogu, _a1 error) *MockDoguRegistry_GetContext_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockDoguRegistry_Unregister_Call) RunAndReturn(run func(string) error) *MockDoguRegistry_Unregister_Call {
+func (_c *MockDoguRegistry_GetContext_Call) RunAndReturn(run func(context.Context, string) (*core.Dogu, error)) *MockDoguRegistry_GetContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVersion provides a mock function with given fields: name, version
+func (_m *MockDoguRegistry) GetVersion(name string, version string) (*core.Dogu, error) {
+	ret := _m.Called(name, version)
+
+	var r0 *core.Dogu
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*core.Dogu, error)); ok {
+		return rf(name, version)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *core.Dogu); ok {
+		r0 = rf(name, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*core.Dogu)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(name, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDoguRegistry_GetVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVersion'
+type MockDoguRegistry_GetVersion_Call struct {
+	*mock.Call
+}
+
+// GetVersion is a helper method to define mock.On call
+//  - name string
+//  - version string
+func (_e *MockDoguRegistry_Expecter) GetVersion(name interface{}, version interface{}) *MockDoguRegistry_GetVersion_Call {
+	return &MockDoguRegistry_GetVersion_Call{Call: _e.mock.On("GetVersion", name, version)}
+}
+
+func (_c *MockDoguRegistry_GetVersion_Call) Run(run func(name string, version string)) *MockDoguRegistry_GetVersion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_GetVersion_Call) Return(_a0 *core.Dogu, _a1 error) *MockDoguRegistry_GetVersion_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDoguRegistry_GetVersion_Call) RunAndReturn(run func(string, string) (*core.Dogu, error)) *MockDoguRegistry_GetVersion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVersionContext provides a mock function with given fields: ctx, name, version
+func (_m *MockDoguRegistry) GetVersionContext(ctx context.Context, name string, version string) (*core.Dogu, error) {
+	ret := _m.Called(ctx, name, version)
+
+	var r0 *core.Dogu
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*core.Dogu, error)); ok {
+		return rf(ctx, name, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *core.Dogu); ok {
+		r0 = rf(ctx, name, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*core.Dogu)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, name, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDoguRegistry_GetVersionContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVersionContext'
+type MockDoguRegistry_GetVersionContext_Call struct {
+	*mock.Call
+}
+
+// GetVersionContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - name string
+//  - version string
+func (_e *MockDoguRegistry_Expecter) GetVersionContext(ctx interface{}, name interface{}, version interface{}) *MockDoguRegistry_GetVersionContext_Call {
+	return &MockDoguRegistry_GetVersionContext_Call{Call: _e.mock.On("GetVersionContext", ctx, name, version)}
+}
+
+func (_c *MockDoguRegistry_GetVersionContext_Call) Run(run func(ctx context.Context, name string, version string)) *MockDoguRegistry_GetVersionContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_GetVersionContext_Call) Return(_a0 *core.Dogu, _a1 error) *MockDoguRegistry_GetVersionContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDoguRegistry_GetVersionContext_Call) RunAndReturn(run func(context.Context, string, string) (*core.Dogu, error)) *MockDoguRegistry_GetVersionContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVersions provides a mock function with given fields: name
+func (_m *MockDoguRegistry) GetVersions(name string) ([]core.Version, error) {
+	ret := _m.Called(name)
+
+	var r0 []core.Version
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]core.Version, error)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) []core.Version); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]core.Version)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDoguRegistry_GetVersions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVersions'
+type MockDoguRegistry_GetVersions_Call struct {
+	*mock.Call
+}
+
+// GetVersions is a helper method to define mock.On call
+//  - name string
+func (_e *MockDoguRegistry_Expecter) GetVersions(name interface{}) *MockDoguRegistry_GetVersions_Call {
+	return &MockDoguRegistry_GetVersions_Call{Call: _e.mock.On("GetVersions", name)}
+}
+
+func (_c *MockDoguRegistry_GetVersions_Call) Run(run func(name string)) *MockDoguRegistry_GetVersions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_GetVersions_Call) Return(_a0 []core.Version, _a1 error) *MockDoguRegistry_GetVersions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDoguRegistry_GetVersions_Call) RunAndReturn(run func(string) ([]core.Version, error)) *MockDoguRegistry_GetVersions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVersionsContext provides a mock function with given fields: ctx, name
+func (_m *MockDoguRegistry) GetVersionsContext(ctx context.Context, name string) ([]core.Version, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 []core.Version
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]core.Version, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []core.Version); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]core.Version)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDoguRegistry_GetVersionsContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVersionsContext'
+type MockDoguRegistry_GetVersionsContext_Call struct {
+	*mock.Call
+}
+
+// GetVersionsContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - name string
+func (_e *MockDoguRegistry_Expecter) GetVersionsContext(ctx interface{}, name interface{}) *MockDoguRegistry_GetVersionsContext_Call {
+	return &MockDoguRegistry_GetVersionsContext_Call{Call: _e.mock.On("GetVersionsContext", ctx, name)}
+}
+
+func (_c *MockDoguRegistry_GetVersionsContext_Call) Run(run func(ctx context.Context, name string)) *MockDoguRegistry_GetVersionsContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_GetVersionsContext_Call) Return(_a0 []core.Version, _a1 error) *MockDoguRegistry_GetVersionsContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDoguRegistry_GetVersionsContext_Call) RunAndReturn(run func(context.Context, string) ([]core.Version, error)) *MockDoguRegistry_GetVersionsContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsEnabled provides a mock function with given fields: name
+func (_m *MockDoguRegistry) IsEnabled(name string) (bool, error) {
+	ret := _m.Called(name)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDoguRegistry_IsEnabled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsEnabled'
+type MockDoguRegistry_IsEnabled_Call struct {
+	*mock.Call
+}
+
+// IsEnabled is a helper method to define mock.On call
+//  - name string
+func (_e *MockDoguRegistry_Expecter) IsEnabled(name interface{}) *MockDoguRegistry_IsEnabled_Call {
+	return &MockDoguRegistry_IsEnabled_Call{Call: _e.mock.On("IsEnabled", name)}
+}
+
+func (_c *MockDoguRegistry_IsEnabled_Call) Run(run func(name string)) *MockDoguRegistry_IsEnabled_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_IsEnabled_Call) Return(_a0 bool, _a1 error) *MockDoguRegistry_IsEnabled_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDoguRegistry_IsEnabled_Call) RunAndReturn(run func(string) (bool, error)) *MockDoguRegistry_IsEnabled_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsEnabledContext provides a mock function with given fields: ctx, name
+func (_m *MockDoguRegistry) IsEnabledContext(ctx context.Context, name string) (bool, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDoguRegistry_IsEnabledContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsEnabledContext'
+type MockDoguRegistry_IsEnabledContext_Call struct {
+	*mock.Call
+}
+
+// IsEnabledContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - name string
+func (_e *MockDoguRegistry_Expecter) IsEnabledContext(ctx interface{}, name interface{}) *MockDoguRegistry_IsEnabledContext_Call {
+	return &MockDoguRegistry_IsEnabledContext_Call{Call: _e.mock.On("IsEnabledContext", ctx, name)}
+}
+
+func (_c *MockDoguRegistry_IsEnabledContext_Call) Run(run func(ctx context.Context, name string)) *MockDoguRegistry_IsEnabledContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_IsEnabledContext_Call) Return(_a0 bool, _a1 error) *MockDoguRegistry_IsEnabledContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDoguRegistry_IsEnabledContext_Call) RunAndReturn(run func(context.Context, string) (bool, error)) *MockDoguRegistry_IsEnabledContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PruneVersions provides a mock function with given fields: name, keep
+func (_m *MockDoguRegistry) PruneVersions(name string, keep int) error {
+	ret := _m.Called(name, keep)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int) error); ok {
+		r0 = rf(name, keep)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDoguRegistry_PruneVersions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PruneVersions'
+type MockDoguRegistry_PruneVersions_Call struct {
+	*mock.Call
+}
+
+// PruneVersions is a helper method to define mock.On call
+//  - name string
+//  - keep int
+func (_e *MockDoguRegistry_Expecter) PruneVersions(name interface{}, keep interface{}) *MockDoguRegistry_PruneVersions_Call {
+	return &MockDoguRegistry_PruneVersions_Call{Call: _e.mock.On("PruneVersions", name, keep)}
+}
+
+func (_c *MockDoguRegistry_PruneVersions_Call) Run(run func(name string, keep int)) *MockDoguRegistry_PruneVersions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_PruneVersions_Call) Return(_a0 error) *MockDoguRegistry_PruneVersions_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDoguRegistry_PruneVersions_Call) RunAndReturn(run func(string, int) error) *MockDoguRegistry_PruneVersions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PruneVersionsContext provides a mock function with given fields: ctx, name, keep
+func (_m *MockDoguRegistry) PruneVersionsContext(ctx context.Context, name string, keep int) error {
+	ret := _m.Called(ctx, name, keep)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, name, keep)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDoguRegistry_PruneVersionsContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PruneVersionsContext'
+type MockDoguRegistry_PruneVersionsContext_Call struct {
+	*mock.Call
+}
+
+// PruneVersionsContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - name string
+//  - keep int
+func (_e *MockDoguRegistry_Expecter) PruneVersionsContext(ctx interface{}, name interface{}, keep interface{}) *MockDoguRegistry_PruneVersionsContext_Call {
+	return &MockDoguRegistry_PruneVersionsContext_Call{Call: _e.mock.On("PruneVersionsContext", ctx, name, keep)}
+}
+
+func (_c *MockDoguRegistry_PruneVersionsContext_Call) Run(run func(ctx context.Context, name string, keep int)) *MockDoguRegistry_PruneVersionsContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_PruneVersionsContext_Call) Return(_a0 error) *MockDoguRegistry_PruneVersionsContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDoguRegistry_PruneVersionsContext_Call) RunAndReturn(run func(context.Context, string, int) error) *MockDoguRegistry_PruneVersionsContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Register provides a mock function with given fields: dogu
+func (_m *MockDoguRegistry) Register(dogu *core.Dogu) error {
+	ret := _m.Called(dogu)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*core.Dogu) error); ok {
+		r0 = rf(dogu)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDoguRegistry_Register_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Register'
+type MockDoguRegistry_Register_Call struct {
+	*mock.Call
+}
+
+// Register is a helper method to define mock.On call
+//  - dogu *core.Dogu
+func (_e *MockDoguRegistry_Expecter) Register(dogu interface{}) *MockDoguRegistry_Register_Call {
+	return &MockDoguRegistry_Register_Call{Call: _e.mock.On("Register", dogu)}
+}
+
+func (_c *MockDoguRegistry_Register_Call) Run(run func(dogu *core.Dogu)) *MockDoguRegistry_Register_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*core.Dogu))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_Register_Call) Return(_a0 error) *MockDoguRegistry_Register_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDoguRegistry_Register_Call) RunAndReturn(run func(*core.Dogu) error) *MockDoguRegistry_Register_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RegisterContext provides a mock function with given fields: ctx, dogu
+func (_m *MockDoguRegistry) RegisterContext(ctx context.Context, dogu *core.Dogu) error {
+	ret := _m.Called(ctx, dogu)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *core.Dogu) error); ok {
+		r0 = rf(ctx, dogu)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDoguRegistry_RegisterContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RegisterContext'
+type MockDoguRegistry_RegisterContext_Call struct {
+	*mock.Call
+}
+
+// RegisterContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - dogu *core.Dogu
+func (_e *MockDoguRegistry_Expecter) RegisterContext(ctx interface{}, dogu interface{}) *MockDoguRegistry_RegisterContext_Call {
+	return &MockDoguRegistry_RegisterContext_Call{Call: _e.mock.On("RegisterContext", ctx, dogu)}
+}
+
+func (_c *MockDoguRegistry_RegisterContext_Call) Run(run func(ctx context.Context, dogu *core.Dogu)) *MockDoguRegistry_RegisterContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*core.Dogu))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_RegisterContext_Call) Return(_a0 error) *MockDoguRegistry_RegisterContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDoguRegistry_RegisterContext_Call) RunAndReturn(run func(context.Context, *core.Dogu) error) *MockDoguRegistry_RegisterContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Unregister provides a mock function with given fields: name
+func (_m *MockDoguRegistry) Unregister(name string) error {
+	ret := _m.Called(name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDoguRegistry_Unregister_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unregister'
+type MockDoguRegistry_Unregister_Call struct {
+	*mock.Call
+}
+
+// Unregister is a helper method to define mock.On call
+//  - name string
+func (_e *MockDoguRegistry_Expecter) Unregister(name interface{}) *MockDoguRegistry_Unregister_Call {
+	return &MockDoguRegistry_Unregister_Call{Call: _e.mock.On("Unregister", name)}
+}
+
+func (_c *MockDoguRegistry_Unregister_Call) Run(run func(name string)) *MockDoguRegistry_Unregister_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_Unregister_Call) Return(_a0 error) *MockDoguRegistry_Unregister_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDoguRegistry_Unregister_Call) RunAndReturn(run func(string) error) *MockDoguRegistry_Unregister_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UnregisterContext provides a mock function with given fields: ctx, name
+func (_m *MockDoguRegistry) UnregisterContext(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDoguRegistry_UnregisterContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UnregisterContext'
+type MockDoguRegistry_UnregisterContext_Call struct {
+	*mock.Call
+}
+
+// UnregisterContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - name string
+func (_e *MockDoguRegistry_Expecter) UnregisterContext(ctx interface{}, name interface{}) *MockDoguRegistry_UnregisterContext_Call {
+	return &MockDoguRegistry_UnregisterContext_Call{Call: _e.mock.On("UnregisterContext", ctx, name)}
+}
+
+func (_c *MockDoguRegistry_UnregisterContext_Call) Run(run func(ctx context.Context, name string)) *MockDoguRegistry_UnregisterContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_UnregisterContext_Call) Return(_a0 error) *MockDoguRegistry_UnregisterContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDoguRegistry_UnregisterContext_Call) RunAndReturn(run func(context.Context, string) error) *MockDoguRegistry_UnregisterContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UnregisterVersion provides a mock function with given fields: name, version
+func (_m *MockDoguRegistry) UnregisterVersion(name string, version string) error {
+	ret := _m.Called(name, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(name, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDoguRegistry_UnregisterVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UnregisterVersion'
+type MockDoguRegistry_UnregisterVersion_Call struct {
+	*mock.Call
+}
+
+// UnregisterVersion is a helper method to define mock.On call
+//  - name string
+//  - version string
+func (_e *MockDoguRegistry_Expecter) UnregisterVersion(name interface{}, version interface{}) *MockDoguRegistry_UnregisterVersion_Call {
+	return &MockDoguRegistry_UnregisterVersion_Call{Call: _e.mock.On("UnregisterVersion", name, version)}
+}
+
+func (_c *MockDoguRegistry_UnregisterVersion_Call) Run(run func(name string, version string)) *MockDoguRegistry_UnregisterVersion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_UnregisterVersion_Call) Return(_a0 error) *MockDoguRegistry_UnregisterVersion_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDoguRegistry_UnregisterVersion_Call) RunAndReturn(run func(string, string) error) *MockDoguRegistry_UnregisterVersion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UnregisterVersionContext provides a mock function with given fields: ctx, name, version
+func (_m *MockDoguRegistry) UnregisterVersionContext(ctx context.Context, name string, version string) error {
+	ret := _m.Called(ctx, name, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, name, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDoguRegistry_UnregisterVersionContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UnregisterVersionContext'
+type MockDoguRegistry_UnregisterVersionContext_Call struct {
+	*mock.Call
+}
+
+// UnregisterVersionContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - name string
+//  - version string
+func (_e *MockDoguRegistry_Expecter) UnregisterVersionContext(ctx interface{}, name interface{}, version interface{}) *MockDoguRegistry_UnregisterVersionContext_Call {
+	return &MockDoguRegistry_UnregisterVersionContext_Call{Call: _e.mock.On("UnregisterVersionContext", ctx, name, version)}
+}
+
+func (_c *MockDoguRegistry_UnregisterVersionContext_Call) Run(run func(ctx context.Context, name string, version string)) *MockDoguRegistry_UnregisterVersionContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockDoguRegistry_UnregisterVersionContext_Call) Return(_a0 error) *MockDoguRegistry_UnregisterVersionContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDoguRegistry_UnregisterVersionContext_Call) RunAndReturn(run func(context.Context, string, string) error) *MockDoguRegistry_UnregisterVersionContext_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -2,7 +2,11 @@
 
 package registry
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // MockRegistry is an autogenerated mock type for the Registry type
 type MockRegistry struct {
@@ -60,6 +64,47 @@ func (_c *MockRegistry_BlueprintRegistry_Call) RunAndReturn(run func() Configura
 	return _c
 }
 
+// Close provides a mock function with given fields:
+func (_m *MockRegistry) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRegistry_Close_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Close'
+type MockRegistry_Close_Call struct {
+	*mock.Call
+}
+
+// Close is a helper method to define mock.On call
+func (_e *MockRegistry_Expecter) Close() *MockRegistry_Close_Call {
+	return &MockRegistry_Close_Call{Call: _e.mock.On("Close")}
+}
+
+func (_c *MockRegistry_Close_Call) Run(run func()) *MockRegistry_Close_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockRegistry_Close_Call) Return(_a0 error) *MockRegistry_Close_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRegistry_Close_Call) RunAndReturn(run func() error) *MockRegistry_Close_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DoguConfig provides a mock function with given fields: dogu
 func (_m *MockRegistry) DoguConfig(dogu string) ConfigurationContext {
 	ret := _m.Called(dogu)
@@ -147,6 +192,142 @@ func (_c *MockRegistry_DoguRegistry_Call) RunAndReturn(run func() DoguRegistry)
 	return _c
 }
 
+// ExportSnapshot provides a mock function with given fields: paths
+func (_m *MockRegistry) ExportSnapshot(paths ...string) (*Snapshot, error) {
+	_va := make([]interface{}, len(paths))
+	for _i := range paths {
+		_va[_i] = paths[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *Snapshot
+	var r1 error
+	if rf, ok := ret.Get(0).(func(...string) (*Snapshot, error)); ok {
+		return rf(paths...)
+	}
+	if rf, ok := ret.Get(0).(func(...string) *Snapshot); ok {
+		r0 = rf(paths...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Snapshot)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(...string) error); ok {
+		r1 = rf(paths...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRegistry_ExportSnapshot_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExportSnapshot'
+type MockRegistry_ExportSnapshot_Call struct {
+	*mock.Call
+}
+
+// ExportSnapshot is a helper method to define mock.On call
+//  - paths ...string
+func (_e *MockRegistry_Expecter) ExportSnapshot(paths ...interface{}) *MockRegistry_ExportSnapshot_Call {
+	return &MockRegistry_ExportSnapshot_Call{Call: _e.mock.On("ExportSnapshot",
+		append([]interface{}{}, paths...)...)}
+}
+
+func (_c *MockRegistry_ExportSnapshot_Call) Run(run func(paths ...string)) *MockRegistry_ExportSnapshot_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]string, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockRegistry_ExportSnapshot_Call) Return(_a0 *Snapshot, _a1 error) *MockRegistry_ExportSnapshot_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRegistry_ExportSnapshot_Call) RunAndReturn(run func(...string) (*Snapshot, error)) *MockRegistry_ExportSnapshot_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ExportSnapshotContext provides a mock function with given fields: ctx, paths
+func (_m *MockRegistry) ExportSnapshotContext(ctx context.Context, paths ...string) (*Snapshot, error) {
+	_va := make([]interface{}, len(paths))
+	for _i := range paths {
+		_va[_i] = paths[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *Snapshot
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) (*Snapshot, error)); ok {
+		return rf(ctx, paths...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) *Snapshot); ok {
+		r0 = rf(ctx, paths...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Snapshot)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ...string) error); ok {
+		r1 = rf(ctx, paths...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRegistry_ExportSnapshotContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExportSnapshotContext'
+type MockRegistry_ExportSnapshotContext_Call struct {
+	*mock.Call
+}
+
+// ExportSnapshotContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - paths ...string
+func (_e *MockRegistry_Expecter) ExportSnapshotContext(ctx interface{}, paths ...interface{}) *MockRegistry_ExportSnapshotContext_Call {
+	return &MockRegistry_ExportSnapshotContext_Call{Call: _e.mock.On("ExportSnapshotContext",
+		append([]interface{}{ctx}, paths...)...)}
+}
+
+func (_c *MockRegistry_ExportSnapshotContext_Call) Run(run func(ctx context.Context, paths ...string)) *MockRegistry_ExportSnapshotContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]string, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(args[0].(context.Context), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockRegistry_ExportSnapshotContext_Call) Return(_a0 *Snapshot, _a1 error) *MockRegistry_ExportSnapshotContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRegistry_ExportSnapshotContext_Call) RunAndReturn(run func(context.Context, ...string) (*Snapshot, error)) *MockRegistry_ExportSnapshotContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetNode provides a mock function with given fields:
 func (_m *MockRegistry) GetNode() (Node, error) {
 	ret := _m.Called()
@@ -198,6 +379,58 @@ func (_c *MockRegistry_GetNode_Call) RunAndReturn(run func() (Node, error)) *Moc
 	return _c
 }
 
+// GetNodeContext provides a mock function with given fields: ctx
+func (_m *MockRegistry) GetNodeContext(ctx context.Context) (Node, error) {
+	ret := _m.Called(ctx)
+
+	var r0 Node
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (Node, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) Node); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(Node)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRegistry_GetNodeContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNodeContext'
+type MockRegistry_GetNodeContext_Call struct {
+	*mock.Call
+}
+
+// GetNodeContext is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockRegistry_Expecter) GetNodeContext(ctx interface{}) *MockRegistry_GetNodeContext_Call {
+	return &MockRegistry_GetNodeContext_Call{Call: _e.mock.On("GetNodeContext", ctx)}
+}
+
+func (_c *MockRegistry_GetNodeContext_Call) Run(run func(ctx context.Context)) *MockRegistry_GetNodeContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockRegistry_GetNodeContext_Call) Return(_a0 Node, _a1 error) *MockRegistry_GetNodeContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRegistry_GetNodeContext_Call) RunAndReturn(run func(context.Context) (Node, error)) *MockRegistry_GetNodeContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GlobalConfig provides a mock function with given fields:
 func (_m *MockRegistry) GlobalConfig() ConfigurationContext {
 	ret := _m.Called()
@@ -285,6 +518,162 @@ func (_c *MockRegistry_HostConfig_Call) RunAndReturn(run func(string) Configurat
 	return _c
 }
 
+// ImportSnapshot provides a mock function with given fields: snapshot, options
+func (_m *MockRegistry) ImportSnapshot(snapshot *Snapshot, options SnapshotImportOptions) (*SnapshotImportReport, error) {
+	ret := _m.Called(snapshot, options)
+
+	var r0 *SnapshotImportReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*Snapshot, SnapshotImportOptions) (*SnapshotImportReport, error)); ok {
+		return rf(snapshot, options)
+	}
+	if rf, ok := ret.Get(0).(func(*Snapshot, SnapshotImportOptions) *SnapshotImportReport); ok {
+		r0 = rf(snapshot, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*SnapshotImportReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*Snapshot, SnapshotImportOptions) error); ok {
+		r1 = rf(snapshot, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRegistry_ImportSnapshot_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportSnapshot'
+type MockRegistry_ImportSnapshot_Call struct {
+	*mock.Call
+}
+
+// ImportSnapshot is a helper method to define mock.On call
+//  - snapshot *Snapshot
+//  - options SnapshotImportOptions
+func (_e *MockRegistry_Expecter) ImportSnapshot(snapshot interface{}, options interface{}) *MockRegistry_ImportSnapshot_Call {
+	return &MockRegistry_ImportSnapshot_Call{Call: _e.mock.On("ImportSnapshot", snapshot, options)}
+}
+
+func (_c *MockRegistry_ImportSnapshot_Call) Run(run func(snapshot *Snapshot, options SnapshotImportOptions)) *MockRegistry_ImportSnapshot_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*Snapshot), args[1].(SnapshotImportOptions))
+	})
+	return _c
+}
+
+func (_c *MockRegistry_ImportSnapshot_Call) Return(_a0 *SnapshotImportReport, _a1 error) *MockRegistry_ImportSnapshot_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRegistry_ImportSnapshot_Call) RunAndReturn(run func(*Snapshot, SnapshotImportOptions) (*SnapshotImportReport, error)) *MockRegistry_ImportSnapshot_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ImportSnapshotContext provides a mock function with given fields: ctx, snapshot, options
+func (_m *MockRegistry) ImportSnapshotContext(ctx context.Context, snapshot *Snapshot, options SnapshotImportOptions) (*SnapshotImportReport, error) {
+	ret := _m.Called(ctx, snapshot, options)
+
+	var r0 *SnapshotImportReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *Snapshot, SnapshotImportOptions) (*SnapshotImportReport, error)); ok {
+		return rf(ctx, snapshot, options)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *Snapshot, SnapshotImportOptions) *SnapshotImportReport); ok {
+		r0 = rf(ctx, snapshot, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*SnapshotImportReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *Snapshot, SnapshotImportOptions) error); ok {
+		r1 = rf(ctx, snapshot, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRegistry_ImportSnapshotContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportSnapshotContext'
+type MockRegistry_ImportSnapshotContext_Call struct {
+	*mock.Call
+}
+
+// ImportSnapshotContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - snapshot *Snapshot
+//  - options SnapshotImportOptions
+func (_e *MockRegistry_Expecter) ImportSnapshotContext(ctx interface{}, snapshot interface{}, options interface{}) *MockRegistry_ImportSnapshotContext_Call {
+	return &MockRegistry_ImportSnapshotContext_Call{Call: _e.mock.On("ImportSnapshotContext", ctx, snapshot, options)}
+}
+
+func (_c *MockRegistry_ImportSnapshotContext_Call) Run(run func(ctx context.Context, snapshot *Snapshot, options SnapshotImportOptions)) *MockRegistry_ImportSnapshotContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*Snapshot), args[2].(SnapshotImportOptions))
+	})
+	return _c
+}
+
+func (_c *MockRegistry_ImportSnapshotContext_Call) Return(_a0 *SnapshotImportReport, _a1 error) *MockRegistry_ImportSnapshotContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRegistry_ImportSnapshotContext_Call) RunAndReturn(run func(context.Context, *Snapshot, SnapshotImportOptions) (*SnapshotImportReport, error)) *MockRegistry_ImportSnapshotContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Lock provides a mock function with given fields: name, options
+func (_m *MockRegistry) Lock(name string, options LockOptions) Lock {
+	ret := _m.Called(name, options)
+
+	var r0 Lock
+	if rf, ok := ret.Get(0).(func(string, LockOptions) Lock); ok {
+		r0 = rf(name, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Lock)
+		}
+	}
+
+	return r0
+}
+
+// MockRegistry_Lock_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Lock'
+type MockRegistry_Lock_Call struct {
+	*mock.Call
+}
+
+// Lock is a helper method to define mock.On call
+//  - name string
+//  - options LockOptions
+func (_e *MockRegistry_Expecter) Lock(name interface{}, options interface{}) *MockRegistry_Lock_Call {
+	return &MockRegistry_Lock_Call{Call: _e.mock.On("Lock", name, options)}
+}
+
+func (_c *MockRegistry_Lock_Call) Run(run func(name string, options LockOptions)) *MockRegistry_Lock_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(LockOptions))
+	})
+	return _c
+}
+
+func (_c *MockRegistry_Lock_Call) Return(_a0 Lock) *MockRegistry_Lock_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRegistry_Lock_Call) RunAndReturn(run func(string, LockOptions) Lock) *MockRegistry_Lock_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // RootConfig provides a mock function with given fields:
 func (_m *MockRegistry) RootConfig() WatchConfigurationContext {
 	ret := _m.Called()
@@ -372,6 +761,50 @@ func (_c *MockRegistry_State_Call) RunAndReturn(run func(string) State) *MockReg
 	return _c
 }
 
+// WaitFor provides a mock function with given fields: ctx, dogu, state
+func (_m *MockRegistry) WaitFor(ctx context.Context, dogu string, state DoguState) error {
+	ret := _m.Called(ctx, dogu, state)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, DoguState) error); ok {
+		r0 = rf(ctx, dogu, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRegistry_WaitFor_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WaitFor'
+type MockRegistry_WaitFor_Call struct {
+	*mock.Call
+}
+
+// WaitFor is a helper method to define mock.On call
+//  - ctx context.Context
+//  - dogu string
+//  - state DoguState
+func (_e *MockRegistry_Expecter) WaitFor(ctx interface{}, dogu interface{}, state interface{}) *MockRegistry_WaitFor_Call {
+	return &MockRegistry_WaitFor_Call{Call: _e.mock.On("WaitFor", ctx, dogu, state)}
+}
+
+func (_c *MockRegistry_WaitFor_Call) Run(run func(ctx context.Context, dogu string, state DoguState)) *MockRegistry_WaitFor_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(DoguState))
+	})
+	return _c
+}
+
+func (_c *MockRegistry_WaitFor_Call) Return(_a0 error) *MockRegistry_WaitFor_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRegistry_WaitFor_Call) RunAndReturn(run func(context.Context, string, DoguState) error) *MockRegistry_WaitFor_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 type mockConstructorTestingTNewMockRegistry interface {
 	mock.TestingT
 	Cleanup(func())
@@ -2,7 +2,11 @@
 
 package registry
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // MockState is an autogenerated mock type for the State type
 type MockState struct {
@@ -68,6 +72,268 @@ func (_c *MockState_Get_Call) RunAndReturn(run func() (string, error)) *MockStat
 	return _c
 }
 
+// GetContext provides a mock function with given fields: ctx
+func (_m *MockState) GetContext(ctx context.Context) (string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockState_GetContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetContext'
+type MockState_GetContext_Call struct {
+	*mock.Call
+}
+
+// GetContext is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockState_Expecter) GetContext(ctx interface{}) *MockState_GetContext_Call {
+	return &MockState_GetContext_Call{Call: _e.mock.On("GetContext", ctx)}
+}
+
+func (_c *MockState_GetContext_Call) Run(run func(ctx context.Context)) *MockState_GetContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockState_GetContext_Call) Return(_a0 string, _a1 error) *MockState_GetContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockState_GetContext_Call) RunAndReturn(run func(context.Context) (string, error)) *MockState_GetContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetState provides a mock function with given fields:
+func (_m *MockState) GetState() (DoguState, error) {
+	ret := _m.Called()
+
+	var r0 DoguState
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (DoguState, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() DoguState); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(DoguState)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockState_GetState_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetState'
+type MockState_GetState_Call struct {
+	*mock.Call
+}
+
+// GetState is a helper method to define mock.On call
+func (_e *MockState_Expecter) GetState() *MockState_GetState_Call {
+	return &MockState_GetState_Call{Call: _e.mock.On("GetState")}
+}
+
+func (_c *MockState_GetState_Call) Run(run func()) *MockState_GetState_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockState_GetState_Call) Return(_a0 DoguState, _a1 error) *MockState_GetState_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockState_GetState_Call) RunAndReturn(run func() (DoguState, error)) *MockState_GetState_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetStateContext provides a mock function with given fields: ctx
+func (_m *MockState) GetStateContext(ctx context.Context) (DoguState, error) {
+	ret := _m.Called(ctx)
+
+	var r0 DoguState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (DoguState, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) DoguState); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(DoguState)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockState_GetStateContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStateContext'
+type MockState_GetStateContext_Call struct {
+	*mock.Call
+}
+
+// GetStateContext is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockState_Expecter) GetStateContext(ctx interface{}) *MockState_GetStateContext_Call {
+	return &MockState_GetStateContext_Call{Call: _e.mock.On("GetStateContext", ctx)}
+}
+
+func (_c *MockState_GetStateContext_Call) Run(run func(ctx context.Context)) *MockState_GetStateContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockState_GetStateContext_Call) Return(_a0 DoguState, _a1 error) *MockState_GetStateContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockState_GetStateContext_Call) RunAndReturn(run func(context.Context) (DoguState, error)) *MockState_GetStateContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// History provides a mock function with given fields:
+func (_m *MockState) History() ([]StateTransition, error) {
+	ret := _m.Called()
+
+	var r0 []StateTransition
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]StateTransition, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []StateTransition); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]StateTransition)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockState_History_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'History'
+type MockState_History_Call struct {
+	*mock.Call
+}
+
+// History is a helper method to define mock.On call
+func (_e *MockState_Expecter) History() *MockState_History_Call {
+	return &MockState_History_Call{Call: _e.mock.On("History")}
+}
+
+func (_c *MockState_History_Call) Run(run func()) *MockState_History_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockState_History_Call) Return(_a0 []StateTransition, _a1 error) *MockState_History_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockState_History_Call) RunAndReturn(run func() ([]StateTransition, error)) *MockState_History_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HistoryContext provides a mock function with given fields: ctx
+func (_m *MockState) HistoryContext(ctx context.Context) ([]StateTransition, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []StateTransition
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]StateTransition, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []StateTransition); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]StateTransition)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockState_HistoryContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HistoryContext'
+type MockState_HistoryContext_Call struct {
+	*mock.Call
+}
+
+// HistoryContext is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockState_Expecter) HistoryContext(ctx interface{}) *MockState_HistoryContext_Call {
+	return &MockState_HistoryContext_Call{Call: _e.mock.On("HistoryContext", ctx)}
+}
+
+func (_c *MockState_HistoryContext_Call) Run(run func(ctx context.Context)) *MockState_HistoryContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockState_HistoryContext_Call) Return(_a0 []StateTransition, _a1 error) *MockState_HistoryContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockState_HistoryContext_Call) RunAndReturn(run func(context.Context) ([]StateTransition, error)) *MockState_HistoryContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Remove provides a mock function with given fields:
 func (_m *MockState) Remove() error {
 	ret := _m.Called()
@@ -109,6 +375,48 @@ func (_c *MockState_Remove_Call) RunAndReturn(run func() error) *MockState_Remov
 	return _c
 }
 
+// RemoveContext provides a mock function with given fields: ctx
+func (_m *MockState) RemoveContext(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockState_RemoveContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveContext'
+type MockState_RemoveContext_Call struct {
+	*mock.Call
+}
+
+// RemoveContext is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockState_Expecter) RemoveContext(ctx interface{}) *MockState_RemoveContext_Call {
+	return &MockState_RemoveContext_Call{Call: _e.mock.On("RemoveContext", ctx)}
+}
+
+func (_c *MockState_RemoveContext_Call) Run(run func(ctx context.Context)) *MockState_RemoveContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockState_RemoveContext_Call) Return(_a0 error) *MockState_RemoveContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockState_RemoveContext_Call) RunAndReturn(run func(context.Context) error) *MockState_RemoveContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Set provides a mock function with given fields: value
 func (_m *MockState) Set(value string) error {
 	ret := _m.Called(value)
@@ -151,6 +459,134 @@ func (_c *MockState_Set_Call) RunAndReturn(run func(string) error) *MockState_Se
 	return _c
 }
 
+// SetContext provides a mock function with given fields: ctx, value
+func (_m *MockState) SetContext(ctx context.Context, value string) error {
+	ret := _m.Called(ctx, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockState_SetContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetContext'
+type MockState_SetContext_Call struct {
+	*mock.Call
+}
+
+// SetContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - value string
+func (_e *MockState_Expecter) SetContext(ctx interface{}, value interface{}) *MockState_SetContext_Call {
+	return &MockState_SetContext_Call{Call: _e.mock.On("SetContext", ctx, value)}
+}
+
+func (_c *MockState_SetContext_Call) Run(run func(ctx context.Context, value string)) *MockState_SetContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockState_SetContext_Call) Return(_a0 error) *MockState_SetContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockState_SetContext_Call) RunAndReturn(run func(context.Context, string) error) *MockState_SetContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Transition provides a mock function with given fields: state
+func (_m *MockState) Transition(state DoguState) error {
+	ret := _m.Called(state)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(DoguState) error); ok {
+		r0 = rf(state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockState_Transition_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Transition'
+type MockState_Transition_Call struct {
+	*mock.Call
+}
+
+// Transition is a helper method to define mock.On call
+//  - state DoguState
+func (_e *MockState_Expecter) Transition(state interface{}) *MockState_Transition_Call {
+	return &MockState_Transition_Call{Call: _e.mock.On("Transition", state)}
+}
+
+func (_c *MockState_Transition_Call) Run(run func(state DoguState)) *MockState_Transition_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(DoguState))
+	})
+	return _c
+}
+
+func (_c *MockState_Transition_Call) Return(_a0 error) *MockState_Transition_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockState_Transition_Call) RunAndReturn(run func(DoguState) error) *MockState_Transition_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TransitionContext provides a mock function with given fields: ctx, state
+func (_m *MockState) TransitionContext(ctx context.Context, state DoguState) error {
+	ret := _m.Called(ctx, state)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, DoguState) error); ok {
+		r0 = rf(ctx, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockState_TransitionContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TransitionContext'
+type MockState_TransitionContext_Call struct {
+	*mock.Call
+}
+
+// TransitionContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - state DoguState
+func (_e *MockState_Expecter) TransitionContext(ctx interface{}, state interface{}) *MockState_TransitionContext_Call {
+	return &MockState_TransitionContext_Call{Call: _e.mock.On("TransitionContext", ctx, state)}
+}
+
+func (_c *MockState_TransitionContext_Call) Run(run func(ctx context.Context, state DoguState)) *MockState_TransitionContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(DoguState))
+	})
+	return _c
+}
+
+func (_c *MockState_TransitionContext_Call) Return(_a0 error) *MockState_TransitionContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockState_TransitionContext_Call) RunAndReturn(run func(context.Context, DoguState) error) *MockState_TransitionContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 type mockConstructorTestingTNewMockState interface {
 	mock.TestingT
 	Cleanup(func())
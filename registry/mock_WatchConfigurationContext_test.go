@@ -129,6 +129,167 @@ func (_c *MockWatchConfigurationContext_GetChildrenPaths_Call) RunAndReturn(run
 	return _c
 }
 
+// GetChildrenPathsContext provides a mock function with given fields: ctx, key
+func (_m *MockWatchConfigurationContext) GetChildrenPathsContext(ctx context.Context, key string) ([]string, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockWatchConfigurationContext_GetChildrenPathsContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChildrenPathsContext'
+type MockWatchConfigurationContext_GetChildrenPathsContext_Call struct {
+	*mock.Call
+}
+
+// GetChildrenPathsContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+func (_e *MockWatchConfigurationContext_Expecter) GetChildrenPathsContext(ctx interface{}, key interface{}) *MockWatchConfigurationContext_GetChildrenPathsContext_Call {
+	return &MockWatchConfigurationContext_GetChildrenPathsContext_Call{Call: _e.mock.On("GetChildrenPathsContext", ctx, key)}
+}
+
+func (_c *MockWatchConfigurationContext_GetChildrenPathsContext_Call) Run(run func(ctx context.Context, key string)) *MockWatchConfigurationContext_GetChildrenPathsContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockWatchConfigurationContext_GetChildrenPathsContext_Call) Return(_a0 []string, _a1 error) *MockWatchConfigurationContext_GetChildrenPathsContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockWatchConfigurationContext_GetChildrenPathsContext_Call) RunAndReturn(run func(context.Context, string) ([]string, error)) *MockWatchConfigurationContext_GetChildrenPathsContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetContext provides a mock function with given fields: ctx, key
+func (_m *MockWatchConfigurationContext) GetContext(ctx context.Context, key string) (string, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockWatchConfigurationContext_GetContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetContext'
+type MockWatchConfigurationContext_GetContext_Call struct {
+	*mock.Call
+}
+
+// GetContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+func (_e *MockWatchConfigurationContext_Expecter) GetContext(ctx interface{}, key interface{}) *MockWatchConfigurationContext_GetContext_Call {
+	return &MockWatchConfigurationContext_GetContext_Call{Call: _e.mock.On("GetContext", ctx, key)}
+}
+
+func (_c *MockWatchConfigurationContext_GetContext_Call) Run(run func(ctx context.Context, key string)) *MockWatchConfigurationContext_GetContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockWatchConfigurationContext_GetContext_Call) Return(_a0 string, _a1 error) *MockWatchConfigurationContext_GetContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockWatchConfigurationContext_GetContext_Call) RunAndReturn(run func(context.Context, string) (string, error)) *MockWatchConfigurationContext_GetContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCurrentIndexContext provides a mock function with given fields: ctx, key
+func (_m *MockWatchConfigurationContext) GetCurrentIndexContext(ctx context.Context, key string) (uint64, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (uint64, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) uint64); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockWatchConfigurationContext_GetCurrentIndexContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCurrentIndexContext'
+type MockWatchConfigurationContext_GetCurrentIndexContext_Call struct {
+	*mock.Call
+}
+
+// GetCurrentIndexContext is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+func (_e *MockWatchConfigurationContext_Expecter) GetCurrentIndexContext(ctx interface{}, key interface{}) *MockWatchConfigurationContext_GetCurrentIndexContext_Call {
+	return &MockWatchConfigurationContext_GetCurrentIndexContext_Call{Call: _e.mock.On("GetCurrentIndexContext", ctx, key)}
+}
+
+func (_c *MockWatchConfigurationContext_GetCurrentIndexContext_Call) Run(run func(ctx context.Context, key string)) *MockWatchConfigurationContext_GetCurrentIndexContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockWatchConfigurationContext_GetCurrentIndexContext_Call) Return(_a0 uint64, _a1 error) *MockWatchConfigurationContext_GetCurrentIndexContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockWatchConfigurationContext_GetCurrentIndexContext_Call) RunAndReturn(run func(context.Context, string) (uint64, error)) *MockWatchConfigurationContext_GetCurrentIndexContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Watch provides a mock function with given fields: ctx, key, recursive, eventChannel
 func (_m *MockWatchConfigurationContext) Watch(ctx context.Context, key string, recursive bool, eventChannel chan *client.Response) {
 	_m.Called(ctx, key, recursive, eventChannel)
@@ -165,6 +326,123 @@ func (_c *MockWatchConfigurationContext_Watch_Call) RunAndReturn(run func(contex
 	return _c
 }
 
+// WatchEvents provides a mock function with given fields: ctx, key, recursive
+func (_m *MockWatchConfigurationContext) WatchEvents(ctx context.Context, key string, recursive bool) (<-chan WatchEvent, <-chan error) {
+	ret := _m.Called(ctx, key, recursive)
+
+	var r0 <-chan WatchEvent
+	var r1 <-chan error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (<-chan WatchEvent, <-chan error)); ok {
+		return rf(ctx, key, recursive)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) <-chan WatchEvent); ok {
+		r0 = rf(ctx, key, recursive)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan WatchEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) <-chan error); ok {
+		r1 = rf(ctx, key, recursive)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(<-chan error)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockWatchConfigurationContext_WatchEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WatchEvents'
+type MockWatchConfigurationContext_WatchEvents_Call struct {
+	*mock.Call
+}
+
+// WatchEvents is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+//  - recursive bool
+func (_e *MockWatchConfigurationContext_Expecter) WatchEvents(ctx interface{}, key interface{}, recursive interface{}) *MockWatchConfigurationContext_WatchEvents_Call {
+	return &MockWatchConfigurationContext_WatchEvents_Call{Call: _e.mock.On("WatchEvents", ctx, key, recursive)}
+}
+
+func (_c *MockWatchConfigurationContext_WatchEvents_Call) Run(run func(ctx context.Context, key string, recursive bool)) *MockWatchConfigurationContext_WatchEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(bool))
+	})
+	return _c
+}
+
+func (_c *MockWatchConfigurationContext_WatchEvents_Call) Return(_a0 <-chan WatchEvent, _a1 <-chan error) *MockWatchConfigurationContext_WatchEvents_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockWatchConfigurationContext_WatchEvents_Call) RunAndReturn(run func(context.Context, string, bool) (<-chan WatchEvent, <-chan error)) *MockWatchConfigurationContext_WatchEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WatchEventsAfter provides a mock function with given fields: ctx, key, recursive, afterIndex
+func (_m *MockWatchConfigurationContext) WatchEventsAfter(ctx context.Context, key string, recursive bool, afterIndex uint64) (<-chan WatchEvent, <-chan error) {
+	ret := _m.Called(ctx, key, recursive, afterIndex)
+
+	var r0 <-chan WatchEvent
+	var r1 <-chan error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool, uint64) (<-chan WatchEvent, <-chan error)); ok {
+		return rf(ctx, key, recursive, afterIndex)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool, uint64) <-chan WatchEvent); ok {
+		r0 = rf(ctx, key, recursive, afterIndex)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan WatchEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool, uint64) <-chan error); ok {
+		r1 = rf(ctx, key, recursive, afterIndex)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(<-chan error)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockWatchConfigurationContext_WatchEventsAfter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WatchEventsAfter'
+type MockWatchConfigurationContext_WatchEventsAfter_Call struct {
+	*mock.Call
+}
+
+// WatchEventsAfter is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+//  - recursive bool
+//  - afterIndex uint64
+func (_e *MockWatchConfigurationContext_Expecter) WatchEventsAfter(ctx interface{}, key interface{}, recursive interface{}, afterIndex interface{}) *MockWatchConfigurationContext_WatchEventsAfter_Call {
+	return &MockWatchConfigurationContext_WatchEventsAfter_Call{Call: _e.mock.On("WatchEventsAfter", ctx, key, recursive, afterIndex)}
+}
+
+func (_c *MockWatchConfigurationContext_WatchEventsAfter_Call) Run(run func(ctx context.Context, key string, recursive bool, afterIndex uint64)) *MockWatchConfigurationContext_WatchEventsAfter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(bool), args[3].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockWatchConfigurationContext_WatchEventsAfter_Call) Return(_a0 <-chan WatchEvent, _a1 <-chan error) *MockWatchConfigurationContext_WatchEventsAfter_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockWatchConfigurationContext_WatchEventsAfter_Call) RunAndReturn(run func(context.Context, string, bool, uint64) (<-chan WatchEvent, <-chan error)) *MockWatchConfigurationContext_WatchEventsAfter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 type mockConstructorTestingTNewMockWatchConfigurationContext interface {
 	mock.TestingT
 	Cleanup(func())
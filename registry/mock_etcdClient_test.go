@@ -23,13 +23,56 @@ func (_m *mockEtcdClient) EXPECT() *mockEtcdClient_Expecter {
 	return &mockEtcdClient_Expecter{mock: &_m.Mock}
 }
 
-// Delete provides a mock function with given fields: key, options
-func (_m *mockEtcdClient) Delete(key string, options *client.DeleteOptions) error {
-	ret := _m.Called(key, options)
+// ApplyBatch provides a mock function with given fields: ctx, changes
+func (_m *mockEtcdClient) ApplyBatch(ctx context.Context, changes []keyChange) error {
+	ret := _m.Called(ctx, changes)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *client.DeleteOptions) error); ok {
-		r0 = rf(key, options)
+	if rf, ok := ret.Get(0).(func(context.Context, []keyChange) error); ok {
+		r0 = rf(ctx, changes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// mockEtcdClient_ApplyBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ApplyBatch'
+type mockEtcdClient_ApplyBatch_Call struct {
+	*mock.Call
+}
+
+// ApplyBatch is a helper method to define mock.On call
+//  - ctx context.Context
+//  - changes []keyChange
+func (_e *mockEtcdClient_Expecter) ApplyBatch(ctx interface{}, changes interface{}) *mockEtcdClient_ApplyBatch_Call {
+	return &mockEtcdClient_ApplyBatch_Call{Call: _e.mock.On("ApplyBatch", ctx, changes)}
+}
+
+func (_c *mockEtcdClient_ApplyBatch_Call) Run(run func(ctx context.Context, changes []keyChange)) *mockEtcdClient_ApplyBatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]keyChange))
+	})
+	return _c
+}
+
+func (_c *mockEtcdClient_ApplyBatch_Call) Return(_a0 error) *mockEtcdClient_ApplyBatch_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *mockEtcdClient_ApplyBatch_Call) RunAndReturn(run func(context.Context, []keyChange) error) *mockEtcdClient_ApplyBatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Delete provides a mock function with given fields: ctx, key, options
+func (_m *mockEtcdClient) Delete(ctx context.Context, key string, options *client.DeleteOptions) error {
+	ret := _m.Called(ctx, key, options)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *client.DeleteOptions) error); ok {
+		r0 = rf(ctx, key, options)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -43,15 +86,16 @@ type mockEtcdClient_Delete_Call struct {
 }
 
 // Delete is a helper method to define mock.On call
+//  - ctx context.Context
 //  - key string
 //  - options *client.DeleteOptions
-func (_e *mockEtcdClient_Expecter) Delete(key interface{}, options interface{}) *mockEtcdClient_Delete_Call {
-	return &mockEtcdClient_Delete_Call{Call: _e.mock.On("Delete", key, options)}
+func (_e *mockEtcdClient_Expecter) Delete(ctx interface{}, key interface{}, options interface{}) *mockEtcdClient_Delete_Call {
+	return &mockEtcdClient_Delete_Call{Call: _e.mock.On("Delete", ctx, key, options)}
 }
 
-func (_c *mockEtcdClient_Delete_Call) Run(run func(key string, options *client.DeleteOptions)) *mockEtcdClient_Delete_Call {
+func (_c *mockEtcdClient_Delete_Call) Run(run func(ctx context.Context, key string, options *client.DeleteOptions)) *mockEtcdClient_Delete_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(*client.DeleteOptions))
+		run(args[0].(context.Context), args[1].(string), args[2].(*client.DeleteOptions))
 	})
 	return _c
 }
@@ -61,18 +105,18 @@ func (_c *mockEtcdClient_Delete_Call) Return(_a0 error) *mockEtcdClient_Delete_C
 	return _c
 }
 
-func (_c *mockEtcdClient_Delete_Call) RunAndReturn(run func(string, *client.DeleteOptions) error) *mockEtcdClient_Delete_Call {
+func (_c *mockEtcdClient_Delete_Call) RunAndReturn(run func(context.Context, string, *client.DeleteOptions) error) *mockEtcdClient_Delete_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// DeleteRecursive provides a mock function with given fields: key
-func (_m *mockEtcdClient) DeleteRecursive(key string) error {
-	ret := _m.Called(key)
+// DeleteRecursive provides a mock function with given fields: ctx, key
+func (_m *mockEtcdClient) DeleteRecursive(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -86,14 +130,15 @@ type mockEtcdClient_DeleteRecursive_Call struct {
 }
 
 // DeleteRecursive is a helper method to define mock.On call
+//  - ctx context.Context
 //  - key string
-func (_e *mockEtcdClient_Expecter) DeleteRecursive(key interface{}) *mockEtcdClient_DeleteRecursive_Call {
-	return &mockEtcdClient_DeleteRecursive_Call{Call: _e.mock.On("DeleteRecursive", key)}
+func (_e *mockEtcdClient_Expecter) DeleteRecursive(ctx interface{}, key interface{}) *mockEtcdClient_DeleteRecursive_Call {
+	return &mockEtcdClient_DeleteRecursive_Call{Call: _e.mock.On("DeleteRecursive", ctx, key)}
 }
 
-func (_c *mockEtcdClient_DeleteRecursive_Call) Run(run func(key string)) *mockEtcdClient_DeleteRecursive_Call {
+func (_c *mockEtcdClient_DeleteRecursive_Call) Run(run func(ctx context.Context, key string)) *mockEtcdClient_DeleteRecursive_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
@@ -103,28 +148,28 @@ func (_c *mockEtcdClient_DeleteRecursive_Call) Return(_a0 error) *mockEtcdClient
 	return _c
 }
 
-func (_c *mockEtcdClient_DeleteRecursive_Call) RunAndReturn(run func(string) error) *mockEtcdClient_DeleteRecursive_Call {
+func (_c *mockEtcdClient_DeleteRecursive_Call) RunAndReturn(run func(context.Context, string) error) *mockEtcdClient_DeleteRecursive_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Exists provides a mock function with given fields: key
-func (_m *mockEtcdClient) Exists(key string) (bool, error) {
-	ret := _m.Called(key)
+// Exists provides a mock function with given fields: ctx, key
+func (_m *mockEtcdClient) Exists(ctx context.Context, key string) (bool, error) {
+	ret := _m.Called(ctx, key)
 
 	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
-		return rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, key)
 	}
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
-		r0 = rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, key)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(key)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -138,14 +183,15 @@ type mockEtcdClient_Exists_Call struct {
 }
 
 // Exists is a helper method to define mock.On call
+//  - ctx context.Context
 //  - key string
-func (_e *mockEtcdClient_Expecter) Exists(key interface{}) *mockEtcdClient_Exists_Call {
-	return &mockEtcdClient_Exists_Call{Call: _e.mock.On("Exists", key)}
+func (_e *mockEtcdClient_Expecter) Exists(ctx interface{}, key interface{}) *mockEtcdClient_Exists_Call {
+	return &mockEtcdClient_Exists_Call{Call: _e.mock.On("Exists", ctx, key)}
 }
 
-func (_c *mockEtcdClient_Exists_Call) Run(run func(key string)) *mockEtcdClient_Exists_Call {
+func (_c *mockEtcdClient_Exists_Call) Run(run func(ctx context.Context, key string)) *mockEtcdClient_Exists_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
@@ -155,28 +201,28 @@ func (_c *mockEtcdClient_Exists_Call) Return(_a0 bool, _a1 error) *mockEtcdClien
 	return _c
 }
 
-func (_c *mockEtcdClient_Exists_Call) RunAndReturn(run func(string) (bool, error)) *mockEtcdClient_Exists_Call {
+func (_c *mockEtcdClient_Exists_Call) RunAndReturn(run func(context.Context, string) (bool, error)) *mockEtcdClient_Exists_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Get provides a mock function with given fields: key
-func (_m *mockEtcdClient) Get(key string) (string, error) {
-	ret := _m.Called(key)
+// Get provides a mock function with given fields: ctx, key
+func (_m *mockEtcdClient) Get(ctx context.Context, key string) (string, error) {
+	ret := _m.Called(ctx, key)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, key)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, key)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(key)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -190,14 +236,15 @@ type mockEtcdClient_Get_Call struct {
 }
 
 // Get is a helper method to define mock.On call
+//  - ctx context.Context
 //  - key string
-func (_e *mockEtcdClient_Expecter) Get(key interface{}) *mockEtcdClient_Get_Call {
-	return &mockEtcdClient_Get_Call{Call: _e.mock.On("Get", key)}
+func (_e *mockEtcdClient_Expecter) Get(ctx interface{}, key interface{}) *mockEtcdClient_Get_Call {
+	return &mockEtcdClient_Get_Call{Call: _e.mock.On("Get", ctx, key)}
 }
 
-func (_c *mockEtcdClient_Get_Call) Run(run func(key string)) *mockEtcdClient_Get_Call {
+func (_c *mockEtcdClient_Get_Call) Run(run func(ctx context.Context, key string)) *mockEtcdClient_Get_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
@@ -207,30 +254,30 @@ func (_c *mockEtcdClient_Get_Call) Return(_a0 string, _a1 error) *mockEtcdClient
 	return _c
 }
 
-func (_c *mockEtcdClient_Get_Call) RunAndReturn(run func(string) (string, error)) *mockEtcdClient_Get_Call {
+func (_c *mockEtcdClient_Get_Call) RunAndReturn(run func(context.Context, string) (string, error)) *mockEtcdClient_Get_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetChildrenPaths provides a mock function with given fields: key
-func (_m *mockEtcdClient) GetChildrenPaths(key string) ([]string, error) {
-	ret := _m.Called(key)
+// GetChildrenPaths provides a mock function with given fields: ctx, key
+func (_m *mockEtcdClient) GetChildrenPaths(ctx context.Context, key string) ([]string, error) {
+	ret := _m.Called(ctx, key)
 
 	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
-		return rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
+		return rf(ctx, key)
 	}
-	if rf, ok := ret.Get(0).(func(string) []string); ok {
-		r0 = rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, key)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]string)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(key)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -244,14 +291,15 @@ type mockEtcdClient_GetChildrenPaths_Call struct {
 }
 
 // GetChildrenPaths is a helper method to define mock.On call
+//  - ctx context.Context
 //  - key string
-func (_e *mockEtcdClient_Expecter) GetChildrenPaths(key interface{}) *mockEtcdClient_GetChildrenPaths_Call {
-	return &mockEtcdClient_GetChildrenPaths_Call{Call: _e.mock.On("GetChildrenPaths", key)}
+func (_e *mockEtcdClient_Expecter) GetChildrenPaths(ctx interface{}, key interface{}) *mockEtcdClient_GetChildrenPaths_Call {
+	return &mockEtcdClient_GetChildrenPaths_Call{Call: _e.mock.On("GetChildrenPaths", ctx, key)}
 }
 
-func (_c *mockEtcdClient_GetChildrenPaths_Call) Run(run func(key string)) *mockEtcdClient_GetChildrenPaths_Call {
+func (_c *mockEtcdClient_GetChildrenPaths_Call) Run(run func(ctx context.Context, key string)) *mockEtcdClient_GetChildrenPaths_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
@@ -261,30 +309,137 @@ func (_c *mockEtcdClient_GetChildrenPaths_Call) Return(_a0 []string, _a1 error)
 	return _c
 }
 
-func (_c *mockEtcdClient_GetChildrenPaths_Call) RunAndReturn(run func(string) ([]string, error)) *mockEtcdClient_GetChildrenPaths_Call {
+func (_c *mockEtcdClient_GetChildrenPaths_Call) RunAndReturn(run func(context.Context, string) ([]string, error)) *mockEtcdClient_GetChildrenPaths_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCurrentIndex provides a mock function with given fields: ctx, key
+func (_m *mockEtcdClient) GetCurrentIndex(ctx context.Context, key string) (uint64, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (uint64, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) uint64); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockEtcdClient_GetCurrentIndex_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCurrentIndex'
+type mockEtcdClient_GetCurrentIndex_Call struct {
+	*mock.Call
+}
+
+// GetCurrentIndex is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+func (_e *mockEtcdClient_Expecter) GetCurrentIndex(ctx interface{}, key interface{}) *mockEtcdClient_GetCurrentIndex_Call {
+	return &mockEtcdClient_GetCurrentIndex_Call{Call: _e.mock.On("GetCurrentIndex", ctx, key)}
+}
+
+func (_c *mockEtcdClient_GetCurrentIndex_Call) Run(run func(ctx context.Context, key string)) *mockEtcdClient_GetCurrentIndex_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *mockEtcdClient_GetCurrentIndex_Call) Return(_a0 uint64, _a1 error) *mockEtcdClient_GetCurrentIndex_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockEtcdClient_GetCurrentIndex_Call) RunAndReturn(run func(context.Context, string) (uint64, error)) *mockEtcdClient_GetCurrentIndex_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetRecursive provides a mock function with given fields: key
-func (_m *mockEtcdClient) GetRecursive(key string) (map[string]string, error) {
-	ret := _m.Called(key)
+// GetMainNode provides a mock function with given fields: ctx
+func (_m *mockEtcdClient) GetMainNode(ctx context.Context) (*client.Node, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *client.Node
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*client.Node, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *client.Node); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.Node)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockEtcdClient_GetMainNode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMainNode'
+type mockEtcdClient_GetMainNode_Call struct {
+	*mock.Call
+}
+
+// GetMainNode is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *mockEtcdClient_Expecter) GetMainNode(ctx interface{}) *mockEtcdClient_GetMainNode_Call {
+	return &mockEtcdClient_GetMainNode_Call{Call: _e.mock.On("GetMainNode", ctx)}
+}
+
+func (_c *mockEtcdClient_GetMainNode_Call) Run(run func(ctx context.Context)) *mockEtcdClient_GetMainNode_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *mockEtcdClient_GetMainNode_Call) Return(_a0 *client.Node, _a1 error) *mockEtcdClient_GetMainNode_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockEtcdClient_GetMainNode_Call) RunAndReturn(run func(context.Context) (*client.Node, error)) *mockEtcdClient_GetMainNode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRecursive provides a mock function with given fields: ctx, key
+func (_m *mockEtcdClient) GetRecursive(ctx context.Context, key string) (map[string]string, error) {
+	ret := _m.Called(ctx, key)
 
 	var r0 map[string]string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (map[string]string, error)); ok {
-		return rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (map[string]string, error)); ok {
+		return rf(ctx, key)
 	}
-	if rf, ok := ret.Get(0).(func(string) map[string]string); ok {
-		r0 = rf(key)
+	if rf, ok := ret.Get(0).(func(context.Context, string) map[string]string); ok {
+		r0 = rf(ctx, key)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(map[string]string)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(key)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -298,14 +453,15 @@ type mockEtcdClient_GetRecursive_Call struct {
 }
 
 // GetRecursive is a helper method to define mock.On call
+//  - ctx context.Context
 //  - key string
-func (_e *mockEtcdClient_Expecter) GetRecursive(key interface{}) *mockEtcdClient_GetRecursive_Call {
-	return &mockEtcdClient_GetRecursive_Call{Call: _e.mock.On("GetRecursive", key)}
+func (_e *mockEtcdClient_Expecter) GetRecursive(ctx interface{}, key interface{}) *mockEtcdClient_GetRecursive_Call {
+	return &mockEtcdClient_GetRecursive_Call{Call: _e.mock.On("GetRecursive", ctx, key)}
 }
 
-func (_c *mockEtcdClient_GetRecursive_Call) Run(run func(key string)) *mockEtcdClient_GetRecursive_Call {
+func (_c *mockEtcdClient_GetRecursive_Call) Run(run func(ctx context.Context, key string)) *mockEtcdClient_GetRecursive_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
@@ -315,28 +471,143 @@ func (_c *mockEtcdClient_GetRecursive_Call) Return(_a0 map[string]string, _a1 er
 	return _c
 }
 
-func (_c *mockEtcdClient_GetRecursive_Call) RunAndReturn(run func(string) (map[string]string, error)) *mockEtcdClient_GetRecursive_Call {
+func (_c *mockEtcdClient_GetRecursive_Call) RunAndReturn(run func(context.Context, string) (map[string]string, error)) *mockEtcdClient_GetRecursive_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Set provides a mock function with given fields: key, value, options
-func (_m *mockEtcdClient) Set(key string, value string, options *client.SetOptions) (string, error) {
-	ret := _m.Called(key, value, options)
+// GetTree provides a mock function with given fields: ctx, key
+func (_m *mockEtcdClient) GetTree(ctx context.Context, key string) (*client.Node, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *client.Node
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*client.Node, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *client.Node); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.Node)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockEtcdClient_GetTree_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTree'
+type mockEtcdClient_GetTree_Call struct {
+	*mock.Call
+}
+
+// GetTree is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+func (_e *mockEtcdClient_Expecter) GetTree(ctx interface{}, key interface{}) *mockEtcdClient_GetTree_Call {
+	return &mockEtcdClient_GetTree_Call{Call: _e.mock.On("GetTree", ctx, key)}
+}
+
+func (_c *mockEtcdClient_GetTree_Call) Run(run func(ctx context.Context, key string)) *mockEtcdClient_GetTree_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *mockEtcdClient_GetTree_Call) Return(_a0 *client.Node, _a1 error) *mockEtcdClient_GetTree_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockEtcdClient_GetTree_Call) RunAndReturn(run func(context.Context, string) (*client.Node, error)) *mockEtcdClient_GetTree_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetWithIndex provides a mock function with given fields: ctx, key
+func (_m *mockEtcdClient) GetWithIndex(ctx context.Context, key string) (string, uint64, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 string
+	var r1 uint64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, uint64, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) uint64); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Get(1).(uint64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, key)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// mockEtcdClient_GetWithIndex_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWithIndex'
+type mockEtcdClient_GetWithIndex_Call struct {
+	*mock.Call
+}
+
+// GetWithIndex is a helper method to define mock.On call
+//  - ctx context.Context
+//  - key string
+func (_e *mockEtcdClient_Expecter) GetWithIndex(ctx interface{}, key interface{}) *mockEtcdClient_GetWithIndex_Call {
+	return &mockEtcdClient_GetWithIndex_Call{Call: _e.mock.On("GetWithIndex", ctx, key)}
+}
+
+func (_c *mockEtcdClient_GetWithIndex_Call) Run(run func(ctx context.Context, key string)) *mockEtcdClient_GetWithIndex_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *mockEtcdClient_GetWithIndex_Call) Return(_a0 string, _a1 uint64, _a2 error) *mockEtcdClient_GetWithIndex_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *mockEtcdClient_GetWithIndex_Call) RunAndReturn(run func(context.Context, string) (string, uint64, error)) *mockEtcdClient_GetWithIndex_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Set provides a mock function with given fields: ctx, key, value, options
+func (_m *mockEtcdClient) Set(ctx context.Context, key string, value string, options *client.SetOptions) (string, error) {
+	ret := _m.Called(ctx, key, value, options)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string, *client.SetOptions) (string, error)); ok {
-		return rf(key, value, options)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *client.SetOptions) (string, error)); ok {
+		return rf(ctx, key, value, options)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, *client.SetOptions) string); ok {
-		r0 = rf(key, value, options)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *client.SetOptions) string); ok {
+		r0 = rf(ctx, key, value, options)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, *client.SetOptions) error); ok {
-		r1 = rf(key, value, options)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *client.SetOptions) error); ok {
+		r1 = rf(ctx, key, value, options)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -350,16 +621,17 @@ type mockEtcdClient_Set_Call struct {
 }
 
 // Set is a helper method to define mock.On call
+//  - ctx context.Context
 //  - key string
 //  - value string
 //  - options *client.SetOptions
-func (_e *mockEtcdClient_Expecter) Set(key interface{}, value interface{}, options interface{}) *mockEtcdClient_Set_Call {
-	return &mockEtcdClient_Set_Call{Call: _e.mock.On("Set", key, value, options)}
+func (_e *mockEtcdClient_Expecter) Set(ctx interface{}, key interface{}, value interface{}, options interface{}) *mockEtcdClient_Set_Call {
+	return &mockEtcdClient_Set_Call{Call: _e.mock.On("Set", ctx, key, value, options)}
 }
 
-func (_c *mockEtcdClient_Set_Call) Run(run func(key string, value string, options *client.SetOptions)) *mockEtcdClient_Set_Call {
+func (_c *mockEtcdClient_Set_Call) Run(run func(ctx context.Context, key string, value string, options *client.SetOptions)) *mockEtcdClient_Set_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string), args[2].(*client.SetOptions))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(*client.SetOptions))
 	})
 	return _c
 }
@@ -369,14 +641,23 @@ func (_c *mockEtcdClient_Set_Call) Return(_a0 string, _a1 error) *mockEtcdClient
 	return _c
 }
 
-func (_c *mockEtcdClient_Set_Call) RunAndReturn(run func(string, string, *client.SetOptions) (string, error)) *mockEtcdClient_Set_Call {
+func (_c *mockEtcdClient_Set_Call) RunAndReturn(run func(context.Context, string, string, *client.SetOptions) (string, error)) *mockEtcdClient_Set_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Watch provides a mock function with given fields: ctx, key, recursive, eventChannel
-func (_m *mockEtcdClient) Watch(ctx context.Context, key string, recursive bool, eventChannel chan *client.Response) {
-	_m.Called(ctx, key, recursive, eventChannel)
+// Watch provides a mock function with given fields: ctx, key, recursive, afterIndex, eventChannel
+func (_m *mockEtcdClient) Watch(ctx context.Context, key string, recursive bool, afterIndex uint64, eventChannel chan<- *client.Response) error {
+	ret := _m.Called(ctx, key, recursive, afterIndex, eventChannel)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool, uint64, chan<- *client.Response) error); ok {
+		r0 = rf(ctx, key, recursive, afterIndex, eventChannel)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
 // mockEtcdClient_Watch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Watch'
@@ -388,24 +669,25 @@ type mockEtcdClient_Watch_Call struct {
 //  - ctx context.Context
 //  - key string
 //  - recursive bool
-//  - eventChannel chan *client.Response
-func (_e *mockEtcdClient_Expecter) Watch(ctx interface{}, key interface{}, recursive interface{}, eventChannel interface{}) *mockEtcdClient_Watch_Call {
-	return &mockEtcdClient_Watch_Call{Call: _e.mock.On("Watch", ctx, key, recursive, eventChannel)}
+//  - afterIndex uint64
+//  - eventChannel chan<- *client.Response
+func (_e *mockEtcdClient_Expecter) Watch(ctx interface{}, key interface{}, recursive interface{}, afterIndex interface{}, eventChannel interface{}) *mockEtcdClient_Watch_Call {
+	return &mockEtcdClient_Watch_Call{Call: _e.mock.On("Watch", ctx, key, recursive, afterIndex, eventChannel)}
 }
 
-func (_c *mockEtcdClient_Watch_Call) Run(run func(ctx context.Context, key string, recursive bool, eventChannel chan *client.Response)) *mockEtcdClient_Watch_Call {
+func (_c *mockEtcdClient_Watch_Call) Run(run func(ctx context.Context, key string, recursive bool, afterIndex uint64, eventChannel chan<- *client.Response)) *mockEtcdClient_Watch_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(bool), args[3].(chan *client.Response))
+		run(args[0].(context.Context), args[1].(string), args[2].(bool), args[3].(uint64), args[4].(chan<- *client.Response))
 	})
 	return _c
 }
 
-func (_c *mockEtcdClient_Watch_Call) Return() *mockEtcdClient_Watch_Call {
-	_c.Call.Return()
+func (_c *mockEtcdClient_Watch_Call) Return(_a0 error) *mockEtcdClient_Watch_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *mockEtcdClient_Watch_Call) RunAndReturn(run func(context.Context, string, bool, chan *client.Response)) *mockEtcdClient_Watch_Call {
+func (_c *mockEtcdClient_Watch_Call) RunAndReturn(run func(context.Context, string, bool, uint64, chan<- *client.Response) error) *mockEtcdClient_Watch_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,190 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/pkg/errors"
+)
+
+// MaskedValue replaces the values of masked keys in a NodeDiff.
+const MaskedValue = "********"
+
+// NodeChangeType describes how a key differs between two nodes.
+type NodeChangeType string
+
+const (
+	// NodeChangeAdded is used for keys which only exist in the new node.
+	NodeChangeAdded NodeChangeType = "added"
+	// NodeChangeRemoved is used for keys which only exist in the old node.
+	NodeChangeRemoved NodeChangeType = "removed"
+	// NodeChangeChanged is used for keys which have different values in both nodes.
+	NodeChangeChanged NodeChangeType = "changed"
+)
+
+// NodeDiffFormat is the output format of a rendered NodeDiff.
+type NodeDiffFormat string
+
+const (
+	// NodeDiffFormatText renders a NodeDiff with one line per change.
+	NodeDiffFormatText NodeDiffFormat = "text"
+	// NodeDiffFormatJSON renders a NodeDiff as JSON array.
+	NodeDiffFormatJSON NodeDiffFormat = "json"
+)
+
+// NodeChange is a single difference between two nodes.
+type NodeChange struct {
+	// Key is the full path of the changed key.
+	Key string `json:"key"`
+	// Type describes how the key differs.
+	Type NodeChangeType `json:"type"`
+	// OldValue is the value of the key in the old node. It is empty for added keys.
+	OldValue string `json:"oldValue,omitempty"`
+	// NewValue is the value of the key in the new node. It is empty for removed keys.
+	NewValue string `json:"newValue,omitempty"`
+	// IsDir is true if the changed key is an empty directory.
+	IsDir bool `json:"dir,omitempty"`
+}
+
+// NodeDiff contains the differences between two nodes ordered by their key.
+type NodeDiff []NodeChange
+
+// NodeDiffOptions configure the comparison of two nodes.
+type NodeDiffOptions struct {
+	// MaskedKeys contains full keys whose values, and the values of all keys below them, are replaced by MaskedValue,
+	// e.g. the keys of encrypted configuration fields.
+	MaskedKeys []string
+}
+
+// DiffNodes compares the keys of two nodes, e.g. of a snapshot and the live registry, and returns the added,
+// removed and changed keys. Directories are only reported if they are empty, because all other directories are
+// covered by the changes of their keys.
+func DiffNodes(oldNode Node, newNode Node, options NodeDiffOptions) NodeDiff {
+	oldEntries := map[string]Node{}
+	collectDiffEntries(oldNode, oldEntries)
+	newEntries := map[string]Node{}
+	collectDiffEntries(newNode, newEntries)
+
+	diff := NodeDiff{}
+	for key, oldEntry := range oldEntries {
+		newEntry, ok := newEntries[key]
+		switch {
+		case !ok:
+			diff = append(diff, NodeChange{Key: key, Type: NodeChangeRemoved, OldValue: oldEntry.Value, IsDir: oldEntry.IsDir})
+		case oldEntry.IsDir != newEntry.IsDir || oldEntry.Value != newEntry.Value:
+			diff = append(diff, NodeChange{Key: key, Type: NodeChangeChanged, OldValue: oldEntry.Value, NewValue: newEntry.Value, IsDir: newEntry.IsDir})
+		}
+	}
+	for key, newEntry := range newEntries {
+		if _, ok := oldEntries[key]; !ok {
+			diff = append(diff, NodeChange{Key: key, Type: NodeChangeAdded, NewValue: newEntry.Value, IsDir: newEntry.IsDir})
+		}
+	}
+
+	for i := range diff {
+		if isMaskedKey(diff[i].Key, options.MaskedKeys) {
+			diff[i].OldValue = maskValue(diff[i].OldValue)
+			diff[i].NewValue = maskValue(diff[i].NewValue)
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Key < diff[j].Key
+	})
+
+	return diff
+}
+
+func collectDiffEntries(node Node, entries map[string]Node) {
+	if node.IsDir && node.HasSubNodes() {
+		for _, subNode := range node.SubNodes {
+			collectDiffEntries(subNode, entries)
+		}
+		return
+	}
+
+	// the root node always exists
+	if node.FullKey != "" {
+		entries[node.FullKey] = node
+	}
+}
+
+func isMaskedKey(key string, maskedKeys []string) bool {
+	for _, maskedKey := range maskedKeys {
+		if key == maskedKey || strings.HasPrefix(key, maskedKey+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func maskValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return MaskedValue
+}
+
+// EncryptedConfigurationKeys returns the full registry keys of the encrypted configuration fields of the given dogus.
+// The keys can be used as masked keys of DiffNodes.
+func EncryptedConfigurationKeys(dogus ...*core.Dogu) []string {
+	var keys []string
+	for _, dogu := range dogus {
+		for _, field := range dogu.Configuration {
+			if !field.Encrypted {
+				continue
+			}
+
+			if field.Global {
+				keys = append(keys, "/config/"+DirectoryGlobal+"/"+field.Name)
+			} else {
+				keys = append(keys, "/config/"+dogu.GetSimpleName()+"/"+field.Name)
+			}
+		}
+	}
+	return keys
+}
+
+// WriteNodeDiff renders the differences in the given format to the writer.
+func WriteNodeDiff(writer io.Writer, diff NodeDiff, format NodeDiffFormat) error {
+	switch format {
+	case NodeDiffFormatText:
+		for _, change := range diff {
+			_, err := fmt.Fprintln(writer, change.String())
+			if err != nil {
+				return errors.Wrap(err, "failed to write node diff as text")
+			}
+		}
+	case NodeDiffFormatJSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		err := encoder.Encode(diff)
+		if err != nil {
+			return errors.Wrap(err, "failed to write node diff as json")
+		}
+	default:
+		return errors.Errorf("unsupported node diff format %s", format)
+	}
+
+	return nil
+}
+
+// String returns the change as a single line, e.g. "~ /config/redmine/logging/root: INFO -> DEBUG".
+func (nc NodeChange) String() string {
+	switch {
+	case nc.Type == NodeChangeAdded && nc.IsDir:
+		return fmt.Sprintf("+ %s/", nc.Key)
+	case nc.Type == NodeChangeAdded:
+		return fmt.Sprintf("+ %s: %s", nc.Key, nc.NewValue)
+	case nc.Type == NodeChangeRemoved && nc.IsDir:
+		return fmt.Sprintf("- %s/", nc.Key)
+	case nc.Type == NodeChangeRemoved:
+		return fmt.Sprintf("- %s: %s", nc.Key, nc.OldValue)
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", nc.Key, nc.OldValue, nc.NewValue)
+	}
+}
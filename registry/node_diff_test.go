@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestDiffNode(subNodes ...Node) Node {
+	return Node{IsDir: true, SubNodes: subNodes}
+}
+
+func createTestDiffDir(key string, subNodes ...Node) Node {
+	return Node{FullKey: key, IsDir: true, SubNodes: subNodes}
+}
+
+func createTestDiffKey(key string, value string) Node {
+	return Node{FullKey: key, Value: value}
+}
+
+func TestDiffNodes(t *testing.T) {
+	t.Run("should report added, removed and changed keys", func(t *testing.T) {
+		// given
+		oldNode := createTestDiffNode(createTestDiffDir("/config",
+			createTestDiffDir("/config/redmine",
+				createTestDiffKey("/config/redmine/logging", "INFO"),
+				createTestDiffKey("/config/redmine/url", "https://example.com"),
+				createTestDiffKey("/config/redmine/removed", "old"),
+			),
+		))
+		newNode := createTestDiffNode(createTestDiffDir("/config",
+			createTestDiffDir("/config/redmine",
+				createTestDiffKey("/config/redmine/logging", "DEBUG"),
+				createTestDiffKey("/config/redmine/url", "https://example.com"),
+				createTestDiffKey("/config/redmine/added", "new"),
+				createTestDiffDir("/config/redmine/empty"),
+			),
+		))
+
+		// when
+		actual := DiffNodes(oldNode, newNode, NodeDiffOptions{})
+
+		// then
+		assert.Equal(t, NodeDiff{
+			{Key: "/config/redmine/added", Type: NodeChangeAdded, NewValue: "new"},
+			{Key: "/config/redmine/empty", Type: NodeChangeAdded, IsDir: true},
+			{Key: "/config/redmine/logging", Type: NodeChangeChanged, OldValue: "INFO", NewValue: "DEBUG"},
+			{Key: "/config/redmine/removed", Type: NodeChangeRemoved, OldValue: "old"},
+		}, actual)
+	})
+
+	t.Run("should report keys which became directories", func(t *testing.T) {
+		// given
+		oldNode := createTestDiffNode(createTestDiffKey("/state", ""))
+		newNode := createTestDiffNode(createTestDiffDir("/state"))
+
+		// when
+		actual := DiffNodes(oldNode, newNode, NodeDiffOptions{})
+
+		// then
+		assert.Equal(t, NodeDiff{{Key: "/state", Type: NodeChangeChanged, IsDir: true}}, actual)
+	})
+
+	t.Run("should return empty diff for equal nodes", func(t *testing.T) {
+		node := createTestDiffNode(createTestDiffKey("/state", "ready"))
+
+		actual := DiffNodes(node, node, NodeDiffOptions{})
+
+		assert.Empty(t, actual)
+		assert.NotNil(t, actual)
+	})
+
+	t.Run("should mask values of masked keys and keys below them", func(t *testing.T) {
+		// given
+		oldNode := createTestDiffNode(createTestDiffDir("/config",
+			createTestDiffDir("/config/redmine",
+				createTestDiffKey("/config/redmine/password", "secret1"),
+				createTestDiffKey("/config/redmine/sa-postgresql/password", "secret2"),
+				createTestDiffKey("/config/redmine/passwords", "visible"),
+			),
+		))
+		newNode := createTestDiffNode(createTestDiffDir("/config",
+			createTestDiffDir("/config/redmine",
+				createTestDiffKey("/config/redmine/password", "changed1"),
+				createTestDiffKey("/config/redmine/passwords", "changed"),
+			),
+		))
+
+		// when
+		actual := DiffNodes(oldNode, newNode, NodeDiffOptions{
+			MaskedKeys: []string{"/config/redmine/password", "/config/redmine/sa-postgresql"},
+		})
+
+		// then
+		assert.Equal(t, NodeDiff{
+			{Key: "/config/redmine/password", Type: NodeChangeChanged, OldValue: MaskedValue, NewValue: MaskedValue},
+			{Key: "/config/redmine/passwords", Type: NodeChangeChanged, OldValue: "visible", NewValue: "changed"},
+			{Key: "/config/redmine/sa-postgresql/password", Type: NodeChangeRemoved, OldValue: MaskedValue},
+		}, actual)
+	})
+}
+
+func TestEncryptedConfigurationKeys(t *testing.T) {
+	// given
+	dogu := &core.Dogu{
+		Name: "official/redmine",
+		Configuration: []core.ConfigurationField{
+			{Name: "logging/root"},
+			{Name: "smtp/password", Encrypted: true},
+			{Name: "license", Encrypted: true, Global: true},
+		},
+	}
+
+	// when
+	actual := EncryptedConfigurationKeys(dogu)
+
+	// then
+	assert.Equal(t, []string{"/config/redmine/smtp/password", "/config/_global/license"}, actual)
+}
+
+func TestWriteNodeDiff(t *testing.T) {
+	diff := NodeDiff{
+		{Key: "/config/redmine/added", Type: NodeChangeAdded, NewValue: "new"},
+		{Key: "/config/redmine/empty", Type: NodeChangeAdded, IsDir: true},
+		{Key: "/config/redmine/logging", Type: NodeChangeChanged, OldValue: "INFO", NewValue: "DEBUG"},
+		{Key: "/config/redmine/removed", Type: NodeChangeRemoved, OldValue: "old"},
+	}
+
+	t.Run("should render text", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+
+		err := WriteNodeDiff(buffer, diff, NodeDiffFormatText)
+
+		require.NoError(t, err)
+		assert.Equal(t, "+ /config/redmine/added: new\n"+
+			"+ /config/redmine/empty/\n"+
+			"~ /config/redmine/logging: INFO -> DEBUG\n"+
+			"- /config/redmine/removed: old\n", buffer.String())
+	})
+
+	t.Run("should render json", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+
+		err := WriteNodeDiff(buffer, diff[2:3], NodeDiffFormatJSON)
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"key": "/config/redmine/logging", "type": "changed", "oldValue": "INFO", "newValue": "DEBUG"}]`, buffer.String())
+	})
+
+	t.Run("should fail for unsupported format", func(t *testing.T) {
+		err := WriteNodeDiff(&bytes.Buffer{}, diff, "html")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported node diff format html")
+	})
+}
@@ -0,0 +1,128 @@
+package registry
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SkipNode can be returned by a NodeVisitor to skip the sub nodes of the visited node. It is not returned by Walk.
+var SkipNode = errors.New("skip this node")
+
+// NodeVisitor is called by Walk for every visited node. Returning SkipNode skips the sub nodes of a directory, every
+// other error stops the walk.
+type NodeVisitor func(node Node) error
+
+// NodeMatcher decides whether a node is kept by Filter.
+type NodeMatcher func(node Node) bool
+
+// Find returns the node with the given key, which is either a full key, e.g. "/config/redmine/logging/root", or a
+// key relative to the current node, e.g. "logging/root". The second return value is false if the node does not
+// exist.
+func (drn Node) Find(key string) (Node, bool) {
+	target := normalizeKey(key)
+	if !strings.HasPrefix(key, "/") {
+		target = normalizeKey(drn.FullKey + "/" + key)
+	}
+
+	current := drn
+	for normalizeKey(current.FullKey) != target {
+		found := false
+		for _, subNode := range current.SubNodes {
+			subKey := normalizeKey(subNode.FullKey)
+			if target == subKey || strings.HasPrefix(target, subKey+"/") {
+				current = subNode
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return Node{}, false
+		}
+	}
+
+	return current, true
+}
+
+// Walk visits the current node and all its sub nodes depth-first, parents before their children. The sub nodes are
+// visited in the order of SubNodes.
+func (drn Node) Walk(visitor NodeVisitor) error {
+	err := drn.walk(visitor)
+	if errors.Is(err, SkipNode) {
+		return nil
+	}
+	return err
+}
+
+func (drn Node) walk(visitor NodeVisitor) error {
+	err := visitor(drn)
+	if err != nil {
+		return err
+	}
+
+	for _, subNode := range drn.SubNodes {
+		err = subNode.walk(visitor)
+		if err != nil && !errors.Is(err, SkipNode) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Flatten returns the values of all keys below the current node by their full key. Directories are not contained.
+func (drn Node) Flatten() map[string]string {
+	result := map[string]string{}
+	_ = drn.Walk(func(node Node) error {
+		if !node.IsDir && node.FullKey != "" {
+			result[node.FullKey] = node.Value
+		}
+		return nil
+	})
+	return result
+}
+
+// Filter returns a copy of the current node which only contains the sub nodes that match, including all their
+// children, and the directories on the path to them. The current node is always returned, even if nothing matches.
+func (drn Node) Filter(matcher NodeMatcher) Node {
+	result := drn
+	result.SubNodes = []Node{}
+
+	for _, subNode := range drn.SubNodes {
+		if matcher(subNode) {
+			result.SubNodes = append(result.SubNodes, subNode)
+			continue
+		}
+
+		filtered := subNode.Filter(matcher)
+		if filtered.HasSubNodes() {
+			result.SubNodes = append(result.SubNodes, filtered)
+		}
+	}
+
+	return result
+}
+
+// MatchKeyPrefix returns a NodeMatcher for nodes whose full key starts with the given prefix, e.g. "/config/red"
+// matches "/config/redmine" and all keys below it.
+func MatchKeyPrefix(prefix string) NodeMatcher {
+	return func(node Node) bool {
+		return strings.HasPrefix(node.FullKey, prefix)
+	}
+}
+
+// MatchKeyGlob returns a NodeMatcher for nodes whose full key matches the given pattern. The pattern has the syntax
+// of path.Match, so a "*" does not match a "/", e.g. "/config/*/logging/root" matches the log level of every dogu.
+func MatchKeyGlob(pattern string) (NodeMatcher, error) {
+	_, err := path.Match(pattern, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid key pattern %s", pattern)
+	}
+
+	return func(node Node) bool {
+		matches, _ := path.Match(pattern, node.FullKey)
+		return matches
+	}, nil
+}
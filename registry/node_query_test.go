@@ -0,0 +1,190 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestQueryNode() Node {
+	return createTestDiffNode(
+		createTestDiffDir("/config",
+			createTestDiffDir("/config/redmine",
+				createTestDiffDir("/config/redmine/logging",
+					createTestDiffKey("/config/redmine/logging/root", "INFO"),
+				),
+				createTestDiffKey("/config/redmine/url", "https://example.com"),
+			),
+			createTestDiffDir("/config/cas",
+				createTestDiffDir("/config/cas/logging",
+					createTestDiffKey("/config/cas/logging/root", "DEBUG"),
+				),
+			),
+		),
+		createTestDiffDir("/state",
+			createTestDiffKey("/state/redmine", "ready"),
+		),
+	)
+}
+
+func TestNode_Find(t *testing.T) {
+	t.Run("should find node by full key", func(t *testing.T) {
+		actual, ok := createTestQueryNode().Find("/config/redmine/logging/root")
+
+		require.True(t, ok)
+		assert.Equal(t, "INFO", actual.Value)
+	})
+
+	t.Run("should find directory by full key", func(t *testing.T) {
+		actual, ok := createTestQueryNode().Find("/config/redmine/")
+
+		require.True(t, ok)
+		assert.True(t, actual.IsDir)
+		assert.Equal(t, "/config/redmine", actual.FullKey)
+	})
+
+	t.Run("should find node by key relative to the node", func(t *testing.T) {
+		// given
+		redmine, ok := createTestQueryNode().Find("/config/redmine")
+		require.True(t, ok)
+
+		// when
+		actual, ok := redmine.Find("logging/root")
+
+		// then
+		require.True(t, ok)
+		assert.Equal(t, "/config/redmine/logging/root", actual.FullKey)
+	})
+
+	t.Run("should find root node", func(t *testing.T) {
+		actual, ok := createTestQueryNode().Find("/")
+
+		require.True(t, ok)
+		assert.Equal(t, "", actual.FullKey)
+	})
+
+	t.Run("should not find missing nodes", func(t *testing.T) {
+		node := createTestQueryNode()
+
+		_, missingOk := node.Find("/config/redmine/logging/level")
+		_, prefixOk := node.Find("/config/red")
+		_, belowKeyOk := node.Find("/state/redmine/ready")
+
+		assert.False(t, missingOk)
+		assert.False(t, prefixOk)
+		assert.False(t, belowKeyOk)
+	})
+}
+
+func TestNode_Walk(t *testing.T) {
+	t.Run("should visit parents before their children", func(t *testing.T) {
+		// given
+		var visited []string
+
+		// when
+		err := createTestQueryNode().Walk(func(node Node) error {
+			visited = append(visited, node.FullKey)
+			return nil
+		})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"", "/config", "/config/redmine", "/config/redmine/logging",
+			"/config/redmine/logging/root", "/config/redmine/url", "/config/cas", "/config/cas/logging",
+			"/config/cas/logging/root", "/state", "/state/redmine"}, visited)
+	})
+
+	t.Run("should skip sub nodes", func(t *testing.T) {
+		// given
+		var visited []string
+
+		// when
+		err := createTestQueryNode().Walk(func(node Node) error {
+			visited = append(visited, node.FullKey)
+			if node.FullKey == "/config" {
+				return SkipNode
+			}
+			return nil
+		})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"", "/config", "/state", "/state/redmine"}, visited)
+	})
+
+	t.Run("should stop on error", func(t *testing.T) {
+		// given
+		var visited []string
+		expectedErr := errors.New("stop")
+
+		// when
+		err := createTestQueryNode().Walk(func(node Node) error {
+			visited = append(visited, node.FullKey)
+			if node.FullKey == "/config/redmine" {
+				return expectedErr
+			}
+			return nil
+		})
+
+		// then
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Equal(t, []string{"", "/config", "/config/redmine"}, visited)
+	})
+}
+
+func TestNode_Flatten(t *testing.T) {
+	actual := createTestQueryNode().Flatten()
+
+	assert.Equal(t, map[string]string{
+		"/config/redmine/logging/root": "INFO",
+		"/config/redmine/url":          "https://example.com",
+		"/config/cas/logging/root":     "DEBUG",
+		"/state/redmine":               "ready",
+	}, actual)
+}
+
+func TestNode_Filter(t *testing.T) {
+	t.Run("should keep matching keys and their parents", func(t *testing.T) {
+		// given
+		matcher, err := MatchKeyGlob("/config/*/logging/root")
+		require.NoError(t, err)
+
+		// when
+		actual := createTestQueryNode().Filter(matcher)
+
+		// then
+		assert.Equal(t, map[string]string{
+			"/config/redmine/logging/root": "INFO",
+			"/config/cas/logging/root":     "DEBUG",
+		}, actual.Flatten())
+		_, ok := actual.Find("/state")
+		assert.False(t, ok)
+	})
+
+	t.Run("should keep all children of matching directories", func(t *testing.T) {
+		actual := createTestQueryNode().Filter(MatchKeyPrefix("/config/red"))
+
+		assert.Equal(t, map[string]string{
+			"/config/redmine/logging/root": "INFO",
+			"/config/redmine/url":          "https://example.com",
+		}, actual.Flatten())
+	})
+
+	t.Run("should return empty root node if nothing matches", func(t *testing.T) {
+		node := createTestQueryNode()
+
+		actual := node.Filter(MatchKeyPrefix("/dogu"))
+
+		assert.Equal(t, Node{IsDir: true, SubNodes: []Node{}}, actual)
+		assert.Len(t, node.SubNodes, 2)
+	})
+
+	t.Run("should fail for invalid glob pattern", func(t *testing.T) {
+		_, err := MatchKeyGlob("/config/[")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid key pattern /config/[")
+	})
+}
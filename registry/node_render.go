@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// NodeFormat is the output format of a rendered Node.
+type NodeFormat string
+
+const (
+	// NodeFormatTree renders a Node as indented tree with one line per key or directory.
+	NodeFormatTree NodeFormat = "tree"
+	// NodeFormatJSON renders a Node as nested JSON object, in which directories are objects and keys are strings.
+	NodeFormatJSON NodeFormat = "json"
+	// NodeFormatYAML renders a Node as nested YAML mapping, in which directories are mappings and keys are strings.
+	NodeFormatYAML NodeFormat = "yaml"
+)
+
+// WriteNode renders the node and all its sub nodes in the given format to the writer. The sub nodes are ordered by
+// their key, e.g. to compare the output of GetNode in support dumps.
+func WriteNode(writer io.Writer, node Node, format NodeFormat) error {
+	switch format {
+	case NodeFormatTree:
+		err := writeNodeTree(writer, node, 0)
+		if err != nil {
+			return errors.Wrap(err, "failed to write node as tree")
+		}
+	case NodeFormatJSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		err := encoder.Encode(toNestedValue(node))
+		if err != nil {
+			return errors.Wrap(err, "failed to write node as json")
+		}
+	case NodeFormatYAML:
+		encoder := yaml.NewEncoder(writer)
+		encoder.SetIndent(2)
+		err := encoder.Encode(toNestedValue(node))
+		if err != nil {
+			return errors.Wrap(err, "failed to write node as yaml")
+		}
+		return encoder.Close()
+	default:
+		return errors.Errorf("unsupported node format %s", format)
+	}
+
+	return nil
+}
+
+func writeNodeTree(writer io.Writer, node Node, depth int) error {
+	indent := strings.Repeat("  ", depth)
+
+	var line string
+	switch {
+	case depth == 0 && node.IsDir:
+		line = normalizeKey(node.FullKey)
+	case depth == 0:
+		line = fmt.Sprintf("%s: %s", normalizeKey(node.FullKey), formatTreeValue(node.Value))
+	case node.IsDir:
+		line = fmt.Sprintf("%s%s/", indent, node.Key())
+	default:
+		line = fmt.Sprintf("%s%s: %s", indent, node.Key(), formatTreeValue(node.Value))
+	}
+
+	_, err := fmt.Fprintln(writer, line)
+	if err != nil {
+		return err
+	}
+
+	for _, subNode := range sortedSubNodes(node) {
+		err = writeNodeTree(writer, subNode, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatTreeValue quotes values which would break the lines of the tree, e.g. certificates.
+func formatTreeValue(value string) string {
+	if strings.ContainsAny(value, "\r\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func sortedSubNodes(node Node) []Node {
+	subNodes := make([]Node, len(node.SubNodes))
+	copy(subNodes, node.SubNodes)
+	sort.SliceStable(subNodes, func(i, j int) bool {
+		return subNodes[i].Key() < subNodes[j].Key()
+	})
+	return subNodes
+}
+
+// toNestedValue converts directories to maps of their sub nodes by key and keys to their value.
+func toNestedValue(node Node) interface{} {
+	if !node.IsDir {
+		return node.Value
+	}
+
+	result := map[string]interface{}{}
+	for _, subNode := range node.SubNodes {
+		result[subNode.Key()] = toNestedValue(subNode)
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteNode(t *testing.T) {
+	node := createTestDiffNode(
+		createTestDiffDir("/state",
+			createTestDiffKey("/state/redmine", "ready"),
+		),
+		createTestDiffDir("/config",
+			createTestDiffDir("/config/redmine",
+				createTestDiffKey("/config/redmine/url", "https://example.com"),
+				createTestDiffKey("/config/redmine/certificate", "line1\nline2"),
+				createTestDiffDir("/config/redmine/empty"),
+			),
+		),
+	)
+
+	t.Run("should write node as tree", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+
+		err := WriteNode(buffer, node, NodeFormatTree)
+
+		require.NoError(t, err)
+		assert.Equal(t, "/\n"+
+			"  config/\n"+
+			"    redmine/\n"+
+			"      certificate: \"line1\\nline2\"\n"+
+			"      empty/\n"+
+			"      url: https://example.com\n"+
+			"  state/\n"+
+			"    redmine: ready\n", buffer.String())
+	})
+
+	t.Run("should write sub node as tree", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		state, ok := node.Find("/state")
+		require.True(t, ok)
+
+		err := WriteNode(buffer, state, NodeFormatTree)
+
+		require.NoError(t, err)
+		assert.Equal(t, "/state\n  redmine: ready\n", buffer.String())
+	})
+
+	t.Run("should write node as json", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+
+		err := WriteNode(buffer, node, NodeFormatJSON)
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"config": {"redmine": {"certificate": "line1\nline2", "empty": {}, "url": "https://example.com"}},
+			"state": {"redmine": "ready"}
+		}`, buffer.String())
+	})
+
+	t.Run("should write node as yaml", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+
+		err := WriteNode(buffer, node, NodeFormatYAML)
+
+		require.NoError(t, err)
+		assert.YAMLEq(t, `
+config:
+  redmine:
+    certificate: "line1\nline2"
+    empty: {}
+    url: https://example.com
+state:
+  redmine: ready
+`, buffer.String())
+	})
+
+	t.Run("should fail for unsupported format", func(t *testing.T) {
+		err := WriteNode(&bytes.Buffer{}, node, "xml")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported node format xml")
+	})
+}
@@ -1,15 +1,28 @@
 package registry
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"strings"
+
 	"github.com/cloudogu/cesapp-lib/core"
 	"go.etcd.io/etcd/client/v2"
 	"os"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 type etcdRegistry struct {
-	client *resilentEtcdClient
+	client etcdClient
+	// reconnectBackoff contains the delays between the reconnects of an interrupted watch
+	reconnectBackoff []time.Duration
+	// reconnectJitter randomizes the delays between the reconnects
+	reconnectJitter float64
+	// metrics records the reconnects of watches if it is not nil
+	metrics core.MetricsRecorder
 }
 
 func newEtcdRegistry(configuration core.Registry) (*etcdRegistry, error) {
@@ -17,14 +30,17 @@ func newEtcdRegistry(configuration core.Registry) (*etcdRegistry, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &etcdRegistry{etcdClient}, nil
-}
 
-func createEtcdClient(configuration core.Registry) (*resilentEtcdClient, error) {
-	if configuration.Type != "etcd" {
-		return nil, errors.New("currently only etcd registries are supported")
+	reconnectBackoff, err := core.GetBackoff(configuration.RetryPolicy)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create reconnect backoff of watches")
 	}
 
+	return &etcdRegistry{client: etcdClient, reconnectBackoff: reconnectBackoff,
+		reconnectJitter: core.GetJitter(configuration.RetryPolicy), metrics: configuration.Metrics}, nil
+}
+
+func createEtcdClient(configuration core.Registry) (etcdClient, error) {
 	var endpoints []string
 	endpoint := os.Getenv("REGISTRY_ENDPOINT")
 	if len(endpoint) > 0 {
@@ -33,7 +49,56 @@ func createEtcdClient(configuration core.Registry) (*resilentEtcdClient, error)
 		endpoints = configuration.Endpoints
 	}
 
-	return newResilientEtcdClient(endpoints, configuration.RetryPolicy)
+	switch configuration.Type {
+	case TypeEtcd:
+		return newResilientEtcdClient(endpoints, configuration)
+	case TypeEtcdV3:
+		return newResilientEtcdV3Client(endpoints, configuration)
+	default:
+		return nil, errors.Errorf("currently only etcd registries are supported, %s was provided", configuration.Type)
+	}
+}
+
+// createEtcdTLSConfig creates the TLS configuration of the connection to etcd. It returns nil if the connection is not
+// encrypted.
+func createEtcdTLSConfig(endpoints []string, configuration core.RegistryTLS) (*tls.Config, error) {
+	usesHTTPS := false
+	for _, endpoint := range endpoints {
+		if strings.HasPrefix(endpoint, "https://") {
+			usesHTTPS = true
+		}
+	}
+	if !usesHTTPS && !configuration.IsConfigured() {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         configuration.ServerName,
+		InsecureSkipVerify: configuration.InsecureSkipVerify,
+	}
+
+	if configuration.CAFile != "" {
+		caCertificates, err := os.ReadFile(configuration.CAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read certificate authority %s of registry", configuration.CAFile)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCertificates) {
+			return nil, errors.Errorf("failed to parse certificate authority %s of registry", configuration.CAFile)
+		}
+	}
+
+	if configuration.CertFile != "" || configuration.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(configuration.CertFile, configuration.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load client certificate of registry")
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
 }
 
 // GlobalConfig returns a ConfigurationContext for the global context.
@@ -53,7 +118,7 @@ func (er *etcdRegistry) DoguConfig(dogu string) ConfigurationContext {
 
 // State returns the state object for the given dogu.
 func (er *etcdRegistry) State(dogu string) State {
-	return &etcdState{"/state/" + dogu, er.client}
+	return &etcdState{path: "/state/" + dogu, historyPath: "/state/_history/" + dogu, client: er.client}
 }
 
 // DoguRegistry returns an object which is able to manage dogus.
@@ -68,18 +133,32 @@ func (er *etcdRegistry) BlueprintRegistry() ConfigurationContext {
 
 // RootConfig returns a ConfigurationContext for the root context
 func (er *etcdRegistry) RootConfig() WatchConfigurationContext {
-	return &etcdWatchConfigurationContext{er.client}
+	return &etcdWatchConfigurationContext{client: er.client, reconnectBackoff: er.reconnectBackoff,
+		reconnectJitter: er.reconnectJitter, metrics: er.metrics}
 }
 
 // GetNode returns a ConfigurationContext for the root context
 func (er *etcdRegistry) GetNode() (Node, error) {
-	mainNode, err := er.client.getMainNode()
+	return er.GetNodeContext(context.Background())
+}
+
+// GetNodeContext returns all keys of the registry packed as Node
+func (er *etcdRegistry) GetNodeContext(ctx context.Context) (Node, error) {
+	mainNode, err := er.client.GetMainNode(ctx)
 	if err != nil {
 		return Node{}, err
 	}
 	return mapEtcdNodeToRegistryNode(mainNode), nil
 }
 
+// Close releases the connection to the backend of the registry if the backend holds one, e.g. etcd with the v3 api.
+func (er *etcdRegistry) Close() error {
+	if closer, ok := er.client.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 func mapEtcdNodeToRegistryNode(node *client.Node) Node {
 	result := Node{
 		SubNodes: []Node{},
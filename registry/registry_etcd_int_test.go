@@ -4,6 +4,7 @@
 package registry
 
 import (
+	"context"
 	"github.com/cloudogu/cesapp-lib/core"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,23 +27,23 @@ func Test_mapEtcdNodeToRegistryNode_inttest(t *testing.T) {
 	})
 
 	defer func() {
-		_ = client.DeleteRecursive("/dir_test")
-		_ = client.DeleteRecursive("/config/_global")
+		_ = client.DeleteRecursive(context.Background(), "/dir_test")
+		_ = client.DeleteRecursive(context.Background(), "/config/_global")
 	}()
 
-	_, err = client.Set("/dir_test/key1/subkey1", "val1", nil)
+	_, err = client.Set(context.Background(), "/dir_test/key1/subkey1", "val1", nil)
 	require.Nil(t, err)
 
-	_, err = client.Set("/dir_test/key1/subkey2", "val2", nil)
+	_, err = client.Set(context.Background(), "/dir_test/key1/subkey2", "val2", nil)
 	require.Nil(t, err)
 
-	_, err = client.Set("/dir_test/key2", "val3", nil)
+	_, err = client.Set(context.Background(), "/dir_test/key2", "val3", nil)
 	require.Nil(t, err)
 
-	_, err = client.Set("/config/_global/key", "val4", nil)
+	_, err = client.Set(context.Background(), "/config/_global/key", "val4", nil)
 	require.Nil(t, err)
 
-	node, err := client.getMainNode()
+	node, err := client.GetMainNode(context.Background())
 	require.NoError(t, err)
 
 	result := mapEtcdNodeToRegistryNode(node)
@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate writes a self-signed certificate and its private key as PEM files and returns their paths.
+func writeTestCertificate(t *testing.T) (certFile string, keyFile string) {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "registry-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(t, err)
+	key, err := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(t, err)
+
+	directory := t.TempDir()
+	certFile = filepath.Join(directory, "client.crt")
+	keyFile = filepath.Join(directory, "client.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key}), 0600))
+
+	return certFile, keyFile
+}
+
+func Test_createEtcdTLSConfig(t *testing.T) {
+	t.Run("should not encrypt connection without tls options and https endpoints", func(t *testing.T) {
+		actual, err := createEtcdTLSConfig([]string{"http://localhost:4001"}, core.RegistryTLS{})
+
+		require.NoError(t, err)
+		assert.Nil(t, actual)
+	})
+
+	t.Run("should use certificate authorities of the system for https endpoints", func(t *testing.T) {
+		actual, err := createEtcdTLSConfig([]string{"http://localhost:4001", "https://localhost:4002"}, core.RegistryTLS{})
+
+		require.NoError(t, err)
+		require.NotNil(t, actual)
+		assert.Nil(t, actual.RootCAs)
+		assert.Empty(t, actual.Certificates)
+		assert.Equal(t, uint16(tls.VersionTLS12), actual.MinVersion)
+	})
+
+	t.Run("should load certificate authority and client certificate", func(t *testing.T) {
+		// given
+		certFile, keyFile := writeTestCertificate(t)
+		configuration := core.RegistryTLS{CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: "etcd.ces.local"}
+
+		// when
+		actual, err := createEtcdTLSConfig([]string{"localhost:2379"}, configuration)
+
+		// then
+		require.NoError(t, err)
+		require.NotNil(t, actual)
+		assert.NotNil(t, actual.RootCAs)
+		assert.Len(t, actual.Certificates, 1)
+		assert.Equal(t, "etcd.ces.local", actual.ServerName)
+		assert.False(t, actual.InsecureSkipVerify)
+	})
+
+	t.Run("should fail for missing or invalid certificate authority", func(t *testing.T) {
+		// given
+		_, keyFile := writeTestCertificate(t)
+
+		// when
+		_, errMissing := createEtcdTLSConfig(nil, core.RegistryTLS{CAFile: filepath.Join(t.TempDir(), "missing.crt")})
+		_, errInvalid := createEtcdTLSConfig(nil, core.RegistryTLS{CAFile: keyFile})
+
+		// then
+		assert.ErrorContains(t, errMissing, "failed to read certificate authority")
+		assert.ErrorContains(t, errInvalid, "failed to parse certificate authority")
+	})
+
+	t.Run("should fail for incomplete client certificate", func(t *testing.T) {
+		certFile, _ := writeTestCertificate(t)
+
+		_, err := createEtcdTLSConfig(nil, core.RegistryTLS{CertFile: certFile})
+
+		assert.ErrorContains(t, err, "failed to load client certificate of registry")
+	})
+}
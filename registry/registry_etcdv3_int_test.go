@@ -0,0 +1,157 @@
+//go:build integration
+// +build integration
+
+package registry_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/cloudogu/cesapp-lib/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/client/v2"
+)
+
+func createEtcdV3TestRegistry(t *testing.T) registry.Registry {
+	t.Helper()
+
+	v3reg, err := registry.New(core.Registry{
+		Type:      registry.TypeEtcdV3,
+		Endpoints: createTestEndpoint(),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, v3reg.Close())
+	})
+
+	return v3reg
+}
+
+func TestEtcdV3GlobalConfig_inttest(t *testing.T) {
+	testConfigurationContext(t, createEtcdV3TestRegistry(t).GlobalConfig())
+}
+
+func TestEtcdV3DoguConfig_inttest(t *testing.T) {
+	v3reg := createEtcdV3TestRegistry(t)
+	cc := v3reg.DoguConfig("unit-test-v3")
+	defer func() { _ = cc.RemoveAll() }()
+
+	require.NoError(t, cc.Set("key-1", "value-1"))
+	require.NoError(t, cc.Set("keys/2", "value-2"))
+
+	keyValuePairs, err := cc.GetAll()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key-1": "value-1", "keys/2": "value-2"}, keyValuePairs)
+
+	err = cc.Delete("keys")
+	require.Error(t, err)
+
+	exists, err := cc.Exists("keys")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, cc.DeleteRecursive("keys"))
+	_, err = cc.Get("keys/2")
+	assert.True(t, registry.IsKeyNotFoundError(err))
+}
+
+func TestEtcdV3CompareAndSwap_inttest(t *testing.T) {
+	testCompareAndSwap(t, createEtcdV3TestRegistry(t).DoguConfig("unit-test-v3-cas"))
+}
+
+func TestEtcdV3ApplyBatch_inttest(t *testing.T) {
+	testApplyBatch(t, createEtcdV3TestRegistry(t).DoguConfig("unit-test-v3-batch"))
+}
+
+func TestEtcdV3SetWithLifetime_inttest(t *testing.T) {
+	cc := createEtcdV3TestRegistry(t).DoguConfig("unit-test-v3-ttl")
+	defer func() { _ = cc.RemoveAll() }()
+
+	require.NoError(t, cc.SetWithLifetime("key", "value", 60))
+	// replacing the lease of the key must not remove the key with the revoked lease
+	require.NoError(t, cc.SetWithLifetime("key", "value", 2))
+	require.NoError(t, cc.Refresh("key", 2))
+
+	value, err := cc.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	time.Sleep(4 * time.Second)
+
+	exists, err := cc.Exists("key")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestEtcdV3DoguRegistry_inttest(t *testing.T) {
+	v3reg := createEtcdV3TestRegistry(t)
+	dogu := &core.Dogu{Name: "official/unit-test-v3", Version: "1.0.0-1"}
+	defer func() {
+		_ = v3reg.DoguRegistry().Unregister("unit-test-v3")
+	}()
+
+	require.NoError(t, v3reg.DoguRegistry().Register(dogu))
+	require.NoError(t, v3reg.DoguRegistry().Enable(dogu))
+
+	actual, err := v3reg.DoguRegistry().Get("unit-test-v3")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0-1", actual.Version)
+
+	version, err := v3reg.RootConfig().Get("/dogu_v2/unit-test-v3/current")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0-1", version)
+}
+
+func TestEtcdV3Watch_inttest(t *testing.T) {
+	v3reg := createEtcdV3TestRegistry(t)
+	cc := v3reg.DoguConfig("unit-test-v3-watch")
+	defer func() { _ = cc.RemoveAll() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan *client.Response)
+	go v3reg.RootConfig().Watch(ctx, "/config/unit-test-v3-watch", true, events)
+	time.Sleep(time.Second)
+
+	require.NoError(t, cc.Set("key", "value"))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "/config/unit-test-v3-watch/key", event.Node.Key)
+		assert.Equal(t, "value", event.Node.Value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected watch event")
+	}
+}
+
+func TestEtcdV3WatchEvents_inttest(t *testing.T) {
+	v3reg := createEtcdV3TestRegistry(t)
+	cc := v3reg.DoguConfig("unit-test-v3-watch-events")
+	defer func() { _ = cc.RemoveAll() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := v3reg.RootConfig().WatchEvents(ctx, "/config/unit-test-v3-watch-events", true)
+	time.Sleep(time.Second)
+
+	require.NoError(t, cc.Set("key", "value"))
+	require.NoError(t, cc.Set("key", "changed"))
+	require.NoError(t, cc.Delete("key"))
+
+	var actions []registry.WatchAction
+	for len(actions) < 3 {
+		select {
+		case event := <-events:
+			assert.Equal(t, "/config/unit-test-v3-watch-events/key", event.Key)
+			actions = append(actions, event.Action)
+		case err := <-errs:
+			t.Fatalf("unexpected watch error: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected watch event")
+		}
+	}
+	assert.Equal(t, []registry.WatchAction{registry.WatchActionCreate, registry.WatchActionUpdate, registry.WatchActionDelete}, actions)
+}
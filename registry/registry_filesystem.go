@@ -0,0 +1,15 @@
+package registry
+
+// NewFilesystemRegistry creates a new registry which stores all keys in the given directory. Every key is a file which
+// contains the value of the key and every directory of the registry is a directory, so that the keys can be inspected
+// and edited with the usual tools. The registry behaves like an etcd based registry, including the time to live of keys
+// and watches, which notice changes of other processes with a file watch. It is meant for development machines and
+// test environments without etcd. Conditional changes like CompareAndSwap are not atomic across processes.
+func NewFilesystemRegistry(directory string) (Registry, error) {
+	fc, err := newFileClient(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	return &etcdRegistry{client: fc}, nil
+}
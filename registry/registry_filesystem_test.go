@@ -0,0 +1,272 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/client/v2"
+)
+
+func TestNewFilesystemRegistry(t *testing.T) {
+	t.Run("should store keys as files", func(t *testing.T) {
+		// given
+		directory := t.TempDir()
+		reg, err := NewFilesystemRegistry(directory)
+		require.NoError(t, err)
+		sut := reg.DoguConfig("redmine")
+
+		// when
+		err = sut.Set("logging/root", "INFO")
+
+		// then
+		require.NoError(t, err)
+		value, err := os.ReadFile(filepath.Join(directory, "config", "redmine", "logging", "root"))
+		require.NoError(t, err)
+		assert.Equal(t, "INFO", string(value))
+
+		// when
+		err = sut.DeleteRecursive("logging")
+
+		// then
+		require.NoError(t, err)
+		assert.NoDirExists(t, filepath.Join(directory, "config", "redmine", "logging"))
+	})
+
+	t.Run("should read keys changed by other processes", func(t *testing.T) {
+		// given
+		directory := t.TempDir()
+		reg, err := NewFilesystemRegistry(directory)
+		require.NoError(t, err)
+		sut := reg.GlobalConfig()
+		require.NoError(t, sut.Set("fqdn", "ces.local"))
+
+		// when
+		require.NoError(t, os.WriteFile(filepath.Join(directory, "config", "_global", "fqdn"), []byte("ces.example.com"), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(directory, "config", "_global", "domain"), []byte("example.com"), 0600))
+
+		// then
+		all, err := sut.GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"fqdn": "ces.example.com", "domain": "example.com"}, all)
+
+		// when
+		require.NoError(t, os.Remove(filepath.Join(directory, "config", "_global", "domain")))
+
+		// then
+		exists, err := sut.Exists("domain")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should keep keys for other registries on the same directory", func(t *testing.T) {
+		// given
+		directory := t.TempDir()
+		first, err := NewFilesystemRegistry(directory)
+		require.NoError(t, err)
+		require.NoError(t, first.State("ldap").Set("ready"))
+
+		// when
+		second, err := NewFilesystemRegistry(directory)
+		require.NoError(t, err)
+		state, err := second.State("ldap").Get()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "ready", state)
+	})
+
+	t.Run("should reject reserved key names", func(t *testing.T) {
+		// given
+		directory := t.TempDir()
+		reg, err := NewFilesystemRegistry(directory)
+		require.NoError(t, err)
+		sut := reg.GlobalConfig()
+
+		// when
+		errSet := sut.Set(".hidden/key", "value")
+		errBatch := sut.ApplyBatch([]ConfigurationChange{
+			{Key: "fqdn", Value: "ces.local"},
+			{Key: ".expirations.json", Value: "{}"},
+		})
+
+		// then
+		require.Error(t, errSet)
+		assert.Contains(t, errSet.Error(), "names starting with \".\" are reserved")
+		require.Error(t, errBatch)
+		exists, err := sut.Exists("fqdn")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should apply all changes of a batch", func(t *testing.T) {
+		// given
+		directory := t.TempDir()
+		reg, err := NewFilesystemRegistry(directory)
+		require.NoError(t, err)
+		sut := reg.DoguConfig("redmine")
+		require.NoError(t, sut.Set("obsolete", "value"))
+
+		// when
+		err = sut.ApplyBatch([]ConfigurationChange{
+			{Key: "logging/root", Value: "DEBUG"},
+			{Key: "obsolete", Delete: true},
+		})
+
+		// then
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(directory, "config", "redmine", "logging", "root"))
+		assert.NoFileExists(t, filepath.Join(directory, "config", "redmine", "obsolete"))
+	})
+
+	t.Run("should serialize conditional changes of registries on the same directory", func(t *testing.T) {
+		// given
+		directory := t.TempDir()
+		first, err := NewFilesystemRegistry(directory)
+		require.NoError(t, err)
+		second, err := NewFilesystemRegistry(directory)
+		require.NoError(t, err)
+		require.NoError(t, first.GlobalConfig().Set("counter", "0"))
+
+		// when
+		var group sync.WaitGroup
+		for _, reg := range []Registry{first, second} {
+			group.Add(1)
+			go func(config ConfigurationContext) {
+				defer group.Done()
+				for increments := 0; increments < 20; {
+					value, err := config.Get("counter")
+					if !assert.NoError(t, err) {
+						return
+					}
+					counter, _ := strconv.Atoi(value)
+					swapped, err := config.CompareAndSwap("counter", value, strconv.Itoa(counter+1))
+					if !assert.NoError(t, err) {
+						return
+					}
+					if swapped {
+						increments++
+					}
+				}
+			}(reg.GlobalConfig())
+		}
+		group.Wait()
+
+		// then
+		value, err := first.GlobalConfig().Get("counter")
+		require.NoError(t, err)
+		assert.Equal(t, "40", value)
+		assert.FileExists(t, filepath.Join(directory, fileLockName))
+	})
+}
+
+func TestNewFilesystemRegistry_TimeToLive(t *testing.T) {
+	t.Run("should expire keys after their lifetime", func(t *testing.T) {
+		// given
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		directory := t.TempDir()
+		fc, err := newFileClient(directory)
+		require.NoError(t, err)
+		fc.memory.now = func() time.Time { return now }
+		sut := (&etcdRegistry{client: fc}).GlobalConfig()
+
+		require.NoError(t, sut.SetWithLifetime("lock", "me", 60))
+		assert.FileExists(t, filepath.Join(directory, fileExpirationsName))
+
+		// when
+		now = now.Add(59 * time.Second)
+		existsBefore, err := sut.Exists("lock")
+		require.NoError(t, err)
+		now = now.Add(time.Second)
+		existsAfter, err := sut.Exists("lock")
+		require.NoError(t, err)
+
+		// then
+		assert.True(t, existsBefore)
+		assert.False(t, existsAfter)
+		assert.NoFileExists(t, filepath.Join(directory, "config", "_global", "lock"))
+		assert.NoFileExists(t, filepath.Join(directory, fileExpirationsName))
+	})
+
+	t.Run("should keep lifetime for other registries on the same directory", func(t *testing.T) {
+		// given
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		directory := t.TempDir()
+		first, err := newFileClient(directory)
+		require.NoError(t, err)
+		first.memory.now = func() time.Time { return now }
+		require.NoError(t, (&etcdRegistry{client: first}).GlobalConfig().SetWithLifetime("lock", "me", 60))
+
+		// when
+		second, err := newFileClient(directory)
+		require.NoError(t, err)
+		second.memory.now = func() time.Time { return now.Add(time.Minute) }
+		exists, err := (&etcdRegistry{client: second}).GlobalConfig().Exists("lock")
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should keep refreshed lifetime for other registries on the same directory", func(t *testing.T) {
+		// given
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		directory := t.TempDir()
+		first, err := newFileClient(directory)
+		require.NoError(t, err)
+		first.memory.now = func() time.Time { return now }
+		require.NoError(t, (&etcdRegistry{client: first}).GlobalConfig().SetWithLifetime("lock", "me", 60))
+		now = now.Add(50 * time.Second)
+		require.NoError(t, (&etcdRegistry{client: first}).GlobalConfig().Refresh("lock", 60))
+
+		// when
+		second, err := newFileClient(directory)
+		require.NoError(t, err)
+		second.memory.now = func() time.Time { return now.Add(time.Minute - time.Second) }
+		exists, err := (&etcdRegistry{client: second}).GlobalConfig().Exists("lock")
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+}
+
+func TestNewFilesystemRegistry_Watch(t *testing.T) {
+	t.Run("should notice keys changed by other processes", func(t *testing.T) {
+		// given
+		directory := t.TempDir()
+		reg, err := NewFilesystemRegistry(directory)
+		require.NoError(t, err)
+		require.NoError(t, reg.DoguConfig("redmine").Set("logging/root", "INFO"))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := make(chan *client.Response, 10)
+		go reg.RootConfig().Watch(ctx, "/config/redmine", true, events)
+		// give the watcher time to subscribe
+		time.Sleep(50 * time.Millisecond)
+
+		// when
+		otherProcess, err := NewFilesystemRegistry(directory)
+		require.NoError(t, err)
+		err = otherProcess.DoguConfig("redmine").Set("logging/root", "DEBUG")
+		require.NoError(t, err)
+
+		// then
+		select {
+		case event := <-events:
+			assert.Equal(t, "set", event.Action)
+			assert.Equal(t, "/config/redmine/logging/root", event.Node.Key)
+			assert.Equal(t, "DEBUG", event.Node.Value)
+			require.NotNil(t, event.PrevNode)
+			assert.Equal(t, "INFO", event.PrevNode.Value)
+		case <-time.After(5 * time.Second):
+			t.Fatal("watch did not notice the changed key")
+		}
+	})
+}
@@ -1,6 +1,8 @@
 package registry
 
 import (
+	"context"
+
 	"github.com/cloudogu/cesapp-lib/core"
 	"github.com/pkg/errors"
 )
@@ -14,6 +16,18 @@ const (
 	KeyDoguPublicKey = "public.pem"
 )
 
+const (
+	// TypeEtcd selects the registry which is backed by an etcd using the v2 API.
+	TypeEtcd = "etcd"
+	// TypeEtcdV3 selects the registry which is backed by an etcd using the v3 API. It uses the same key layout as
+	// the v2 API.
+	TypeEtcdV3 = "etcdv3"
+	// TypeMemory selects the registry which keeps all keys in memory.
+	TypeMemory = "memory"
+	// TypeFilesystem selects the registry which stores all keys in the directory of the first endpoint.
+	TypeFilesystem = "filesystem"
+)
+
 // Registry represents the main registry of a cloudogu ecosystem. The registry
 // manage dogus, their configuration and their states.
 type Registry interface {
@@ -25,6 +39,8 @@ type Registry interface {
 	DoguConfig(dogu string) ConfigurationContext
 	// State returns the state object for the given dogu
 	State(dogu string) State
+	// WaitFor blocks until the dogu has the given state or the given context is done
+	WaitFor(ctx context.Context, dogu string, state DoguState) error
 	// DoguRegistry returns an object which is able to manage dogus
 	DoguRegistry() DoguRegistry
 	// BlueprintRegistry to maintain a blueprint history
@@ -33,12 +49,40 @@ type Registry interface {
 	RootConfig() WatchConfigurationContext
 	// GetNode returns all keys that are included in any path, packed as Node
 	GetNode() (Node, error)
+	// GetNodeContext returns all keys that are included in any path, packed as Node. The given context limits the
+	// time of the request.
+	GetNodeContext(ctx context.Context) (Node, error)
+	// ExportSnapshot exports the given subtrees of the registry, e.g. /config/redmine or /dogu_v2. If no path is
+	// given, the whole registry is exported. Hidden keys like /config/_host are exported as well.
+	ExportSnapshot(paths ...string) (*Snapshot, error)
+	// ExportSnapshotContext works like ExportSnapshot. The given context limits the time of the request.
+	ExportSnapshotContext(ctx context.Context, paths ...string) (*Snapshot, error)
+	// ImportSnapshot recreates the keys, directories and time to live of the snapshot in the registry. Keys of the
+	// registry which are not part of the snapshot are kept. The import is not atomic: a failed import keeps the
+	// already written entries.
+	ImportSnapshot(snapshot *Snapshot, options SnapshotImportOptions) (*SnapshotImportReport, error)
+	// ImportSnapshotContext works like ImportSnapshot. The given context limits the time of the request.
+	ImportSnapshotContext(ctx context.Context, snapshot *Snapshot, options SnapshotImportOptions) (*SnapshotImportReport, error)
+	// Lock returns the distributed lock with the given name, e.g. to make sure that only one process at a time
+	// changes the ecosystem.
+	Lock(name string, options LockOptions) Lock
+	// Close releases the connections of the registry. The registry must not be used afterwards.
+	Close() error
 }
 
 // New creates a new registry
 func New(configuration core.Registry) (Registry, error) {
-	if configuration.Type != "etcd" {
-		return nil, errors.Errorf("currently only etcd registry is supported, %s was provided", configuration.Type)
+	switch configuration.Type {
+	case TypeEtcd, TypeEtcdV3:
+		return newEtcdRegistry(configuration)
+	case TypeMemory:
+		return NewInMemoryRegistry(), nil
+	case TypeFilesystem:
+		if len(configuration.Endpoints) == 0 {
+			return nil, errors.New("the filesystem registry requires the directory of the registry as endpoint")
+		}
+		return NewFilesystemRegistry(configuration.Endpoints[0])
+	default:
+		return nil, errors.Errorf("currently only etcd, etcdv3, memory and filesystem registries are supported, %s was provided", configuration.Type)
 	}
-	return newEtcdRegistry(configuration)
 }
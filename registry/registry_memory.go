@@ -0,0 +1,8 @@
+package registry
+
+// NewInMemoryRegistry creates a new registry which keeps all keys in memory. The registry behaves like an etcd based
+// registry, including the time to live of keys and watches, but its content is lost as soon as the process ends. It is
+// meant for tests and offline tools which must not depend on a running etcd.
+func NewInMemoryRegistry() Registry {
+	return &etcdRegistry{client: newMemoryClient()}
+}
@@ -0,0 +1,733 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/client/v2"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("should create in-memory registry", func(t *testing.T) {
+		reg, err := New(core.Registry{Type: TypeMemory})
+
+		require.NoError(t, err)
+		assert.NotNil(t, reg)
+	})
+
+	t.Run("should create filesystem registry", func(t *testing.T) {
+		reg, err := New(core.Registry{Type: TypeFilesystem, Endpoints: []string{t.TempDir()}})
+
+		require.NoError(t, err)
+		assert.NotNil(t, reg)
+	})
+
+	t.Run("should fail for filesystem registry without directory", func(t *testing.T) {
+		_, err := New(core.Registry{Type: TypeFilesystem})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "requires the directory of the registry")
+	})
+
+	t.Run("should fail for unsupported registry type", func(t *testing.T) {
+		_, err := New(core.Registry{Type: "consul"})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "consul was provided")
+	})
+}
+
+func TestNewInMemoryRegistry_ConfigurationContext(t *testing.T) {
+	t.Run("should set, get and delete keys", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguConfig("redmine")
+
+		// when
+		err := sut.Set("logging/root", "INFO")
+		require.NoError(t, err)
+		value, err := sut.Get("logging/root")
+		require.NoError(t, err)
+		exists, err := sut.Exists("logging/root")
+		require.NoError(t, err)
+
+		// then
+		assert.Equal(t, "INFO", value)
+		assert.True(t, exists)
+
+		// when
+		err = sut.Delete("logging/root")
+		require.NoError(t, err)
+		_, err = sut.Get("logging/root")
+
+		// then
+		require.Error(t, err)
+		assert.True(t, IsKeyNotFoundError(err))
+	})
+
+	t.Run("should return key not found error for missing keys", func(t *testing.T) {
+		sut := NewInMemoryRegistry().GlobalConfig()
+
+		_, err := sut.Get("fqdn")
+		require.Error(t, err)
+		assert.True(t, IsKeyNotFoundError(err))
+
+		err = sut.Delete("fqdn")
+		require.Error(t, err)
+		assert.True(t, IsKeyNotFoundError(err))
+	})
+
+	t.Run("should not delete directory without recursive flag", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguConfig("redmine")
+		require.NoError(t, sut.Set("logging/root", "INFO"))
+
+		// when
+		err := sut.Delete("logging")
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Not a file")
+
+		// when
+		err = sut.DeleteRecursive("logging")
+
+		// then
+		require.NoError(t, err)
+		exists, err := sut.Exists("logging/root")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should return all keys of the context", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		sut := reg.DoguConfig("redmine")
+		require.NoError(t, sut.Set("logging/root", "INFO"))
+		require.NoError(t, sut.Set("container_config/memory_limit", "1g"))
+		require.NoError(t, reg.DoguConfig("cas").Set("logging/root", "DEBUG"))
+
+		// when
+		all, err := sut.GetAll()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"logging/root": "INFO", "container_config/memory_limit": "1g"}, all)
+	})
+
+	t.Run("should remove all keys of the context", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguConfig("redmine")
+		require.NoError(t, sut.Set("logging/root", "INFO"))
+
+		// when
+		err := sut.RemoveAll()
+
+		// then
+		require.NoError(t, err)
+		_, err = sut.GetAll()
+		assert.True(t, IsKeyNotFoundError(err))
+	})
+
+	t.Run("should distinguish between empty and missing values", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().GlobalConfig()
+		require.NoError(t, sut.Set("empty", ""))
+
+		// when
+		emptyExists, emptyValue, err := sut.GetOrFalse("empty")
+		require.NoError(t, err)
+		missingExists, _, err := sut.GetOrFalse("missing")
+		require.NoError(t, err)
+
+		// then
+		assert.True(t, emptyExists)
+		assert.Equal(t, "", emptyValue)
+		assert.False(t, missingExists)
+	})
+}
+
+func TestNewInMemoryRegistry_CompareAndSwap(t *testing.T) {
+	t.Run("should only set absent keys", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguConfig("redmine")
+
+		// when
+		first, err := sut.SetIfAbsent("lock", "first")
+		require.NoError(t, err)
+		second, err := sut.SetIfAbsent("lock", "second")
+		require.NoError(t, err)
+
+		// then
+		assert.True(t, first)
+		assert.False(t, second)
+		value, err := sut.Get("lock")
+		require.NoError(t, err)
+		assert.Equal(t, "first", value)
+	})
+
+	t.Run("should swap value only if old value matches", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguConfig("redmine")
+		require.NoError(t, sut.Set("counter", "1"))
+
+		// when
+		mismatch, err := sut.CompareAndSwap("counter", "0", "2")
+		require.NoError(t, err)
+		match, err := sut.CompareAndSwap("counter", "1", "2")
+		require.NoError(t, err)
+		missing, err := sut.CompareAndSwap("missing", "1", "2")
+		require.NoError(t, err)
+
+		// then
+		assert.False(t, mismatch)
+		assert.True(t, match)
+		assert.False(t, missing)
+		value, err := sut.Get("counter")
+		require.NoError(t, err)
+		assert.Equal(t, "2", value)
+	})
+
+	t.Run("should compare empty values", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguConfig("redmine")
+		require.NoError(t, sut.Set("empty", ""))
+		require.NoError(t, sut.Set("filled", "value"))
+
+		// when
+		emptySwapped, err := sut.CompareAndSwap("empty", "", "value")
+		require.NoError(t, err)
+		filledSwapped, err := sut.CompareAndSwap("filled", "", "other")
+		require.NoError(t, err)
+		missingSwapped, err := sut.CompareAndSwap("missing", "", "value")
+		require.NoError(t, err)
+
+		// then
+		assert.True(t, emptySwapped)
+		assert.False(t, filledSwapped)
+		assert.False(t, missingSwapped)
+		value, err := sut.Get("filled")
+		require.NoError(t, err)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("should delete key only if old value matches", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguConfig("redmine")
+		require.NoError(t, sut.Set("lock", "me"))
+
+		// when
+		mismatch, err := sut.CompareAndDelete("lock", "you")
+		require.NoError(t, err)
+		match, err := sut.CompareAndDelete("lock", "me")
+		require.NoError(t, err)
+		missing, err := sut.CompareAndDelete("lock", "me")
+		require.NoError(t, err)
+
+		// then
+		assert.False(t, mismatch)
+		assert.True(t, match)
+		assert.False(t, missing)
+		exists, err := sut.Exists("lock")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestNewInMemoryRegistry_ApplyBatch(t *testing.T) {
+	t.Run("should apply all changes", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguConfig("redmine")
+		require.NoError(t, sut.Set("logging/root", "INFO"))
+		require.NoError(t, sut.Set("obsolete", "value"))
+
+		// when
+		err := sut.ApplyBatch([]ConfigurationChange{
+			{Key: "logging/root", Value: "DEBUG"},
+			{Key: "container_config/memory_limit", Value: "1g"},
+			{Key: "obsolete", Delete: true},
+			{Key: "missing", Delete: true},
+		})
+
+		// then
+		require.NoError(t, err)
+		all, err := sut.GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"logging/root": "DEBUG", "container_config/memory_limit": "1g"}, all)
+	})
+
+	t.Run("should apply no change if one change fails", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		sut := reg.DoguConfig("redmine")
+		require.NoError(t, sut.Set("logging/root", "INFO"))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := make(chan *client.Response, 10)
+		go reg.RootConfig().Watch(ctx, "/config/redmine", true, events)
+		// give the watcher time to subscribe
+		time.Sleep(10 * time.Millisecond)
+
+		// when
+		err := sut.ApplyBatch([]ConfigurationChange{
+			{Key: "url", Value: "https://example.com"},
+			{Key: "logging", Value: "directories cannot be set"},
+		})
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Not a file")
+		all, err := sut.GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"logging/root": "INFO"}, all)
+		select {
+		case event := <-events:
+			t.Fatalf("unexpected watch event %s on %s", event.Action, event.Node.Key)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+func TestNewInMemoryRegistry_Context(t *testing.T) {
+	t.Run("should fail on cancelled context", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// when
+		errSet := reg.GlobalConfig().SetContext(ctx, "fqdn", "ces.local")
+		_, errGet := reg.DoguRegistry().GetAllContext(ctx)
+		errState := reg.State("ldap").SetContext(ctx, "ready")
+
+		// then
+		assert.ErrorIs(t, errSet, context.Canceled)
+		assert.ErrorIs(t, errGet, context.Canceled)
+		assert.ErrorIs(t, errState, context.Canceled)
+		exists, err := reg.GlobalConfig().Exists("fqdn")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestNewInMemoryRegistry_TimeToLive(t *testing.T) {
+	t.Run("should expire keys after their lifetime", func(t *testing.T) {
+		// given
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		memClient := newMemoryClient()
+		memClient.now = func() time.Time { return now }
+		sut := (&etcdRegistry{client: memClient}).GlobalConfig()
+
+		require.NoError(t, sut.SetWithLifetime("lock", "me", 60))
+
+		// when
+		now = now.Add(59 * time.Second)
+		existsBefore, err := sut.Exists("lock")
+		require.NoError(t, err)
+		now = now.Add(time.Second)
+		existsAfter, err := sut.Exists("lock")
+		require.NoError(t, err)
+
+		// then
+		assert.True(t, existsBefore)
+		assert.False(t, existsAfter)
+	})
+
+	t.Run("should extend lifetime on refresh without changing the value", func(t *testing.T) {
+		// given
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		memClient := newMemoryClient()
+		memClient.now = func() time.Time { return now }
+		sut := (&etcdRegistry{client: memClient}).GlobalConfig()
+
+		require.NoError(t, sut.SetWithLifetime("lock", "me", 60))
+
+		// when
+		now = now.Add(50 * time.Second)
+		err := sut.Refresh("lock", 60)
+		require.NoError(t, err)
+		now = now.Add(50 * time.Second)
+		value, err := sut.Get("lock")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "me", value)
+	})
+
+	t.Run("should fail to refresh missing key", func(t *testing.T) {
+		sut := NewInMemoryRegistry().GlobalConfig()
+
+		err := sut.Refresh("lock", 60)
+
+		require.Error(t, err)
+		assert.True(t, IsKeyNotFoundError(err))
+	})
+}
+
+func TestNewInMemoryRegistry_DoguRegistry(t *testing.T) {
+	t.Run("should register, enable and get dogus", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguRegistry()
+		ldap := newTestDogu("ldap", "2.4.48-4")
+		cas := newTestDogu("cas", "6.5.0-1")
+
+		// when
+		for _, dogu := range []*core.Dogu{ldap, cas} {
+			require.NoError(t, sut.Register(dogu))
+			require.NoError(t, sut.Enable(dogu))
+		}
+
+		// then
+		actual, err := sut.Get("ldap")
+		require.NoError(t, err)
+		assert.Equal(t, "official/ldap", actual.Name)
+		assert.Equal(t, "2.4.48-4", actual.Version)
+
+		enabled, err := sut.IsEnabled("cas")
+		require.NoError(t, err)
+		assert.True(t, enabled)
+
+		all, err := sut.GetAll()
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+		assert.True(t, core.ContainsDoguWithName(all, "official/ldap"))
+		assert.True(t, core.ContainsDoguWithName(all, "official/cas"))
+	})
+
+	t.Run("should write v1 and v2 descriptors", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		dogu := newTestDogu("ldap", "2.4.48-4")
+
+		// when
+		require.NoError(t, reg.DoguRegistry().Register(dogu))
+		require.NoError(t, reg.DoguRegistry().Enable(dogu))
+
+		// then
+		v1Current, err := reg.RootConfig().Get("/dogu/ldap/current")
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.48-4", v1Current)
+		v2Current, err := reg.RootConfig().Get("/dogu_v2/ldap/current")
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.48-4", v2Current)
+	})
+
+	t.Run("should unregister dogu", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguRegistry()
+		dogu := newTestDogu("ldap", "2.4.48-4")
+		require.NoError(t, sut.Register(dogu))
+		require.NoError(t, sut.Enable(dogu))
+
+		// when
+		err := sut.Unregister("ldap")
+
+		// then
+		require.NoError(t, err)
+		enabled, err := sut.IsEnabled("ldap")
+		require.NoError(t, err)
+		assert.False(t, enabled)
+		_, err = sut.Get("ldap")
+		assert.True(t, IsKeyNotFoundError(err))
+	})
+}
+
+func TestNewInMemoryRegistry_DoguVersions(t *testing.T) {
+	registerVersions := func(t *testing.T, sut DoguRegistry, versions ...string) {
+		t.Helper()
+		for _, version := range versions {
+			dogu := newTestDogu("ldap", version)
+			require.NoError(t, sut.Register(dogu))
+			require.NoError(t, sut.Enable(dogu))
+		}
+	}
+	rawVersions := func(versions []core.Version) []string {
+		var raw []string
+		for _, version := range versions {
+			raw = append(raw, version.Raw)
+		}
+		return raw
+	}
+
+	t.Run("should return registered versions from newest to oldest", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguRegistry()
+		registerVersions(t, sut, "2.4.48-4", "2.4.48-10", "2.4.7-1")
+
+		// when
+		versions, err := sut.GetVersions("ldap")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2.4.48-10", "2.4.48-4", "2.4.7-1"}, rawVersions(versions))
+	})
+
+	t.Run("should return no versions for unknown dogu", func(t *testing.T) {
+		versions, err := NewInMemoryRegistry().DoguRegistry().GetVersions("ldap")
+
+		require.NoError(t, err)
+		assert.Empty(t, versions)
+	})
+
+	t.Run("should return versions which only exist in v1 registry", func(t *testing.T) {
+		// given
+		memClient := newMemoryClient()
+		reg := &etcdRegistry{client: memClient}
+		registerVersions(t, reg.DoguRegistry(), "2.4.48-4")
+		_, err := memClient.Set(context.Background(), "/dogu/ldap/2.4.7-1", `{"Name": "official/ldap", "Version": "2.4.7-1"}`, nil)
+		require.NoError(t, err)
+
+		// when
+		versions, err := reg.DoguRegistry().GetVersions("ldap")
+		dogu, errGet := reg.DoguRegistry().GetVersion("ldap", "2.4.7-1")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2.4.48-4", "2.4.7-1"}, rawVersions(versions))
+		require.NoError(t, errGet)
+		assert.Equal(t, "2.4.7-1", dogu.Version)
+	})
+
+	t.Run("should get registered version", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguRegistry()
+		registerVersions(t, sut, "2.4.7-1", "2.4.48-4")
+
+		// when
+		dogu, err := sut.GetVersion("ldap", "2.4.7-1")
+		_, errMissing := sut.GetVersion("ldap", "1.0.0")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "official/ldap", dogu.Name)
+		assert.Equal(t, "2.4.7-1", dogu.Version)
+		assert.True(t, IsKeyNotFoundError(errMissing))
+	})
+
+	t.Run("should enable earlier version", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		sut := reg.DoguRegistry()
+		registerVersions(t, sut, "2.4.7-1", "2.4.48-4")
+
+		// when
+		err := sut.EnableVersion("ldap", "2.4.7-1")
+
+		// then
+		require.NoError(t, err)
+		dogu, err := sut.Get("ldap")
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.7-1", dogu.Version)
+		v1Current, err := reg.RootConfig().Get("/dogu/ldap/current")
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.7-1", v1Current)
+	})
+
+	t.Run("should fall back to v1 registry when enabling a version which only exists in v1 registry", func(t *testing.T) {
+		// given
+		memClient := newMemoryClient()
+		sut := (&etcdRegistry{client: memClient}).DoguRegistry()
+		registerVersions(t, sut, "2.4.48-4")
+		_, err := memClient.Set(context.Background(), "/dogu/ldap/2.4.7-1", `{"Name": "official/ldap", "Version": "2.4.7-1"}`, nil)
+		require.NoError(t, err)
+
+		// when
+		err = sut.EnableVersion("ldap", "2.4.7-1")
+
+		// then
+		require.NoError(t, err)
+		dogu, err := sut.Get("ldap")
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.7-1", dogu.Version)
+		exists, err := memClient.Exists(context.Background(), "/dogu_v2/ldap/current")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should fail to enable unregistered version", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().DoguRegistry()
+		registerVersions(t, sut, "2.4.48-4")
+
+		// when
+		err := sut.EnableVersion("ldap", "1.0.0")
+
+		// then
+		require.Error(t, err)
+		assert.True(t, IsKeyNotFoundError(err))
+		dogu, err := sut.Get("ldap")
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.48-4", dogu.Version)
+	})
+
+	t.Run("should prune old versions except of the enabled version", func(t *testing.T) {
+		// given
+		memClient := newMemoryClient()
+		sut := (&etcdRegistry{client: memClient}).DoguRegistry()
+		registerVersions(t, sut, "2.4.7-1", "2.4.7-2", "2.4.48-1", "2.4.48-2", "2.4.48-3")
+		require.NoError(t, sut.EnableVersion("ldap", "2.4.7-1"))
+
+		// when
+		err := sut.PruneVersions("ldap", 2)
+
+		// then
+		require.NoError(t, err)
+		versions, err := sut.GetVersions("ldap")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2.4.48-3", "2.4.48-2", "2.4.7-1"}, rawVersions(versions))
+		exists, err := memClient.Exists(context.Background(), "/dogu/ldap/2.4.48-1")
+		require.NoError(t, err)
+		assert.False(t, exists)
+		dogu, err := sut.Get("ldap")
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.7-1", dogu.Version)
+	})
+
+	t.Run("should fail to prune with negative number of versions", func(t *testing.T) {
+		err := NewInMemoryRegistry().DoguRegistry().PruneVersions("ldap", -1)
+
+		assert.ErrorContains(t, err, "number of versions to keep must not be negative")
+	})
+}
+
+func TestNewInMemoryRegistry_State(t *testing.T) {
+	// given
+	sut := NewInMemoryRegistry().State("ldap")
+
+	// when
+	initial, err := sut.Get()
+	require.NoError(t, err)
+	require.NoError(t, sut.Set("ready"))
+	ready, err := sut.Get()
+	require.NoError(t, err)
+	require.NoError(t, sut.Remove())
+	removed, err := sut.Get()
+	require.NoError(t, err)
+
+	// then
+	assert.Equal(t, "", initial)
+	assert.Equal(t, "ready", ready)
+	assert.Equal(t, "", removed)
+}
+
+func TestNewInMemoryRegistry_RootConfig(t *testing.T) {
+	t.Run("should watch for changes recursively", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := make(chan *client.Response)
+		go reg.RootConfig().Watch(ctx, "/config/redmine", true, events)
+		// give the watcher time to subscribe
+		time.Sleep(10 * time.Millisecond)
+
+		// when
+		require.NoError(t, reg.DoguConfig("cas").Set("logging/root", "INFO"))
+		require.NoError(t, reg.DoguConfig("redmine").Set("logging/root", "DEBUG"))
+
+		// then
+		select {
+		case event := <-events:
+			assert.Equal(t, "set", event.Action)
+			assert.Equal(t, "/config/redmine/logging/root", event.Node.Key)
+			assert.Equal(t, "DEBUG", event.Node.Value)
+		case <-time.After(time.Second):
+			t.Fatal("expected watch event")
+		}
+	})
+
+	t.Run("should send typed watch events", func(t *testing.T) {
+		// given
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		memClient := newMemoryClient()
+		memClient.now = func() time.Time { return now }
+		reg := &etcdRegistry{client: memClient}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events, _ := reg.RootConfig().WatchEvents(ctx, "/config/redmine", true)
+		// give the watcher time to subscribe
+		time.Sleep(10 * time.Millisecond)
+
+		// when
+		require.NoError(t, reg.DoguConfig("redmine").Set("logging/root", "INFO"))
+		require.NoError(t, reg.DoguConfig("redmine").Set("logging/root", "DEBUG"))
+		require.NoError(t, reg.DoguConfig("redmine").Delete("logging/root"))
+		require.NoError(t, reg.DoguConfig("redmine").SetWithLifetime("lock", "me", 1))
+		now = now.Add(time.Second)
+		_, _ = reg.DoguConfig("redmine").Exists("lock")
+
+		// then
+		var actual []WatchEvent
+		for len(actual) < 5 {
+			select {
+			case event := <-events:
+				event.Revision = 0
+				actual = append(actual, event)
+			case <-time.After(time.Second):
+				t.Fatalf("expected five watch events but got %v", actual)
+			}
+		}
+		assert.Equal(t, []WatchEvent{
+			{Key: "/config/redmine/logging/root", NewValue: "INFO", Action: WatchActionCreate},
+			{Key: "/config/redmine/logging/root", OldValue: "INFO", NewValue: "DEBUG", Action: WatchActionUpdate},
+			{Key: "/config/redmine/logging/root", OldValue: "DEBUG", Action: WatchActionDelete},
+			{Key: "/config/redmine/lock", NewValue: "me", Action: WatchActionCreate},
+			{Key: "/config/redmine/lock", OldValue: "me", Action: WatchActionExpire},
+		}, actual)
+	})
+
+	t.Run("should fail to resume watch if events were removed from the history", func(t *testing.T) {
+		// given
+		memClient := newMemoryClient()
+		cc := (&etcdRegistry{client: memClient}).GlobalConfig()
+		require.NoError(t, cc.Set("key", "0"))
+		for i := 0; i < maxMemoryEventHistory+1; i++ {
+			require.NoError(t, cc.Set("key", "value"))
+		}
+
+		// when
+		err := memClient.Watch(context.Background(), "/config", true, 1, make(chan *client.Response))
+
+		// then
+		require.Error(t, err)
+		assert.True(t, IsEventIndexClearedError(err))
+	})
+
+	t.Run("should list children paths without hidden keys", func(t *testing.T) {
+		// given
+		reg := NewInMemoryRegistry()
+		require.NoError(t, reg.GlobalConfig().Set("fqdn", "ces.local"))
+		require.NoError(t, reg.DoguConfig("redmine").Set("logging/root", "INFO"))
+
+		// when
+		children, err := reg.RootConfig().GetChildrenPaths("/config")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/config/redmine"}, children)
+	})
+}
+
+func TestNewInMemoryRegistry_GetNode(t *testing.T) {
+	// given
+	reg := NewInMemoryRegistry()
+	require.NoError(t, reg.GlobalConfig().Set("test/key", "false"))
+
+	// when
+	node, err := reg.GetNode()
+
+	// then
+	require.NoError(t, err)
+	assert.True(t, node.IsDir)
+	assert.Equal(t, "", node.FullKey)
+	testKey := node.SubNodeByName("config").SubNodeByName("_global").SubNodeByName("test").SubNodeByName("key")
+	assert.Equal(t, "false", testKey.Value)
+	assert.Equal(t, "/config/_global/test/key", testKey.FullKey)
+}
+
+func newTestDogu(name string, version string) *core.Dogu {
+	return &core.Dogu{Name: "official/" + name, Version: version}
+}
@@ -4,14 +4,15 @@ import (
 	"fmt"
 	"github.com/cloudogu/cesapp-lib/core"
 	"go.etcd.io/etcd/client/v2"
-	"sync"
 	"time"
 
 	"context"
 
+	"net/http"
 	"strings"
 
 	"github.com/eapache/go-resiliency/retrier"
+	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 )
 
@@ -20,33 +21,128 @@ var defaultBackoff = retrier.ExponentialBackoff(5, 100*time.Millisecond)
 
 type etcdClassifier struct{}
 
-// Classify returns succeeds if the error is nil or an etcd not found error, in all other cases the classifier will return retry.
+// NewEtcdRetryClassifier returns the classifier which decides about the retries of etcd requests if the configuration
+// of the registry contains no RetryClassifier. Custom classifiers may delegate to it.
+func NewEtcdRetryClassifier() retrier.Classifier {
+	return &etcdClassifier{}
+}
+
+// Classify returns succeeds if the error is nil, an etcd not found error or the failed precondition of a conditional
+// request. It returns fail for other permanent errors, e.g. a missing permission or a directory which should be
+// changed like a key, because a retry will not change their result. In all other cases the classifier will return
+// retry.
 func (classifier *etcdClassifier) Classify(err error) retrier.Action {
-	if err == nil || IsKeyNotFoundError(err) {
+	if err == nil || IsKeyNotFoundError(err) || isPreconditionFailed(err) {
 		return retrier.Succeed
 	}
+	if isPermanentError(err) {
+		return retrier.Fail
+	}
 	return retrier.Retry
 }
 
-// newResilientEtcdClient is build up on the kapi of etcd and adds constant retries for every failed request.
-func newResilientEtcdClient(endpoints []string, config core.RetryPolicy) (*resilentEtcdClient, error) {
-	log.Debug("create etcd client for endpoints", endpoints)
+// etcdMetricsComponent is the name of the etcd clients in the recorded metrics.
+const etcdMetricsComponent = "etcd"
+
+// etcdRetriers contains the retriers of the etcd clients. Reading, writing and deleting requests may be retried with
+// different backoffs.
+type etcdRetriers struct {
+	read   *retrier.Retrier
+	write  *retrier.Retrier
+	delete *retrier.Retrier
+	// metrics records the durations and retries of the requests if it is not nil.
+	metrics core.MetricsRecorder
+}
+
+// run runs the request of the given operation with the retrier and records its duration and retries.
+func (retriers etcdRetriers) run(ctx context.Context, r *retrier.Retrier, operation string, request func(ctx context.Context) error) error {
+	if retriers.metrics == nil {
+		return r.RunCtx(ctx, request)
+	}
+
+	start := time.Now()
+	attempts := 0
+	err := r.RunCtx(ctx, func(ctx context.Context) error {
+		attempts++
+		if attempts > 1 {
+			retriers.metrics.CountRetry(etcdMetricsComponent, operation)
+		}
+		return request(ctx)
+	})
+	retriers.metrics.ObserveRequest(etcdMetricsComponent, operation, time.Since(start), err)
+
+	return err
+}
 
-	backoff, err := core.GetBackoff(config)
+// newEtcdRetriers creates the retriers of the configured retry policies. The RetryPolicy of the configuration is used
+// for every class of requests without an own policy.
+func newEtcdRetriers(configuration core.Registry) (etcdRetriers, error) {
+	classifier := configuration.RetryClassifier
+	if classifier == nil {
+		classifier = NewEtcdRetryClassifier()
+	}
+
+	read, err := newEtcdRetrier(configuration.RetryPolicy, configuration.ReadRetryPolicy, classifier)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create resilentEtcdClient: %w", err)
+		return etcdRetriers{}, errors.Wrap(err, "failed to create retrier of reading requests")
+	}
+	write, err := newEtcdRetrier(configuration.RetryPolicy, configuration.WriteRetryPolicy, classifier)
+	if err != nil {
+		return etcdRetriers{}, errors.Wrap(err, "failed to create retrier of writing requests")
+	}
+	deleteRetrier, err := newEtcdRetrier(configuration.RetryPolicy, configuration.DeleteRetryPolicy, classifier)
+	if err != nil {
+		return etcdRetriers{}, errors.Wrap(err, "failed to create retrier of deleting requests")
+	}
+
+	return etcdRetriers{read: read, write: write, delete: deleteRetrier, metrics: configuration.Metrics}, nil
+}
+
+func newEtcdRetrier(defaultPolicy core.RetryPolicy, policy *core.RetryPolicy, classifier retrier.Classifier) (*retrier.Retrier, error) {
+	if policy == nil {
+		policy = &defaultPolicy
+	}
+
+	backoff, err := core.GetBackoff(*policy)
+	if err != nil {
+		return nil, err
 	}
 	if len(backoff) < 1 {
 		backoff = defaultBackoff
 	}
-	r := retrier.New(
-		backoff,
-		&etcdClassifier{},
-	)
+
+	etcdRetrier := retrier.New(backoff, classifier)
+	etcdRetrier.SetJitter(core.GetJitter(*policy))
+	return etcdRetrier, nil
+}
+
+// newResilientEtcdClient is build up on the kapi of etcd and adds retries for every failed request.
+// The connection is encrypted and authenticated according to the TLS options and the credentials of the configuration.
+func newResilientEtcdClient(endpoints []string, configuration core.Registry) (*resilentEtcdClient, error) {
+	log.Debug("create etcd client for endpoints", endpoints)
+
+	retriers, err := newEtcdRetriers(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resilentEtcdClient: %w", err)
+	}
+
+	tlsConfig, err := createEtcdTLSConfig(endpoints, configuration.TLS)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create etcd client")
+	}
+
+	transport := client.DefaultTransport
+	if tlsConfig != nil {
+		httpTransport := client.DefaultTransport.(*http.Transport).Clone()
+		httpTransport.TLSClientConfig = tlsConfig
+		transport = httpTransport
+	}
 
 	cfg := client.Config{
 		Endpoints: endpoints,
-		Transport: client.DefaultTransport,
+		Transport: transport,
+		Username:  configuration.Username,
+		Password:  configuration.Password,
 	}
 
 	conn, err := client.New(cfg)
@@ -54,26 +150,39 @@ func newResilientEtcdClient(endpoints []string, config core.RetryPolicy) (*resil
 		return nil, errors.Wrap(err, "failed to create etcd client")
 	}
 
+	// Watches are cancelled by closing the body of the pending response. If the response arrives at the same time,
+	// the connection may be reused while the cancelled watch still waits for it, which blocks the next request on
+	// this connection. Therefore, watches use their own connections which are never reused.
+	watchTransport := client.DefaultTransport.(*http.Transport).Clone()
+	watchTransport.TLSClientConfig = tlsConfig
+	watchTransport.DisableKeepAlives = true
+	cfg.Transport = watchTransport
+
+	watchConn, err := client.New(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create etcd client for watches")
+	}
+
 	return &resilentEtcdClient{
-		kapi:        client.NewKeysAPI(conn),
-		retrier:     r,
-		recentIndex: 0,
+		kapi:      client.NewKeysAPI(conn),
+		watchKapi: client.NewKeysAPI(watchConn),
+		retriers:  retriers,
 	}, nil
 }
 
 type resilentEtcdClient struct {
-	kapi        client.KeysAPI
-	retrier     *retrier.Retrier
-	indexMutex  sync.Mutex
-	recentIndex uint64
+	kapi client.KeysAPI
+	// watchKapi is used for watches. The kapi is used instead if it is nil.
+	watchKapi client.KeysAPI
+	retriers  etcdRetriers
 }
 
 // Exists returns true if the key exists
-func (etcd *resilentEtcdClient) Exists(key string) (bool, error) {
+func (etcd *resilentEtcdClient) Exists(ctx context.Context, key string) (bool, error) {
 	var exists bool
-	err := etcd.retrier.Run(func() error {
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "exists", func(ctx context.Context) error {
 		core.GetLogger().Debugf("check if key %s exists", key)
-		_, err := etcd.kapi.Get(context.Background(), key, nil)
+		_, err := etcd.kapi.Get(ctx, key, nil)
 		if err == nil {
 			exists = true
 			return nil
@@ -95,17 +204,15 @@ func (etcd *resilentEtcdClient) Exists(key string) (bool, error) {
 
 // Get returns the value of the given node, otherwise it returns an error. If the given key cannot be found a
 // KeyNotFoundError is returned.
-func (etcd *resilentEtcdClient) Get(key string) (string, error) {
+func (etcd *resilentEtcdClient) Get(ctx context.Context, key string) (string, error) {
 	var result string
-	err := etcd.retrier.Run(func() error {
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "get", func(ctx context.Context) error {
 		core.GetLogger().Debugf("read key %s", key)
-		response, err := etcd.kapi.Get(context.Background(), key, nil)
+		response, err := etcd.kapi.Get(ctx, key, nil)
 		if err != nil {
 			return err
 		}
 
-		etcd.updateIndexIfNecessary(response.Index)
-
 		result = response.Node.Value
 		return nil
 	})
@@ -118,11 +225,11 @@ func (etcd *resilentEtcdClient) Get(key string) (string, error) {
 }
 
 // GetRecursive returns a map of key Value pairs below the given key
-func (etcd *resilentEtcdClient) GetRecursive(key string) (map[string]string, error) {
+func (etcd *resilentEtcdClient) GetRecursive(ctx context.Context, key string) (map[string]string, error) {
 	var result map[string]string
-	err := etcd.retrier.Run(func() error {
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "getRecursive", func(ctx context.Context) error {
 		core.GetLogger().Debugf("read key %s recursive", key)
-		response, err := etcd.kapi.Get(context.Background(), key, &client.GetOptions{Recursive: true})
+		response, err := etcd.kapi.Get(ctx, key, &client.GetOptions{Recursive: true})
 		if err != nil {
 			return err
 		}
@@ -150,8 +257,9 @@ func (etcd *resilentEtcdClient) addNodeDirValuesToMap(keyValuePairs map[string]s
 	}
 }
 
-func (etcd *resilentEtcdClient) getMainNode() (*client.Node, error) {
-	response, err := etcd.kapi.Get(context.Background(), "/", &client.GetOptions{Recursive: true})
+// GetMainNode returns the root node of etcd including all its children recursively.
+func (etcd *resilentEtcdClient) GetMainNode(ctx context.Context) (*client.Node, error) {
+	response, err := etcd.kapi.Get(ctx, "/", &client.GetOptions{Recursive: true})
 	if err != nil {
 		return nil, fmt.Errorf("cannot get main node from etcd: %w", err)
 	}
@@ -159,7 +267,7 @@ func (etcd *resilentEtcdClient) getMainNode() (*client.Node, error) {
 	// `config/_global` is a hidden directory and has to be queried explicit. There is no other way to list hidden dirs.
 	for _, node := range response.Node.Nodes {
 		if node.Key == "/config" {
-			globalConfig, err := etcd.kapi.Get(context.Background(), "/config/_global", &client.GetOptions{Recursive: true})
+			globalConfig, err := etcd.kapi.Get(ctx, "/config/_global", &client.GetOptions{Recursive: true})
 			if err == nil {
 				node.Nodes = append(node.Nodes, globalConfig.Node)
 			} else if !strings.Contains(err.Error(), "Key not found (/config/_global)") {
@@ -173,6 +281,69 @@ func (etcd *resilentEtcdClient) getMainNode() (*client.Node, error) {
 	return response.Node, err
 }
 
+// hiddenEtcdDirectories contains the hidden directories of the registry. The v2 api of etcd does not list hidden keys,
+// so they have to be queried explicitly.
+var hiddenEtcdDirectories = []string{"/config/" + DirectoryGlobal, "/config/" + directoryHost, "/state/_history"}
+
+// GetTree returns the node of the given key including all its children recursively. Unlike GetMainNode, the known
+// hidden directories below the key are included, e.g. /config/_host. Other hidden keys cannot be listed with the v2
+// api and are only included if the key itself is hidden.
+func (etcd *resilentEtcdClient) GetTree(ctx context.Context, key string) (*client.Node, error) {
+	key = normalizeKey(key)
+
+	var tree *client.Node
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "getTree", func(ctx context.Context) error {
+		response, err := etcd.kapi.Get(ctx, key, &client.GetOptions{Recursive: true, Sort: true})
+		if err != nil {
+			return err
+		}
+
+		for _, hiddenDirectory := range hiddenEtcdDirectories {
+			if key != "/" && !strings.HasPrefix(hiddenDirectory, key+"/") {
+				continue
+			}
+
+			hiddenResponse, err := etcd.kapi.Get(ctx, hiddenDirectory, &client.GetOptions{Recursive: true, Sort: true})
+			if IsKeyNotFoundError(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			parent := findEtcdNodeByKey(response.Node, hiddenDirectory[:strings.LastIndex(hiddenDirectory, "/")])
+			if parent != nil {
+				parent.Nodes = append(parent.Nodes, hiddenResponse.Node)
+			}
+		}
+
+		tree = response.Node
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read key %s recursive", key)
+	}
+
+	return tree, nil
+}
+
+// findEtcdNodeByKey returns the node with the given key from the tree of the given node or nil if the tree does not
+// contain the key.
+func findEtcdNodeByKey(node *client.Node, key string) *client.Node {
+	if node.Key == key {
+		return node
+	}
+
+	for _, child := range node.Nodes {
+		if child.Key == key || strings.HasPrefix(key, child.Key+"/") {
+			return findEtcdNodeByKey(child, key)
+		}
+	}
+
+	return nil
+}
+
 func (etcd *resilentEtcdClient) createKey(parent string, nodeKey string) string {
 	key := parent
 	if parent != "" {
@@ -190,12 +361,12 @@ func (etcd *resilentEtcdClient) createKey(parent string, nodeKey string) string
 }
 
 // GetChildrenPaths returns an array of all children keys of the given key
-func (etcd *resilentEtcdClient) GetChildrenPaths(key string) ([]string, error) {
+func (etcd *resilentEtcdClient) GetChildrenPaths(ctx context.Context, key string) ([]string, error) {
 	children := []string{}
-	err := etcd.retrier.Run(func() error {
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "getChildrenPaths", func(ctx context.Context) error {
 		core.GetLogger().Debugf("read children paths from %s", key)
 
-		resp, err := etcd.kapi.Get(context.Background(), key, nil)
+		resp, err := etcd.kapi.Get(ctx, key, nil)
 		if err != nil && IsKeyNotFoundError(err) {
 			return nil
 		} else if err != nil {
@@ -217,10 +388,10 @@ func (etcd *resilentEtcdClient) GetChildrenPaths(key string) ([]string, error) {
 }
 
 // Set sets the key to the given value
-func (etcd *resilentEtcdClient) Set(key string, value string, options *client.SetOptions) (string, error) {
+func (etcd *resilentEtcdClient) Set(ctx context.Context, key string, value string, options *client.SetOptions) (string, error) {
 	var result string
-	err := etcd.retrier.Run(func() error {
-		response, err := etcd.kapi.Set(context.Background(), key, value, options)
+	err := etcd.retriers.run(ctx, etcd.retriers.write, "set", func(ctx context.Context) error {
+		response, err := etcd.kapi.Set(ctx, key, value, options)
 		if err != nil {
 			return err
 		}
@@ -237,10 +408,10 @@ func (etcd *resilentEtcdClient) Set(key string, value string, options *client.Se
 }
 
 // Delete deletes the given key or directory
-func (etcd *resilentEtcdClient) Delete(key string, options *client.DeleteOptions) error {
-	err := etcd.retrier.Run(func() error {
+func (etcd *resilentEtcdClient) Delete(ctx context.Context, key string, options *client.DeleteOptions) error {
+	err := etcd.retriers.run(ctx, etcd.retriers.delete, "delete", func(ctx context.Context) error {
 		core.GetLogger().Debugf("delete key %s", key)
-		_, err := etcd.kapi.Delete(context.Background(), key, options)
+		_, err := etcd.kapi.Delete(ctx, key, options)
 		if err != nil {
 			return err
 		}
@@ -256,62 +427,238 @@ func (etcd *resilentEtcdClient) Delete(key string, options *client.DeleteOptions
 }
 
 // DeleteRecursive deletes the given key and all its children
-func (etcd *resilentEtcdClient) DeleteRecursive(key string) error {
+func (etcd *resilentEtcdClient) DeleteRecursive(ctx context.Context, key string) error {
 	core.GetLogger().Debugf("delete key %s recursive", key)
 
-	return etcd.Delete(key, &client.DeleteOptions{
+	return etcd.Delete(ctx, key, &client.DeleteOptions{
 		Recursive: true,
 	})
 }
 
-// Watch watches for changes of the provided key and sends the event through the channel
-func (etcd *resilentEtcdClient) Watch(ctx context.Context, key string, recursive bool, eventChannel chan *client.Response) {
-	options := client.WatcherOptions{AfterIndex: etcd.recentIndex, Recursive: recursive}
-	watcher := etcd.kapi.Watcher(key, &options)
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			etcd.doWatch(ctx, watcher, eventChannel)
+// GetWithIndex returns the value and the modified index of the given key. If the given key cannot be found a
+// KeyNotFoundError is returned.
+func (etcd *resilentEtcdClient) GetWithIndex(ctx context.Context, key string) (string, uint64, error) {
+	var value string
+	var index uint64
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "getWithIndex", func(ctx context.Context) error {
+		core.GetLogger().Debugf("read key %s with index", key)
+		response, err := etcd.kapi.Get(ctx, key, nil)
+		if err != nil {
+			return err
 		}
+
+		value = response.Node.Value
+		index = response.Node.ModifiedIndex
+		return nil
+	})
+
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "failed to read key %s", key)
 	}
+
+	return value, index, nil
+}
+
+// GetCurrentIndex returns the current index of the cluster, which is sent with the response of a read of the given
+// key. The given key is not required to exist.
+func (etcd *resilentEtcdClient) GetCurrentIndex(ctx context.Context, key string) (uint64, error) {
+	var index uint64
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "getCurrentIndex", func(ctx context.Context) error {
+		core.GetLogger().Debugf("read current index with key %s", key)
+		response, err := etcd.kapi.Get(ctx, key, nil)
+		if IsKeyNotFoundError(err) {
+			index = errorIndex(err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		index = response.Index
+		return nil
+	})
+
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read current index with key %s", key)
+	}
+
+	return index, nil
 }
 
-func (etcd *resilentEtcdClient) doWatch(ctx context.Context, watcher client.Watcher, eventChannel chan *client.Response) {
-	resp, err := watcher.Next(ctx)
+// ApplyBatch applies all changes of the batch. The v2 api of etcd does not support transactions, so batches are not
+// atomic: the changes are applied one after another and other clients may read a partially applied batch. Every
+// change is guarded by the index of the key which was read before. If one change fails, the already applied changes
+// are reverted. A revert is guarded by the index of the own change, so keys which were changed by other clients in
+// the meantime are kept, and the error reports them as not reverted.
+func (etcd *resilentEtcdClient) ApplyBatch(ctx context.Context, changes []keyChange) error {
+	previousNodes, err := etcd.getPreviousNodes(ctx, changes)
 	if err != nil {
-		if strings.Contains(err.Error(), "etcd cluster is unavailable or misconfigured") {
-			core.GetLogger().Infof("Cannot reach etcd cluster. Try again in 10 seconds. Error: %v", err)
-			etcd.indexMutex.Lock()
-			defer etcd.indexMutex.Unlock()
-			etcd.recentIndex = 0
-			time.Sleep(time.Second * 10)
-			return
-		} else {
-			core.GetLogger().Infof("Could not get event. Try again in 30 seconds. Error: %v", err)
-			etcd.indexMutex.Lock()
-			defer etcd.indexMutex.Unlock()
-			etcd.recentIndex = 0
-			time.Sleep(time.Second * 30)
-			return
+		return err
+	}
+
+	appliedIndexes := make([]uint64, len(changes))
+	for i, change := range changes {
+		appliedIndexes[i], err = etcd.applyChange(ctx, change, previousNodes[i])
+		if err == nil {
+			continue
+		}
+
+		err = errors.Wrapf(err, "failed to apply change of key %s", change.key)
+		// the changes have to be reverted even if the given context is already cancelled
+		revertErr := etcd.revertChanges(context.WithoutCancel(ctx), changes[:i], previousNodes[:i], appliedIndexes[:i])
+		if revertErr != nil {
+			return multierror.Append(err, revertErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (etcd *resilentEtcdClient) getPreviousNodes(ctx context.Context, changes []keyChange) ([]*client.Node, error) {
+	previousNodes := make([]*client.Node, len(changes))
+	for i, change := range changes {
+		node, err := etcd.getNodeOrNil(ctx, change.key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read key %s", change.key)
+		}
+
+		if node != nil && node.Dir {
+			return nil, client.Error{Code: client.ErrorCodeNotFile, Message: "Not a file", Cause: change.key}
+		}
+		previousNodes[i] = node
+	}
+
+	return previousNodes, nil
+}
+
+// getNodeOrNil returns the node of the key or nil if the key does not exist.
+func (etcd *resilentEtcdClient) getNodeOrNil(ctx context.Context, key string) (*client.Node, error) {
+	var node *client.Node
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "get", func(ctx context.Context) error {
+		response, err := etcd.kapi.Get(ctx, key, nil)
+		if IsKeyNotFoundError(err) {
+			node = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		node = response.Node
+		return nil
+	})
+
+	return node, err
+}
+
+// applyChange applies the change if the key was not changed since it was read and returns the modified index of the
+// written key. Deleted keys have no index.
+func (etcd *resilentEtcdClient) applyChange(ctx context.Context, change keyChange, previousNode *client.Node) (uint64, error) {
+	if change.delete {
+		if previousNode == nil {
+			return 0, nil
+		}
+		return 0, etcd.deleteIfUnchanged(ctx, change.key, previousNode.ModifiedIndex)
+	}
+
+	options := &client.SetOptions{PrevExist: client.PrevNoExist}
+	if previousNode != nil {
+		options = &client.SetOptions{PrevIndex: previousNode.ModifiedIndex}
+	}
+	return etcd.setIfUnchanged(ctx, change.key, change.value, options)
+}
+
+// setIfUnchanged sets the key with the given preconditions and returns the modified index of the written key. If the
+// response of a successful write is lost, the retry fails because of the changed index. Therefore, a failed
+// precondition counts as success if the key already has the written value.
+func (etcd *resilentEtcdClient) setIfUnchanged(ctx context.Context, key string, value string, options *client.SetOptions) (uint64, error) {
+	var index uint64
+	err := etcd.retriers.run(ctx, etcd.retriers.write, "set", func(ctx context.Context) error {
+		response, err := etcd.kapi.Set(ctx, key, value, options)
+		if err != nil {
+			return err
+		}
+
+		index = response.Node.ModifiedIndex
+		return nil
+	})
+
+	if isPreconditionFailed(err) {
+		node, getErr := etcd.getNodeOrNil(ctx, key)
+		if getErr == nil && node != nil && !node.Dir && node.Value == value {
+			return node.ModifiedIndex, nil
+		}
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to set key %s", key)
+	}
+
+	return index, nil
+}
+
+// deleteIfUnchanged deletes the key if it still has the given modified index. A key which does not exist counts as
+// deleted, e.g. because the response of a successful delete was lost.
+func (etcd *resilentEtcdClient) deleteIfUnchanged(ctx context.Context, key string, index uint64) error {
+	err := etcd.Delete(ctx, key, &client.DeleteOptions{PrevIndex: index})
+	if IsKeyNotFoundError(err) {
+		return nil
+	}
+	return err
+}
+
+func (etcd *resilentEtcdClient) revertChanges(ctx context.Context, changes []keyChange, previousNodes []*client.Node, appliedIndexes []uint64) error {
+	var result error
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := changes[i]
+		previousNode := previousNodes[i]
+
+		var err error
+		switch {
+		case previousNode == nil && change.delete:
+			continue
+		case previousNode == nil:
+			err = etcd.deleteIfUnchanged(ctx, change.key, appliedIndexes[i])
+		case change.delete:
+			_, err = etcd.setIfUnchanged(ctx, change.key, previousNode.Value, &client.SetOptions{
+				PrevExist: client.PrevNoExist,
+				TTL:       time.Duration(previousNode.TTL) * time.Second,
+			})
+		default:
+			_, err = etcd.setIfUnchanged(ctx, change.key, previousNode.Value, &client.SetOptions{
+				PrevIndex: appliedIndexes[i],
+				TTL:       time.Duration(previousNode.TTL) * time.Second,
+			})
+		}
+
+		if err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to revert change of key %s", change.key))
 		}
 	}
-	eventChannel <- resp
+
+	return result
 }
 
-// We only update the recent index iff it is 0; which happens only in 2 cases:
-// 1. At startup
-// 2. In case of an error during watch
-// We do this, to not miss any changes made to etcd between
-// 1. Startup and starting the watcher
-// 2. An error and the restart of the watcher
-func (etcd *resilentEtcdClient) updateIndexIfNecessary(index uint64) {
-	if etcd.recentIndex == 0 {
-		etcd.indexMutex.Lock()
-		defer etcd.indexMutex.Unlock()
-		if etcd.recentIndex == 0 {
-			etcd.recentIndex = index
+// Watch watches for changes of the provided key which happened after the given index and sends the event through
+// the channel. An index of 0 starts the watch at the current index. The call blocks until the given context is done
+// or the watch fails.
+func (etcd *resilentEtcdClient) Watch(ctx context.Context, key string, recursive bool, afterIndex uint64, eventChannel chan<- *client.Response) error {
+	kapi := etcd.watchKapi
+	if kapi == nil {
+		kapi = etcd.kapi
+	}
+
+	watcher := kapi.Watcher(key, &client.WatcherOptions{AfterIndex: afterIndex, Recursive: recursive})
+	for {
+		response, err := watcher.Next(ctx)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case eventChannel <- response:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
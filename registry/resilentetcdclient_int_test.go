@@ -88,7 +88,7 @@ func newServer() *httptest.Server {
 	return httptest.NewServer(reverseProxyHandler)
 }
 
-func Test_getMainNode_inttest(t *testing.T) {
+func Test_GetMainNode_inttest(t *testing.T) {
 	// create etcd address, for local execution and on ci
 	etcd := os.Getenv("ETCD")
 	if etcd == "" {
@@ -103,28 +103,28 @@ func Test_getMainNode_inttest(t *testing.T) {
 	})
 
 	defer func() {
-		_ = client.DeleteRecursive("/dir_test")
-		_ = client.DeleteRecursive("/config")
+		_ = client.DeleteRecursive(context.Background(), "/dir_test")
+		_ = client.DeleteRecursive(context.Background(), "/config")
 	}()
 
-	_, err = client.Set("/dir_test/key1/subkey1", "val1", nil)
+	_, err = client.Set(context.Background(), "/dir_test/key1/subkey1", "val1", nil)
 	require.Nil(t, err)
 
-	_, err = client.Set("/dir_test/key1/subkey2", "val2", nil)
+	_, err = client.Set(context.Background(), "/dir_test/key1/subkey2", "val2", nil)
 	require.Nil(t, err)
 
-	_, err = client.Set("/dir_test/key2", "val3", nil)
+	_, err = client.Set(context.Background(), "/dir_test/key2", "val3", nil)
 	require.Nil(t, err)
 
 	// Does not fail when config/_global is unset
-	_, err = client.getMainNode()
+	_, err = client.GetMainNode(context.Background())
 	require.NoError(t, err)
 
-	_, err = client.Set("/config/_global/key", "val4", nil)
+	_, err = client.Set(context.Background(), "/config/_global/key", "val4", nil)
 	require.Nil(t, err)
 
 	// Does not fail when config/_global is set
-	node, err := client.getMainNode()
+	node, err := client.GetMainNode(context.Background())
 	require.NoError(t, err)
 
 	found := false
@@ -162,48 +162,48 @@ func TestGetSetDeleteWithRetry_inttest(t *testing.T) {
 	server := newFaultyServer()
 	defer server.Close()
 
-	cl, err := newResilientEtcdClient([]string{server.URL}, core.RetryPolicy{Interval: 100})
+	cl, err := newResilientEtcdClient([]string{server.URL}, core.Registry{RetryPolicy: core.RetryPolicy{Interval: 100}})
 	require.Nil(t, err)
 
-	_, err = cl.Set("/test/one", "1", nil)
+	_, err = cl.Set(context.Background(), "/test/one", "1", nil)
 	require.Nil(t, err)
 
-	_, err = cl.Set("/mydir", "", &client.SetOptions{
+	_, err = cl.Set(context.Background(), "/mydir", "", &client.SetOptions{
 		Dir:       true,
 		PrevExist: client.PrevIgnore,
 	})
 	require.Nil(t, err)
 
-	_, err = cl.Set("/mydir/key/one", "val", nil)
+	_, err = cl.Set(context.Background(), "/mydir/key/one", "val", nil)
 	require.Nil(t, err)
 
-	exists, err := cl.Exists("/test/one")
+	exists, err := cl.Exists(context.Background(), "/test/one")
 	require.Nil(t, err)
 	require.True(t, exists)
 
-	value, err := cl.Get("/test/one")
+	value, err := cl.Get(context.Background(), "/test/one")
 	require.Nil(t, err)
 
 	require.Equal(t, "1", value)
 
-	err = cl.Delete("/test/one", nil)
+	err = cl.Delete(context.Background(), "/test/one", nil)
 	require.Nil(t, err)
 
-	exists, err = cl.Exists("/test/one")
+	exists, err = cl.Exists(context.Background(), "/test/one")
 	require.Nil(t, err)
 	require.False(t, exists)
 
-	exists, err = cl.Exists("/mydir")
+	exists, err = cl.Exists(context.Background(), "/mydir")
 	require.Nil(t, err)
 	require.True(t, exists)
 
-	exists, err = cl.Exists("/mydir/key/one")
+	exists, err = cl.Exists(context.Background(), "/mydir/key/one")
 	require.Nil(t, err)
 	require.True(t, exists)
 
-	err = cl.DeleteRecursive("/mydir")
+	err = cl.DeleteRecursive(context.Background(), "/mydir")
 
-	exists, err = cl.Exists("/mydir/key/one")
+	exists, err = cl.Exists(context.Background(), "/mydir/key/one")
 	require.Nil(t, err)
 	require.False(t, exists)
 }
@@ -214,7 +214,7 @@ func TestWatch_inttest(t *testing.T) {
 	server := newServer()
 	defer server.Close()
 
-	cl, err := newResilientEtcdClient([]string{server.URL}, core.RetryPolicy{Interval: 100})
+	cl, err := newResilientEtcdClient([]string{server.URL}, core.Registry{RetryPolicy: core.RetryPolicy{Interval: 100}})
 	require.Nil(t, err)
 
 	myResponseChannel := make(chan *client.Response)
@@ -224,12 +224,12 @@ func TestWatch_inttest(t *testing.T) {
 	changeKeyTimer := time.NewTimer(time.Second * 2)
 	go func() {
 		<-changeKeyTimer.C
-		_, err = cl.Set("/mywatchkey", "myvalue", &client.SetOptions{})
+		_, err = cl.Set(context.Background(), "/mywatchkey", "myvalue", &client.SetOptions{})
 		require.NoError(t, err)
 		changeKeyTimer.Stop()
 	}()
 
-	go cl.Watch(ctx, "/mywatchkey", false, myResponseChannel)
+	go func() { _ = cl.Watch(ctx, "/mywatchkey", false, 0, myResponseChannel) }()
 
 	for {
 		select {
@@ -266,22 +266,22 @@ func TestSetWithTTL_inttest(t *testing.T) {
 	server := newFaultyServer()
 	defer server.Close()
 
-	etcdClient, err := newResilientEtcdClient([]string{server.URL}, core.RetryPolicy{Interval: 100})
+	etcdClient, err := newResilientEtcdClient([]string{server.URL}, core.Registry{RetryPolicy: core.RetryPolicy{Interval: 100}})
 	require.Nil(t, err)
 
-	_, err = etcdClient.Set("/test/one", "1", setOptions)
+	_, err = etcdClient.Set(context.Background(), "/test/one", "1", setOptions)
 	require.Nil(t, err)
 
-	exists, err := etcdClient.Exists("/test/one")
+	exists, err := etcdClient.Exists(context.Background(), "/test/one")
 	require.Nil(t, err)
 	require.True(t, exists)
 
-	value, err := etcdClient.Get("/test/one")
+	value, err := etcdClient.Get(context.Background(), "/test/one")
 	require.Nil(t, err)
 	require.Equal(t, "1", value)
 
 	// Refresh to have the maximum ttl
-	value, err = etcdClient.Set("/test/one", "", refreshOptions)
+	value, err = etcdClient.Set(context.Background(), "/test/one", "", refreshOptions)
 	require.Nil(t, err)
 	require.Equal(t, "1", value)
 
@@ -292,14 +292,14 @@ func TestSetWithTTL_inttest(t *testing.T) {
 	fmt.Printf("Waiting %d seconds...\n", refreshWaitDuration)
 	time.Sleep(refreshWaitDurationParsed)
 
-	value, err = etcdClient.Set("/test/one", "", refreshOptions)
+	value, err = etcdClient.Set(context.Background(), "/test/one", "", refreshOptions)
 	require.Nil(t, err)
 	require.Equal(t, "1", value)
 
 	// Wait again ttl-2 seconds and make sure that the value still exists
 	fmt.Printf("Waiting %d seconds...\n", refreshWaitDuration)
 	time.Sleep(refreshWaitDurationParsed)
-	value, err = etcdClient.Get("/test/one")
+	value, err = etcdClient.Get(context.Background(), "/test/one")
 	require.Nil(t, err)
 	require.Equal(t, "1", value)
 
@@ -310,7 +310,7 @@ func TestSetWithTTL_inttest(t *testing.T) {
 	fmt.Printf("Waiting %d seconds...\n", expireDuration)
 	time.Sleep(expireDurationParsed)
 
-	exists, err = etcdClient.Exists("/test/one")
+	exists, err = etcdClient.Exists(context.Background(), "/test/one")
 	require.Nil(t, err)
 	require.False(t, exists)
 }
@@ -321,38 +321,38 @@ func TestGetChildrenPathsAndRecursiveOperations_inttest(t *testing.T) {
 	server := newFaultyServer()
 	defer server.Close()
 
-	etcdClient, err := newResilientEtcdClient([]string{server.URL}, core.RetryPolicy{Interval: 100})
+	etcdClient, err := newResilientEtcdClient([]string{server.URL}, core.Registry{RetryPolicy: core.RetryPolicy{Interval: 100}})
 	require.Nil(t, err)
 
-	_, err = etcdClient.Set("/parent/child0/cchild0", "1", nil)
+	_, err = etcdClient.Set(context.Background(), "/parent/child0/cchild0", "1", nil)
 	require.Nil(t, err)
 
-	_, err = etcdClient.Set("/parent/child0/cchild1", "1", nil)
+	_, err = etcdClient.Set(context.Background(), "/parent/child0/cchild1", "1", nil)
 	require.Nil(t, err)
 
-	_, err = etcdClient.Set("/parent/child1/cchild0", "1", nil)
+	_, err = etcdClient.Set(context.Background(), "/parent/child1/cchild0", "1", nil)
 	require.Nil(t, err)
 
-	_, err = etcdClient.Set("/parent/child2", "1", nil)
+	_, err = etcdClient.Set(context.Background(), "/parent/child2", "1", nil)
 	require.Nil(t, err)
 
-	childrenPaths, err := etcdClient.GetChildrenPaths("/parent")
+	childrenPaths, err := etcdClient.GetChildrenPaths(context.Background(), "/parent")
 	require.Nil(t, err)
 
 	require.Contains(t, childrenPaths, "/parent/child0")
 	require.Contains(t, childrenPaths, "/parent/child1")
 
-	children, err := etcdClient.GetRecursive("/parent")
+	children, err := etcdClient.GetRecursive(context.Background(), "/parent")
 	require.Nil(t, err)
 
 	require.Equal(t, "1", children["child0/cchild0"])
 	require.Equal(t, "1", children["child0/cchild1"])
 	require.Equal(t, "1", children["child1/cchild0"])
 
-	err = etcdClient.DeleteRecursive("/parent")
+	err = etcdClient.DeleteRecursive(context.Background(), "/parent")
 	require.Nil(t, err)
 
-	node, err := etcdClient.Get("/parent")
+	node, err := etcdClient.Get(context.Background(), "/parent")
 	require.NotNil(t, err)
 	require.Equal(t, "", node)
 }
@@ -368,9 +368,9 @@ func Test_resilentEtcdClient_Get_inttest(t *testing.T) {
 		clientErr := client.Error{Code: client.ErrorCodeKeyNotFound}
 		clientResponse := &client.Response{}
 		mockedKeysAPI.On("Get", mock.Anything, "/config/theKey", mock.Anything).Return(clientResponse, clientErr)
-		sut := resilentEtcdClient{kapi: mockedKeysAPI, retrier: mockedRetrier}
+		sut := resilentEtcdClient{kapi: mockedKeysAPI, retriers: etcdRetriers{read: mockedRetrier, write: mockedRetrier, delete: mockedRetrier}}
 
-		actual, err := sut.Get("/config/theKey")
+		actual, err := sut.Get(context.Background(), "/config/theKey")
 
 		require.Error(t, err)
 		require.True(t, IsKeyNotFoundError(err))
@@ -391,7 +391,7 @@ func Test_resilentEtcdClient_Watch_inttest(t *testing.T) {
 		watcherMock.On("Next", mock.Anything).Return(clientResponse, nil)
 		mockedKeysAPI := new(mockKeysAPI)
 		mockedKeysAPI.On("Watcher", "/key", mock.Anything).Return(watcherMock)
-		underTest := resilentEtcdClient{kapi: mockedKeysAPI, retrier: mockedRetrier}
+		underTest := resilentEtcdClient{kapi: mockedKeysAPI, retriers: etcdRetriers{read: mockedRetrier, write: mockedRetrier, delete: mockedRetrier}}
 		eventChannel := make(chan *client.Response)
 
 		ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*2)
@@ -403,8 +403,61 @@ func Test_resilentEtcdClient_Watch_inttest(t *testing.T) {
 		}()
 
 		// when
-		underTest.Watch(ctx, "/key", false, eventChannel)
+		err := underTest.Watch(ctx, "/key", false, 0, eventChannel)
 		cancelFunc()
+
+		// then
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+	t.Run("should use the keys api for watches if it is set", func(t *testing.T) {
+		// given
+		mockedRetrier := retrier.New(
+			retrier.ExponentialBackoff(1, time.Millisecond),
+			&etcdClassifier{},
+		)
+		watcherMock := new(mockWatcher)
+		watcherMock.On("Next", mock.Anything).Return(&client.Response{}, context.Canceled)
+		mockedKeysAPI := new(mockKeysAPI)
+		mockedWatchKeysAPI := new(mockKeysAPI)
+		mockedWatchKeysAPI.On("Watcher", "/key", mock.Anything).Return(watcherMock)
+		underTest := resilentEtcdClient{kapi: mockedKeysAPI, watchKapi: mockedWatchKeysAPI, retriers: etcdRetriers{read: mockedRetrier, write: mockedRetrier, delete: mockedRetrier}}
+
+		// when
+		err := underTest.Watch(context.Background(), "/key", false, 0, make(chan *client.Response))
+
+		// then
+		require.ErrorIs(t, err, context.Canceled)
+		mockedKeysAPI.AssertExpectations(t)
+		mockedWatchKeysAPI.AssertExpectations(t)
+	})
+}
+
+func Test_resilentEtcdClient_ApplyBatch_inttest(t *testing.T) {
+	t.Run("should revert applied changes if a change fails", func(t *testing.T) {
+		// given
+		mockedRetrier := retrier.New(
+			retrier.ExponentialBackoff(1, time.Millisecond),
+			&etcdClassifier{},
+		)
+		mockedKeysAPI := new(mockKeysAPI)
+		existingNode := &client.Node{Key: "/config/existing", Value: "old", ModifiedIndex: 4}
+		mockedKeysAPI.On("Get", mock.Anything, "/config/existing", mock.Anything).Return(&client.Response{Node: existingNode}, nil)
+		mockedKeysAPI.On("Get", mock.Anything, "/config/new", mock.Anything).Return(&client.Response{}, client.Error{Code: client.ErrorCodeKeyNotFound})
+		mockedKeysAPI.On("Set", mock.Anything, "/config/existing", "changed", &client.SetOptions{PrevIndex: 4}).Return(&client.Response{Node: &client.Node{}}, nil)
+		mockedKeysAPI.On("Set", mock.Anything, "/config/new", "value", &client.SetOptions{PrevExist: client.PrevNoExist}).Return(&client.Response{}, client.Error{Code: client.ErrorCodeNodeExist})
+		mockedKeysAPI.On("Set", mock.Anything, "/config/existing", "old", &client.SetOptions{}).Return(&client.Response{Node: existingNode}, nil)
+		sut := resilentEtcdClient{kapi: mockedKeysAPI, retriers: etcdRetriers{read: mockedRetrier, write: mockedRetrier, delete: mockedRetrier}}
+
+		// when
+		err := sut.ApplyBatch(context.Background(), []keyChange{
+			{key: "/config/existing", value: "changed"},
+			{key: "/config/new", value: "value"},
+		})
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to apply change of key /config/new")
+		mockedKeysAPI.AssertExpectations(t)
 	})
 }
 
@@ -1,17 +1,374 @@
 package registry
 
 import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
 	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/eapache/go-resiliency/retrier"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"testing"
+	"go.etcd.io/etcd/client/v2"
 )
 
 func Test_newResilientEtcdClient(t *testing.T) {
 	t.Run("should return an error if creating the backoff fails", func(t *testing.T) {
-		config := core.RetryPolicy{Interval: -2}
+		config := core.Registry{RetryPolicy: core.RetryPolicy{Interval: -2}}
+
+		_, err := newResilientEtcdClient(nil, config)
+
+		require.Error(t, err)
+	})
+
+	t.Run("should return an error if the tls configuration is invalid", func(t *testing.T) {
+		config := core.Registry{TLS: core.RegistryTLS{CAFile: filepath.Join(t.TempDir(), "missing.crt")}}
+
+		_, err := newResilientEtcdClient([]string{"https://localhost:4001"}, config)
+
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to create etcd client")
+	})
+
+	t.Run("should connect with tls and credentials", func(t *testing.T) {
+		// given
+		server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			username, password, ok := request.BasicAuth()
+			if !ok || username != "root" || password != "secret" {
+				writer.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			writer.Header().Set("Content-Type", "application/json")
+			writer.Header().Set("X-Etcd-Index", "1")
+			_, _ = writer.Write([]byte(`{"action":"get","node":{"key":"/key","value":"value","modifiedIndex":1,"createdIndex":1}}`))
+		}))
+		defer server.Close()
+
+		caFile := filepath.Join(t.TempDir(), "ca.crt")
+		caCertificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		require.NoError(t, os.WriteFile(caFile, caCertificate, 0600))
+
+		sut, err := newResilientEtcdClient([]string{server.URL}, core.Registry{
+			TLS:      core.RegistryTLS{CAFile: caFile},
+			Username: "root",
+			Password: "secret",
+		})
+		require.NoError(t, err)
+
+		// when
+		actual, err := sut.Get(context.Background(), "/key")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "value", actual)
+	})
+}
+
+func Test_resilentEtcdClient_retries(t *testing.T) {
+	createCountingServer := func(t *testing.T, statusCode int, body string) (*httptest.Server, *atomic.Int32) {
+		requests := &atomic.Int32{}
+		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			requests.Add(1)
+			writer.Header().Set("Content-Type", "application/json")
+			writer.Header().Set("X-Etcd-Index", "1")
+			writer.WriteHeader(statusCode)
+			_, _ = writer.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return server, requests
+	}
+
+	t.Run("should not retry permanent errors", func(t *testing.T) {
+		// given
+		server, requests := createCountingServer(t, http.StatusForbidden, `{"errorCode":102,"message":"Not a file","cause":"/key","index":1}`)
+		sut, err := newResilientEtcdClient([]string{server.URL}, core.Registry{
+			RetryPolicy: core.RetryPolicy{Interval: 1, MaxRetryCount: 3},
+		})
+		require.NoError(t, err)
+
+		// when
+		_, err = sut.Get(context.Background(), "/key")
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "Not a file")
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("should retry every class of requests with its own retry policy", func(t *testing.T) {
+		// given
+		server, requests := createCountingServer(t, http.StatusServiceUnavailable, "")
+		sut, err := newResilientEtcdClient([]string{server.URL}, core.Registry{
+			RetryPolicy:       core.RetryPolicy{Interval: 1, MaxRetryCount: 1},
+			DeleteRetryPolicy: &core.RetryPolicy{Interval: 1, MaxRetryCount: 3},
+		})
+		require.NoError(t, err)
+
+		// when
+		_, getErr := sut.Get(context.Background(), "/key")
+		getRequests := requests.Swap(0)
+		deleteErr := sut.Delete(context.Background(), "/key", nil)
+		deleteRequests := requests.Load()
+
+		// then
+		require.Error(t, getErr)
+		require.Error(t, deleteErr)
+		assert.Equal(t, int32(2), getRequests)
+		assert.Equal(t, int32(4), deleteRequests)
+	})
+
+	t.Run("should decide about retries with the configured classifier", func(t *testing.T) {
+		// given
+		server, requests := createCountingServer(t, http.StatusServiceUnavailable, "")
+		sut, err := newResilientEtcdClient([]string{server.URL}, core.Registry{
+			RetryPolicy:     core.RetryPolicy{Interval: 1, MaxRetryCount: 3},
+			RetryClassifier: retrier.WhitelistClassifier{},
+		})
+		require.NoError(t, err)
+
+		// when
+		_, err = sut.Set(context.Background(), "/key", "value", nil)
+
+		// then
+		require.Error(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("should record requests and retries", func(t *testing.T) {
+		// given
+		server, _ := createCountingServer(t, http.StatusServiceUnavailable, "")
+		recorder := &recordingMetricsRecorder{}
+		sut, err := newResilientEtcdClient([]string{server.URL}, core.Registry{
+			RetryPolicy: core.RetryPolicy{Interval: 1, MaxRetryCount: 2},
+			Metrics:     recorder,
+		})
+		require.NoError(t, err)
+
+		// when
+		_, err = sut.Get(context.Background(), "/key")
+
+		// then
+		require.Error(t, err)
+		assert.Equal(t, []string{"etcd get error"}, recorder.getRequests())
+		assert.Equal(t, []string{"etcd get", "etcd get"}, recorder.getRetries())
+	})
+
+	t.Run("should return an error if a retry policy of an operation is invalid", func(t *testing.T) {
+		config := core.Registry{WriteRetryPolicy: &core.RetryPolicy{Interval: -2}}
 
 		_, err := newResilientEtcdClient(nil, config)
 
 		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to create retrier of writing requests")
 	})
 }
+
+func Test_etcdClassifier_Classify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want retrier.Action
+	}{
+		{"should succeed without error", nil, retrier.Succeed},
+		{"should succeed on missing key", client.Error{Code: client.ErrorCodeKeyNotFound}, retrier.Succeed},
+		{"should succeed on failed precondition", client.Error{Code: client.ErrorCodeTestFailed}, retrier.Succeed},
+		{"should fail on directory", client.Error{Code: client.ErrorCodeNotFile}, retrier.Fail},
+		{"should fail on missing permission", client.Error{Code: client.ErrorCodeUnauthorized}, retrier.Fail},
+		{"should retry leader election", client.Error{Code: client.ErrorCodeLeaderElect}, retrier.Retry},
+		{"should retry unknown errors", errors.New("connection refused"), retrier.Retry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewEtcdRetryClassifier().Classify(tt.err))
+		})
+	}
+}
+
+func Test_resilentEtcdClient_ApplyBatch(t *testing.T) {
+	createSut := func(t *testing.T, kapi *fakeKeysAPI) *resilentEtcdClient {
+		retriers, err := newEtcdRetriers(core.Registry{RetryPolicy: core.RetryPolicy{Interval: 1, MaxRetryCount: 2}})
+		require.NoError(t, err)
+		return &resilentEtcdClient{kapi: kapi, retriers: retriers}
+	}
+
+	t.Run("should not revert a write whose response was lost", func(t *testing.T) {
+		// given
+		kapi := newFakeKeysAPI(map[string]string{"/a": "old"})
+		kapi.lostResponses["/a"] = true
+		sut := createSut(t, kapi)
+
+		// when
+		err := sut.ApplyBatch(context.Background(), []keyChange{{key: "/a", value: "new"}, {key: "/b", value: "created"}})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"/a": "new", "/b": "created"}, kapi.values())
+	})
+
+	t.Run("should only revert keys which were not changed concurrently", func(t *testing.T) {
+		// given
+		kapi := newFakeKeysAPI(map[string]string{"/a": "old", "/c": "old"})
+		kapi.beforeSet = func(key string) error {
+			if key != "/d" {
+				return nil
+			}
+			kapi.set("/a", "concurrent")
+			return client.Error{Code: client.ErrorCodeNotFile, Message: "Not a file", Cause: key}
+		}
+		sut := createSut(t, kapi)
+
+		// when
+		err := sut.ApplyBatch(context.Background(), []keyChange{
+			{key: "/a", value: "new"},
+			{key: "/b", value: "created"},
+			{key: "/c", delete: true},
+			{key: "/d", value: "fails"},
+		})
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to apply change of key /d")
+		assert.ErrorContains(t, err, "failed to revert change of key /a")
+		assert.Equal(t, map[string]string{"/a": "concurrent", "/c": "old"}, kapi.values())
+	})
+}
+
+// fakeKeysAPI keeps the keys of the v2 api in memory. It supports the preconditions of the batches.
+type fakeKeysAPI struct {
+	client.KeysAPI
+	nodes map[string]*client.Node
+	index uint64
+	// lostResponses contains the keys whose next successful write returns an error like a lost response.
+	lostResponses map[string]bool
+	// beforeSet is called before every write and fails the write if it returns an error.
+	beforeSet func(key string) error
+}
+
+func newFakeKeysAPI(values map[string]string) *fakeKeysAPI {
+	kapi := &fakeKeysAPI{nodes: map[string]*client.Node{}, lostResponses: map[string]bool{}}
+	for key, value := range values {
+		kapi.set(key, value)
+	}
+	return kapi
+}
+
+func (kapi *fakeKeysAPI) set(key string, value string) *client.Node {
+	kapi.index++
+	node := &client.Node{Key: key, Value: value, ModifiedIndex: kapi.index}
+	kapi.nodes[key] = node
+	return node
+}
+
+func (kapi *fakeKeysAPI) values() map[string]string {
+	result := map[string]string{}
+	for key, node := range kapi.nodes {
+		result[key] = node.Value
+	}
+	return result
+}
+
+func (kapi *fakeKeysAPI) Get(_ context.Context, key string, _ *client.GetOptions) (*client.Response, error) {
+	node, ok := kapi.nodes[key]
+	if !ok {
+		return nil, client.Error{Code: client.ErrorCodeKeyNotFound, Message: "Key not found", Cause: key}
+	}
+	return &client.Response{Action: "get", Node: node}, nil
+}
+
+func (kapi *fakeKeysAPI) Set(_ context.Context, key string, value string, options *client.SetOptions) (*client.Response, error) {
+	if kapi.beforeSet != nil {
+		if err := kapi.beforeSet(key); err != nil {
+			return nil, err
+		}
+	}
+
+	node, exists := kapi.nodes[key]
+	if options != nil && options.PrevExist == client.PrevNoExist && exists {
+		return nil, client.Error{Code: client.ErrorCodeNodeExist, Message: "Key already exists", Cause: key}
+	}
+	if options != nil && options.PrevIndex != 0 && (!exists || node.ModifiedIndex != options.PrevIndex) {
+		return nil, client.Error{Code: client.ErrorCodeTestFailed, Message: "Compare failed", Cause: key}
+	}
+
+	node = kapi.set(key, value)
+	if kapi.lostResponses[key] {
+		delete(kapi.lostResponses, key)
+		return nil, errors.New("connection reset by peer")
+	}
+	return &client.Response{Action: "set", Node: node}, nil
+}
+
+func (kapi *fakeKeysAPI) Delete(_ context.Context, key string, options *client.DeleteOptions) (*client.Response, error) {
+	node, exists := kapi.nodes[key]
+	if !exists {
+		return nil, client.Error{Code: client.ErrorCodeKeyNotFound, Message: "Key not found", Cause: key}
+	}
+	if options != nil && options.PrevIndex != 0 && node.ModifiedIndex != options.PrevIndex {
+		return nil, client.Error{Code: client.ErrorCodeTestFailed, Message: "Compare failed", Cause: key}
+	}
+
+	delete(kapi.nodes, key)
+	return &client.Response{Action: "delete", PrevNode: node}, nil
+}
+
+// recordingMetricsRecorder records the component and operation of every observed request, retry and cache event.
+type recordingMetricsRecorder struct {
+	mutex       sync.Mutex
+	requests    []string
+	retries     []string
+	cacheEvents []string
+}
+
+func (recorder *recordingMetricsRecorder) ObserveRequest(component string, operation string, _ time.Duration, err error) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	recorder.requests = append(recorder.requests, component+" "+operation+" "+result)
+}
+
+func (recorder *recordingMetricsRecorder) CountRetry(component string, operation string) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.retries = append(recorder.retries, component+" "+operation)
+}
+
+func (recorder *recordingMetricsRecorder) CountCacheEvent(component string, event core.CacheEvent) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.cacheEvents = append(recorder.cacheEvents, component+" "+string(event))
+}
+
+func (recorder *recordingMetricsRecorder) getRequests() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return append([]string(nil), recorder.requests...)
+}
+
+func (recorder *recordingMetricsRecorder) getRetries() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return append([]string(nil), recorder.retries...)
+}
+
+func (recorder *recordingMetricsRecorder) getCacheEvents() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return append([]string(nil), recorder.cacheEvents...)
+}
@@ -0,0 +1,877 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/client/v2"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const etcdV3DialTimeout = 5 * time.Second
+
+// resilentEtcdV3Client is build up on the v3 api of etcd and adds retries for every failed request. The v3 api has
+// a flat key space, so the client emulates the directories of the v2 api: every key prefix which ends with a slash
+// is treated as directory. Keys which are only used as directory do not exist in etcd. The time to live of keys is
+// realized with one lease per key.
+type resilentEtcdV3Client struct {
+	conn     *clientv3.Client
+	kv       clientv3.KV
+	lease    clientv3.Lease
+	watcher  clientv3.Watcher
+	retriers etcdRetriers
+}
+
+// newResilientEtcdV3Client creates a client for the v3 api of etcd which retries every failed request.
+// The connection is encrypted and authenticated according to the TLS options and the credentials of the configuration.
+func newResilientEtcdV3Client(endpoints []string, configuration core.Registry) (*resilentEtcdV3Client, error) {
+	log.Debug("create etcd v3 client for endpoints", endpoints)
+
+	retriers, err := newEtcdRetriers(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resilentEtcdV3Client: %w", err)
+	}
+
+	tlsConfig, err := createEtcdTLSConfig(endpoints, configuration.TLS)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create etcd v3 client")
+	}
+
+	conn, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: etcdV3DialTimeout,
+		TLS:         tlsConfig,
+		Username:    configuration.Username,
+		Password:    configuration.Password,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create etcd v3 client")
+	}
+
+	return &resilentEtcdV3Client{
+		conn:     conn,
+		kv:       conn.KV,
+		lease:    conn.Lease,
+		watcher:  conn.Watcher,
+		retriers: retriers,
+	}, nil
+}
+
+// Close closes the connection to etcd. The client must not be used afterwards.
+func (etcd *resilentEtcdV3Client) Close() error {
+	if etcd.conn == nil {
+		return nil
+	}
+	return etcd.conn.Close()
+}
+
+// Exists returns true if the key or a directory with the given name exists
+func (etcd *resilentEtcdV3Client) Exists(ctx context.Context, key string) (bool, error) {
+	key = normalizeKey(key)
+
+	var exists bool
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "exists", func(ctx context.Context) error {
+		core.GetLogger().Debugf("check if key %s exists", key)
+		var err error
+		exists, err = etcd.existsKeyOrDirectory(ctx, key)
+		return err
+	})
+
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to read key %s", key)
+	}
+
+	return exists, nil
+}
+
+func (etcd *resilentEtcdV3Client) existsKeyOrDirectory(ctx context.Context, key string) (bool, error) {
+	response, err := etcd.kv.Get(ctx, key, clientv3.WithCountOnly())
+	if err != nil {
+		return false, err
+	}
+	if response.Count > 0 {
+		return true, nil
+	}
+
+	return etcd.isDirectory(ctx, key)
+}
+
+func (etcd *resilentEtcdV3Client) isDirectory(ctx context.Context, key string) (bool, error) {
+	response, err := etcd.kv.Get(ctx, directoryPrefix(key), clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return false, err
+	}
+
+	return response.Count > 0, nil
+}
+
+// Get returns the value of the given key. Directories have an empty value. If the given key cannot be found a
+// KeyNotFoundError is returned.
+func (etcd *resilentEtcdV3Client) Get(ctx context.Context, key string) (string, error) {
+	key = normalizeKey(key)
+
+	var result string
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "get", func(ctx context.Context) error {
+		core.GetLogger().Debugf("read key %s", key)
+		response, err := etcd.kv.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if len(response.Kvs) > 0 {
+			result = string(response.Kvs[0].Value)
+			return nil
+		}
+
+		isDir, err := etcd.isDirectory(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !isDir {
+			return newEtcdV3Error(client.ErrorCodeKeyNotFound, "Key not found", key, response.Header)
+		}
+
+		result = ""
+		return nil
+	})
+
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read key %s", key)
+	}
+
+	return result, nil
+}
+
+// GetRecursive returns a map of key Value pairs below the given key
+func (etcd *resilentEtcdV3Client) GetRecursive(ctx context.Context, key string) (map[string]string, error) {
+	key = normalizeKey(key)
+	prefix := directoryPrefix(key)
+
+	var result map[string]string
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "getRecursive", func(ctx context.Context) error {
+		core.GetLogger().Debugf("read key %s recursive", key)
+		response, err := etcd.kv.Get(ctx, prefix, clientv3.WithPrefix())
+		if err != nil {
+			return err
+		}
+
+		if len(response.Kvs) == 0 {
+			exists, err := etcd.existsKeyOrDirectory(ctx, key)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return newEtcdV3Error(client.ErrorCodeKeyNotFound, "Key not found", key, response.Header)
+			}
+		}
+
+		result = map[string]string{}
+		for _, kv := range response.Kvs {
+			relativeKey := strings.TrimPrefix(string(kv.Key), prefix)
+			if !isHiddenPath(relativeKey) {
+				result[relativeKey] = string(kv.Value)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read key %s recursive", key)
+	}
+
+	return result, nil
+}
+
+// GetChildrenPaths returns an array of all children keys of the given key
+func (etcd *resilentEtcdV3Client) GetChildrenPaths(ctx context.Context, key string) ([]string, error) {
+	key = normalizeKey(key)
+	prefix := directoryPrefix(key)
+
+	children := []string{}
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "getChildrenPaths", func(ctx context.Context) error {
+		core.GetLogger().Debugf("read children paths from %s", key)
+
+		response, err := etcd.kv.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+		if err != nil {
+			return err
+		}
+
+		var keys []string
+		for _, kv := range response.Kvs {
+			keys = append(keys, string(kv.Key))
+		}
+		children = childPathsOf(prefix, keys)
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read children from %s", key)
+	}
+
+	return children, nil
+}
+
+// GetMainNode returns the root node including all keys of etcd.
+func (etcd *resilentEtcdV3Client) GetMainNode(ctx context.Context) (*client.Node, error) {
+	var mainNode *client.Node
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "getMainNode", func(ctx context.Context) error {
+		response, err := etcd.kv.Get(ctx, "/", clientv3.WithPrefix())
+		if err != nil {
+			return err
+		}
+
+		leaseTTLs, err := etcd.leaseTTLs(ctx, response.Kvs)
+		if err != nil {
+			return err
+		}
+
+		mainNode = buildEtcdNodeTree(response.Kvs, leaseTTLs, false)
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot get main node from etcd: %w", err)
+	}
+
+	return mainNode, nil
+}
+
+// GetTree returns the node of the given key including all its children recursively. Unlike GetMainNode, hidden keys
+// are included.
+func (etcd *resilentEtcdV3Client) GetTree(ctx context.Context, key string) (*client.Node, error) {
+	key = normalizeKey(key)
+
+	var tree *client.Node
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "getTree", func(ctx context.Context) error {
+		response, err := etcd.kv.Txn(ctx).
+			Then(clientv3.OpGet(key), clientv3.OpGet(directoryPrefix(key), clientv3.WithPrefix())).
+			Commit()
+		if err != nil {
+			return err
+		}
+
+		var kvs []*mvccpb.KeyValue
+		for _, operationResponse := range response.Responses {
+			kvs = append(kvs, operationResponse.GetResponseRange().Kvs...)
+		}
+
+		leaseTTLs, err := etcd.leaseTTLs(ctx, kvs)
+		if err != nil {
+			return err
+		}
+
+		root := buildEtcdNodeTree(kvs, leaseTTLs, true)
+		if key == "/" {
+			tree = root
+			return nil
+		}
+
+		tree = findEtcdNodeByKey(root, key)
+		if tree == nil {
+			return newEtcdV3Error(client.ErrorCodeKeyNotFound, "Key not found", key, response.Header)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read key %s recursive", key)
+	}
+
+	return tree, nil
+}
+
+// Set sets the key to the given value. The options of the v2 api are translated to leases and transactions.
+func (etcd *resilentEtcdV3Client) Set(ctx context.Context, key string, value string, options *client.SetOptions) (string, error) {
+	key = normalizeKey(key)
+	if options == nil {
+		options = &client.SetOptions{}
+	}
+
+	var result string
+	err := etcd.retriers.run(ctx, etcd.retriers.write, "set", func(ctx context.Context) error {
+		core.GetLogger().Debugf("set key %s", key)
+		if options.Refresh {
+			var err error
+			result, err = etcd.refresh(ctx, key, options)
+			return err
+		}
+
+		if options.Dir {
+			// directories exist implicitly through their keys
+			result = ""
+			return nil
+		}
+
+		leaseID, err := etcd.grantLease(ctx, options.TTL)
+		if err != nil {
+			return err
+		}
+
+		previousLeaseID, err := etcd.put(ctx, key, value, options, leaseID)
+		if err != nil {
+			var etcdErr client.Error
+			if errors.As(err, &etcdErr) {
+				// the key was not written, so nobody else uses the new lease
+				etcd.revokeLease(ctx, leaseID)
+			}
+			return err
+		}
+		if previousLeaseID != leaseID {
+			// the key is detached from its previous lease, which would otherwise live until its time to live expires
+			etcd.revokeLease(ctx, previousLeaseID)
+		}
+
+		result = value
+		return nil
+	})
+
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to set key %s", key)
+	}
+
+	return result, nil
+}
+
+// put writes the key with the given lease, which may be 0 for a key without time to live. It returns the lease of the
+// previous value of the key.
+func (etcd *resilentEtcdV3Client) put(ctx context.Context, key string, value string, options *client.SetOptions, leaseID clientv3.LeaseID) (clientv3.LeaseID, error) {
+	putOptions := []clientv3.OpOption{clientv3.WithPrevKV()}
+	if leaseID != 0 {
+		putOptions = append(putOptions, clientv3.WithLease(leaseID))
+	}
+
+	comparisons := setComparisons(key, options)
+	if len(comparisons) == 0 {
+		response, err := etcd.kv.Put(ctx, key, value, putOptions...)
+		if err != nil {
+			return 0, err
+		}
+		return previousLease(response.PrevKv), nil
+	}
+
+	response, err := etcd.kv.Txn(ctx).
+		If(comparisons...).
+		Then(clientv3.OpPut(key, value, putOptions...)).
+		Else(clientv3.OpGet(key)).
+		Commit()
+	if err != nil {
+		return 0, err
+	}
+	if response.Succeeded {
+		return previousLease(response.Responses[0].GetResponsePut().PrevKv), nil
+	}
+
+	exists := len(response.Responses) > 0 && len(response.Responses[0].GetResponseRange().Kvs) > 0
+	switch {
+	case exists && options.PrevExist == client.PrevNoExist:
+		return 0, newEtcdV3Error(client.ErrorCodeNodeExist, "Key already exists", key, response.Header)
+	case !exists:
+		return 0, newEtcdV3Error(client.ErrorCodeKeyNotFound, "Key not found", key, response.Header)
+	default:
+		return 0, newEtcdV3Error(client.ErrorCodeTestFailed, "Compare failed", key, response.Header)
+	}
+}
+
+func previousLease(prevKv *mvccpb.KeyValue) clientv3.LeaseID {
+	if prevKv == nil {
+		return 0
+	}
+	return clientv3.LeaseID(prevKv.Lease)
+}
+
+func setComparisons(key string, options *client.SetOptions) []clientv3.Cmp {
+	var comparisons []clientv3.Cmp
+
+	switch options.PrevExist {
+	case client.PrevNoExist:
+		comparisons = append(comparisons, clientv3.Compare(clientv3.CreateRevision(key), "=", 0))
+	case client.PrevExist:
+		comparisons = append(comparisons, clientv3.Compare(clientv3.CreateRevision(key), ">", 0))
+	}
+
+	if options.PrevValue != "" {
+		comparisons = append(comparisons, clientv3.Compare(clientv3.Value(key), "=", options.PrevValue))
+	}
+	if options.PrevIndex != 0 {
+		comparisons = append(comparisons, clientv3.Compare(clientv3.ModRevision(key), "=", int64(options.PrevIndex)))
+	}
+
+	return comparisons
+}
+
+// grantLease grants a new lease with the given time to live. It returns 0 if the time to live is not positive, because
+// such keys do not expire.
+func (etcd *resilentEtcdV3Client) grantLease(ctx context.Context, ttl time.Duration) (clientv3.LeaseID, error) {
+	if ttl <= 0 {
+		return 0, nil
+	}
+
+	lease, err := etcd.lease.Grant(ctx, ttlInSeconds(ttl))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to grant lease")
+	}
+
+	return lease.ID, nil
+}
+
+// revokeLease revokes a lease which is no longer attached to its key. Every key has its own lease, so revoking it does
+// not delete other keys. A failed revocation is only logged, because the lease expires after its time to live anyway.
+func (etcd *resilentEtcdV3Client) revokeLease(ctx context.Context, leaseID clientv3.LeaseID) {
+	if leaseID == 0 {
+		return
+	}
+
+	_, err := etcd.lease.Revoke(ctx, leaseID)
+	if err != nil && !errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		log.Warningf("failed to revoke lease %x: %v", leaseID, err)
+	}
+}
+
+// refresh resets the time to live of the key to the time to live of its lease without changing its value. The time
+// to live of a lease cannot be changed, so the given time to live is only used for keys without a lease, which have
+// to be written again to attach a lease. Use Set to change the time to live of a key with a lease. The previous value
+// and index of the options are compared with the key like in etcd v2.
+func (etcd *resilentEtcdV3Client) refresh(ctx context.Context, key string, options *client.SetOptions) (string, error) {
+	response, err := etcd.kv.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if len(response.Kvs) == 0 {
+		return "", newEtcdV3Error(client.ErrorCodeKeyNotFound, "Key not found", key, response.Header)
+	}
+	kv := response.Kvs[0]
+	// the lease of the compared key is kept alive even if the key is replaced in the meantime, which never extends
+	// the lifetime of the new value, because every set grants a new lease
+	if (options.PrevValue != "" && string(kv.Value) != options.PrevValue) ||
+		(options.PrevIndex != 0 && uint64(kv.ModRevision) != options.PrevIndex) {
+		return "", newEtcdV3Error(client.ErrorCodeTestFailed, "Compare failed", key, response.Header)
+	}
+
+	if kv.Lease != 0 {
+		_, err = etcd.lease.KeepAliveOnce(ctx, clientv3.LeaseID(kv.Lease))
+		if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+			// the lease expired in the meantime and took the key with it
+			return "", newEtcdV3Error(client.ErrorCodeKeyNotFound, "Key not found", key, response.Header)
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to refresh lease of key %s", key)
+		}
+		return string(kv.Value), nil
+	}
+
+	leaseID, err := etcd.grantLease(ctx, options.TTL)
+	if err != nil {
+		return "", err
+	}
+	_, err = etcd.kv.Put(ctx, key, "", clientv3.WithLease(leaseID), clientv3.WithIgnoreValue())
+	if err != nil {
+		return "", err
+	}
+
+	return string(kv.Value), nil
+}
+
+// Delete deletes the given key or directory
+func (etcd *resilentEtcdV3Client) Delete(ctx context.Context, key string, options *client.DeleteOptions) error {
+	key = normalizeKey(key)
+	if options == nil {
+		options = &client.DeleteOptions{}
+	}
+
+	err := etcd.retriers.run(ctx, etcd.retriers.delete, "delete", func(ctx context.Context) error {
+		core.GetLogger().Debugf("delete key %s", key)
+		if options.Recursive {
+			return etcd.deleteRecursive(ctx, key)
+		}
+
+		return etcd.delete(ctx, key, options)
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete key %s", key)
+	}
+
+	return nil
+}
+
+func (etcd *resilentEtcdV3Client) delete(ctx context.Context, key string, options *client.DeleteOptions) error {
+	var comparisons []clientv3.Cmp
+	if options.PrevValue != "" {
+		comparisons = append(comparisons, clientv3.Compare(clientv3.Value(key), "=", options.PrevValue))
+	}
+	if options.PrevIndex != 0 {
+		comparisons = append(comparisons, clientv3.Compare(clientv3.ModRevision(key), "=", int64(options.PrevIndex)))
+	}
+
+	response, err := etcd.kv.Txn(ctx).
+		If(comparisons...).
+		Then(clientv3.OpDelete(key)).
+		Else(clientv3.OpGet(key)).
+		Commit()
+	if err != nil {
+		return err
+	}
+
+	if !response.Succeeded {
+		if len(response.Responses[0].GetResponseRange().Kvs) == 0 {
+			return newEtcdV3Error(client.ErrorCodeKeyNotFound, "Key not found", key, response.Header)
+		}
+		return newEtcdV3Error(client.ErrorCodeTestFailed, "Compare failed", key, response.Header)
+	}
+
+	if response.Responses[0].GetResponseDeleteRange().Deleted > 0 {
+		return nil
+	}
+
+	isDir, err := etcd.isDirectory(ctx, key)
+	if err != nil {
+		return err
+	}
+	if isDir {
+		return newEtcdV3Error(client.ErrorCodeNotFile, "Not a file", key, response.Header)
+	}
+
+	return newEtcdV3Error(client.ErrorCodeKeyNotFound, "Key not found", key, response.Header)
+}
+
+func (etcd *resilentEtcdV3Client) deleteRecursive(ctx context.Context, key string) error {
+	response, err := etcd.kv.Txn(ctx).
+		Then(clientv3.OpDelete(key), clientv3.OpDelete(directoryPrefix(key), clientv3.WithPrefix())).
+		Commit()
+	if err != nil {
+		return err
+	}
+
+	var deleted int64
+	for _, operationResponse := range response.Responses {
+		deleted += operationResponse.GetResponseDeleteRange().Deleted
+	}
+	if deleted == 0 {
+		return newEtcdV3Error(client.ErrorCodeKeyNotFound, "Key not found", key, response.Header)
+	}
+
+	return nil
+}
+
+// DeleteRecursive deletes the given key and all its children
+func (etcd *resilentEtcdV3Client) DeleteRecursive(ctx context.Context, key string) error {
+	core.GetLogger().Debugf("delete key %s recursive", key)
+
+	return etcd.Delete(ctx, key, &client.DeleteOptions{
+		Recursive: true,
+	})
+}
+
+// GetWithIndex returns the value and the modification revision of the given key. Directories do not have a
+// revision, so a KeyNotFoundError is returned for them like for keys which do not exist.
+func (etcd *resilentEtcdV3Client) GetWithIndex(ctx context.Context, key string) (string, uint64, error) {
+	key = normalizeKey(key)
+
+	var value string
+	var index uint64
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "getWithIndex", func(ctx context.Context) error {
+		core.GetLogger().Debugf("read key %s with index", key)
+		response, err := etcd.kv.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if len(response.Kvs) == 0 {
+			return newEtcdV3Error(client.ErrorCodeKeyNotFound, "Key not found", key, response.Header)
+		}
+
+		value = string(response.Kvs[0].Value)
+		index = uint64(response.Kvs[0].ModRevision)
+		return nil
+	})
+
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "failed to read key %s", key)
+	}
+
+	return value, index, nil
+}
+
+// GetCurrentIndex returns the current revision of the cluster, which is sent with the response of a read of the
+// given key. The given key is not required to exist.
+func (etcd *resilentEtcdV3Client) GetCurrentIndex(ctx context.Context, key string) (uint64, error) {
+	key = normalizeKey(key)
+
+	var index uint64
+	err := etcd.retriers.run(ctx, etcd.retriers.read, "getCurrentIndex", func(ctx context.Context) error {
+		core.GetLogger().Debugf("read current index with key %s", key)
+		response, err := etcd.kv.Get(ctx, key, clientv3.WithCountOnly())
+		if err != nil {
+			return err
+		}
+
+		index = uint64(response.Header.Revision)
+		return nil
+	})
+
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read current index with key %s", key)
+	}
+
+	return index, nil
+}
+
+// ApplyBatch applies all changes within a single transaction. Like the v2 api, the transaction refuses to set or
+// delete a key which is used as directory. etcd limits the number of operations of a transaction, which is 128 by
+// default.
+func (etcd *resilentEtcdV3Client) ApplyBatch(ctx context.Context, changes []keyChange) error {
+	var comparisons []clientv3.Cmp
+	var operations []clientv3.Op
+	var directoryChecks []clientv3.Op
+	for _, change := range changes {
+		key := normalizeKey(change.key)
+		prefix := directoryPrefix(key)
+
+		comparisons = append(comparisons, clientv3.Compare(clientv3.CreateRevision(prefix), "=", 0).WithPrefix())
+		directoryChecks = append(directoryChecks, clientv3.OpGet(prefix, clientv3.WithPrefix(), clientv3.WithCountOnly()))
+		if change.delete {
+			operations = append(operations, clientv3.OpDelete(key))
+		} else {
+			operations = append(operations, clientv3.OpPut(key, change.value))
+		}
+	}
+
+	err := etcd.retriers.run(ctx, etcd.retriers.write, "applyBatch", func(ctx context.Context) error {
+		core.GetLogger().Debugf("apply batch of %d changes", len(changes))
+		response, err := etcd.kv.Txn(ctx).
+			If(comparisons...).
+			Then(operations...).
+			Else(directoryChecks...).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if response.Succeeded {
+			return nil
+		}
+
+		for i, operationResponse := range response.Responses {
+			if operationResponse.GetResponseRange().Count > 0 {
+				return newEtcdV3Error(client.ErrorCodeNotFile, "Not a file", normalizeKey(changes[i].key), response.Header)
+			}
+		}
+
+		// the directories were removed in the meantime
+		return errors.New("transaction failed because of concurrent changes")
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "failed to apply batch")
+	}
+
+	return nil
+}
+
+// Watch watches for changes of the provided key which happened after the given revision and sends the event through
+// the channel. A revision of 0 starts the watch at the current revision. The call blocks until the given context is
+// done or the watch fails. If the requested revision was already compacted, an EventIndexCleared error is returned.
+func (etcd *resilentEtcdV3Client) Watch(ctx context.Context, key string, recursive bool, afterIndex uint64, eventChannel chan<- *client.Response) error {
+	key = normalizeKey(key)
+
+	options := []clientv3.OpOption{clientv3.WithPrevKV()}
+	if recursive {
+		options = append(options, clientv3.WithPrefix())
+	}
+	if afterIndex > 0 {
+		options = append(options, clientv3.WithRev(int64(afterIndex)+1))
+	}
+
+	// cancelling the context releases the watch on the server
+	watchCtx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+
+	for response := range etcd.watcher.Watch(watchCtx, key, options...) {
+		if response.CompactRevision > 0 {
+			return newEtcdV3Error(client.ErrorCodeEventIndexCleared, "The event in requested index is outdated and cleared", key, &response.Header)
+		}
+		if err := response.Err(); err != nil {
+			return err
+		}
+
+		for _, event := range response.Events {
+			if !isWatchedKey(string(event.Kv.Key), key, recursive) {
+				continue
+			}
+
+			select {
+			case eventChannel <- mapEtcdV3Event(event):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return errors.Errorf("watch of key %s was closed", key)
+}
+
+func isWatchedKey(eventKey string, key string, recursive bool) bool {
+	return eventKey == key || (recursive && strings.HasPrefix(eventKey, directoryPrefix(key)))
+}
+
+func mapEtcdV3Event(event *clientv3.Event) *client.Response {
+	action := "set"
+	if event.Type == mvccpb.DELETE {
+		action = "delete"
+	}
+
+	response := &client.Response{
+		Action: action,
+		Node:   mapEtcdV3KeyValue(event.Kv),
+		Index:  uint64(event.Kv.ModRevision),
+	}
+	if event.PrevKv != nil {
+		response.PrevNode = mapEtcdV3KeyValue(event.PrevKv)
+	}
+
+	return response
+}
+
+func mapEtcdV3KeyValue(kv *mvccpb.KeyValue) *client.Node {
+	return &client.Node{
+		Key:           string(kv.Key),
+		Value:         string(kv.Value),
+		CreatedIndex:  uint64(kv.CreateRevision),
+		ModifiedIndex: uint64(kv.ModRevision),
+	}
+}
+
+// leaseTTLs returns the remaining time to live in seconds of the leases of the given keys.
+func (etcd *resilentEtcdV3Client) leaseTTLs(ctx context.Context, kvs []*mvccpb.KeyValue) (map[int64]int64, error) {
+	ttls := map[int64]int64{}
+	for _, kv := range kvs {
+		if kv.Lease == 0 {
+			continue
+		}
+		if _, ok := ttls[kv.Lease]; ok {
+			continue
+		}
+
+		leaseInfo, err := etcd.lease.TimeToLive(ctx, clientv3.LeaseID(kv.Lease))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read lease of key %s", string(kv.Key))
+		}
+		ttls[kv.Lease] = leaseInfo.TTL
+	}
+
+	return ttls, nil
+}
+
+// buildEtcdNodeTree creates the directory tree of the v2 api from the flat keys of the v3 api. The time to live of
+// the keys is taken from the given remaining time to live of their leases. Like the v2 api, hidden keys are omitted
+// except the global config unless they are included explicitly.
+func buildEtcdNodeTree(kvs []*mvccpb.KeyValue, leaseTTLs map[int64]int64, includeHidden bool) *client.Node {
+	root := &client.Node{Key: "", Dir: true, Nodes: client.Nodes{}}
+	nodes := map[string]*client.Node{"": root}
+
+	for _, kv := range kvs {
+		key := normalizeKey(string(kv.Key))
+		if !includeHidden && isHiddenMainNodeKey(key) {
+			continue
+		}
+		segments := strings.Split(strings.TrimPrefix(key, "/"), "/")
+
+		parent := root
+		parentKey := ""
+		for _, segment := range segments[:len(segments)-1] {
+			parentKey += "/" + segment
+			directory, ok := nodes[parentKey]
+			if !ok {
+				directory = &client.Node{Key: parentKey}
+				nodes[parentKey] = directory
+				parent.Nodes = append(parent.Nodes, directory)
+			}
+			if !directory.Dir {
+				// a key which is used as value and as directory at the same time is not possible in the v2 api, so
+				// the key is merged into the directory and keeps its value
+				directory.Dir = true
+				directory.Nodes = client.Nodes{}
+			}
+			parent = directory
+		}
+
+		node := mapEtcdV3KeyValue(kv)
+		node.Key = key
+		node.TTL = leaseTTLs[kv.Lease]
+		if existing, ok := nodes[key]; ok {
+			existing.Value = node.Value
+			continue
+		}
+		nodes[key] = node
+		parent.Nodes = append(parent.Nodes, node)
+	}
+
+	return root
+}
+
+// isHiddenMainNodeKey returns true if the v2 api omits the key from the main node. These are all keys with a segment
+// which starts with an underscore, except the keys of the global config, which are queried explicitly.
+func isHiddenMainNodeKey(key string) bool {
+	globalConfigPrefix := "/config/" + DirectoryGlobal
+	if key == globalConfigPrefix {
+		return false
+	}
+	if strings.HasPrefix(key, globalConfigPrefix+"/") {
+		return isHiddenPath(strings.TrimPrefix(key, globalConfigPrefix+"/"))
+	}
+	return isHiddenPath(strings.TrimPrefix(key, "/"))
+}
+
+// childPathsOf returns the sorted paths of the direct children of the given directory prefix. Hidden children are
+// omitted like in the v2 api.
+func childPathsOf(prefix string, keys []string) []string {
+	children := []string{}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		name := strings.SplitN(strings.TrimPrefix(key, prefix), "/", 2)[0]
+		if name == "" || strings.HasPrefix(name, "_") || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		children = append(children, prefix+name)
+	}
+
+	sort.Strings(children)
+	return children
+}
+
+func isHiddenPath(relativeKey string) bool {
+	for _, segment := range strings.Split(relativeKey, "/") {
+		if strings.HasPrefix(segment, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+func directoryPrefix(key string) string {
+	return strings.TrimSuffix(key, "/") + "/"
+}
+
+func ttlInSeconds(ttl time.Duration) int64 {
+	return int64(math.Ceil(ttl.Seconds()))
+}
+
+// newEtcdV3Error creates an error of the v2 api, so that the error handling of the registry, e.g. IsKeyNotFoundError,
+// works for both apis.
+func newEtcdV3Error(code int, message string, key string, header interface{ GetRevision() int64 }) error {
+	var index uint64
+	if header != nil {
+		index = uint64(header.GetRevision())
+	}
+	return client.Error{Code: code, Message: message, Cause: key, Index: index}
+}
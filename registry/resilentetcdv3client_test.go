@@ -0,0 +1,162 @@
+package registry
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/v2"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func Test_newResilientEtcdV3Client(t *testing.T) {
+	t.Run("should return an error if creating the backoff fails", func(t *testing.T) {
+		config := core.Registry{RetryPolicy: core.RetryPolicy{Interval: -2}}
+
+		_, err := newResilientEtcdV3Client(nil, config)
+
+		require.Error(t, err)
+	})
+
+	t.Run("should return an error if the tls configuration is invalid", func(t *testing.T) {
+		config := core.Registry{TLS: core.RegistryTLS{CAFile: filepath.Join(t.TempDir(), "missing.crt")}}
+
+		_, err := newResilientEtcdV3Client([]string{"localhost:2379"}, config)
+
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to create etcd v3 client")
+	})
+
+	t.Run("should be selected by registry type", func(t *testing.T) {
+		reg, err := New(core.Registry{Type: TypeEtcdV3, Endpoints: []string{"http://localhost:2379"}})
+
+		require.NoError(t, err)
+		assert.IsType(t, &resilentEtcdV3Client{}, reg.(*etcdRegistry).client)
+	})
+}
+
+func Test_buildEtcdNodeTree(t *testing.T) {
+	// given
+	kvs := []*mvccpb.KeyValue{
+		{Key: []byte("/config/_global/fqdn"), Value: []byte("ces.local"), ModRevision: 3},
+		{Key: []byte("/config/redmine/logging/root"), Value: []byte("INFO"), ModRevision: 4},
+		{Key: []byte("/state/redmine"), Value: []byte("ready"), ModRevision: 5, Lease: 42},
+	}
+
+	// when
+	root := buildEtcdNodeTree(kvs, map[int64]int64{42: 30}, false)
+
+	// then
+	actual := mapEtcdNodeToRegistryNode(root)
+	assert.True(t, actual.IsDir)
+	assert.Equal(t, "", actual.FullKey)
+	assert.Equal(t, "ces.local", actual.SubNodeByName("config").SubNodeByName("_global").SubNodeByName("fqdn").Value)
+	logging := actual.SubNodeByName("config").SubNodeByName("redmine").SubNodeByName("logging")
+	assert.True(t, logging.IsDir)
+	assert.Equal(t, "/config/redmine/logging", logging.FullKey)
+	assert.Equal(t, "INFO", logging.SubNodeByName("root").Value)
+	assert.Equal(t, "ready", actual.SubNodeByName("state").SubNodeByName("redmine").Value)
+	assert.Equal(t, int64(30), root.Nodes[1].Nodes[0].TTL)
+}
+
+func Test_buildEtcdNodeTree_hiddenKeys(t *testing.T) {
+	// given
+	kvs := []*mvccpb.KeyValue{
+		{Key: []byte("/config/_global/fqdn"), Value: []byte("ces.local")},
+		{Key: []byte("/config/_global/_internal"), Value: []byte("hidden")},
+		{Key: []byte("/config/_host/k8s/key"), Value: []byte("hidden")},
+		{Key: []byte("/state/_history/redmine"), Value: []byte("hidden")},
+		{Key: []byte("/state/redmine"), Value: []byte("ready")},
+	}
+
+	// when
+	root := buildEtcdNodeTree(kvs, map[int64]int64{}, false)
+
+	// then
+	actual := mapEtcdNodeToRegistryNode(root)
+	global := actual.SubNodeByName("config").SubNodeByName("_global")
+	require.Len(t, global.SubNodes, 1)
+	assert.Equal(t, "ces.local", global.SubNodeByName("fqdn").Value)
+	assert.Len(t, actual.SubNodeByName("config").SubNodes, 1)
+	state := actual.SubNodeByName("state")
+	require.Len(t, state.SubNodes, 1)
+	assert.Equal(t, "ready", state.SubNodeByName("redmine").Value)
+}
+
+func Test_buildEtcdNodeTree_keyUsedAsDirectory(t *testing.T) {
+	// given
+	kvs := []*mvccpb.KeyValue{
+		{Key: []byte("/config/redmine"), Value: []byte("value")},
+		{Key: []byte("/config/redmine/logging"), Value: []byte("INFO")},
+	}
+
+	// when
+	root := buildEtcdNodeTree(kvs, map[int64]int64{}, false)
+
+	// then
+	config := mapEtcdNodeToRegistryNode(root).SubNodeByName("config")
+	require.Len(t, config.SubNodes, 1)
+	redmine := config.SubNodes[0]
+	assert.True(t, redmine.IsDir)
+	assert.Equal(t, "value", redmine.Value)
+	assert.Equal(t, "INFO", redmine.SubNodeByName("logging").Value)
+}
+
+func Test_childPathsOf(t *testing.T) {
+	keys := []string{
+		"/dogu_v2/redmine/current",
+		"/dogu_v2/redmine/4.2.3-10",
+		"/dogu_v2/cas/current",
+		"/dogu_v2/_hidden/current",
+	}
+
+	actual := childPathsOf("/dogu_v2/", keys)
+
+	assert.Equal(t, []string{"/dogu_v2/cas", "/dogu_v2/redmine"}, actual)
+}
+
+func Test_mapEtcdV3Event(t *testing.T) {
+	t.Run("should map put event", func(t *testing.T) {
+		event := &clientv3.Event{
+			Type:   mvccpb.PUT,
+			Kv:     &mvccpb.KeyValue{Key: []byte("/config/redmine/key"), Value: []byte("new"), CreateRevision: 2, ModRevision: 7},
+			PrevKv: &mvccpb.KeyValue{Key: []byte("/config/redmine/key"), Value: []byte("old"), ModRevision: 2},
+		}
+
+		actual := mapEtcdV3Event(event)
+
+		assert.Equal(t, "set", actual.Action)
+		assert.Equal(t, uint64(7), actual.Index)
+		assert.Equal(t, "new", actual.Node.Value)
+		assert.Equal(t, "old", actual.PrevNode.Value)
+	})
+
+	t.Run("should map delete event", func(t *testing.T) {
+		event := &clientv3.Event{
+			Type: mvccpb.DELETE,
+			Kv:   &mvccpb.KeyValue{Key: []byte("/config/redmine/key"), ModRevision: 8},
+		}
+
+		actual := mapEtcdV3Event(event)
+
+		assert.Equal(t, "delete", actual.Action)
+		assert.Equal(t, "/config/redmine/key", actual.Node.Key)
+		assert.Nil(t, actual.PrevNode)
+	})
+}
+
+func Test_newEtcdV3Error(t *testing.T) {
+	err := newEtcdV3Error(client.ErrorCodeKeyNotFound, "Key not found", "/config/redmine/key", nil)
+
+	assert.True(t, IsKeyNotFoundError(err))
+}
+
+func Test_isWatchedKey(t *testing.T) {
+	assert.True(t, isWatchedKey("/config/redmine", "/config/redmine", false))
+	assert.True(t, isWatchedKey("/config/redmine/key", "/config/redmine", true))
+	assert.False(t, isWatchedKey("/config/redmine/key", "/config/redmine", false))
+	assert.False(t, isWatchedKey("/config/redmine2/key", "/config/redmine", true))
+}
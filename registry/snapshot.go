@@ -0,0 +1,157 @@
+package registry
+
+import (
+	"encoding/json"
+	"io"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// SnapshotVersion is the version of the snapshot format which is created by the export.
+const SnapshotVersion = 1
+
+// SnapshotFormat is the document format of a serialized snapshot.
+type SnapshotFormat string
+
+const (
+	// SnapshotFormatJSON serializes snapshots as JSON document.
+	SnapshotFormatJSON SnapshotFormat = "json"
+	// SnapshotFormatYAML serializes snapshots as YAML document.
+	SnapshotFormatYAML SnapshotFormat = "yaml"
+)
+
+// Snapshot contains the keys and directories of the registry at a point in time. It is used to back up the registry
+// and to clone the configuration of an ecosystem to another machine.
+type Snapshot struct {
+	// Version is the version of the snapshot format.
+	Version int `json:"version" yaml:"version"`
+	// CreatedAt is the time at which the snapshot was exported.
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+	// Paths contains the exported subtrees of the registry. It contains "/" if the whole registry was exported.
+	Paths []string `json:"paths" yaml:"paths"`
+	// Entries contains the keys and directories of the exported subtrees ordered by their key.
+	Entries []SnapshotEntry `json:"entries" yaml:"entries"`
+}
+
+// SnapshotEntry is a single key or directory of a snapshot.
+type SnapshotEntry struct {
+	// Key is the full path of the key.
+	Key string `json:"key" yaml:"key"`
+	// Value is the value of the key. It is empty for directories.
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+	// IsDir is true if the entry is a directory.
+	IsDir bool `json:"dir,omitempty" yaml:"dir,omitempty"`
+	// TTL is the remaining time to live of the key in seconds at the time of the export. It is 0 if the key does not
+	// expire.
+	TTL int64 `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+}
+
+// SnapshotImportOptions configure the import of a snapshot.
+type SnapshotImportOptions struct {
+	// DryRun only reports the changes of the import without applying them.
+	DryRun bool
+}
+
+// SnapshotImportReport describes the changes of a snapshot import.
+type SnapshotImportReport struct {
+	// DryRun is true if the changes were not applied.
+	DryRun bool
+	// Created contains the keys and directories which did not exist before the import.
+	Created []string
+	// Updated contains the keys which got a new value or time to live.
+	Updated []string
+	// Unchanged contains the keys and directories which already existed with the same value.
+	Unchanged []string
+}
+
+// WriteSnapshot serializes the snapshot in the given format to the writer.
+func WriteSnapshot(writer io.Writer, snapshot *Snapshot, format SnapshotFormat) error {
+	switch format {
+	case SnapshotFormatJSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		err := encoder.Encode(snapshot)
+		if err != nil {
+			return errors.Wrap(err, "failed to write snapshot as json")
+		}
+	case SnapshotFormatYAML:
+		encoder := yaml.NewEncoder(writer)
+		encoder.SetIndent(2)
+		err := encoder.Encode(snapshot)
+		if err != nil {
+			return errors.Wrap(err, "failed to write snapshot as yaml")
+		}
+		return encoder.Close()
+	default:
+		return errors.Errorf("unsupported snapshot format %s", format)
+	}
+
+	return nil
+}
+
+// ReadSnapshot deserializes a snapshot in the given format from the reader. It fails if the snapshot has an
+// unsupported version or contains invalid entries.
+func ReadSnapshot(reader io.Reader, format SnapshotFormat) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+
+	switch format {
+	case SnapshotFormatJSON:
+		err := json.NewDecoder(reader).Decode(snapshot)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read snapshot as json")
+		}
+	case SnapshotFormatYAML:
+		err := yaml.NewDecoder(reader).Decode(snapshot)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read snapshot as yaml")
+		}
+	default:
+		return nil, errors.Errorf("unsupported snapshot format %s", format)
+	}
+
+	err := snapshot.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// Validate checks if the snapshot can be imported.
+func (s *Snapshot) Validate() error {
+	if s.Version != SnapshotVersion {
+		return errors.Errorf("unsupported snapshot version %d, only version %d is supported", s.Version, SnapshotVersion)
+	}
+
+	// keys maps the keys of the entries to whether they are directories
+	keys := map[string]bool{}
+	for _, entry := range s.Entries {
+		if entry.Key == "" || normalizeKey(entry.Key) != entry.Key || entry.Key == "/" {
+			return errors.Errorf("invalid snapshot entry with key '%s'", entry.Key)
+		}
+		if _, exists := keys[entry.Key]; exists {
+			return errors.Errorf("snapshot contains key %s more than once", entry.Key)
+		}
+		if entry.IsDir && (entry.Value != "" || entry.TTL != 0) {
+			return errors.Errorf("snapshot contains directory %s with value or time to live", entry.Key)
+		}
+		if entry.TTL < 0 {
+			return errors.Errorf("snapshot contains key %s with negative time to live", entry.Key)
+		}
+		keys[entry.Key] = entry.IsDir
+	}
+
+	// a key below a plain key could not be restored
+	for key := range keys {
+		for parent := path.Dir(key); parent != "/"; parent = path.Dir(parent) {
+			if isDir, exists := keys[parent]; exists && !isDir {
+				return errors.Errorf("snapshot contains key %s below key %s, which is not a directory", key, parent)
+			}
+		}
+	}
+
+	return nil
+}
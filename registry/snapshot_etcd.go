@@ -0,0 +1,156 @@
+package registry
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/client/v2"
+)
+
+// ExportSnapshot exports the given subtrees of the registry, e.g. /config/redmine or /dogu_v2. If no path is given,
+// the whole registry is exported. Hidden keys like /config/_host are exported as well.
+func (er *etcdRegistry) ExportSnapshot(paths ...string) (*Snapshot, error) {
+	return er.ExportSnapshotContext(context.Background(), paths...)
+}
+
+// ExportSnapshotContext exports the given subtrees of the registry, e.g. /config/redmine or /dogu_v2. If no path is
+// given, the whole registry is exported. Hidden keys like /config/_host are exported as well.
+func (er *etcdRegistry) ExportSnapshotContext(ctx context.Context, paths ...string) (*Snapshot, error) {
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+
+	snapshot := &Snapshot{
+		Version:   SnapshotVersion,
+		CreatedAt: time.Now().UTC(),
+		Entries:   []SnapshotEntry{},
+	}
+	exportedKeys := map[string]bool{}
+	for _, path := range paths {
+		path = normalizeKey(path)
+		core.GetLogger().Debugf("export snapshot of %s", path)
+
+		node, err := er.client.GetTree(ctx, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to export snapshot of %s", path)
+		}
+
+		snapshot.Paths = append(snapshot.Paths, path)
+		snapshot.Entries = appendSnapshotEntries(snapshot.Entries, node, exportedKeys)
+	}
+
+	sort.Slice(snapshot.Entries, func(i, j int) bool {
+		return snapshot.Entries[i].Key < snapshot.Entries[j].Key
+	})
+
+	return snapshot, nil
+}
+
+func appendSnapshotEntries(entries []SnapshotEntry, node *client.Node, exportedKeys map[string]bool) []SnapshotEntry {
+	// the root node is not part of a snapshot, because it always exists
+	if node.Key != "" && node.Key != "/" && !exportedKeys[node.Key] {
+		exportedKeys[node.Key] = true
+		entry := SnapshotEntry{Key: node.Key, IsDir: node.Dir}
+		if !node.Dir {
+			entry.Value = node.Value
+			entry.TTL = node.TTL
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, child := range node.Nodes {
+		entries = appendSnapshotEntries(entries, child, exportedKeys)
+	}
+
+	return entries
+}
+
+// ImportSnapshot recreates the keys, directories and time to live of the snapshot in the registry. Keys of the
+// registry which are not part of the snapshot are kept. The import fails without any change if a key of the
+// snapshot is a directory in the registry or vice versa. The import is not atomic: the entries are written one after
+// another, so other clients may read a partially imported snapshot and a failed import keeps the already written
+// entries.
+func (er *etcdRegistry) ImportSnapshot(snapshot *Snapshot, options SnapshotImportOptions) (*SnapshotImportReport, error) {
+	return er.ImportSnapshotContext(context.Background(), snapshot, options)
+}
+
+// ImportSnapshotContext works like ImportSnapshot. The given context limits the time of the request.
+func (er *etcdRegistry) ImportSnapshotContext(ctx context.Context, snapshot *Snapshot, options SnapshotImportOptions) (*SnapshotImportReport, error) {
+	err := snapshot.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to import snapshot")
+	}
+
+	tree, err := er.client.GetTree(ctx, "/")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to import snapshot")
+	}
+	existingNodes := map[string]*client.Node{}
+	collectEtcdNodes(tree, existingNodes)
+
+	entries := make([]SnapshotEntry, len(snapshot.Entries))
+	copy(entries, snapshot.Entries)
+	// parents are created before their children
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	report := &SnapshotImportReport{DryRun: options.DryRun}
+	var changes []SnapshotEntry
+	for _, entry := range entries {
+		existing := existingNodes[entry.Key]
+		switch {
+		case existing == nil:
+			report.Created = append(report.Created, entry.Key)
+			changes = append(changes, entry)
+		case existing.Dir != entry.IsDir:
+			return nil, errors.Errorf("failed to import snapshot: key %s is a directory either in the snapshot or in the registry", entry.Key)
+		case entry.IsDir || (existing.Value == entry.Value && existing.TTL == 0 && entry.TTL == 0):
+			report.Unchanged = append(report.Unchanged, entry.Key)
+		default:
+			report.Updated = append(report.Updated, entry.Key)
+			changes = append(changes, entry)
+		}
+	}
+
+	if options.DryRun {
+		return report, nil
+	}
+
+	for _, change := range changes {
+		err = er.importSnapshotEntry(ctx, change)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to import snapshot entry %s", change.Key)
+		}
+	}
+
+	return report, nil
+}
+
+func collectEtcdNodes(node *client.Node, nodes map[string]*client.Node) {
+	nodes[node.Key] = node
+	for _, child := range node.Nodes {
+		collectEtcdNodes(child, nodes)
+	}
+}
+
+func (er *etcdRegistry) importSnapshotEntry(ctx context.Context, entry SnapshotEntry) error {
+	core.GetLogger().Debugf("import snapshot entry %s", entry.Key)
+
+	if entry.IsDir {
+		_, err := er.client.Set(ctx, entry.Key, "", &client.SetOptions{Dir: true, PrevExist: client.PrevNoExist})
+		// the directory may have been created in the meantime, e.g. as parent of another key
+		if hasErrorCode(err, client.ErrorCodeNodeExist) {
+			return nil
+		}
+		return err
+	}
+
+	_, err := er.client.Set(ctx, entry.Key, entry.Value, &client.SetOptions{
+		TTL: time.Duration(entry.TTL) * time.Second,
+	})
+	return err
+}
@@ -0,0 +1,177 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/client/v2"
+)
+
+func Test_etcdRegistry_ExportSnapshot(t *testing.T) {
+	t.Run("should export whole registry", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry()
+		require.NoError(t, sut.GlobalConfig().Set("fqdn", "ces.local"))
+		require.NoError(t, sut.State("ldap").Set("ready"))
+
+		// when
+		actual, err := sut.ExportSnapshot()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, SnapshotVersion, actual.Version)
+		assert.Equal(t, []string{"/"}, actual.Paths)
+		assert.Equal(t, []SnapshotEntry{
+			{Key: "/config", IsDir: true},
+			{Key: "/config/_global", IsDir: true},
+			{Key: "/config/_global/fqdn", Value: "ces.local"},
+			{Key: "/state", IsDir: true},
+			{Key: "/state/ldap", Value: "ready"},
+		}, actual.Entries)
+	})
+
+	t.Run("should export selected subtrees with time to live", func(t *testing.T) {
+		// given
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		memClient := newMemoryClient()
+		memClient.now = func() time.Time { return now }
+		sut := &etcdRegistry{client: memClient}
+		require.NoError(t, sut.DoguConfig("redmine").SetWithLifetime("lock", "me", 60))
+		require.NoError(t, sut.DoguConfig("cas").Set("logging/root", "INFO"))
+		require.NoError(t, sut.State("redmine").Set("ready"))
+		now = now.Add(20 * time.Second)
+
+		// when
+		actual, err := sut.ExportSnapshot("/config/redmine", "/state/", "/config/redmine/lock")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/config/redmine", "/state", "/config/redmine/lock"}, actual.Paths)
+		assert.Equal(t, []SnapshotEntry{
+			{Key: "/config/redmine", IsDir: true},
+			{Key: "/config/redmine/lock", Value: "me", TTL: 40},
+			{Key: "/state", IsDir: true},
+			{Key: "/state/redmine", Value: "ready"},
+		}, actual.Entries)
+	})
+
+	t.Run("should export hidden keys", func(t *testing.T) {
+		// given
+		memClient := newMemoryClient()
+		sut := &etcdRegistry{client: memClient}
+		require.NoError(t, sut.HostConfig("k8s").Set("namespace", "ecosystem"))
+		_, err := memClient.Set(context.Background(), "/state/_history/ldap", "[]", nil)
+		require.NoError(t, err)
+
+		// when
+		all, err := sut.ExportSnapshot()
+		require.NoError(t, err)
+		host, err := sut.ExportSnapshot("/config/_host/k8s")
+		require.NoError(t, err)
+
+		// then
+		assert.Equal(t, []SnapshotEntry{
+			{Key: "/config", IsDir: true},
+			{Key: "/config/_host", IsDir: true},
+			{Key: "/config/_host/k8s", IsDir: true},
+			{Key: "/config/_host/k8s/namespace", Value: "ecosystem"},
+			{Key: "/state", IsDir: true},
+			{Key: "/state/_history", IsDir: true},
+			{Key: "/state/_history/ldap", Value: "[]"},
+		}, all.Entries)
+		assert.Equal(t, []SnapshotEntry{
+			{Key: "/config/_host/k8s", IsDir: true},
+			{Key: "/config/_host/k8s/namespace", Value: "ecosystem"},
+		}, host.Entries)
+	})
+
+	t.Run("should fail for missing subtree", func(t *testing.T) {
+		_, err := NewInMemoryRegistry().ExportSnapshot("/config/redmine")
+
+		require.Error(t, err)
+		assert.True(t, IsKeyNotFoundError(err))
+	})
+}
+
+func Test_etcdRegistry_ImportSnapshot(t *testing.T) {
+	t.Run("should clone exported registry", func(t *testing.T) {
+		// given
+		memClient := newMemoryClient()
+		source := &etcdRegistry{client: memClient}
+		require.NoError(t, source.DoguConfig("redmine").Set("logging/root", "INFO"))
+		require.NoError(t, source.DoguConfig("redmine").SetWithLifetime("lock", "me", 60))
+		_, err := memClient.Set(context.Background(), "/config/redmine/empty", "", &client.SetOptions{Dir: true})
+		require.NoError(t, err)
+		snapshot, err := source.ExportSnapshot("/config/redmine")
+		require.NoError(t, err)
+		target := NewInMemoryRegistry()
+
+		// when
+		report, err := target.ImportSnapshot(snapshot, SnapshotImportOptions{})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/config/redmine", "/config/redmine/empty", "/config/redmine/lock", "/config/redmine/logging", "/config/redmine/logging/root"}, report.Created)
+		cloned, err := target.ExportSnapshot("/config/redmine")
+		require.NoError(t, err)
+		assert.Equal(t, snapshot.Entries, cloned.Entries)
+	})
+
+	t.Run("should report changes without applying them in dry run", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry()
+		require.NoError(t, sut.DoguConfig("redmine").Set("logging/root", "INFO"))
+		require.NoError(t, sut.DoguConfig("redmine").Set("url", "https://example.com"))
+		snapshot := &Snapshot{Version: SnapshotVersion, Entries: []SnapshotEntry{
+			{Key: "/config/redmine/url", Value: "https://example.com"},
+			{Key: "/config/redmine/logging/root", Value: "DEBUG"},
+			{Key: "/config/redmine/logging", IsDir: true},
+			{Key: "/config/redmine/new", Value: "value"},
+		}}
+
+		// when
+		report, err := sut.ImportSnapshot(snapshot, SnapshotImportOptions{DryRun: true})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, &SnapshotImportReport{
+			DryRun:    true,
+			Created:   []string{"/config/redmine/new"},
+			Updated:   []string{"/config/redmine/logging/root"},
+			Unchanged: []string{"/config/redmine/logging", "/config/redmine/url"},
+		}, report)
+		all, err := sut.DoguConfig("redmine").GetAll()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"logging/root": "INFO", "url": "https://example.com"}, all)
+	})
+
+	t.Run("should fail without changes if key is a directory in the registry", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry()
+		require.NoError(t, sut.DoguConfig("redmine").Set("logging/root", "INFO"))
+		snapshot := &Snapshot{Version: SnapshotVersion, Entries: []SnapshotEntry{
+			{Key: "/config/redmine/a", Value: "value"},
+			{Key: "/config/redmine/logging", Value: "INFO"},
+		}}
+
+		// when
+		_, err := sut.ImportSnapshot(snapshot, SnapshotImportOptions{})
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "key /config/redmine/logging is a directory")
+		exists, err := sut.DoguConfig("redmine").Exists("a")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should fail for invalid snapshot", func(t *testing.T) {
+		_, err := NewInMemoryRegistry().ImportSnapshot(&Snapshot{Version: 0}, SnapshotImportOptions{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported snapshot version 0")
+	})
+}
@@ -0,0 +1,74 @@
+//go:build integration
+// +build integration
+
+package registry_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cloudogu/cesapp-lib/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_inttest(t *testing.T) {
+	testSnapshot(t, reg, "unit-test-snapshot")
+}
+
+func TestEtcdV3Snapshot_inttest(t *testing.T) {
+	testSnapshot(t, createEtcdV3TestRegistry(t), "unit-test-v3-snapshot")
+}
+
+func testSnapshot(t *testing.T, testRegistry registry.Registry, dogu string) {
+	t.Helper()
+	cc := testRegistry.DoguConfig(dogu)
+	defer cc.RemoveAll()
+
+	require.NoError(t, cc.Set("logging/root", "INFO"))
+	require.NoError(t, cc.SetWithLifetime("lock", "me", 60))
+
+	snapshot, err := testRegistry.ExportSnapshot("/config/" + dogu)
+	require.NoError(t, err)
+	buffer := &bytes.Buffer{}
+	require.NoError(t, registry.WriteSnapshot(buffer, snapshot, registry.SnapshotFormatYAML))
+
+	require.NoError(t, cc.RemoveAll())
+	readSnapshot, err := registry.ReadSnapshot(buffer, registry.SnapshotFormatYAML)
+	require.NoError(t, err)
+
+	report, err := testRegistry.ImportSnapshot(readSnapshot, registry.SnapshotImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Contains(t, report.Created, "/config/"+dogu+"/logging/root")
+	exists, err := cc.Exists("logging/root")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = testRegistry.ImportSnapshot(readSnapshot, registry.SnapshotImportOptions{})
+	require.NoError(t, err)
+
+	value, err := cc.Get("logging/root")
+	require.NoError(t, err)
+	assert.Equal(t, "INFO", value)
+
+	restored, err := testRegistry.ExportSnapshot("/config/" + dogu + "/lock")
+	require.NoError(t, err)
+	require.Len(t, restored.Entries, 1)
+	assert.Equal(t, "me", restored.Entries[0].Value)
+	assert.InDelta(t, 60, restored.Entries[0].TTL, 2)
+
+	hostConfig := testRegistry.HostConfig(dogu)
+	defer hostConfig.RemoveAll()
+	require.NoError(t, hostConfig.Set("key", "value"))
+
+	hostSnapshot, err := testRegistry.ExportSnapshot("/config/_host/" + dogu)
+	require.NoError(t, err)
+	assert.Equal(t, []registry.SnapshotEntry{
+		{Key: "/config/_host/" + dogu, IsDir: true},
+		{Key: "/config/_host/" + dogu + "/key", Value: "value"},
+	}, hostSnapshot.Entries)
+
+	fullSnapshot, err := testRegistry.ExportSnapshot()
+	require.NoError(t, err)
+	assert.Contains(t, fullSnapshot.Entries, registry.SnapshotEntry{Key: "/config/_host/" + dogu + "/key", Value: "value"})
+}
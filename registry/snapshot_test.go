@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestSnapshot() *Snapshot {
+	return &Snapshot{
+		Version:   SnapshotVersion,
+		CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Paths:     []string{"/config/redmine"},
+		Entries: []SnapshotEntry{
+			{Key: "/config/redmine", IsDir: true},
+			{Key: "/config/redmine/lock", Value: "me", TTL: 30},
+			{Key: "/config/redmine/logging", IsDir: true},
+			{Key: "/config/redmine/logging/root", Value: "INFO"},
+		},
+	}
+}
+
+func TestWriteSnapshot(t *testing.T) {
+	for _, format := range []SnapshotFormat{SnapshotFormatJSON, SnapshotFormatYAML} {
+		t.Run("should read written snapshot as "+string(format), func(t *testing.T) {
+			// given
+			snapshot := createTestSnapshot()
+			buffer := &bytes.Buffer{}
+
+			// when
+			err := WriteSnapshot(buffer, snapshot, format)
+			require.NoError(t, err)
+			actual, err := ReadSnapshot(buffer, format)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, snapshot, actual)
+		})
+	}
+
+	t.Run("should fail for unsupported format", func(t *testing.T) {
+		err := WriteSnapshot(&bytes.Buffer{}, createTestSnapshot(), "xml")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported snapshot format xml")
+	})
+}
+
+func TestReadSnapshot(t *testing.T) {
+	t.Run("should read json document", func(t *testing.T) {
+		// given
+		document := `{"version": 1, "paths": ["/"], "entries": [{"key": "/state/ldap", "value": "ready"}]}`
+
+		// when
+		actual, err := ReadSnapshot(strings.NewReader(document), SnapshotFormatJSON)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []SnapshotEntry{{Key: "/state/ldap", Value: "ready"}}, actual.Entries)
+	})
+
+	t.Run("should fail for unsupported version", func(t *testing.T) {
+		// given
+		document := "version: 2\nentries: []\n"
+
+		// when
+		_, err := ReadSnapshot(strings.NewReader(document), SnapshotFormatYAML)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported snapshot version 2")
+	})
+
+	t.Run("should fail for invalid document", func(t *testing.T) {
+		_, err := ReadSnapshot(strings.NewReader("{"), SnapshotFormatJSON)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read snapshot as json")
+	})
+}
+
+func TestSnapshot_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   SnapshotEntry
+		wantErr string
+	}{
+		{name: "relative key", entry: SnapshotEntry{Key: "config/redmine"}, wantErr: "invalid snapshot entry with key 'config/redmine'"},
+		{name: "root key", entry: SnapshotEntry{Key: "/"}, wantErr: "invalid snapshot entry with key '/'"},
+		{name: "directory with value", entry: SnapshotEntry{Key: "/config", IsDir: true, Value: "x"}, wantErr: "directory /config with value"},
+		{name: "negative ttl", entry: SnapshotEntry{Key: "/lock", TTL: -1}, wantErr: "negative time to live"},
+		{name: "duplicate key", entry: SnapshotEntry{Key: "/config/redmine/lock"}, wantErr: "contains key /config/redmine/lock more than once"},
+		{name: "key below plain key", entry: SnapshotEntry{Key: "/config/redmine/lock/owner", Value: "me"}, wantErr: "contains key /config/redmine/lock/owner below key /config/redmine/lock, which is not a directory"},
+	}
+	for _, tt := range tests {
+		t.Run("should fail for "+tt.name, func(t *testing.T) {
+			snapshot := createTestSnapshot()
+			snapshot.Entries = append(snapshot.Entries, tt.entry)
+
+			err := snapshot.Validate()
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -1,5 +1,79 @@
 package registry
 
+import (
+	"context"
+	"time"
+)
+
+// StateHistoryLimit is the number of transitions which are kept in the state history of a dogu.
+const StateHistoryLimit = 20
+
+// DoguState is the state of a dogu, e.g. ready.
+type DoguState string
+
+const (
+	// StateInstalling is used while the dogu sets up its configuration, e.g. on every start of the container.
+	StateInstalling DoguState = "installing"
+	// StateStarting is used while the dogu starts its service.
+	StateStarting DoguState = "starting"
+	// StateReady is used if the dogu is ready to use. The health check of the type state expects this state.
+	StateReady DoguState = "ready"
+	// StateUpgrading is used while the dogu is upgraded to another version.
+	StateUpgrading DoguState = "upgrading"
+	// StateStopping is used while the dogu is shut down.
+	StateStopping DoguState = "stopping"
+	// StateStopped is used if the dogu is not running.
+	StateStopped DoguState = "stopped"
+	// StateFailed is used if the dogu could not be installed, started or upgraded.
+	StateFailed DoguState = "failed"
+)
+
+var stateTransitions = map[DoguState][]DoguState{
+	StateInstalling: {StateStarting, StateReady, StateStopping, StateFailed},
+	StateStarting:   {StateInstalling, StateReady, StateStopping, StateFailed},
+	StateReady:      {StateInstalling, StateStarting, StateUpgrading, StateStopping, StateFailed},
+	StateUpgrading:  {StateInstalling, StateStarting, StateReady, StateFailed},
+	StateStopping:   {StateStopped, StateFailed},
+	StateStopped:    {StateInstalling, StateStarting, StateUpgrading, StateFailed},
+	StateFailed:     {StateInstalling, StateStarting, StateUpgrading, StateStopping, StateStopped},
+}
+
+// IsKnown returns true if the state is one of the predefined states of this package. Dogus may use further states.
+func (ds DoguState) IsKnown() bool {
+	_, ok := stateTransitions[ds]
+	return ok
+}
+
+// CanTransitionTo returns true if a dogu in this state may change to the target state. A dogu without state or with
+// an unknown state may change to every known state. A dogu may never change to an unknown state.
+func (ds DoguState) CanTransitionTo(target DoguState) bool {
+	if !target.IsKnown() {
+		return false
+	}
+
+	allowedTargets, ok := stateTransitions[ds]
+	if !ok || ds == target {
+		return true
+	}
+
+	for _, allowedTarget := range allowedTargets {
+		if allowedTarget == target {
+			return true
+		}
+	}
+	return false
+}
+
+// StateTransition is a change of the state of a dogu.
+type StateTransition struct {
+	// From is the state before the transition. It is empty if the dogu had no state.
+	From DoguState `json:"from"`
+	// To is the state after the transition.
+	To DoguState `json:"to"`
+	// Time is the time of the transition.
+	Time time.Time `json:"time"`
+}
+
 // State handles state related functions of a dogu
 type State interface {
 	// Get returns the current state value
@@ -8,4 +82,28 @@ type State interface {
 	Set(value string) error
 	// Remove removes the state of the dogu
 	Remove() error
+
+	// GetContext returns the current state value. The given context limits the time of the request.
+	GetContext(ctx context.Context) (string, error)
+	// SetContext sets the state of the dogu. The given context limits the time of the request.
+	SetContext(ctx context.Context, value string) error
+	// RemoveContext removes the state of the dogu. The given context limits the time of the request.
+	RemoveContext(ctx context.Context) error
+
+	// GetState returns the current state of the dogu. It is empty if the dogu has no state.
+	GetState() (DoguState, error)
+	// Transition changes the state of the dogu if the current state may change to the given state and afterwards
+	// records the transition in the history of the dogu. Unlike Set, the transition fails if the state was changed
+	// concurrently.
+	Transition(state DoguState) error
+	// History returns the last transitions of the dogu ordered from the oldest to the newest. It contains only the
+	// transitions which were made with Transition.
+	History() ([]StateTransition, error)
+
+	// GetStateContext works like GetState. The given context limits the time of the request.
+	GetStateContext(ctx context.Context) (DoguState, error)
+	// TransitionContext works like Transition. The given context limits the time of the request.
+	TransitionContext(ctx context.Context, state DoguState) error
+	// HistoryContext works like History. The given context limits the time of the request.
+	HistoryContext(ctx context.Context) ([]StateTransition, error)
 }
@@ -1,19 +1,30 @@
 package registry
 
 import (
+	"context"
+	"encoding/json"
+	"time"
+
 	"github.com/cloudogu/cesapp-lib/core"
 	"github.com/pkg/errors"
+	"go.etcd.io/etcd/client/v2"
 )
 
 type etcdState struct {
-	path   string
-	client *resilentEtcdClient
+	path        string
+	historyPath string
+	client      etcdClient
 }
 
 // Get returns the current state value
 func (es *etcdState) Get() (string, error) {
+	return es.GetContext(context.Background())
+}
+
+// GetContext returns the current state value
+func (es *etcdState) GetContext(ctx context.Context) (string, error) {
 	core.GetLogger().Debug("try to get state key at", es.path)
-	keyExists, err := es.client.Exists(es.path)
+	keyExists, err := es.client.Exists(ctx, es.path)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to check if key %s exists", es.path)
 	}
@@ -21,7 +32,7 @@ func (es *etcdState) Get() (string, error) {
 		core.GetLogger().Debugf("key %s not found. returning empty state", es.path)
 		return "", nil
 	}
-	state, err := es.client.Get(es.path)
+	state, err := es.client.Get(ctx, es.path)
 	if err != nil {
 		return "", errors.Wrapf(err, "could not get state value at %s", es.path)
 	}
@@ -30,19 +41,29 @@ func (es *etcdState) Get() (string, error) {
 
 // Set sets the state of the dogu
 func (es *etcdState) Set(value string) error {
+	return es.SetContext(context.Background(), value)
+}
+
+// SetContext sets the state of the dogu
+func (es *etcdState) SetContext(ctx context.Context, value string) error {
 	core.GetLogger().Debug("try to set state key", es.path)
-	_, err := es.client.Set(es.path, value, nil)
+	_, err := es.client.Set(ctx, es.path, value, nil)
 	if err != nil {
 		return errors.Wrapf(err, "could not set state value at %s", es.path)
 	}
 	return nil
 }
 
-// Set sets the state of the dogu
+// Remove removes the state of the dogu
 func (es *etcdState) Remove() error {
+	return es.RemoveContext(context.Background())
+}
+
+// RemoveContext removes the state of the dogu
+func (es *etcdState) RemoveContext(ctx context.Context) error {
 	core.GetLogger().Debug("try to remove state key", es.path)
 
-	exists, err := es.client.Exists(es.path)
+	exists, err := es.client.Exists(ctx, es.path)
 	if err != nil {
 		return errors.Wrapf(err, "failed to check if state key exists")
 	}
@@ -51,10 +72,191 @@ func (es *etcdState) Remove() error {
 		return nil
 	}
 
-	err = es.client.Delete(es.path, nil)
+	err = es.client.Delete(ctx, es.path, nil)
 	if err != nil {
 		return errors.Wrap(err, "could not remove dogu state")
 	}
 
 	return nil
 }
+
+// GetState returns the current state of the dogu
+func (es *etcdState) GetState() (DoguState, error) {
+	return es.GetStateContext(context.Background())
+}
+
+// GetStateContext returns the current state of the dogu
+func (es *etcdState) GetStateContext(ctx context.Context) (DoguState, error) {
+	state, err := es.GetContext(ctx)
+	return DoguState(state), err
+}
+
+// Transition changes the state of the dogu if the current state may change to the given state and records the
+// transition in the history of the dogu
+func (es *etcdState) Transition(state DoguState) error {
+	return es.TransitionContext(context.Background(), state)
+}
+
+// TransitionContext changes the state of the dogu if the current state may change to the given state and records
+// the transition in the history of the dogu
+func (es *etcdState) TransitionContext(ctx context.Context, state DoguState) error {
+	core.GetLogger().Debugf("try to change state at %s to %s", es.path, state)
+
+	value, index, err := es.client.GetWithIndex(ctx, es.path)
+	if err != nil && !IsKeyNotFoundError(err) {
+		return errors.Wrapf(err, "could not get state value at %s", es.path)
+	}
+
+	current := DoguState(value)
+	if current == state {
+		return nil
+	}
+	if !current.CanTransitionTo(state) {
+		return errors.Errorf("invalid transition of state at %s from '%s' to '%s'", es.path, current, state)
+	}
+
+	options := &client.SetOptions{PrevExist: client.PrevNoExist}
+	if index != 0 {
+		options = &client.SetOptions{PrevIndex: index}
+	}
+	_, err = es.client.Set(ctx, es.path, string(state), options)
+	if isPreconditionFailed(err) {
+		return errors.Wrapf(err, "state at %s was changed concurrently", es.path)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not set state value at %s", es.path)
+	}
+
+	return es.appendHistory(ctx, StateTransition{From: current, To: state, Time: time.Now().UTC()})
+}
+
+// maxStateHistoryAttempts is the number of attempts to append a transition to a history which is changed
+// concurrently by other transitions.
+const maxStateHistoryAttempts = 10
+
+func (es *etcdState) appendHistory(ctx context.Context, transition StateTransition) error {
+	for attempt := 0; attempt < maxStateHistoryAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrapf(err, "could not set state history at %s", es.historyPath)
+		}
+
+		history, index, err := es.getHistory(ctx)
+		if err != nil {
+			return err
+		}
+		if containsTransition(history, transition) {
+			// the response of a successful write was lost
+			return nil
+		}
+
+		history = append(history, transition)
+		if len(history) > StateHistoryLimit {
+			history = history[len(history)-StateHistoryLimit:]
+		}
+
+		data, err := json.Marshal(history)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal state history of %s", es.path)
+		}
+
+		options := &client.SetOptions{PrevExist: client.PrevNoExist}
+		if index != 0 {
+			options = &client.SetOptions{PrevIndex: index}
+		}
+		_, err = es.client.Set(ctx, es.historyPath, string(data), options)
+		// another transition changed the history in the meantime, so its transition must be kept
+		if isPreconditionFailed(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not set state history at %s", es.historyPath)
+		}
+
+		return nil
+	}
+
+	return errors.Errorf("could not set state history at %s, because it was changed concurrently %d times", es.historyPath, maxStateHistoryAttempts)
+}
+
+func containsTransition(history []StateTransition, transition StateTransition) bool {
+	for _, existing := range history {
+		if existing.From == transition.From && existing.To == transition.To && existing.Time.Equal(transition.Time) {
+			return true
+		}
+	}
+	return false
+}
+
+// History returns the last transitions of the dogu ordered from the oldest to the newest
+func (es *etcdState) History() ([]StateTransition, error) {
+	return es.HistoryContext(context.Background())
+}
+
+// HistoryContext returns the last transitions of the dogu ordered from the oldest to the newest
+func (es *etcdState) HistoryContext(ctx context.Context) ([]StateTransition, error) {
+	history, _, err := es.getHistory(ctx)
+	return history, err
+}
+
+func (es *etcdState) getHistory(ctx context.Context) ([]StateTransition, uint64, error) {
+	value, index, err := es.client.GetWithIndex(ctx, es.historyPath)
+	if IsKeyNotFoundError(err) {
+		return []StateTransition{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "could not get state history at %s", es.historyPath)
+	}
+
+	history := []StateTransition{}
+	err = json.Unmarshal([]byte(value), &history)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to unmarshal state history at %s", es.historyPath)
+	}
+
+	return history, index, nil
+}
+
+// WaitFor blocks until the dogu has the given state or the given context is done. It returns immediately if the
+// dogu already has the state.
+func (er *etcdRegistry) WaitFor(ctx context.Context, dogu string, state DoguState) error {
+	path := "/state/" + dogu
+	core.GetLogger().Debugf("wait for state %s at %s", state, path)
+
+	value, index, err := er.client.GetWithIndex(ctx, path)
+	if IsKeyNotFoundError(err) {
+		// the watch starts at the index of the failed request, so that no change after the request is missed
+		value, index, err = "", errorIndex(err), nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to wait for state %s of dogu %s", state, dogu)
+	}
+	if DoguState(value) == state {
+		return nil
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reached := false
+	watchWithReconnect(watchCtx, er.client, path, false, index, er.reconnectBackoff, er.reconnectJitter, er.metrics,
+		func(response *client.Response) {
+			event := newWatchEvent(response)
+			if event.Action != WatchActionDelete && event.Action != WatchActionExpire && DoguState(event.NewValue) == state {
+				reached = true
+				cancel()
+			}
+		},
+		func(err error) {
+			// the watch may have missed events, e.g. if the event history was cleared
+			current, getErr := er.State(dogu).GetContext(watchCtx)
+			if getErr == nil && DoguState(current) == state {
+				reached = true
+				cancel()
+			}
+		})
+
+	if reached {
+		return nil
+	}
+	return errors.Wrapf(ctx.Err(), "failed to wait for state %s of dogu %s", state, dogu)
+}
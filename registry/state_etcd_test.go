@@ -0,0 +1,242 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/client/v2"
+)
+
+func TestDoguState_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from DoguState
+		to   DoguState
+		want bool
+	}{
+		{from: "", to: StateInstalling, want: true},
+		{from: "", to: StateReady, want: true},
+		{from: "custom", to: StateStopped, want: true},
+		{from: StateInstalling, to: StateReady, want: true},
+		{from: StateReady, to: StateInstalling, want: true},
+		{from: StateReady, to: StateUpgrading, want: true},
+		{from: StateReady, to: StateReady, want: true},
+		{from: StateUpgrading, to: StateReady, want: true},
+		{from: StateStopping, to: StateStopped, want: true},
+		{from: StateFailed, to: StateInstalling, want: true},
+		{from: StateStopping, to: StateReady, want: false},
+		{from: StateStopped, to: StateReady, want: false},
+		{from: StateUpgrading, to: StateStopping, want: false},
+		{from: StateReady, to: "custom", want: false},
+		{from: "", to: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s to %s", tt.from, tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func Test_etcdState_Transition(t *testing.T) {
+	t.Run("should change state and record history", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().State("ldap")
+
+		// when
+		for _, state := range []DoguState{StateInstalling, StateStarting, StateReady, StateReady} {
+			require.NoError(t, sut.Transition(state))
+		}
+
+		// then
+		state, err := sut.GetState()
+		require.NoError(t, err)
+		assert.Equal(t, StateReady, state)
+
+		history, err := sut.History()
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.Equal(t, DoguState(""), history[0].From)
+		assert.Equal(t, StateInstalling, history[0].To)
+		assert.Equal(t, StateInstalling, history[1].From)
+		assert.Equal(t, StateStarting, history[1].To)
+		assert.Equal(t, StateStarting, history[2].From)
+		assert.Equal(t, StateReady, history[2].To)
+		assert.False(t, history[2].Time.Before(history[0].Time))
+	})
+
+	t.Run("should reject invalid transition", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().State("ldap")
+		require.NoError(t, sut.Set(string(StateStopped)))
+
+		// when
+		err := sut.Transition(StateReady)
+
+		// then
+		assert.ErrorContains(t, err, "invalid transition of state at /state/ldap from 'stopped' to 'ready'")
+		state, err := sut.GetState()
+		require.NoError(t, err)
+		assert.Equal(t, StateStopped, state)
+		history, err := sut.History()
+		require.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	t.Run("should keep only the last transitions", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry().State("ldap")
+
+		// when
+		for i := 0; i < StateHistoryLimit; i++ {
+			require.NoError(t, sut.Transition(StateInstalling))
+			require.NoError(t, sut.Transition(StateReady))
+		}
+
+		// then
+		history, err := sut.History()
+		require.NoError(t, err)
+		require.Len(t, history, StateHistoryLimit)
+		assert.Equal(t, StateInstalling, history[0].To)
+		assert.Equal(t, StateReady, history[StateHistoryLimit-1].To)
+	})
+
+	t.Run("should fail if state was changed concurrently", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.EXPECT().GetWithIndex(mock.Anything, "/state/ldap").Return("ready", 5, nil)
+		etcdClientMock.EXPECT().Set(mock.Anything, "/state/ldap", "upgrading", &client.SetOptions{PrevIndex: 5}).
+			Return("", client.Error{Code: client.ErrorCodeTestFailed})
+		sut := &etcdState{path: "/state/ldap", historyPath: "/state/_history/ldap", client: etcdClientMock}
+
+		// when
+		err := sut.Transition(StateUpgrading)
+
+		// then
+		assert.ErrorContains(t, err, "state at /state/ldap was changed concurrently")
+	})
+
+	t.Run("should stop appending history which is changed concurrently all the time", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.EXPECT().GetWithIndex(mock.Anything, "/state/ldap").Return("ready", 5, nil)
+		etcdClientMock.EXPECT().Set(mock.Anything, "/state/ldap", "upgrading", &client.SetOptions{PrevIndex: 5}).
+			Return("upgrading", nil)
+		etcdClientMock.EXPECT().GetWithIndex(mock.Anything, "/state/_history/ldap").Return("[]", 6, nil).
+			Times(maxStateHistoryAttempts)
+		etcdClientMock.EXPECT().Set(mock.Anything, "/state/_history/ldap", mock.Anything, &client.SetOptions{PrevIndex: 6}).
+			Return("", client.Error{Code: client.ErrorCodeTestFailed}).Times(maxStateHistoryAttempts)
+		sut := &etcdState{path: "/state/ldap", historyPath: "/state/_history/ldap", client: etcdClientMock}
+
+		// when
+		err := sut.Transition(StateUpgrading)
+
+		// then
+		assert.ErrorContains(t, err, "could not set state history at /state/_history/ldap, because it was changed concurrently")
+	})
+
+	t.Run("should stop appending history if the context is done", func(t *testing.T) {
+		// given
+		ctx, cancel := context.WithCancel(context.Background())
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.EXPECT().GetWithIndex(mock.Anything, "/state/ldap").Return("ready", 5, nil)
+		etcdClientMock.EXPECT().Set(mock.Anything, "/state/ldap", "upgrading", &client.SetOptions{PrevIndex: 5}).
+			Return("upgrading", nil)
+		etcdClientMock.EXPECT().GetWithIndex(mock.Anything, "/state/_history/ldap").Return("[]", 6, nil).Once()
+		etcdClientMock.EXPECT().Set(mock.Anything, "/state/_history/ldap", mock.Anything, &client.SetOptions{PrevIndex: 6}).
+			RunAndReturn(func(context.Context, string, string, *client.SetOptions) (string, error) {
+				cancel()
+				return "", client.Error{Code: client.ErrorCodeTestFailed}
+			}).Once()
+		sut := &etcdState{path: "/state/ldap", historyPath: "/state/_history/ldap", client: etcdClientMock}
+
+		// when
+		err := sut.TransitionContext(ctx, StateUpgrading)
+
+		// then
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("should fail if state cannot be read", func(t *testing.T) {
+		// given
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.EXPECT().GetWithIndex(mock.Anything, "/state/ldap").Return("", 0, assert.AnError)
+		sut := &etcdState{path: "/state/ldap", historyPath: "/state/_history/ldap", client: etcdClientMock}
+
+		// when
+		err := sut.Transition(StateReady)
+
+		// then
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("should not list history as dogu state", func(t *testing.T) {
+		// given
+		memClient := newMemoryClient()
+		sut := (&etcdRegistry{client: memClient}).State("ldap")
+		require.NoError(t, sut.Transition(StateReady))
+
+		// when
+		children, err := memClient.GetChildrenPaths(context.Background(), "/state")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/state/ldap"}, children)
+	})
+}
+
+func Test_etcdRegistry_WaitFor(t *testing.T) {
+	t.Run("should return immediately if dogu has the state", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry()
+		require.NoError(t, sut.State("ldap").Set("ready"))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		// when
+		err := sut.WaitFor(ctx, "ldap", StateReady)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should wait until dogu has the state", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		go func() {
+			for _, state := range []DoguState{StateInstalling, StateStarting, StateReady} {
+				time.Sleep(10 * time.Millisecond)
+				_ = sut.State("ldap").Transition(state)
+			}
+		}()
+
+		// when
+		err := sut.WaitFor(ctx, "ldap", StateReady)
+
+		// then
+		require.NoError(t, err)
+		state, err := sut.State("ldap").GetState()
+		require.NoError(t, err)
+		assert.Equal(t, StateReady, state)
+	})
+
+	t.Run("should fail if context is done before dogu has the state", func(t *testing.T) {
+		// given
+		sut := NewInMemoryRegistry()
+		require.NoError(t, sut.State("ldap").Set("installing"))
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		// when
+		err := sut.WaitFor(ctx, "ldap", StateReady)
+
+		// then
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "failed to wait for state ready of dogu ldap")
+	})
+}
@@ -4,9 +4,13 @@
 package registry_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/cloudogu/cesapp-lib/registry"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestState(t *testing.T) {
@@ -49,3 +53,38 @@ func TestState(t *testing.T) {
 		assert.Equal(t, "", value)
 	})
 }
+
+func TestStateTransition_inttest(t *testing.T) {
+	registries := map[string]registry.Registry{"etcd": reg, "etcdv3": createEtcdV3TestRegistry(t)}
+	for name, r := range registries {
+		t.Run(name, func(t *testing.T) {
+			state := r.State("unit-test-transition")
+			defer func() {
+				_ = state.Remove()
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for _, s := range []registry.DoguState{registry.StateInstalling, registry.StateReady} {
+					time.Sleep(50 * time.Millisecond)
+					_ = state.Transition(s)
+				}
+			}()
+
+			err := r.WaitFor(ctx, "unit-test-transition", registry.StateReady)
+			require.NoError(t, err)
+			<-done
+
+			history, err := state.History()
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, len(history), 2)
+			assert.Equal(t, registry.StateReady, history[len(history)-1].To)
+
+			err = state.Transition(registry.StateStopped)
+			assert.Error(t, err)
+		})
+	}
+}
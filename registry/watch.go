@@ -0,0 +1,137 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/client/v2"
+)
+
+// WatchAction describes how a watched key was changed.
+type WatchAction string
+
+const (
+	// WatchActionCreate is used for keys which did not exist before the change.
+	WatchActionCreate WatchAction = "create"
+	// WatchActionUpdate is used for keys which got a new value.
+	WatchActionUpdate WatchAction = "update"
+	// WatchActionDelete is used for keys which were removed.
+	WatchActionDelete WatchAction = "delete"
+	// WatchActionExpire is used for keys which were removed because their time to live ran out.
+	WatchActionExpire WatchAction = "expire"
+)
+
+// WatchEvent describes the change of a watched key.
+type WatchEvent struct {
+	// Key is the full path of the changed key.
+	Key string
+	// OldValue is the value of the key before the change. It is empty for created keys.
+	OldValue string
+	// NewValue is the value of the key after the change. It is empty for deleted and expired keys.
+	NewValue string
+	// Action describes how the key was changed.
+	Action WatchAction
+	// Revision is the index of the registry at which the change happened.
+	Revision uint64
+	// IsDir is true if the changed key is a directory.
+	IsDir bool
+}
+
+func newWatchEvent(response *client.Response) WatchEvent {
+	event := WatchEvent{
+		Key:      response.Node.Key,
+		NewValue: response.Node.Value,
+		Revision: response.Node.ModifiedIndex,
+		IsDir:    response.Node.Dir,
+	}
+	if response.PrevNode != nil {
+		event.OldValue = response.PrevNode.Value
+	}
+
+	switch response.Action {
+	case "delete", "compareAndDelete":
+		event.Action = WatchActionDelete
+		event.NewValue = ""
+	case "expire":
+		event.Action = WatchActionExpire
+		event.NewValue = ""
+	default:
+		event.Action = WatchActionUpdate
+		if response.PrevNode == nil {
+			event.Action = WatchActionCreate
+		}
+	}
+
+	return event
+}
+
+// watchWithReconnect watches the key until the given context is done. Every event is passed to onEvent. If the watch
+// is interrupted, the error is passed to onError and the watch is restarted after the next delay of the backoff. The
+// restarted watch resumes after the last received event, so that no event is missed. Only if the registry no longer
+// knows the events after the last received event, the watch resumes after the index of the registry at the time of
+// the error, so that onError can check the current value without missing later events. The first watch starts after
+// the given index. If the given index is 0, the current index is read first and the watch starts after it, so that
+// no change is missed even if the watch is interrupted before the first event. Every delay is randomized by the jitter. Every
+// reconnect is counted by the metrics recorder if it is not nil.
+func watchWithReconnect(ctx context.Context, etcdClient etcdClient, key string, recursive bool, afterIndex uint64,
+	backoff []time.Duration, jitter float64, metrics core.MetricsRecorder, onEvent func(response *client.Response), onError func(err error)) {
+	if len(backoff) == 0 {
+		backoff = defaultBackoff
+	}
+
+	lastIndex := afterIndex
+	attempt := 0
+	for {
+		var err error
+		if lastIndex == 0 {
+			// the current index is read before the first watch, so that a reconnect before the first event resumes
+			// after it instead of at the index of the reconnect
+			lastIndex, err = etcdClient.GetCurrentIndex(ctx, key)
+		}
+
+		if err == nil {
+			responses := make(chan *client.Response)
+			watchErr := make(chan error, 1)
+			go func(index uint64) {
+				watchErr <- etcdClient.Watch(ctx, key, recursive, index, responses)
+			}(lastIndex)
+
+		receive:
+			for {
+				select {
+				case response := <-responses:
+					lastIndex = response.Node.ModifiedIndex
+					attempt = 0
+					onEvent(response)
+				case err = <-watchErr:
+					break receive
+				}
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if IsEventIndexClearedError(err) {
+			// errors without index resume at the current index
+			lastIndex = errorIndex(err)
+		}
+
+		delay := core.RandomizeDelay(backoff[min(attempt, len(backoff)-1)], jitter)
+		attempt++
+		core.GetLogger().Infof("watch of key %s was interrupted, try again in %s: %v", key, delay, err)
+		onError(errors.Wrapf(err, "watch of key %s was interrupted", key))
+		if metrics != nil {
+			metrics.CountRetry(etcdMetricsComponent, "watch")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
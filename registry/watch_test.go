@@ -0,0 +1,151 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/client/v2"
+)
+
+func Test_newWatchEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *client.Response
+		want     WatchEvent
+	}{
+		{
+			name:     "should map set of new key to create",
+			response: &client.Response{Action: "set", Node: &client.Node{Key: "/a", Value: "1", ModifiedIndex: 2}},
+			want:     WatchEvent{Key: "/a", NewValue: "1", Action: WatchActionCreate, Revision: 2},
+		},
+		{
+			name: "should map set of existing key to update",
+			response: &client.Response{
+				Action:   "compareAndSwap",
+				Node:     &client.Node{Key: "/a", Value: "2", ModifiedIndex: 3},
+				PrevNode: &client.Node{Key: "/a", Value: "1", ModifiedIndex: 2},
+			},
+			want: WatchEvent{Key: "/a", OldValue: "1", NewValue: "2", Action: WatchActionUpdate, Revision: 3},
+		},
+		{
+			name: "should map delete",
+			response: &client.Response{
+				Action:   "delete",
+				Node:     &client.Node{Key: "/a", Dir: true, ModifiedIndex: 4},
+				PrevNode: &client.Node{Key: "/a", Dir: true, ModifiedIndex: 2},
+			},
+			want: WatchEvent{Key: "/a", Action: WatchActionDelete, Revision: 4, IsDir: true},
+		},
+		{
+			name: "should map expire",
+			response: &client.Response{
+				Action:   "expire",
+				Node:     &client.Node{Key: "/a", ModifiedIndex: 5},
+				PrevNode: &client.Node{Key: "/a", Value: "1", ModifiedIndex: 2},
+			},
+			want: WatchEvent{Key: "/a", OldValue: "1", Action: WatchActionExpire, Revision: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newWatchEvent(tt.response))
+		})
+	}
+}
+
+// interruptedWatchClient interrupts the first watch before it sends any event.
+type interruptedWatchClient struct {
+	etcdClient
+	watches int
+}
+
+func (iwc *interruptedWatchClient) Watch(ctx context.Context, key string, recursive bool, afterIndex uint64, eventChannel chan<- *client.Response) error {
+	iwc.watches++
+	if iwc.watches == 1 {
+		return assert.AnError
+	}
+	return iwc.etcdClient.Watch(ctx, key, recursive, afterIndex, eventChannel)
+}
+
+func Test_watchWithReconnect(t *testing.T) {
+	t.Run("should start after the given index", func(t *testing.T) {
+		// given
+		memClient := newMemoryClient()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err := memClient.Set(ctx, "/state/ldap", "installing", nil)
+		require.NoError(t, err)
+		_, index, err := memClient.GetWithIndex(ctx, "/state/ldap")
+		require.NoError(t, err)
+		_, err = memClient.Set(ctx, "/state/ldap", "ready", nil)
+		require.NoError(t, err)
+		var values []string
+
+		// when
+		watchWithReconnect(ctx, memClient, "/state/ldap", false, index, nil, 0, nil, func(response *client.Response) {
+			values = append(values, response.Node.Value)
+			cancel()
+		}, func(err error) {})
+
+		// then
+		assert.Equal(t, []string{"ready"}, values)
+	})
+
+	t.Run("should not miss changes if the watch is interrupted before the first event", func(t *testing.T) {
+		// given
+		memClient := newMemoryClient()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err := memClient.Set(ctx, "/state/ldap", "installing", nil)
+		require.NoError(t, err)
+		sut := &interruptedWatchClient{etcdClient: memClient}
+		var values []string
+
+		// when
+		watchWithReconnect(ctx, sut, "/state/ldap", false, 0, []time.Duration{time.Millisecond}, 0, nil,
+			func(response *client.Response) {
+				values = append(values, response.Node.Value)
+				cancel()
+			}, func(err error) {
+				// the key is changed while the watch is interrupted
+				_, setErr := memClient.Set(ctx, "/state/ldap", "ready", nil)
+				require.NoError(t, setErr)
+			})
+
+		// then
+		assert.Equal(t, 2, sut.watches)
+		assert.Equal(t, []string{"ready"}, values)
+	})
+
+	t.Run("should resume after the index of the error if events were cleared", func(t *testing.T) {
+		// given
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		etcdClientMock := newMockEtcdClient(t)
+		etcdClientMock.EXPECT().Watch(mock.Anything, "/state/ldap", false, uint64(3), mock.Anything).
+			Return(client.Error{Code: client.ErrorCodeEventIndexCleared, Index: 1500}).Once()
+		etcdClientMock.EXPECT().Watch(mock.Anything, "/state/ldap", false, uint64(1500), mock.Anything).
+			Run(func(_ context.Context, _ string, _ bool, _ uint64, _ chan<- *client.Response) {
+				cancel()
+			}).
+			Return(context.Canceled).Once()
+		var errs []error
+		recorder := &recordingMetricsRecorder{}
+
+		// when
+		watchWithReconnect(ctx, etcdClientMock, "/state/ldap", false, 3, []time.Duration{time.Millisecond}, 0, recorder,
+			func(response *client.Response) {}, func(err error) {
+				errs = append(errs, err)
+			})
+
+		// then
+		require.Len(t, errs, 1)
+		assert.True(t, IsEventIndexClearedError(errs[0]))
+		assert.Equal(t, []string{"etcd watch"}, recorder.getRetries())
+	})
+}
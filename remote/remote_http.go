@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"crypto/tls"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,8 +14,6 @@ import (
 	"strings"
 	"time"
 
-	neturl "net/url"
-
 	"github.com/cloudogu/cesapp-lib/core"
 	"github.com/cloudogu/cesapp-lib/util"
 	"github.com/eapache/go-resiliency/retrier"
@@ -29,6 +26,9 @@ var errForbidden = errors.New("403 forbidden, not enough privileges")
 var errNotFound = errors.New("404 not found")
 var defaultBackoff = retrier.ConstantBackoff(1, 100*time.Millisecond)
 
+// metricsComponent is the name of the remote registry in the recorded metrics.
+const metricsComponent = "remote"
+
 // httpRemote is able to handle request to a remote registry.
 type httpRemote struct {
 	endpoint            string
@@ -39,6 +39,8 @@ type httpRemote struct {
 	urlSchema           URLSchema
 	useCache            bool
 	remoteConfiguration *core.Remote
+	// metrics records the durations and retries of the requests and the fallbacks to the cache if it is not nil.
+	metrics core.MetricsRecorder
 }
 
 func newHTTPRemote(remoteConfig *core.Remote, credentials *core.Credentials) (*httpRemote, error) {
@@ -53,6 +55,7 @@ func newHTTPRemote(remoteConfig *core.Remote, credentials *core.Credentials) (*h
 		backoff,
 		retrier.BlacklistClassifier{errUnauthorized, errForbidden},
 	)
+	netRetrier.SetJitter(core.GetJitter(remoteConfig.RetryPolicy))
 
 	checkSum := fmt.Sprintf("%x", sha256.Sum256([]byte(remoteConfig.CacheDir)))
 
@@ -72,6 +75,7 @@ func newHTTPRemote(remoteConfig *core.Remote, credentials *core.Credentials) (*h
 		urlSchema:           urlSchema,
 		useCache:            true,
 		remoteConfiguration: remoteConfig,
+		metrics:             remoteConfig.Metrics,
 	}, nil
 }
 
@@ -94,15 +98,13 @@ func createProxyHTTPTransport(config *core.Remote) (*http.Transport, error) {
 	transport := &http.Transport{}
 
 	if config.ProxySettings.Enabled {
-		proxyURLString := config.ProxySettings.CreateURL()
-		core.GetLogger().Infof("configure http client to use proxy %s", proxyURLString)
-
-		proxyURL, err := neturl.Parse(proxyURLString)
+		err := config.ProxySettings.Validate()
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to parse proxy url %s", proxyURLString)
+			return nil, errors.Wrap(err, "invalid proxy settings")
 		}
-		transport.Proxy = http.ProxyURL(proxyURL)
-		appendProxyAuthorizationIfRequired(transport, &config.ProxySettings)
+
+		core.GetLogger().Infof("configure http client to use proxy %s", config.ProxySettings.CreateURL())
+		transport.Proxy = config.ProxySettings.ProxyFunc()
 	}
 
 	transport.TLSClientConfig = &tls.Config{
@@ -112,16 +114,24 @@ func createProxyHTTPTransport(config *core.Remote) (*http.Transport, error) {
 	return transport, nil
 }
 
-func appendProxyAuthorizationIfRequired(transport *http.Transport, proxySettings *core.ProxySettings) {
-	if proxySettings.Username != "" {
-		authorization := proxySettings.Username + ":" + proxySettings.Password
-		basicAuthorization := "Basic " + base64.StdEncoding.EncodeToString([]byte(authorization))
-		if transport.ProxyConnectHeader == nil {
-			transport.ProxyConnectHeader = make(map[string][]string)
+// run runs the request of the given operation with the retrier and records its duration and retries.
+func (r *httpRemote) run(operation string, request func() error) error {
+	if r.metrics == nil {
+		return r.retrier.Run(request)
+	}
+
+	start := time.Now()
+	attempts := 0
+	err := r.retrier.Run(func() error {
+		attempts++
+		if attempts > 1 {
+			r.metrics.CountRetry(metricsComponent, operation)
 		}
+		return request()
+	})
+	r.metrics.ObserveRequest(metricsComponent, operation, time.Since(start), err)
 
-		transport.ProxyConnectHeader.Add("Proxy-Authorization", basicAuthorization)
-	}
+	return err
 }
 
 // SetUseCache disables or enables the caching for the remote http registry.
@@ -132,7 +142,7 @@ func (r *httpRemote) SetUseCache(useCache bool) {
 // Create the dogu on the remote server.
 func (r *httpRemote) Create(dogu *core.Dogu) error {
 	// call with retrier
-	err := r.retrier.Run(func() error {
+	err := r.run("create", func() error {
 
 		core.GetLogger().Info("send dogu to remote server")
 
@@ -171,7 +181,7 @@ func (r *httpRemote) Create(dogu *core.Dogu) error {
 
 // Delete removes a specific dogu descriptor from the dogu registry.
 func (r *httpRemote) Delete(dogu *core.Dogu) error {
-	err := r.retrier.Run(func() error {
+	err := r.run("delete", func() error {
 		core.GetLogger().Info("delete dogu from remote server")
 
 		data, err := core.WriteDoguToString(dogu)
@@ -211,7 +221,7 @@ func (r *httpRemote) Delete(dogu *core.Dogu) error {
 func (r *httpRemote) Get(name string) (*core.Dogu, error) {
 	requestUrl := r.urlSchema.Get(name)
 	cacheDirectory := filepath.Join(r.endpointCacheDir, name)
-	return r.receiveDoguFromRemoteOrCache(requestUrl, cacheDirectory)
+	return r.receiveDoguFromRemoteOrCache("get", requestUrl, cacheDirectory)
 }
 
 // GetVersion returns a version specific detail about the dogu. Name is mandatory. Version is optional; if no version
@@ -219,12 +229,12 @@ func (r *httpRemote) Get(name string) (*core.Dogu, error) {
 func (r *httpRemote) GetVersion(name, version string) (*core.Dogu, error) {
 	requestUrl := r.urlSchema.GetVersion(name, version)
 	cacheDirectory := filepath.Join(r.endpointCacheDir, name, version)
-	return r.receiveDoguFromRemoteOrCache(requestUrl, cacheDirectory)
+	return r.receiveDoguFromRemoteOrCache("getVersion", requestUrl, cacheDirectory)
 }
 
-func (r *httpRemote) receiveDoguFromRemoteOrCache(requestUrl string, cacheDirectory string) (*core.Dogu, error) {
+func (r *httpRemote) receiveDoguFromRemoteOrCache(operation string, requestUrl string, cacheDirectory string) (*core.Dogu, error) {
 	var dogu *core.Dogu
-	err := r.retrier.Run(func() error {
+	err := r.run(operation, func() error {
 		if r.remoteConfiguration.AnonymousAccess {
 			return r.requestWithoutCredentialsFirst(requestUrl, &dogu)
 		}
@@ -246,7 +256,7 @@ func (r *httpRemote) receiveDoguFromRemoteOrCache(requestUrl string, cacheDirect
 // GetAll returns all dogus from the remote server.
 func (r *httpRemote) GetAll() ([]*core.Dogu, error) {
 	var dogus []*core.Dogu
-	err := r.retrier.Run(func() error {
+	err := r.run("getAll", func() error {
 		if r.remoteConfiguration.AnonymousAccess {
 			return r.requestWithoutCredentialsFirst(r.urlSchema.GetAll(), &dogus)
 		}
@@ -263,7 +273,7 @@ func (r *httpRemote) GetAll() ([]*core.Dogu, error) {
 // GetVersionsOf return all versions of a dogu.
 func (r *httpRemote) GetVersionsOf(name string) ([]core.Version, error) {
 	stringVersions := make([]string, 0)
-	err := r.retrier.Run(func() error {
+	err := r.run("getVersionsOf", func() error {
 		if r.remoteConfiguration.AnonymousAccess {
 			return r.requestWithoutCredentialsFirst(r.urlSchema.GetVersionsOf(name), &stringVersions)
 		}
@@ -300,6 +310,9 @@ func (r *httpRemote) handleCachingIfNecessary(cachingType interface{}, requestEr
 			if err != nil {
 				return errors.Wrap(err, "failed to read from remote registry and cache")
 			}
+			if r.metrics != nil {
+				r.metrics.CountCacheEvent(metricsComponent, core.CacheFallback)
+			}
 		} else {
 			err := r.writeCacheWithFilename(cachingType, dirname, filename)
 			if err != nil {
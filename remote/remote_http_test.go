@@ -5,7 +5,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -245,3 +248,70 @@ func Test_httpRemote_Delete(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func Test_httpRemote_metrics(t *testing.T) {
+	t.Run("should record requests, retries and fallbacks to the cache", func(t *testing.T) {
+		// given
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		cacheDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "versions.json"), []byte(`["1.2.3-4"]`), 0600))
+		recorder := &recordingMetricsRecorder{}
+		sut := httpRemote{
+			endpointCacheDir:    cacheDir,
+			retrier:             retrier.New(retrier.ConstantBackoff(1, time.Millisecond), retrier.BlacklistClassifier{errUnauthorized, errForbidden}),
+			urlSchema:           NewURLSchemaByName("default", ts.URL+"/api/v2/dogus"),
+			client:              &http.Client{Timeout: 1 * time.Second},
+			useCache:            true,
+			remoteConfiguration: &core.Remote{},
+			metrics:             recorder,
+		}
+
+		// when
+		versions, err := sut.GetVersionsOf("official/redmine")
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		assert.Equal(t, "1.2.3-4", versions[0].Raw)
+		assert.Equal(t, []string{"remote getVersionsOf error"}, recorder.requests)
+		assert.Equal(t, []string{"remote getVersionsOf"}, recorder.retries)
+		assert.Equal(t, []string{"remote fallback"}, recorder.cacheEvents)
+	})
+}
+
+// recordingMetricsRecorder records the component and operation of every observed request, retry and cache event.
+type recordingMetricsRecorder struct {
+	mutex       sync.Mutex
+	requests    []string
+	retries     []string
+	cacheEvents []string
+}
+
+func (recorder *recordingMetricsRecorder) ObserveRequest(component string, operation string, _ time.Duration, err error) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	recorder.requests = append(recorder.requests, component+" "+operation+" "+result)
+}
+
+func (recorder *recordingMetricsRecorder) CountRetry(component string, operation string) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.retries = append(recorder.retries, component+" "+operation)
+}
+
+func (recorder *recordingMetricsRecorder) CountCacheEvent(component string, event core.CacheEvent) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.cacheEvents = append(recorder.cacheEvents, component+" "+string(event))
+}
@@ -598,6 +598,64 @@ func TestGetAllWithProxy(t *testing.T) {
 	require.Equal(t, "a", sample.Name)
 }
 
+func TestGetWithProxyAuthorization(t *testing.T) {
+	var proxyAuthorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyAuthorization = r.Header.Get("Proxy-Authorization")
+		data, err := core.WriteDoguToString(&core.Dogu{Name: "a", Version: "1.0"})
+		require.Nil(t, err)
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(200)
+		_, err = w.Write([]byte(data))
+		require.Nil(t, err)
+	}))
+	defer ts.Close()
+
+	parsedURL, err := url.Parse(ts.URL)
+	require.Nil(t, err)
+	port, err := strconv.Atoi(parsedURL.Port())
+	require.Nil(t, err)
+
+	testRemote, err := remote.New(
+		&core.Remote{
+			Endpoint: "http://pangalaktischer-donnergurgler.org",
+			CacheDir: "/tmp/ces/cache/remote_test",
+			ProxySettings: core.ProxySettings{
+				Enabled:  true,
+				Server:   parsedURL.Hostname(),
+				Port:     port,
+				Username: "trillian",
+				Password: "mcmillian",
+			},
+		},
+		&core.Credentials{
+			Username: "trillian",
+			Password: "secret",
+		},
+	)
+	require.Nil(t, err)
+	testRemote.SetUseCache(false)
+
+	_, err = testRemote.Get("sample")
+	require.Nil(t, err)
+	assert.Equal(t, "Basic dHJpbGxpYW46bWNtaWxsaWFu", proxyAuthorization)
+}
+
+func TestNewWithInvalidProxySettings(t *testing.T) {
+	_, err := remote.New(
+		&core.Remote{
+			Endpoint:      "http://pangalaktischer-donnergurgler.org",
+			CacheDir:      "/tmp/ces/cache/remote_test",
+			ProxySettings: core.ProxySettings{Enabled: true, Server: "http://proxy.cloudogu.com", Port: 3128},
+		},
+		&core.Credentials{},
+	)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid proxy settings")
+}
+
 func TestGetAllWithIndexURLSchema(t *testing.T) {
 	var url string
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
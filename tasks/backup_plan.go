@@ -0,0 +1,169 @@
+package tasks
+
+import (
+	"fmt"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/cloudogu/cesapp-lib/registry"
+)
+
+const serviceAccountKindDogu = "dogu"
+
+// BackupPlan describes how the installed dogus are backed up and restored. It is serializable to JSON for the backup
+// tooling, which executes it in the following order:
+//   - stop the dogus of StopOrder one after another and invoke the ConsumerCommands of a dogu right after it was
+//     stopped, so that the providers of the commands are still running
+//   - save the Volumes
+//   - start the dogus of RestoreOrder one after another
+//
+// A restore uses the same RestoreOrder, so that the dogus which are needed by other dogus are restored and started
+// first.
+type BackupPlan struct {
+	// StopOrder contains the simple names of the dogus which are stopped during the backup. Dogus which depend on
+	// other dogus are stopped first.
+	StopOrder []string `json:"stopOrder"`
+	// ConsumerCommands contains the backup-consumer commands which save the data of service account consumers in
+	// the order of StopOrder.
+	ConsumerCommands []BackupConsumerCommand `json:"consumerCommands"`
+	// Volumes contains the volumes which are saved in the order of RestoreOrder.
+	Volumes []BackupVolume `json:"volumes"`
+	// RestoreOrder contains the simple names of the dogus of StopOrder in reverse order, so that the dogus which are
+	// needed by other dogus come first.
+	RestoreOrder []string `json:"restoreOrder"`
+}
+
+// BackupVolume is a volume of a dogu which is saved during a backup.
+type BackupVolume struct {
+	// Dogu is the simple name of the dogu of the volume.
+	Dogu string `json:"dogu"`
+	// Name is the name of the volume.
+	Name string `json:"name"`
+	// Path is the path of the volume in the container of the dogu.
+	Path string `json:"path"`
+	// Owner is the numeric id of the user who owns the volume.
+	Owner string `json:"owner,omitempty"`
+	// Group is the numeric id of the group which owns the volume.
+	Group string `json:"group,omitempty"`
+}
+
+// BackupConsumerCommand is a backup-consumer command of a service account provider which saves the data of a
+// service account consumer, e.g. the database of a dogu in postgresql.
+type BackupConsumerCommand struct {
+	// Provider is the simple name of the dogu which provides the service account and executes the command.
+	Provider string `json:"provider"`
+	// Consumer is the simple name of the dogu which consumes the service account.
+	Consumer string `json:"consumer"`
+	// Command is the script of the command in the container of the provider.
+	Command string `json:"command"`
+	// Args contains the arguments of the command.
+	Args []string `json:"args"`
+}
+
+// CreateBackupPlan creates the plan to back up and restore all installed dogus of the given dogu registry. A dogu is
+// part of the plan if one of its volumes needs a backup or if it consumes a service account of a dogu with a
+// backup-consumer command. The consumers have to depend on the providers of their service accounts, so that the
+// providers are still running when the commands are invoked.
+func CreateBackupPlan(doguRegistry registry.DoguRegistry) (*BackupPlan, error) {
+	installed, err := doguRegistry.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get installed dogus: %w", err)
+	}
+
+	sorted, err := core.SortDogusByDependencyWithError(installed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to order dogus for backup: %w", err)
+	}
+
+	positions := map[string]int{}
+	for i, dogu := range sorted {
+		positions[dogu.GetSimpleName()] = i
+	}
+
+	plan := &BackupPlan{
+		StopOrder:        []string{},
+		ConsumerCommands: []BackupConsumerCommand{},
+		Volumes:          []BackupVolume{},
+		RestoreOrder:     []string{},
+	}
+	for _, dogu := range sorted {
+		volumes := backupVolumes(dogu)
+		commands, err := backupConsumerCommands(dogu, sorted, positions)
+		if err != nil {
+			return nil, err
+		}
+		if len(volumes) == 0 && len(commands) == 0 {
+			continue
+		}
+
+		plan.RestoreOrder = append(plan.RestoreOrder, dogu.GetSimpleName())
+		plan.Volumes = append(plan.Volumes, volumes...)
+		// the consumers are stopped in reverse order, so their commands are prepended as well
+		plan.ConsumerCommands = append(append([]BackupConsumerCommand{}, commands...), plan.ConsumerCommands...)
+	}
+
+	for i := len(plan.RestoreOrder) - 1; i >= 0; i-- {
+		plan.StopOrder = append(plan.StopOrder, plan.RestoreOrder[i])
+	}
+
+	return plan, nil
+}
+
+func backupVolumes(dogu *core.Dogu) []BackupVolume {
+	var volumes []BackupVolume
+	for _, volume := range dogu.Volumes {
+		if volume.NeedsBackup {
+			volumes = append(volumes, BackupVolume{
+				Dogu:  dogu.GetSimpleName(),
+				Name:  volume.Name,
+				Path:  volume.Path,
+				Owner: volume.Owner,
+				Group: volume.Group,
+			})
+		}
+	}
+	return volumes
+}
+
+// backupConsumerCommands returns the backup-consumer commands of the installed providers of the service accounts of
+// the consumer. Providers which the consumer does not depend on are rejected, because their order is not defined.
+func backupConsumerCommands(consumer *core.Dogu, installed []*core.Dogu, positions map[string]int) ([]BackupConsumerCommand, error) {
+	var commands []BackupConsumerCommand
+	for _, serviceAccount := range consumer.ServiceAccounts {
+		if serviceAccount.Kind != "" && serviceAccount.Kind != serviceAccountKindDogu {
+			continue
+		}
+
+		position, ok := positions[serviceAccount.Type]
+		if !ok {
+			continue
+		}
+		provider := installed[position]
+		command := provider.GetExposedCommand(core.ExposedCommandBackupConsumer)
+		if command == nil {
+			continue
+		}
+
+		if !dependsOnDogu(consumer, provider.GetSimpleName()) {
+			return nil, fmt.Errorf("dogu %s consumes a service account of %s, which has a %s command, but does not depend on it",
+				consumer.GetSimpleName(), provider.GetSimpleName(), core.ExposedCommandBackupConsumer)
+		}
+
+		commands = append(commands, BackupConsumerCommand{
+			Provider: provider.GetSimpleName(),
+			Consumer: consumer.GetSimpleName(),
+			Command:  command.Command,
+			Args:     []string{consumer.GetSimpleName()},
+		})
+	}
+	return commands, nil
+}
+
+// dependsOnDogu returns true if the dogu has a mandatory or optional dependency on the dogu with the given name.
+func dependsOnDogu(dogu *core.Dogu, name string) bool {
+	for _, dependency := range dogu.GetAllDependenciesOfType(core.DependencyTypeDogu) {
+		if dependency.Name == name {
+			return true
+		}
+	}
+	return false
+}
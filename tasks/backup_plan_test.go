@@ -0,0 +1,154 @@
+package tasks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/cloudogu/cesapp-lib/registry/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBackupTestDogus() []*core.Dogu {
+	postgresql := &core.Dogu{
+		Name:    "official/postgresql",
+		Version: "12.15-2",
+		Volumes: []core.Volume{{Name: "data", Path: "/var/lib/postgresql", NeedsBackup: false}},
+		ExposedCommands: []core.ExposedCommand{
+			{Name: core.ExposedCommandServiceAccountCreate, Command: "/create-sa.sh"},
+			{Name: core.ExposedCommandBackupConsumer, Command: "/backup-consumer.sh"},
+		},
+	}
+	ldap := &core.Dogu{
+		Name:    "official/ldap",
+		Version: "2.6.2-7",
+		Volumes: []core.Volume{
+			{Name: "db", Path: "/var/lib/openldap", Owner: "100", Group: "101", NeedsBackup: true},
+			{Name: "config", Path: "/etc/openldap/slapd.d", Owner: "100", Group: "101", NeedsBackup: true},
+			{Name: "localConfig", Path: "/var/ces/config", NeedsBackup: false},
+		},
+	}
+	redmine := &core.Dogu{
+		Name:    "official/redmine",
+		Version: "5.1.3-1",
+		Dependencies: []core.Dependency{
+			{Type: core.DependencyTypeDogu, Name: "ldap"},
+			{Type: core.DependencyTypeDogu, Name: "postgresql"},
+		},
+		Volumes:         []core.Volume{{Name: "files", Path: "/usr/share/webapps/redmine/files", NeedsBackup: true}},
+		ServiceAccounts: []core.ServiceAccount{{Type: "postgresql"}, {Type: "ldap"}, {Type: "k8s-ces-control", Kind: "k8s"}},
+	}
+	scm := &core.Dogu{
+		Name:            "official/scm",
+		Version:         "3.1.0-1",
+		Dependencies:    []core.Dependency{{Type: core.DependencyTypeDogu, Name: "postgresql"}},
+		ServiceAccounts: []core.ServiceAccount{{Type: "postgresql"}},
+	}
+	nginx := &core.Dogu{
+		Name:    "official/nginx",
+		Version: "1.26.1-1",
+		Volumes: []core.Volume{{Name: "log", Path: "/var/log/nginx", NeedsBackup: false}},
+	}
+	return []*core.Dogu{redmine, nginx, scm, ldap, postgresql}
+}
+
+func TestCreateBackupPlan(t *testing.T) {
+	t.Run("should create plan of installed dogus", func(t *testing.T) {
+		// given
+		doguRegistry := newUpgradeTestRegistry(t, newBackupTestDogus()...).DoguRegistry()
+
+		// when
+		plan, err := CreateBackupPlan(doguRegistry)
+
+		// then
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"ldap", "redmine", "scm"}, plan.RestoreOrder)
+		assert.Less(t, indexOf(plan.RestoreOrder, "ldap"), indexOf(plan.RestoreOrder, "redmine"))
+		assert.Equal(t, len(plan.RestoreOrder), len(plan.StopOrder))
+		for i, dogu := range plan.StopOrder {
+			assert.Equal(t, plan.RestoreOrder[len(plan.RestoreOrder)-1-i], dogu)
+		}
+
+		assert.ElementsMatch(t, []BackupVolume{
+			{Dogu: "ldap", Name: "db", Path: "/var/lib/openldap", Owner: "100", Group: "101"},
+			{Dogu: "ldap", Name: "config", Path: "/etc/openldap/slapd.d", Owner: "100", Group: "101"},
+			{Dogu: "redmine", Name: "files", Path: "/usr/share/webapps/redmine/files"},
+		}, plan.Volumes)
+
+		assert.ElementsMatch(t, []BackupConsumerCommand{
+			{Provider: "postgresql", Consumer: "redmine", Command: "/backup-consumer.sh", Args: []string{"redmine"}},
+			{Provider: "postgresql", Consumer: "scm", Command: "/backup-consumer.sh", Args: []string{"scm"}},
+		}, plan.ConsumerCommands)
+		for i := 1; i < len(plan.ConsumerCommands); i++ {
+			assert.Less(t, indexOf(plan.StopOrder, plan.ConsumerCommands[i-1].Consumer), indexOf(plan.StopOrder, plan.ConsumerCommands[i].Consumer))
+		}
+	})
+
+	t.Run("should serialize plan to json", func(t *testing.T) {
+		// given
+		ldap := &core.Dogu{Name: "official/ldap", Version: "2.6.2-7", Volumes: []core.Volume{{Name: "db", Path: "/var/lib/openldap", NeedsBackup: true}}}
+		plan, err := CreateBackupPlan(newUpgradeTestRegistry(t, ldap).DoguRegistry())
+		require.NoError(t, err)
+
+		// when
+		data, err := json.Marshal(plan)
+
+		// then
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"stopOrder": ["ldap"],
+			"consumerCommands": [],
+			"volumes": [{"dogu": "ldap", "name": "db", "path": "/var/lib/openldap"}],
+			"restoreOrder": ["ldap"]
+		}`, string(data))
+	})
+
+	t.Run("should fail if a consumer does not depend on the provider", func(t *testing.T) {
+		// given
+		dogus := newBackupTestDogus()
+		dogus[2].Dependencies = nil
+
+		// when
+		_, err := CreateBackupPlan(newUpgradeTestRegistry(t, dogus...).DoguRegistry())
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "dogu scm consumes a service account of postgresql, which has a backup-consumer command, but does not depend on it")
+	})
+
+	t.Run("should fail on cyclic dependencies", func(t *testing.T) {
+		// given
+		dogus := newBackupTestDogus()
+		dogus[3].Dependencies = []core.Dependency{{Type: core.DependencyTypeDogu, Name: "redmine"}}
+
+		// when
+		_, err := CreateBackupPlan(newUpgradeTestRegistry(t, dogus...).DoguRegistry())
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to order dogus for backup")
+	})
+
+	t.Run("should fail if the installed dogus could not be read", func(t *testing.T) {
+		// given
+		doguRegistry := mocks.NewDoguRegistry(t)
+		doguRegistry.On("GetAll").Return(nil, assert.AnError)
+
+		// when
+		_, err := CreateBackupPlan(doguRegistry)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -4,141 +4,290 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/cloudogu/cesapp-lib/core"
 	"github.com/cloudogu/cesapp-lib/registry"
 )
 
 const (
 	CriticalProcessIndicatorName   = "critical_process_running"
 	criticalProcessTimeoutDuration = 60
+	criticalProcessErrorBufferSize = 10
+	criticalProcessHostKey         = "host"
+	criticalProcessPIDKey          = "pid"
 )
 
-// CriticalSystemState is a state which indicates that the ces is blocked for user interaction.
+// CriticalProcess describes the process which holds the critical system state.
+type CriticalProcess struct {
+	// SystemProcess is the name of the process.
+	SystemProcess string
+	// Host is the name of the host on which the process runs.
+	Host string
+	// PID is the process id of the process.
+	PID int
+	// StartedAt is the time at which the process entered the critical system state.
+	StartedAt time.Time
+}
+
+// CriticalSystemState is a state which indicates that the ces is blocked for user interaction. Only one process can
+// hold the critical system state at a time. It is stored as lock with a lifetime in the global configuration, which
+// is refreshed while the state is held, so that the state of crashed processes expires.
 type CriticalSystemState struct {
 	SystemProcess                  string
 	reg                            registry.Registry
-	channel                        chan string
+	lock                           registry.Lock
 	errors                         chan error
-	cancel                         context.CancelFunc
+	ctx                            context.Context
+	stop                           chan struct{}
+	paused                         bool
+	mutex                          sync.Mutex
 	criticalProcessTimeoutDuration time.Duration
 }
 
 // NewCriticalSystemState creates a new critical state object.
 func NewCriticalSystemState(reg registry.Registry, processName string) *CriticalSystemState {
-	return &CriticalSystemState{
+	css := &CriticalSystemState{
 		reg:                            reg,
 		SystemProcess:                  processName,
-		channel:                        make(chan string),
-		errors:                         make(chan error),
+		errors:                         make(chan error, criticalProcessErrorBufferSize),
 		criticalProcessTimeoutDuration: criticalProcessTimeoutDuration,
 	}
+	css.lock = css.newLock()
+	return css
 }
 
-// getCurrentCriticalSystemState returns the value of the currently set critical system state key.
-func (css *CriticalSystemState) getCurrentCriticalSystemState() (*CriticalSystemState, error) {
-	exists, err := css.reg.GlobalConfig().Exists(CriticalProcessIndicatorName)
+// Current returns the process which currently holds the critical system state. It returns false if no process holds
+// it.
+func (css *CriticalSystemState) Current() (*CriticalProcess, bool, error) {
+	holder, held, err := css.lock.Holder(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("could not find critical process key in registry: %w", err)
+		return nil, false, fmt.Errorf("could not get the current critical process: %w", err)
+	}
+	if !held {
+		return nil, false, nil
 	}
 
-	if !exists {
-		return &CriticalSystemState{}, nil
+	owner := holder.Owner
+	if owner == "" {
+		owner, err = css.legacyOwner()
+		if err != nil {
+			return nil, false, fmt.Errorf("could not get the current critical process: %w", err)
+		}
 	}
 
-	val, err := css.reg.GlobalConfig().Get(CriticalProcessIndicatorName)
+	// the host and the process id are missing in entries of older versions
+	pid, _ := strconv.Atoi(holder.Metadata[criticalProcessPIDKey])
+	return &CriticalProcess{
+		SystemProcess: owner,
+		Host:          holder.Metadata[criticalProcessHostKey],
+		PID:           pid,
+		StartedAt:     holder.AcquiredAt,
+	}, true, nil
+}
+
+// legacyOwner returns the name of the process of an entry of an older version, which only contains the field
+// SystemProcess.
+func (css *CriticalSystemState) legacyOwner() (string, error) {
+	value, err := css.reg.GlobalConfig().Get(CriticalProcessIndicatorName)
+	if registry.IsKeyNotFoundError(err) {
+		// the entry expired in the meantime
+		return "", nil
+	}
 	if err != nil {
-		return &CriticalSystemState{}, fmt.Errorf("error while getting current critical process in registry: %w", err)
+		return "", err
 	}
 
-	var current *CriticalSystemState
-	err = json.Unmarshal([]byte(val), &current)
+	var legacyEntry struct {
+		SystemProcess string
+	}
+	err = json.Unmarshal([]byte(value), &legacyEntry)
 	if err != nil {
-		return &CriticalSystemState{}, err
+		return "", err
 	}
-	return current, nil
+	return legacyEntry.SystemProcess, nil
 }
 
-// Start sets the critical process key for this process and starts the refresh routine.
+// Start sets the critical process key for this process and refreshes it until the process is stopped or the given
+// context is done. It fails if another process holds the critical system state.
 func (css *CriticalSystemState) Start(ctx context.Context) error {
-	existing, err := css.isAnotherProcessRunning()
-	if err != nil {
-		return fmt.Errorf("could not find out if there is a critical process running: %w", err)
+	return css.start(ctx, false)
+}
+
+// ForceStart works like Start, but takes over the critical system state if another process holds it. It should only
+// be used if the other process is known to be gone, e.g. because it crashed and its entry did not expire yet.
+func (css *CriticalSystemState) ForceStart(ctx context.Context) error {
+	return css.start(ctx, true)
+}
+
+func (css *CriticalSystemState) start(ctx context.Context, takeover bool) error {
+	css.mutex.Lock()
+	defer css.mutex.Unlock()
+
+	if css.stop != nil || css.paused {
+		return fmt.Errorf("the critical system state '%s' is already running", css.SystemProcess)
 	}
-	if existing {
-		return fmt.Errorf("there is already a critical process running")
+
+	if takeover {
+		current, held, err := css.Current()
+		if err != nil {
+			return err
+		}
+		if held {
+			core.GetLogger().Warningf("taking over the critical system state of process '%s' on host %s", current.SystemProcess, current.Host)
+		}
+
+		err = css.lock.Break(ctx)
+		if err != nil {
+			return fmt.Errorf("could not take over critical process key: %w", err)
+		}
 	}
 
-	err = css.setKey()
+	err := css.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	css.ctx = ctx
+	return nil
+}
+
+// acquire sets the critical process key and starts the routine which watches it. The mutex has to be held.
+func (css *CriticalSystemState) acquire(ctx context.Context) error {
+	acquired, err := css.lock.TryAcquire(ctx)
 	if err != nil {
 		return fmt.Errorf("could not set critical process key: %w", err)
 	}
+	if !acquired {
+		return css.newAlreadyRunningError()
+	}
 
-	interval := (css.criticalProcessTimeoutDuration - 10) * time.Second
+	stop := make(chan struct{})
+	css.stop = stop
+	go css.watch(ctx, stop, css.lock.Lost())
 
-	subctx, cancel := context.WithCancel(ctx)
-	css.cancel = cancel
+	return nil
+}
 
-	go func(ctx context.Context) {
-		for {
-			time.Sleep(interval)
-			select {
-			case <-ctx.Done():
-				return
-			case s := <-css.channel:
-				if s == "wait" {
-					css.waitForStart(ctx)
-				}
-			default:
-			}
+func (css *CriticalSystemState) newAlreadyRunningError() error {
+	current, held, err := css.Current()
+	if err != nil {
+		return fmt.Errorf("could not find out if there is a critical process running: %w", err)
+	}
+	if !held {
+		return fmt.Errorf("there is already a critical process running")
+	}
 
-			err := css.refreshKey()
+	return fmt.Errorf("there is already a critical process running: '%s' on host %s with pid %d since %s",
+		current.SystemProcess, current.Host, current.PID, current.StartedAt.Format(time.RFC3339))
+}
+
+// watch reports the loss of the critical system state and releases it when the given context is done. After the loss
+// the process is no longer running, so that it can be started again without Stop.
+func (css *CriticalSystemState) watch(ctx context.Context, stop chan struct{}, lost <-chan struct{}) {
+	select {
+	case <-stop:
+	case <-lost:
+		css.mutex.Lock()
+		defer css.mutex.Unlock()
+
+		// the process may have been stopped in the meantime
+		if css.stop == stop {
+			close(css.stop)
+			css.stop = nil
+		}
+		css.reportError(fmt.Errorf("the critical system state '%s' was lost", css.SystemProcess))
+	case <-ctx.Done():
+		css.mutex.Lock()
+		defer css.mutex.Unlock()
+
+		// the process may have been stopped in the meantime
+		if css.stop == stop {
+			err := css.release()
 			if err != nil {
-				go func() { css.errors <- err }()
+				css.reportError(err)
 			}
 		}
-	}(subctx)
-
-	return nil
+	}
 }
 
-// Pause sends the "wait" signal to the refresh routine.
+// Pause removes the critical process key, e.g. to let another process enter the critical system state for a while.
 func (css *CriticalSystemState) Pause() error {
+	css.mutex.Lock()
+	defer css.mutex.Unlock()
+
 	err := css.validateProcessIsRunning()
 	if err != nil {
 		return err
 	}
 
-	css.channel <- "wait"
-	return nil
+	css.paused = true
+	return css.release()
 }
 
-// Unpause sends the "start" signal to the refresh routine.
+// Unpause sets the critical process key again after a pause. It fails if another process entered the critical
+// system state in the meantime. It also fails if the context of Start is done, then the process is no longer paused
+// and has to be started again.
 func (css *CriticalSystemState) Unpause() error {
-	err := css.validateProcessIsRunning()
+	css.mutex.Lock()
+	defer css.mutex.Unlock()
+
+	if !css.paused {
+		return fmt.Errorf("the critical system state '%s' is not paused", css.SystemProcess)
+	}
+	if err := css.ctx.Err(); err != nil {
+		css.paused = false
+		return fmt.Errorf("could not unpause the critical system state '%s', its context is done: %w", css.SystemProcess, err)
+	}
+
+	err := css.acquire(css.ctx)
 	if err != nil {
 		return err
 	}
 
-	css.channel <- "start"
+	css.paused = false
 	return nil
 }
 
 // Stop removes the critical process key of this process from the registry and stops the refresh routine.
 func (css *CriticalSystemState) Stop() error {
+	css.mutex.Lock()
+	defer css.mutex.Unlock()
+
+	if css.paused {
+		css.paused = false
+		return nil
+	}
+
 	err := css.validateProcessIsRunning()
 	if err != nil {
 		return err
 	}
 
-	err = css.removeKey()
-	if err != nil {
+	return css.release()
+}
+
+// release removes the critical process key and stops the routine which watches it. The mutex has to be held.
+func (css *CriticalSystemState) release() error {
+	close(css.stop)
+	css.stop = nil
+
+	err := css.lock.Release(context.Background())
+	if err != nil && !registry.IsLockNotHeldError(err) {
 		return fmt.Errorf("error removing critical process key: %w", err)
 	}
-	css.cancel()
 	return nil
 }
 
+// Errors returns a channel which receives the errors of the refresh routine, e.g. if the critical process key could
+// not be refreshed or was lost. Errors are dropped if the channel is not consumed.
+func (css *CriticalSystemState) Errors() <-chan error {
+	return css.errors
+}
+
 // GetErrors returns a slice with any error that was sent to the errors channel.
 func (css *CriticalSystemState) GetErrors() []error {
 	errs := make([]error, 0)
@@ -152,83 +301,37 @@ func (css *CriticalSystemState) GetErrors() []error {
 	}
 }
 
-// waitForStart holds until the string "start" is sent to the channel.
-func (css *CriticalSystemState) waitForStart(ctx context.Context) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case w := <-css.channel:
-			if w == "start" {
-				return
-			}
-		}
-	}
-}
-
-// setKey sets the critical process key of this process in registry.
-func (css *CriticalSystemState) setKey() error {
-	data, err := json.Marshal(css)
-	if err != nil {
-		return err
-	}
-	err = css.reg.GlobalConfig().SetWithLifetime(CriticalProcessIndicatorName, string(data), int(css.criticalProcessTimeoutDuration))
-	if err != nil {
-		return err
+func (css *CriticalSystemState) reportError(err error) {
+	select {
+	case css.errors <- err:
+	default:
+		core.GetLogger().Warningf("dropped error of critical system state: %v", err)
 	}
-	return nil
 }
 
-// refreshKey refreshes the lifetime of this process' key in the registry. If unset, the key will be set.
-func (css *CriticalSystemState) refreshKey() error {
-	exists, err := css.reg.GlobalConfig().Exists(CriticalProcessIndicatorName)
+func (css *CriticalSystemState) newLock() registry.Lock {
+	hostname, err := os.Hostname()
 	if err != nil {
-		return err
-	}
-
-	if !exists {
-		err = css.setKey()
-		if err != nil {
-			return err
-		}
+		hostname = "unknown"
 	}
 
-	err = css.reg.GlobalConfig().Refresh(CriticalProcessIndicatorName, int(css.criticalProcessTimeoutDuration))
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-// removeKey removes the critical process key of this process from registry.
-func (css *CriticalSystemState) removeKey() error {
-	keyExists, err := css.reg.GlobalConfig().Exists(CriticalProcessIndicatorName)
-	if err != nil {
-		return err
-	}
-	if keyExists {
-		err := css.reg.GlobalConfig().Delete(CriticalProcessIndicatorName)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return css.reg.Lock(CriticalProcessIndicatorName, registry.LockOptions{
+		Owner: css.SystemProcess,
+		Metadata: map[string]string{
+			criticalProcessHostKey: hostname,
+			criticalProcessPIDKey:  strconv.Itoa(os.Getpid()),
+		},
+		TTL:          css.criticalProcessTimeoutDuration * time.Second,
+		Key:          "/config/" + registry.DirectoryGlobal + "/" + CriticalProcessIndicatorName,
+		OnRenewError: css.reportError,
+	})
 }
 
 // validateProcessIsRunning checks if the process is currently running.
 func (css *CriticalSystemState) validateProcessIsRunning() error {
-	if css.channel == nil || css.cancel == nil {
+	if css.stop == nil {
 		return fmt.Errorf("the critical system state '%s' is not running", css.SystemProcess)
 	}
 
 	return nil
 }
-
-// isAnotherProcessRunning checks whether there is a process running which is not this process.
-func (css *CriticalSystemState) isAnotherProcessRunning() (bool, error) {
-	current, err := css.getCurrentCriticalSystemState()
-	if err != nil {
-		return false, fmt.Errorf("could not get the current running critical process: %w", err)
-	}
-	return current.SystemProcess == css.SystemProcess, nil
-}
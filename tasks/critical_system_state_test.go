@@ -2,105 +2,285 @@ package tasks
 
 import (
 	"context"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/stretchr/testify/mock"
+	"github.com/cloudogu/cesapp-lib/registry"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
 
-	"github.com/cloudogu/cesapp-lib/registry/mocks"
+// failingLock is a registry.Lock whose registry requests fail.
+type failingLock struct {
+	registry.Lock
+	err error
+}
 
-	"github.com/stretchr/testify/assert"
+func (fl *failingLock) TryAcquire(context.Context) (bool, error) {
+	return false, fl.err
+}
 
-	"github.com/pkg/errors"
+func (fl *failingLock) Holder(context.Context) (*registry.LockInfo, bool, error) {
+	return nil, false, fl.err
+}
 
-	"github.com/stretchr/testify/require"
-)
+func newTestCriticalSystemState(reg registry.Registry, processName string) *CriticalSystemState {
+	css := NewCriticalSystemState(reg, processName)
+	// the lock is renewed every third of a second
+	css.criticalProcessTimeoutDuration = 1
+	css.lock = css.newLock()
+	return css
+}
 
 func TestCanStartAndStopCriticalProcess(t *testing.T) {
 	// given
-	emptyState := `{}`
-	state := "{\"SystemProcess\":\"testprocess\"}"
-	reg := mocks.NewRegistry(t)
-	globalConfig := mocks.NewConfigurationContext(t)
-
-	reg.On("GlobalConfig").Return(globalConfig)
-	globalConfig.On("Exists", "critical_process_running").Return(true, nil)
-	globalConfig.On("Get", "critical_process_running").Once().Return(emptyState, nil)
-	globalConfig.On("Get", "critical_process_running").Once().Return(state, nil)
-	globalConfig.On("Get", "critical_process_running").Once().Return(emptyState, nil)
-	globalConfig.On("SetWithLifetime", "critical_process_running", state, 11).Return(nil)
-	globalConfig.On("Refresh", "critical_process_running", 11).Return(nil)
-	globalConfig.On("Delete", "critical_process_running").Return(nil)
-
-	ctx := context.Background()
-
+	reg := registry.NewInMemoryRegistry()
 	process := NewCriticalSystemState(reg, "testprocess")
-	process.criticalProcessTimeoutDuration = 11 // The interval has an offset of 10
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
 
 	// when
-	err := process.Stop()
+	err = process.Stop()
 	require.Error(t, err, "is not running")
 
-	err = process.Start(ctx)
-	require.Nil(t, err)
-
-	// Time for a refresh call
-	time.Sleep(2 * time.Second)
+	err = process.Start(context.Background())
+	require.NoError(t, err)
 
-	current, err := process.getCurrentCriticalSystemState()
-	require.Nil(t, err)
+	current, held, err := process.Current()
+	require.NoError(t, err)
 
 	// then
-	require.Equal(t, "testprocess", current.SystemProcess)
+	require.True(t, held)
+	assert.Equal(t, "testprocess", current.SystemProcess)
+	assert.Equal(t, hostname, current.Host)
+	assert.Equal(t, os.Getpid(), current.PID)
+	assert.WithinDuration(t, time.Now(), current.StartedAt, time.Minute)
+	exists, err := reg.GlobalConfig().Exists(CriticalProcessIndicatorName)
+	require.NoError(t, err)
+	assert.True(t, exists)
 
 	// when
-	err = process.Pause()
-	require.Nil(t, err)
-
-	err = process.Unpause()
-	require.Nil(t, err)
-
 	err = process.Stop()
 	require.NoError(t, err)
-	current, err = process.getCurrentCriticalSystemState()
+	_, held, err = process.Current()
 
 	// then
-	require.Nil(t, err)
-	require.Equal(t, "", current.SystemProcess)
+	require.NoError(t, err)
+	assert.False(t, held)
+	assert.Empty(t, process.GetErrors())
+}
+
+func TestCanOnlyStartOneCriticalProcess(t *testing.T) {
+	t.Run("should block processes with another name", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		process1 := NewCriticalSystemState(reg, "backup")
+		process2 := NewCriticalSystemState(reg, "upgrade")
+		require.NoError(t, process1.Start(context.Background()))
+		defer func() { _ = process1.Stop() }()
+
+		// when
+		err := process2.Start(context.Background())
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "there is already a critical process running: 'backup' on host")
+	})
+
+	t.Run("should block processes with the same name", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		process1 := NewCriticalSystemState(reg, "testprocess")
+		process2 := NewCriticalSystemState(reg, "testprocess")
+		require.NoError(t, process1.Start(context.Background()))
+		defer func() { _ = process1.Stop() }()
+
+		// when
+		err := process2.Start(context.Background())
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "there is already a critical process running")
+	})
+
+	t.Run("should start only one of concurrent processes", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		var started []*CriticalSystemState
+		var startedMutex sync.Mutex
+		var wg sync.WaitGroup
+
+		// when
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				process := NewCriticalSystemState(reg, "testprocess")
+				if process.Start(context.Background()) == nil {
+					startedMutex.Lock()
+					defer startedMutex.Unlock()
+					started = append(started, process)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// then
+		require.Len(t, started, 1)
+		require.NoError(t, started[0].Stop())
+	})
+
+	t.Run("should fail if the process is already running", func(t *testing.T) {
+		// given
+		process := NewCriticalSystemState(registry.NewInMemoryRegistry(), "testprocess")
+		require.NoError(t, process.Start(context.Background()))
+		defer func() { _ = process.Stop() }()
+
+		// when
+		err := process.Start(context.Background())
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the critical system state 'testprocess' is already running")
+	})
+}
+
+func TestCriticalProcessForceStart(t *testing.T) {
+	t.Run("should take over the state of another process", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		stale := newTestCriticalSystemState(reg, "backup")
+		require.NoError(t, stale.Start(context.Background()))
+		sut := NewCriticalSystemState(reg, "upgrade")
+
+		// when
+		err := sut.ForceStart(context.Background())
+		require.NoError(t, err)
+		defer func() { _ = sut.Stop() }()
+
+		// then
+		current, held, err := sut.Current()
+		require.NoError(t, err)
+		require.True(t, held)
+		assert.Equal(t, "upgrade", current.SystemProcess)
+		select {
+		case err = <-stale.Errors():
+			assert.Contains(t, err.Error(), "failed to renew lock")
+		case <-time.After(2 * time.Second):
+			t.Fatal("the former process was not notified")
+		}
+		// the former process can start again without Stop as soon as the state is free
+		assert.Eventually(t, func() bool {
+			err := stale.Start(context.Background())
+			return err != nil && strings.Contains(err.Error(), "there is already a critical process running: 'upgrade'")
+		}, 2*time.Second, 10*time.Millisecond)
+		require.NoError(t, sut.Stop())
+		require.NoError(t, stale.Start(context.Background()))
+		require.NoError(t, stale.Stop())
+	})
+
+	t.Run("should take over entries of older versions", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		require.NoError(t, reg.GlobalConfig().Set(CriticalProcessIndicatorName, `{"SystemProcess":"backup"}`))
+		sut := NewCriticalSystemState(reg, "upgrade")
+		err := sut.Start(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "there is already a critical process running: 'backup'")
+		legacy, held, err := sut.Current()
+		require.NoError(t, err)
+		require.True(t, held)
+		assert.Equal(t, "backup", legacy.SystemProcess)
+
+		// when
+		err = sut.ForceStart(context.Background())
+		require.NoError(t, err)
+		defer func() { _ = sut.Stop() }()
+
+		// then
+		current, held, err := sut.Current()
+		require.NoError(t, err)
+		require.True(t, held)
+		assert.Equal(t, "upgrade", current.SystemProcess)
+	})
 }
 
-func TestCanOnlySttOneCriticalProcess(t *testing.T) {
+func TestCriticalProcessPause(t *testing.T) {
 	// given
-	ctx := context.Background()
-	emptyState := `{}`
-	state := "{\"SystemProcess\":\"testprocess\"}"
-	reg := mocks.NewRegistry(t)
-	globalConfig := mocks.NewConfigurationContext(t)
+	reg := registry.NewInMemoryRegistry()
+	sut := NewCriticalSystemState(reg, "upgrade")
+	other := NewCriticalSystemState(reg, "backup")
+	require.NoError(t, sut.Start(context.Background()))
 
-	reg.On("GlobalConfig").Return(globalConfig)
-	globalConfig.On("Exists", "critical_process_running").Return(true, nil)
-	globalConfig.On("Get", "critical_process_running").Once().Return(emptyState, nil)
-	globalConfig.On("Get", "critical_process_running").Once().Return(state, nil)
-	globalConfig.On("SetWithLifetime", "critical_process_running", state, 60).Return(nil)
+	// when
+	err := sut.Pause()
+	require.NoError(t, err)
 
-	process1 := NewCriticalSystemState(reg, "testprocess")
-	process2 := NewCriticalSystemState(reg, "testprocess")
+	// then
+	require.NoError(t, other.Start(context.Background()))
+	err = sut.Unpause()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "there is already a critical process running: 'backup'")
 
 	// when
-	err := process1.Start(ctx)
+	require.NoError(t, other.Stop())
+	err = sut.Unpause()
+
 	// then
-	require.Nil(t, err)
+	require.NoError(t, err)
+	current, held, err := sut.Current()
+	require.NoError(t, err)
+	require.True(t, held)
+	assert.Equal(t, "upgrade", current.SystemProcess)
+	require.NoError(t, sut.Stop())
+}
+
+func TestCriticalProcessUnpauseFailsAfterContext(t *testing.T) {
+	// given
+	reg := registry.NewInMemoryRegistry()
+	sut := NewCriticalSystemState(reg, "upgrade")
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, sut.Start(ctx))
+	require.NoError(t, sut.Pause())
 
 	// when
-	err = process2.Start(ctx)
+	cancel()
+	err := sut.Unpause()
+
 	// then
-	require.NotNil(t, err)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, err.Error(), "could not unpause the critical system state 'upgrade', its context is done")
+	_, held, err := sut.Current()
+	require.NoError(t, err)
+	assert.False(t, held)
+	require.NoError(t, sut.Start(context.Background()))
+	require.NoError(t, sut.Stop())
 }
 
-func TestCannotPauseOrStopPressWithoutStart(t *testing.T) {
-	reg := mocks.NewRegistry(t)
+func TestCriticalProcessStopsWithContext(t *testing.T) {
+	// given
+	reg := registry.NewInMemoryRegistry()
+	sut := NewCriticalSystemState(reg, "upgrade")
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, sut.Start(ctx))
 
-	process := NewCriticalSystemState(reg, "testprocess")
+	// when
+	cancel()
+
+	// then
+	assert.Eventually(t, func() bool {
+		_, held, err := sut.Current()
+		return err == nil && !held
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCannotPauseOrStopPressWithoutStart(t *testing.T) {
+	process := NewCriticalSystemState(registry.NewInMemoryRegistry(), "testprocess")
 	require.NotNil(t, process)
 
 	err := process.Stop()
@@ -112,95 +292,41 @@ func TestCannotPauseOrStopPressWithoutStart(t *testing.T) {
 	assert.Contains(t, err.Error(), "the critical system state 'testprocess' is not running")
 }
 
-func TestCriticalProcessFailsOnExistsError(t *testing.T) {
-	r := mocks.CreateMockRegistry(nil)
-	reg := r.Registry
-	regs := r.SubRegistries
-	mocks.OnExists(regs["_global"], CriticalProcessIndicatorName, false, errors.New("test"))
-
-	css := NewCriticalSystemState(reg, "test")
-	err := css.Start(context.Background())
-
-	assert.NotNil(t, err)
-	require.Contains(t, err.Error(), "test")
-	regs["_global"].AssertExpectations(t)
-}
-
-func TestCriticalProcessFailsOnGetError(t *testing.T) {
-	r := mocks.CreateMockRegistry(nil)
-	reg := r.Registry
-	regs := r.SubRegistries
-	mocks.OnExists(regs["_global"], CriticalProcessIndicatorName, true, nil)
-	mocks.OnGet(regs["_global"], mock.Anything, mock.Anything, errors.New("test"))
-
-	css := NewCriticalSystemState(reg, "test")
-	err := css.Start(context.Background())
+func TestUnpauseFailsWhenNotPaused(t *testing.T) {
+	css := NewCriticalSystemState(registry.NewInMemoryRegistry(), "test")
+	err := css.Unpause()
 
 	require.NotNil(t, err)
-	require.Contains(t, err.Error(), "test")
-	regs["_global"].AssertExpectations(t)
+	require.Contains(t, err.Error(), "is not paused")
 }
 
-func TestCriticalProcessFailsOnInvalidJson(t *testing.T) {
-	r := mocks.CreateMockRegistry(nil)
-	reg := r.Registry
-	regs := r.SubRegistries
-	mocks.OnExists(regs["_global"], CriticalProcessIndicatorName, true, nil)
-	mocks.OnGet(regs["_global"], CriticalProcessIndicatorName, "", nil)
+func TestCriticalProcessFailsOnSet(t *testing.T) {
+	css := NewCriticalSystemState(registry.NewInMemoryRegistry(), "test")
+	css.lock = &failingLock{err: errors.New("test")}
 
-	css := NewCriticalSystemState(reg, "test")
 	err := css.Start(context.Background())
 
 	require.NotNil(t, err)
-	require.Contains(t, err.Error(), "unexpected end of JSON input")
+	assert.Contains(t, err.Error(), "could not set critical process key: test")
 }
 
-func TestCriticalProcessFailsOnSet(t *testing.T) {
-	r := mocks.CreateMockRegistry(nil)
-	reg := r.Registry
-	regs := r.SubRegistries
-	mocks.OnExists(regs["_global"], CriticalProcessIndicatorName, false, nil)
-	mocks.OnSetWithLifetime(regs["_global"], CriticalProcessIndicatorName, mocks.Anything, mocks.AnyLifetime, errors.New("test"))
+func TestCriticalProcessFailsOnGet(t *testing.T) {
+	css := NewCriticalSystemState(registry.NewInMemoryRegistry(), "test")
+	css.lock = &failingLock{err: errors.New("test")}
 
-	css := NewCriticalSystemState(reg, "test")
-	err := css.Start(context.Background())
-
-	assert.NotNil(t, err)
-	require.Contains(t, err.Error(), "test")
-	regs["_global"].AssertExpectations(t)
-}
-
-func TestUnpauseFailsWhenNotStarted(t *testing.T) {
-	reg := mocks.NewRegistry(t)
-	css := NewCriticalSystemState(reg, "test")
-	err := css.Pause()
+	_, _, err := css.Current()
 
 	require.NotNil(t, err)
-	require.Contains(t, err.Error(), "is not running")
+	assert.Contains(t, err.Error(), "could not get the current critical process: test")
 }
 
-func TestStopFailsOnExistsError(t *testing.T) {
-	first := true
-	r := mocks.CreateMockRegistry(nil)
-	reg := r.Registry
-	regs := r.SubRegistries
-	regs["_global"].On("Exists", mock.MatchedBy(func(key string) bool {
-		if first {
-			first = false
-			return true
-		}
-		return false
-	})).Return(false, nil)
-
-	mocks.OnSetWithLifetime(regs["_global"], CriticalProcessIndicatorName, mocks.Anything, mocks.AnyLifetime, nil)
-
+func TestCriticalProcessFailsOnInvalidJson(t *testing.T) {
+	reg := registry.NewInMemoryRegistry()
+	require.NoError(t, reg.GlobalConfig().Set(CriticalProcessIndicatorName, ""))
 	css := NewCriticalSystemState(reg, "test")
+
 	err := css.Start(context.Background())
-	require.Nil(t, err)
-	mocks.OnExists(regs["_global"], CriticalProcessIndicatorName, false, errors.New("testerror"))
 
-	err = css.Stop()
 	require.NotNil(t, err)
-	require.Contains(t, err.Error(), "test")
-	regs["_global"].AssertExpectations(t)
+	require.Contains(t, err.Error(), "unexpected end of JSON input")
 }
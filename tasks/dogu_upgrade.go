@@ -0,0 +1,451 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/cloudogu/cesapp-lib/dependencies"
+	"github.com/cloudogu/cesapp-lib/registry"
+	"github.com/hashicorp/go-multierror"
+)
+
+const doguUpgradeProcessName = "dogu-upgrade"
+
+// DoguUpgradeStep identifies a step of a dogu upgrade.
+type DoguUpgradeStep string
+
+const (
+	// DoguUpgradeStepCheckDependencies checks the dependencies of the target version and the dependencies of the
+	// other installed dogus on the upgraded dogu.
+	DoguUpgradeStepCheckDependencies DoguUpgradeStep = "check-dependencies"
+	// DoguUpgradeStepUpgradeNotification runs the upgrade-notification command of the target version. Its output is
+	// sent with the finished event of the step.
+	DoguUpgradeStepUpgradeNotification DoguUpgradeStep = "upgrade-notification"
+	// DoguUpgradeStepActivateMaintenanceMode activates the maintenance mode. An already active maintenance mode is
+	// replaced during the upgrade.
+	DoguUpgradeStepActivateMaintenanceMode DoguUpgradeStep = "activate-maintenance-mode"
+	// DoguUpgradeStepStartCriticalSystemState enters the critical system state.
+	DoguUpgradeStepStartCriticalSystemState DoguUpgradeStep = "start-critical-system-state"
+	// DoguUpgradeStepPreUpgrade runs the pre-upgrade command of the target version in the installed dogu.
+	DoguUpgradeStepPreUpgrade DoguUpgradeStep = "pre-upgrade"
+	// DoguUpgradeStepRegisterDogu registers and enables the descriptor of the target version.
+	DoguUpgradeStepRegisterDogu DoguUpgradeStep = "register-dogu"
+	// DoguUpgradeStepPostUpgrade runs the post-upgrade command of the target version in the upgraded dogu.
+	DoguUpgradeStepPostUpgrade DoguUpgradeStep = "post-upgrade"
+	// DoguUpgradeStepStopCriticalSystemState leaves the critical system state.
+	DoguUpgradeStepStopCriticalSystemState DoguUpgradeStep = "stop-critical-system-state"
+	// DoguUpgradeStepDeactivateMaintenanceMode restores the maintenance mode which was active before the upgrade or
+	// deactivates the maintenance mode if there was none.
+	DoguUpgradeStepDeactivateMaintenanceMode DoguUpgradeStep = "deactivate-maintenance-mode"
+)
+
+// DoguUpgradeStatus describes the progress of a step of a dogu upgrade.
+type DoguUpgradeStatus string
+
+const (
+	// DoguUpgradeStatusStarted is reported before a step is executed.
+	DoguUpgradeStatusStarted DoguUpgradeStatus = "started"
+	// DoguUpgradeStatusFinished is reported after a step was executed successfully.
+	DoguUpgradeStatusFinished DoguUpgradeStatus = "finished"
+	// DoguUpgradeStatusFailed is reported if a step failed.
+	DoguUpgradeStatusFailed DoguUpgradeStatus = "failed"
+	// DoguUpgradeStatusRolledBack is reported if a finished step was reverted after a later step failed.
+	DoguUpgradeStatusRolledBack DoguUpgradeStatus = "rolled-back"
+	// DoguUpgradeStatusRollbackFailed is reported if a finished step could not be reverted.
+	DoguUpgradeStatusRollbackFailed DoguUpgradeStatus = "rollback-failed"
+)
+
+// DoguUpgradeEvent reports the progress of a dogu upgrade.
+type DoguUpgradeEvent struct {
+	// Dogu is the full name of the upgraded dogu.
+	Dogu string
+	// Step is the step which the event belongs to.
+	Step DoguUpgradeStep
+	// Status is the progress of the step.
+	Status DoguUpgradeStatus
+	// Output is the output of the exposed command of the step, if it has one.
+	Output string
+	// Err is the reason of failed steps and rollbacks.
+	Err error
+	// Time is the time of the event.
+	Time time.Time
+}
+
+// ExposedCommandRunner executes the exposed commands of dogus. It is implemented by the platform, e.g. with docker
+// exec, and has to make sure that the container of the dogu is running.
+type ExposedCommandRunner interface {
+	// Run executes the command in the container of the given dogu with the given arguments and returns its output.
+	// The command is not necessarily defined by the given dogu, e.g. the pre-upgrade command of the target version
+	// is executed in the installed version.
+	Run(ctx context.Context, dogu *core.Dogu, command *core.ExposedCommand, args ...string) (string, error)
+}
+
+// DoguUpgradePlan contains the steps to upgrade a dogu from the installed to the target version.
+type DoguUpgradePlan struct {
+	// Installed is the descriptor of the installed version.
+	Installed *core.Dogu
+	// Target is the descriptor of the target version.
+	Target *core.Dogu
+	// Steps contains the steps of the upgrade in the order of their execution.
+	Steps []DoguUpgradeStep
+}
+
+// PlanDoguUpgrade checks if the installed dogu can be upgraded to the target version and returns the steps of the
+// upgrade. The exposed commands are only part of the plan if the target version defines them.
+func PlanDoguUpgrade(installed *core.Dogu, target *core.Dogu) (*DoguUpgradePlan, error) {
+	if installed == nil || target == nil {
+		return nil, fmt.Errorf("the installed and the target dogu are required to plan an upgrade")
+	}
+	if installed.GetSimpleName() != target.GetSimpleName() {
+		return nil, fmt.Errorf("cannot upgrade dogu %s to another dogu %s", installed.Name, target.Name)
+	}
+
+	installedVersion, err := core.ParseVersion(installed.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version of installed dogu %s: %w", installed.Name, err)
+	}
+	targetVersion, err := core.ParseVersion(target.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version of target dogu %s: %w", target.Name, err)
+	}
+	if !targetVersion.IsNewerThan(installedVersion) {
+		return nil, fmt.Errorf("the target version %s of dogu %s is not newer than the installed version %s",
+			target.Version, target.Name, installed.Version)
+	}
+
+	steps := []DoguUpgradeStep{DoguUpgradeStepCheckDependencies}
+	if target.HasExposedCommand(core.ExposedCommandUpgradeNotification) {
+		steps = append(steps, DoguUpgradeStepUpgradeNotification)
+	}
+	steps = append(steps, DoguUpgradeStepActivateMaintenanceMode, DoguUpgradeStepStartCriticalSystemState)
+	if target.HasExposedCommand(core.ExposedCommandPreUpgrade) {
+		steps = append(steps, DoguUpgradeStepPreUpgrade)
+	}
+	steps = append(steps, DoguUpgradeStepRegisterDogu)
+	if target.HasExposedCommand(core.ExposedCommandPostUpgrade) {
+		steps = append(steps, DoguUpgradeStepPostUpgrade)
+	}
+	steps = append(steps, DoguUpgradeStepStopCriticalSystemState, DoguUpgradeStepDeactivateMaintenanceMode)
+
+	return &DoguUpgradePlan{Installed: installed, Target: target, Steps: steps}, nil
+}
+
+// DoguUpgradeOptions configures a DoguUpgrader.
+type DoguUpgradeOptions struct {
+	// MaintenanceMode is activated during the upgrade. A maintenance mode which names the upgraded dogu is used if it
+	// is nil.
+	MaintenanceMode *MaintenanceMode
+	// OnEvent is called synchronously for every event of the upgrade if it is set.
+	OnEvent func(event DoguUpgradeEvent)
+}
+
+// DoguUpgrader executes plans of dogu upgrades.
+type DoguUpgrader struct {
+	reg     registry.Registry
+	runner  ExposedCommandRunner
+	options DoguUpgradeOptions
+}
+
+// NewDoguUpgrader creates a new DoguUpgrader which executes the exposed commands of dogus with the given runner.
+func NewDoguUpgrader(reg registry.Registry, runner ExposedCommandRunner, options DoguUpgradeOptions) *DoguUpgrader {
+	return &DoguUpgrader{reg: reg, runner: runner, options: options}
+}
+
+// doguUpgradeAction executes a step of an upgrade and reverts it if a later step fails.
+type doguUpgradeAction struct {
+	run      func(ctx context.Context) (string, error)
+	rollback func(ctx context.Context) error
+	// partial is true if the step can fail after it changed the registry. Its rollback is executed if it fails.
+	partial bool
+}
+
+// doguUpgradeState contains the state which the steps of a single upgrade share with their rollbacks.
+type doguUpgradeState struct {
+	css *CriticalSystemState
+	// cssCtx is the context of the critical system state. It is not canceled with the context of the upgrade, so that
+	// the rollback can leave the critical system state itself.
+	cssCtx context.Context
+	// previousMaintenanceMode is the maintenance mode which was active before the upgrade. It is nil if there was none.
+	previousMaintenanceMode *MaintenanceMode
+	// registeredTarget is true if the target version was registered by the upgrade and not before.
+	registeredTarget bool
+}
+
+// Upgrade executes the steps of the plan. If a step fails, its changes and the finished steps are reverted in reverse
+// order, also if the given context is canceled: the descriptor of the installed version is enabled again and the
+// target version is unregistered, the critical system state is left and the maintenance mode which was active before
+// the upgrade is restored. The commands of the dogu cannot be reverted. The returned error contains the failed step
+// and the errors of the rollback.
+func (du *DoguUpgrader) Upgrade(ctx context.Context, plan *DoguUpgradePlan) error {
+	if plan == nil || plan.Installed == nil || plan.Target == nil {
+		return fmt.Errorf("a plan with the installed and the target dogu is required to upgrade a dogu")
+	}
+
+	cssCtx, cancelCSS := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelCSS()
+	state := &doguUpgradeState{css: NewCriticalSystemState(du.reg, doguUpgradeProcessName), cssCtx: cssCtx}
+
+	var finished []DoguUpgradeStep
+	var cleanups []DoguUpgradeStep
+	for _, step := range plan.Steps {
+		action, err := du.newAction(plan, step, state)
+		if err != nil {
+			return err
+		}
+
+		du.report(plan, step, DoguUpgradeStatusStarted, "", nil)
+		output, err := action.run(ctx)
+		if err != nil {
+			du.report(plan, step, DoguUpgradeStatusFailed, output, err)
+			if isDoguUpgradeCleanup(step) {
+				// the dogu is already upgraded, so only the remaining cleanups are executed
+				cleanups = append(cleanups, step)
+				continue
+			}
+
+			err = fmt.Errorf("failed to upgrade dogu %s in step %s: %w", plan.Target.Name, step, err)
+			if action.partial {
+				finished = append(finished, step)
+			}
+			rollbackErr := du.rollback(plan, finished, state)
+			if rollbackErr != nil {
+				err = multierror.Append(err, rollbackErr)
+			}
+			return err
+		}
+
+		du.report(plan, step, DoguUpgradeStatusFinished, output, nil)
+		finished = append(finished, step)
+	}
+
+	if len(cleanups) > 0 {
+		return fmt.Errorf("upgraded dogu %s, but the steps %v failed", plan.Target.Name, cleanups)
+	}
+	return nil
+}
+
+func (du *DoguUpgrader) newAction(plan *DoguUpgradePlan, step DoguUpgradeStep, state *doguUpgradeState) (doguUpgradeAction, error) {
+	installed := plan.Installed
+	target := plan.Target
+
+	switch step {
+	case DoguUpgradeStepCheckDependencies:
+		return doguUpgradeAction{run: func(context.Context) (string, error) {
+			return "", du.checkDependencies(target)
+		}}, nil
+	case DoguUpgradeStepUpgradeNotification:
+		return du.newCommandAction(installed, target, target, core.ExposedCommandUpgradeNotification), nil
+	case DoguUpgradeStepActivateMaintenanceMode:
+		return doguUpgradeAction{
+			run: func(context.Context) (string, error) {
+				return "", du.activateMaintenanceMode(plan, state)
+			},
+			rollback: func(context.Context) error {
+				return du.restoreMaintenanceMode(state)
+			},
+		}, nil
+	case DoguUpgradeStepStartCriticalSystemState:
+		return doguUpgradeAction{
+			run: func(ctx context.Context) (string, error) {
+				if err := ctx.Err(); err != nil {
+					return "", err
+				}
+				return "", state.css.Start(state.cssCtx)
+			},
+			rollback: func(context.Context) error {
+				return state.css.Stop()
+			},
+		}, nil
+	case DoguUpgradeStepPreUpgrade:
+		return du.newCommandAction(installed, target, installed, core.ExposedCommandPreUpgrade), nil
+	case DoguUpgradeStepRegisterDogu:
+		return doguUpgradeAction{
+			run: func(ctx context.Context) (string, error) {
+				return "", du.registerDogu(ctx, target, state)
+			},
+			rollback: func(ctx context.Context) error {
+				return du.unregisterDogu(ctx, installed, target, state)
+			},
+			// the target version may be registered although it could not be enabled
+			partial: true,
+		}, nil
+	case DoguUpgradeStepPostUpgrade:
+		return du.newCommandAction(installed, target, target, core.ExposedCommandPostUpgrade), nil
+	case DoguUpgradeStepStopCriticalSystemState:
+		return doguUpgradeAction{run: func(context.Context) (string, error) {
+			return "", state.css.Stop()
+		}}, nil
+	case DoguUpgradeStepDeactivateMaintenanceMode:
+		return doguUpgradeAction{run: func(context.Context) (string, error) {
+			return "", du.restoreMaintenanceMode(state)
+		}}, nil
+	default:
+		return doguUpgradeAction{}, fmt.Errorf("unknown dogu upgrade step %s", step)
+	}
+}
+
+// newCommandAction runs the command of the target version in the container of the given dogu with the installed and
+// the target version as arguments.
+func (du *DoguUpgrader) newCommandAction(installed *core.Dogu, target *core.Dogu, dogu *core.Dogu, commandName string) doguUpgradeAction {
+	return doguUpgradeAction{run: func(ctx context.Context) (string, error) {
+		command := target.GetExposedCommand(commandName)
+		if command == nil {
+			return "", fmt.Errorf("dogu %s has no exposed command %s", target.Name, commandName)
+		}
+		return du.runner.Run(ctx, dogu, command, installed.Version, target.Version)
+	}}
+}
+
+// checkDependencies checks the dependencies of the target version and if the other installed dogus accept the target
+// version.
+func (du *DoguUpgrader) checkDependencies(target *core.Dogu) error {
+	var problems error
+
+	err := dependencies.NewDoguDependencyChecker(du.reg.DoguRegistry()).CheckAllDependencies(*target)
+	if err != nil {
+		problems = multierror.Append(problems, err)
+	}
+
+	installedDogus, err := du.reg.DoguRegistry().GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to get installed dogus: %w", err)
+	}
+	upgraded := core.Dependency{Name: target.GetSimpleName(), Version: target.Version}
+	for _, dogu := range installedDogus {
+		for _, dependency := range dogu.GetAllDependenciesOfType(core.DependencyTypeDogu) {
+			if dependency.Name != upgraded.Name {
+				continue
+			}
+			err = dependencies.CheckDependencyVersion(dependency, upgraded, core.DependencyTypeDogu)
+			if err != nil {
+				problems = multierror.Append(problems, fmt.Errorf("dogu %s does not accept the target version: %w", dogu.Name, err))
+			}
+		}
+	}
+
+	return problems
+}
+
+func (du *DoguUpgrader) registerDogu(ctx context.Context, target *core.Dogu, state *doguUpgradeState) error {
+	_, err := du.reg.DoguRegistry().GetVersionContext(ctx, target.GetSimpleName(), target.Version)
+	if err != nil && !registry.IsKeyNotFoundError(err) {
+		return fmt.Errorf("failed to check if version %s of dogu %s is registered: %w", target.Version, target.Name, err)
+	}
+	// the rollback unregisters the target version also if the registration fails after a part of it was written
+	state.registeredTarget = err != nil
+
+	err = du.reg.DoguRegistry().RegisterContext(ctx, target)
+	if err != nil {
+		return fmt.Errorf("failed to register dogu %s: %w", target.Name, err)
+	}
+
+	err = du.reg.DoguRegistry().EnableContext(ctx, target)
+	if err != nil {
+		return fmt.Errorf("failed to enable dogu %s: %w", target.Name, err)
+	}
+	return nil
+}
+
+// unregisterDogu enables the installed version again and removes the target version if the upgrade registered it.
+func (du *DoguUpgrader) unregisterDogu(ctx context.Context, installed *core.Dogu, target *core.Dogu, state *doguUpgradeState) error {
+	err := du.reg.DoguRegistry().EnableVersionContext(ctx, installed.GetSimpleName(), installed.Version)
+	if err != nil {
+		return fmt.Errorf("failed to enable installed version %s of dogu %s: %w", installed.Version, installed.Name, err)
+	}
+	if !state.registeredTarget {
+		return nil
+	}
+
+	err = du.reg.DoguRegistry().UnregisterVersionContext(ctx, target.GetSimpleName(), target.Version)
+	if err != nil {
+		return fmt.Errorf("failed to unregister target version %s of dogu %s: %w", target.Version, target.Name, err)
+	}
+	return nil
+}
+
+// activateMaintenanceMode remembers the active maintenance mode, e.g. one of an administrator, and replaces it with
+// the maintenance mode of the upgrade.
+func (du *DoguUpgrader) activateMaintenanceMode(plan *DoguUpgradePlan, state *doguUpgradeState) error {
+	previous, _, err := GetMaintenanceMode(du.reg)
+	if err != nil {
+		return err
+	}
+	state.previousMaintenanceMode = previous
+
+	return SetMaintenanceMode(du.maintenanceMode(plan), du.reg)
+}
+
+// restoreMaintenanceMode activates the maintenance mode which was active before the upgrade again. The maintenance
+// mode is deactivated if there was none or if its planned end has passed during the upgrade.
+func (du *DoguUpgrader) restoreMaintenanceMode(state *doguUpgradeState) error {
+	previous := state.previousMaintenanceMode
+	if previous == nil || (previous.PlannedEnd != nil && !previous.PlannedEnd.After(time.Now())) {
+		return DeactivateMaintenanceMode(du.reg)
+	}
+
+	return SetMaintenanceMode(*previous, du.reg)
+}
+
+func (du *DoguUpgrader) maintenanceMode(plan *DoguUpgradePlan) MaintenanceMode {
+	if du.options.MaintenanceMode != nil {
+		return *du.options.MaintenanceMode
+	}
+
+	return MaintenanceMode{
+		Title:         defaultMaintenanceTitle,
+		Text:          fmt.Sprintf("The dogu %s is upgraded from version %s to %s.", plan.Target.GetSimpleName(), plan.Installed.Version, plan.Target.Version),
+		Initiator:     doguUpgradeProcessName,
+		AffectedDogus: []string{plan.Target.GetSimpleName()},
+	}
+}
+
+// rollback reverts the given finished steps in reverse order and continues if a step cannot be reverted.
+func (du *DoguUpgrader) rollback(plan *DoguUpgradePlan, finished []DoguUpgradeStep, state *doguUpgradeState) error {
+	// the rollback has to be executed even if the upgrade was canceled
+	ctx := context.Background()
+
+	var problems error
+	for i := len(finished) - 1; i >= 0; i-- {
+		step := finished[i]
+		action, err := du.newAction(plan, step, state)
+		if err != nil || action.rollback == nil {
+			continue
+		}
+
+		err = action.rollback(ctx)
+		if err != nil {
+			du.report(plan, step, DoguUpgradeStatusRollbackFailed, "", err)
+			problems = multierror.Append(problems, fmt.Errorf("failed to roll back step %s: %w", step, err))
+			continue
+		}
+		du.report(plan, step, DoguUpgradeStatusRolledBack, "", nil)
+	}
+
+	return problems
+}
+
+func (du *DoguUpgrader) report(plan *DoguUpgradePlan, step DoguUpgradeStep, status DoguUpgradeStatus, output string, err error) {
+	if status == DoguUpgradeStatusFailed || status == DoguUpgradeStatusRollbackFailed {
+		log.Errorf("dogu upgrade of %s: step %s %s: %v", plan.Target.Name, step, status, err)
+	} else {
+		log.Infof("dogu upgrade of %s: step %s %s", plan.Target.Name, step, status)
+	}
+
+	if du.options.OnEvent != nil {
+		du.options.OnEvent(DoguUpgradeEvent{
+			Dogu:   plan.Target.Name,
+			Step:   step,
+			Status: status,
+			Output: output,
+			Err:    err,
+			Time:   time.Now(),
+		})
+	}
+}
+
+// isDoguUpgradeCleanup returns true for the steps which are executed after the dogu was upgraded.
+func isDoguUpgradeCleanup(step DoguUpgradeStep) bool {
+	return step == DoguUpgradeStepStopCriticalSystemState || step == DoguUpgradeStepDeactivateMaintenanceMode
+}
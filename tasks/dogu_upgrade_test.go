@@ -0,0 +1,448 @@
+package tasks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/cloudogu/cesapp-lib/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type runnerCall struct {
+	dogu    string
+	command string
+	args    []string
+}
+
+// fakeCommandRunner records the executed commands and fails for the command with the name failOn.
+type fakeCommandRunner struct {
+	calls  []runnerCall
+	failOn string
+}
+
+func (fcr *fakeCommandRunner) Run(_ context.Context, dogu *core.Dogu, command *core.ExposedCommand, args ...string) (string, error) {
+	fcr.calls = append(fcr.calls, runnerCall{dogu: dogu.Name + ":" + dogu.Version, command: command.Name, args: args})
+	if command.Name == fcr.failOn {
+		return "", assert.AnError
+	}
+	return "output of " + command.Name, nil
+}
+
+// failingEnableRegistry is a registry whose dogu registry cannot enable dogus.
+type failingEnableRegistry struct {
+	registry.Registry
+}
+
+func (fer *failingEnableRegistry) DoguRegistry() registry.DoguRegistry {
+	return &failingEnableDoguRegistry{DoguRegistry: fer.Registry.DoguRegistry()}
+}
+
+type failingEnableDoguRegistry struct {
+	registry.DoguRegistry
+}
+
+func (fedr *failingEnableDoguRegistry) EnableContext(context.Context, *core.Dogu) error {
+	return assert.AnError
+}
+
+func newUpgradeTestDogu(version string, commands ...string) *core.Dogu {
+	dogu := &core.Dogu{Name: "official/ldap", Version: version}
+	for _, command := range commands {
+		dogu.ExposedCommands = append(dogu.ExposedCommands, core.ExposedCommand{Name: command, Command: "/" + command + ".sh"})
+	}
+	return dogu
+}
+
+func newUpgradeTestRegistry(t *testing.T, dogus ...*core.Dogu) registry.Registry {
+	reg := registry.NewInMemoryRegistry()
+	for _, dogu := range dogus {
+		require.NoError(t, reg.DoguRegistry().Register(dogu))
+		require.NoError(t, reg.DoguRegistry().Enable(dogu))
+	}
+	return reg
+}
+
+func collectUpgradeEvents(events *[]DoguUpgradeEvent) DoguUpgradeOptions {
+	return DoguUpgradeOptions{OnEvent: func(event DoguUpgradeEvent) {
+		*events = append(*events, event)
+	}}
+}
+
+func eventStatuses(events []DoguUpgradeEvent) []string {
+	var statuses []string
+	for _, event := range events {
+		statuses = append(statuses, string(event.Step)+" "+string(event.Status))
+	}
+	return statuses
+}
+
+func TestPlanDoguUpgrade(t *testing.T) {
+	t.Run("should plan all steps", func(t *testing.T) {
+		// given
+		installed := newUpgradeTestDogu("2.4.0-1")
+		target := newUpgradeTestDogu("2.5.0-1", core.ExposedCommandUpgradeNotification, core.ExposedCommandPreUpgrade, core.ExposedCommandPostUpgrade)
+
+		// when
+		plan, err := PlanDoguUpgrade(installed, target)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []DoguUpgradeStep{
+			DoguUpgradeStepCheckDependencies,
+			DoguUpgradeStepUpgradeNotification,
+			DoguUpgradeStepActivateMaintenanceMode,
+			DoguUpgradeStepStartCriticalSystemState,
+			DoguUpgradeStepPreUpgrade,
+			DoguUpgradeStepRegisterDogu,
+			DoguUpgradeStepPostUpgrade,
+			DoguUpgradeStepStopCriticalSystemState,
+			DoguUpgradeStepDeactivateMaintenanceMode,
+		}, plan.Steps)
+	})
+
+	t.Run("should skip commands which are not defined by the target version", func(t *testing.T) {
+		// given
+		installed := newUpgradeTestDogu("2.4.0-1", core.ExposedCommandPreUpgrade)
+		target := newUpgradeTestDogu("2.5.0-1")
+
+		// when
+		plan, err := PlanDoguUpgrade(installed, target)
+
+		// then
+		require.NoError(t, err)
+		assert.NotContains(t, plan.Steps, DoguUpgradeStepPreUpgrade)
+		assert.NotContains(t, plan.Steps, DoguUpgradeStepUpgradeNotification)
+		assert.NotContains(t, plan.Steps, DoguUpgradeStepPostUpgrade)
+	})
+
+	t.Run("should fail if the target version is not newer", func(t *testing.T) {
+		_, err := PlanDoguUpgrade(newUpgradeTestDogu("2.5.0-1"), newUpgradeTestDogu("2.5.0-1"))
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the target version 2.5.0-1 of dogu official/ldap is not newer than the installed version 2.5.0-1")
+	})
+
+	t.Run("should fail for another dogu", func(t *testing.T) {
+		_, err := PlanDoguUpgrade(newUpgradeTestDogu("2.4.0-1"), &core.Dogu{Name: "official/cas", Version: "7.0.0-1"})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot upgrade dogu official/ldap to another dogu official/cas")
+	})
+
+	t.Run("should fail on invalid version", func(t *testing.T) {
+		_, err := PlanDoguUpgrade(newUpgradeTestDogu("2.4.0-1"), newUpgradeTestDogu("latest"))
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse version of target dogu official/ldap")
+	})
+}
+
+func TestDoguUpgrader_Upgrade(t *testing.T) {
+	t.Run("should upgrade the dogu", func(t *testing.T) {
+		// given
+		installed := newUpgradeTestDogu("2.4.0-1")
+		target := newUpgradeTestDogu("2.5.0-1", core.ExposedCommandUpgradeNotification, core.ExposedCommandPreUpgrade, core.ExposedCommandPostUpgrade)
+		reg := newUpgradeTestRegistry(t, installed)
+		plan, err := PlanDoguUpgrade(installed, target)
+		require.NoError(t, err)
+		runner := &fakeCommandRunner{}
+		var events []DoguUpgradeEvent
+		var maintenanceDuringPreUpgrade *MaintenanceMode
+		var criticalDuringPreUpgrade bool
+		options := collectUpgradeEvents(&events)
+		onEvent := options.OnEvent
+		options.OnEvent = func(event DoguUpgradeEvent) {
+			if event.Step == DoguUpgradeStepPreUpgrade && event.Status == DoguUpgradeStatusStarted {
+				maintenanceDuringPreUpgrade, _, _ = GetMaintenanceMode(reg)
+				_, criticalDuringPreUpgrade, _ = NewCriticalSystemState(reg, "test").Current()
+			}
+			onEvent(event)
+		}
+		sut := NewDoguUpgrader(reg, runner, options)
+
+		// when
+		err = sut.Upgrade(context.Background(), plan)
+
+		// then
+		require.NoError(t, err)
+		enabled, err := reg.DoguRegistry().Get("ldap")
+		require.NoError(t, err)
+		assert.Equal(t, "2.5.0-1", enabled.Version)
+		assert.Equal(t, []runnerCall{
+			{dogu: "official/ldap:2.5.0-1", command: core.ExposedCommandUpgradeNotification, args: []string{"2.4.0-1", "2.5.0-1"}},
+			{dogu: "official/ldap:2.4.0-1", command: core.ExposedCommandPreUpgrade, args: []string{"2.4.0-1", "2.5.0-1"}},
+			{dogu: "official/ldap:2.5.0-1", command: core.ExposedCommandPostUpgrade, args: []string{"2.4.0-1", "2.5.0-1"}},
+		}, runner.calls)
+
+		require.NotNil(t, maintenanceDuringPreUpgrade)
+		assert.Equal(t, "The dogu ldap is upgraded from version 2.4.0-1 to 2.5.0-1.", maintenanceDuringPreUpgrade.Text)
+		assert.Equal(t, []string{"ldap"}, maintenanceDuringPreUpgrade.AffectedDogus)
+		assert.True(t, criticalDuringPreUpgrade)
+		_, active, err := GetMaintenanceMode(reg)
+		require.NoError(t, err)
+		assert.False(t, active)
+		_, critical, err := NewCriticalSystemState(reg, "test").Current()
+		require.NoError(t, err)
+		assert.False(t, critical)
+
+		require.Len(t, events, 2*len(plan.Steps))
+		assert.Equal(t, "official/ldap", events[0].Dogu)
+		assert.Equal(t, "check-dependencies started", eventStatuses(events)[0])
+		assert.Equal(t, "upgrade-notification finished", eventStatuses(events)[3])
+		assert.Equal(t, "output of upgrade-notification", events[3].Output)
+	})
+
+	t.Run("should use the given maintenance mode", func(t *testing.T) {
+		// given
+		installed := newUpgradeTestDogu("2.4.0-1")
+		target := newUpgradeTestDogu("2.5.0-1", core.ExposedCommandPreUpgrade)
+		reg := newUpgradeTestRegistry(t, installed)
+		plan, err := PlanDoguUpgrade(installed, target)
+		require.NoError(t, err)
+		var text string
+		runner := &fakeCommandRunner{}
+		sut := NewDoguUpgrader(reg, runner, DoguUpgradeOptions{
+			MaintenanceMode: &MaintenanceMode{Title: "Upgrade", Text: "The ldap is upgraded"},
+			OnEvent: func(event DoguUpgradeEvent) {
+				if event.Step == DoguUpgradeStepPreUpgrade && event.Status == DoguUpgradeStatusStarted {
+					mode, _, _ := GetMaintenanceMode(reg)
+					text = mode.Text
+				}
+			},
+		})
+
+		// when
+		err = sut.Upgrade(context.Background(), plan)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "The ldap is upgraded", text)
+	})
+
+	t.Run("should roll back if the post-upgrade fails", func(t *testing.T) {
+		// given
+		installed := newUpgradeTestDogu("2.4.0-1")
+		target := newUpgradeTestDogu("2.5.0-1", core.ExposedCommandPreUpgrade, core.ExposedCommandPostUpgrade)
+		reg := newUpgradeTestRegistry(t, installed)
+		plan, err := PlanDoguUpgrade(installed, target)
+		require.NoError(t, err)
+		var events []DoguUpgradeEvent
+		sut := NewDoguUpgrader(reg, &fakeCommandRunner{failOn: core.ExposedCommandPostUpgrade}, collectUpgradeEvents(&events))
+
+		// when
+		err = sut.Upgrade(context.Background(), plan)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, err.Error(), "failed to upgrade dogu official/ldap in step post-upgrade")
+		enabled, err := reg.DoguRegistry().Get("ldap")
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.0-1", enabled.Version)
+		versions, err := reg.DoguRegistry().GetVersions("ldap")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		assert.Equal(t, "2.4.0-1", versions[0].Raw)
+		_, active, err := GetMaintenanceMode(reg)
+		require.NoError(t, err)
+		assert.False(t, active)
+		_, critical, err := NewCriticalSystemState(reg, "test").Current()
+		require.NoError(t, err)
+		assert.False(t, critical)
+		assert.Equal(t, []string{
+			"check-dependencies started",
+			"check-dependencies finished",
+			"activate-maintenance-mode started",
+			"activate-maintenance-mode finished",
+			"start-critical-system-state started",
+			"start-critical-system-state finished",
+			"pre-upgrade started",
+			"pre-upgrade finished",
+			"register-dogu started",
+			"register-dogu finished",
+			"post-upgrade started",
+			"post-upgrade failed",
+			"register-dogu rolled-back",
+			"start-critical-system-state rolled-back",
+			"activate-maintenance-mode rolled-back",
+		}, eventStatuses(events))
+	})
+
+	t.Run("should not start if another critical process is running", func(t *testing.T) {
+		// given
+		installed := newUpgradeTestDogu("2.4.0-1")
+		target := newUpgradeTestDogu("2.5.0-1", core.ExposedCommandPreUpgrade)
+		reg := newUpgradeTestRegistry(t, installed)
+		backup := NewCriticalSystemState(reg, "backup")
+		require.NoError(t, backup.Start(context.Background()))
+		defer func() { _ = backup.Stop() }()
+		plan, err := PlanDoguUpgrade(installed, target)
+		require.NoError(t, err)
+		runner := &fakeCommandRunner{}
+		sut := NewDoguUpgrader(reg, runner, DoguUpgradeOptions{})
+
+		// when
+		err = sut.Upgrade(context.Background(), plan)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "there is already a critical process running: 'backup'")
+		assert.Empty(t, runner.calls)
+		_, active, err := GetMaintenanceMode(reg)
+		require.NoError(t, err)
+		assert.False(t, active)
+		current, _, err := backup.Current()
+		require.NoError(t, err)
+		assert.Equal(t, "backup", current.SystemProcess)
+	})
+
+	t.Run("should fail on unmet dependencies", func(t *testing.T) {
+		// given
+		installed := newUpgradeTestDogu("2.4.0-1")
+		target := newUpgradeTestDogu("2.5.0-1")
+		target.Dependencies = []core.Dependency{{Type: core.DependencyTypeDogu, Name: "postfix"}}
+		cas := &core.Dogu{Name: "official/cas", Version: "7.0.0-1",
+			Dependencies: []core.Dependency{{Type: core.DependencyTypeDogu, Name: "ldap", Version: "<2.5.0"}}}
+		reg := newUpgradeTestRegistry(t, installed, cas)
+		plan, err := PlanDoguUpgrade(installed, target)
+		require.NoError(t, err)
+		var events []DoguUpgradeEvent
+		sut := NewDoguUpgrader(reg, &fakeCommandRunner{}, collectUpgradeEvents(&events))
+
+		// when
+		err = sut.Upgrade(context.Background(), plan)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to resolve dependencies postfix")
+		assert.Contains(t, err.Error(), "dogu official/cas does not accept the target version")
+		assert.Equal(t, []string{"check-dependencies started", "check-dependencies failed"}, eventStatuses(events))
+		enabled, err := reg.DoguRegistry().Get("ldap")
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.0-1", enabled.Version)
+	})
+
+	t.Run("should restore the maintenance mode of the administrator", func(t *testing.T) {
+		for _, failOn := range []string{"", core.ExposedCommandPostUpgrade} {
+			// given
+			installed := newUpgradeTestDogu("2.4.0-1")
+			target := newUpgradeTestDogu("2.5.0-1", core.ExposedCommandPostUpgrade)
+			reg := newUpgradeTestRegistry(t, installed)
+			require.NoError(t, SetMaintenanceMode(MaintenanceMode{Title: "Backup", Text: "The ecosystem is backed up"}, reg))
+			plan, err := PlanDoguUpgrade(installed, target)
+			require.NoError(t, err)
+			sut := NewDoguUpgrader(reg, &fakeCommandRunner{failOn: failOn}, DoguUpgradeOptions{})
+
+			// when
+			err = sut.Upgrade(context.Background(), plan)
+
+			// then
+			assert.Equal(t, failOn != "", err != nil)
+			mode, active, err := GetMaintenanceMode(reg)
+			require.NoError(t, err)
+			require.True(t, active)
+			assert.Equal(t, "The ecosystem is backed up", mode.Text)
+		}
+	})
+
+	t.Run("should keep a target version which was registered before", func(t *testing.T) {
+		// given
+		installed := newUpgradeTestDogu("2.4.0-1")
+		target := newUpgradeTestDogu("2.5.0-1", core.ExposedCommandPostUpgrade)
+		reg := newUpgradeTestRegistry(t, installed)
+		require.NoError(t, reg.DoguRegistry().Register(target))
+		plan, err := PlanDoguUpgrade(installed, target)
+		require.NoError(t, err)
+		sut := NewDoguUpgrader(reg, &fakeCommandRunner{failOn: core.ExposedCommandPostUpgrade}, DoguUpgradeOptions{})
+
+		// when
+		err = sut.Upgrade(context.Background(), plan)
+
+		// then
+		require.Error(t, err)
+		enabled, err := reg.DoguRegistry().Get("ldap")
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.0-1", enabled.Version)
+		versions, err := reg.DoguRegistry().GetVersions("ldap")
+		require.NoError(t, err)
+		assert.Len(t, versions, 2)
+	})
+
+	t.Run("should unregister the target version if it cannot be enabled", func(t *testing.T) {
+		// given
+		installed := newUpgradeTestDogu("2.4.0-1")
+		target := newUpgradeTestDogu("2.5.0-1")
+		reg := newUpgradeTestRegistry(t, installed)
+		plan, err := PlanDoguUpgrade(installed, target)
+		require.NoError(t, err)
+		var events []DoguUpgradeEvent
+		sut := NewDoguUpgrader(&failingEnableRegistry{Registry: reg}, &fakeCommandRunner{}, collectUpgradeEvents(&events))
+
+		// when
+		err = sut.Upgrade(context.Background(), plan)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, err.Error(), "failed to upgrade dogu official/ldap in step register-dogu")
+		enabled, err := reg.DoguRegistry().Get("ldap")
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.0-1", enabled.Version)
+		versions, err := reg.DoguRegistry().GetVersions("ldap")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		assert.Equal(t, "2.4.0-1", versions[0].Raw)
+		assert.Contains(t, eventStatuses(events), "register-dogu rolled-back")
+	})
+
+	t.Run("should leave the critical system state if the upgrade is canceled", func(t *testing.T) {
+		// given
+		installed := newUpgradeTestDogu("2.4.0-1")
+		target := newUpgradeTestDogu("2.5.0-1", core.ExposedCommandPostUpgrade)
+		reg := newUpgradeTestRegistry(t, installed)
+		plan, err := PlanDoguUpgrade(installed, target)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var events []DoguUpgradeEvent
+		options := collectUpgradeEvents(&events)
+		onEvent := options.OnEvent
+		options.OnEvent = func(event DoguUpgradeEvent) {
+			if event.Step == DoguUpgradeStepPostUpgrade && event.Status == DoguUpgradeStatusStarted {
+				cancel()
+				// give the critical system state time to notice the canceled context
+				time.Sleep(50 * time.Millisecond)
+			}
+			onEvent(event)
+		}
+		sut := NewDoguUpgrader(reg, &fakeCommandRunner{failOn: core.ExposedCommandPostUpgrade}, options)
+
+		// when
+		err = sut.Upgrade(ctx, plan)
+
+		// then
+		require.Error(t, err)
+		assert.NotContains(t, eventStatuses(events), "start-critical-system-state rollback-failed")
+		assert.Contains(t, eventStatuses(events), "start-critical-system-state rolled-back")
+		_, critical, err := NewCriticalSystemState(reg, "test").Current()
+		require.NoError(t, err)
+		assert.False(t, critical)
+	})
+
+	t.Run("should fail without plan", func(t *testing.T) {
+		// given
+		sut := NewDoguUpgrader(registry.NewInMemoryRegistry(), &fakeCommandRunner{}, DoguUpgradeOptions{})
+
+		// when
+		errNil := sut.Upgrade(context.Background(), nil)
+		errEmpty := sut.Upgrade(context.Background(), &DoguUpgradePlan{})
+
+		// then
+		require.Error(t, errNil)
+		assert.Contains(t, errNil.Error(), "a plan with the installed and the target dogu is required")
+		require.Error(t, errEmpty)
+	})
+}
@@ -1,7 +1,11 @@
 package tasks
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/cloudogu/cesapp-lib/core"
 	"github.com/cloudogu/cesapp-lib/registry"
@@ -14,6 +18,47 @@ const activateMaintenanceModeDescription = "Activate maintenance mode"
 
 var log = core.GetLogger()
 
+// MaintenanceMode describes the maintenance mode which is presented to the users while the ecosystem is maintained.
+// It is stored as JSON in the global configuration.
+type MaintenanceMode struct {
+	// Title is the title of the maintenance message. It must not be empty.
+	Title string `json:"title"`
+	// Text is the maintenance message. It must not be empty.
+	Text string `json:"text"`
+	// Start is the time at which the maintenance mode starts. It is the time of the activation if it is not set.
+	Start time.Time `json:"start"`
+	// PlannedEnd is the time at which the maintenance mode ends automatically. The maintenance mode has to be
+	// deactivated explicitly if it is not set.
+	PlannedEnd *time.Time `json:"plannedEnd,omitempty"`
+	// Initiator describes who activated the maintenance mode, e.g. a user or a process.
+	Initiator string `json:"initiator,omitempty"`
+	// AffectedDogus contains the names of the dogus which are maintained. The whole ecosystem is maintained if it
+	// is empty.
+	AffectedDogus []string `json:"affectedDogus,omitempty"`
+}
+
+// MaintenanceModeEvent describes the start or the end of the maintenance mode.
+type MaintenanceModeEvent struct {
+	// Active is true if the maintenance mode was activated or changed and false if it ended.
+	Active bool
+	// Mode is the active maintenance mode. It is nil if the maintenance mode ended.
+	Mode *MaintenanceMode
+}
+
+// Validate checks if the maintenance mode can be activated.
+func (mm MaintenanceMode) Validate() error {
+	if mm.Text == "" {
+		return fmt.Errorf("could not activate maintenance mode. Message text is missing")
+	}
+	if mm.Title == "" {
+		return fmt.Errorf("could not activate maintenance mode. Message title is missing")
+	}
+	if mm.PlannedEnd != nil && !mm.PlannedEnd.After(mm.Start) {
+		return fmt.Errorf("could not activate maintenance mode. The planned end has to be after the start")
+	}
+	return nil
+}
+
 // ActivateMaintenanceMode activates the maintenance mode in the registry. The given message text will be presented
 // to the user. The message text must not be empty.
 func ActivateMaintenanceMode(messageText string, registry registry.Registry) error {
@@ -23,25 +68,157 @@ func ActivateMaintenanceMode(messageText string, registry registry.Registry) err
 // ActivateMaintenanceModeWithTitle activates the maintenance mode in the registry. The given message text will be presented
 // to the user. The message text must not be empty. The given title will be presented to the user. The title must not be empty.
 func ActivateMaintenanceModeWithTitle(messageText string, title string, registry registry.Registry) error {
-	if messageText == "" {
-		return fmt.Errorf("could not activate maintenance mode. Message text is missing")
+	return SetMaintenanceMode(MaintenanceMode{Title: title, Text: messageText}, registry)
+}
+
+// SetMaintenanceMode activates the given maintenance mode in the registry or replaces the active one. If the
+// maintenance mode has a planned end, it is removed from the registry automatically at that time. Maintenance modes
+// which start in the future have to be activated with ScheduleMaintenanceMode.
+func SetMaintenanceMode(mode MaintenanceMode, registry registry.Registry) error {
+	now := time.Now()
+	if mode.Start.IsZero() {
+		mode.Start = now
 	}
-	if title == "" {
-		return fmt.Errorf("could not activate maintenance mode. Message title is missing")
+
+	err := mode.Validate()
+	if err != nil {
+		return err
+	}
+	if mode.Start.After(now) {
+		return fmt.Errorf("could not activate maintenance mode. It starts at %s, use ScheduleMaintenanceMode instead", mode.Start.Format(time.RFC3339))
 	}
 
 	log.Info(activateMaintenanceModeDescription)
 
-	json := fmt.Sprintf("{\"title\": \"%s\", "+
-		"\"text\": \"%s\"}", title, messageText)
+	data, err := json.Marshal(mode)
+	if err != nil {
+		return fmt.Errorf("failed to serialize maintenance mode: %w", err)
+	}
 
-	err := registry.GlobalConfig().Set(MaintenanceRegistryKey, json)
+	if mode.PlannedEnd == nil {
+		err = registry.GlobalConfig().Set(MaintenanceRegistryKey, string(data))
+	} else {
+		remaining := mode.PlannedEnd.Sub(now)
+		if remaining <= 0 {
+			return fmt.Errorf("could not activate maintenance mode. The planned end %s is in the past", mode.PlannedEnd.Format(time.RFC3339))
+		}
+		err = registry.GlobalConfig().SetWithLifetime(MaintenanceRegistryKey, string(data), int(math.Ceil(remaining.Seconds())))
+	}
 	if err != nil {
 		return fmt.Errorf("failed to activate maintenance mode: %w", err)
 	}
 	return nil
 }
 
+// ScheduleMaintenanceMode waits until the start of the given maintenance mode and activates it. It blocks until the
+// maintenance mode is activated or the given context is done, so it is usually called in its own goroutine.
+func ScheduleMaintenanceMode(ctx context.Context, mode MaintenanceMode, registry registry.Registry) error {
+	err := mode.Validate()
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(time.Until(mode.Start))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("scheduled maintenance mode was not activated: %w", ctx.Err())
+	case <-timer.C:
+	}
+
+	return SetMaintenanceMode(mode, registry)
+}
+
+// GetMaintenanceMode returns the active maintenance mode. It returns false if the maintenance mode is not active.
+func GetMaintenanceMode(registry registry.Registry) (*MaintenanceMode, bool, error) {
+	exists, value, err := registry.GlobalConfig().GetOrFalse(MaintenanceRegistryKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get maintenance mode: %w", err)
+	}
+	if !exists {
+		return nil, false, nil
+	}
+
+	mode, err := parseMaintenanceMode(value)
+	if err != nil {
+		return nil, false, err
+	}
+	return mode, true, nil
+}
+
+// WatchMaintenanceMode watches the maintenance mode until the given context is done, e.g. to show the maintenance
+// message as soon as it is activated. Every activation, change and end of the maintenance mode is sent through the
+// returned event channel. Errors of the watch and invalid maintenance modes are sent through the returned error
+// channel. Both channels have to be consumed and are closed when the context is done.
+func WatchMaintenanceMode(ctx context.Context, reg registry.Registry) (<-chan MaintenanceModeEvent, <-chan error) {
+	key := "/config/" + registry.DirectoryGlobal + "/" + MaintenanceRegistryKey
+	watchEvents, watchErrors := reg.RootConfig().WatchEvents(ctx, key, false)
+
+	events := make(chan MaintenanceModeEvent)
+	errs := make(chan error)
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		for watchEvents != nil || watchErrors != nil {
+			select {
+			case watchEvent, ok := <-watchEvents:
+				if !ok {
+					watchEvents = nil
+					continue
+				}
+
+				event, err := newMaintenanceModeEvent(watchEvent)
+				if err != nil {
+					sendMaintenanceModeWatchError(ctx, errs, err)
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+				}
+			case err, ok := <-watchErrors:
+				if !ok {
+					watchErrors = nil
+					continue
+				}
+				sendMaintenanceModeWatchError(ctx, errs, fmt.Errorf("failed to watch maintenance mode: %w", err))
+			}
+		}
+	}()
+
+	return events, errs
+}
+
+func newMaintenanceModeEvent(watchEvent registry.WatchEvent) (MaintenanceModeEvent, error) {
+	if watchEvent.Action == registry.WatchActionDelete || watchEvent.Action == registry.WatchActionExpire {
+		return MaintenanceModeEvent{Active: false}, nil
+	}
+
+	mode, err := parseMaintenanceMode(watchEvent.NewValue)
+	if err != nil {
+		return MaintenanceModeEvent{}, err
+	}
+	return MaintenanceModeEvent{Active: true, Mode: mode}, nil
+}
+
+func sendMaintenanceModeWatchError(ctx context.Context, errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	case <-ctx.Done():
+	}
+}
+
+func parseMaintenanceMode(value string) (*MaintenanceMode, error) {
+	mode := &MaintenanceMode{}
+	err := json.Unmarshal([]byte(value), mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse maintenance mode: %w", err)
+	}
+	return mode, nil
+}
+
 // DeactivateMaintenanceMode deactivates the maintenance mode in the registry if it exists.
 // This function will not return an error if the key is not present in the registry.
 func DeactivateMaintenanceMode(registry registry.Registry) error {
@@ -1,20 +1,34 @@
 package tasks
 
 import (
-	"github.com/cloudogu/cesapp-lib/registry/mocks"
+	"context"
+	"encoding/json"
 	"testing"
+	"time"
+
+	"github.com/cloudogu/cesapp-lib/registry"
+	"github.com/cloudogu/cesapp-lib/registry/mocks"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
+// maintenanceModeWith matches serialized maintenance modes with the given title and text.
+func maintenanceModeWith(title string, text string) interface{} {
+	return mock.MatchedBy(func(value string) bool {
+		mode := MaintenanceMode{}
+		return json.Unmarshal([]byte(value), &mode) == nil && mode.Title == title && mode.Text == text
+	})
+}
+
 func TestActivateMaintenanceMode(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		// given
 		reg := mocks.NewRegistry(t)
 		globalConfig := mocks.NewConfigurationContext(t)
 		reg.On("GlobalConfig").Return(globalConfig)
-		globalConfig.On("Set", "maintenance", "{\"title\": \"Maintenance\", \"text\": \"ipsum lorem\"}").Return(nil)
+		globalConfig.On("Set", "maintenance", maintenanceModeWith("Maintenance", "ipsum lorem")).Return(nil)
 
 		// when
 		err := ActivateMaintenanceMode("ipsum lorem", reg)
@@ -28,7 +42,7 @@ func TestActivateMaintenanceMode(t *testing.T) {
 		reg := mocks.NewRegistry(t)
 		globalConfig := mocks.NewConfigurationContext(t)
 		reg.On("GlobalConfig").Return(globalConfig)
-		globalConfig.On("Set", "maintenance", "{\"title\": \"Maintenance\", \"text\": \"ipsum lorem\"}").Return(assert.AnError)
+		globalConfig.On("Set", "maintenance", maintenanceModeWith("Maintenance", "ipsum lorem")).Return(assert.AnError)
 
 		// when
 		err := ActivateMaintenanceMode("ipsum lorem", reg)
@@ -51,6 +65,22 @@ func TestActivateMaintenanceMode(t *testing.T) {
 		require.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Message title is missing")
 	})
+
+	t.Run("should escape quotes in title and text", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+
+		// when
+		err := ActivateMaintenanceModeWithTitle(`the "new" ldap`, `update of "ldap"`, reg)
+		require.NoError(t, err)
+
+		// then
+		mode, active, err := GetMaintenanceMode(reg)
+		require.NoError(t, err)
+		require.True(t, active)
+		assert.Equal(t, `update of "ldap"`, mode.Title)
+		assert.Equal(t, `the "new" ldap`, mode.Text)
+	})
 }
 
 func TestDeactivateMaintenanceMode(t *testing.T) {
@@ -59,7 +89,7 @@ func TestDeactivateMaintenanceMode(t *testing.T) {
 		reg := mocks.NewRegistry(t)
 		globalConfig := mocks.NewConfigurationContext(t)
 		reg.On("GlobalConfig").Return(globalConfig)
-		globalConfig.On("Set", "maintenance", "{\"title\": \"Maintenance\", \"text\": \"test message\"}").Return(nil)
+		globalConfig.On("Set", "maintenance", maintenanceModeWith("Maintenance", "test message")).Return(nil)
 		globalConfig.On("Exists", "maintenance").Return(true, nil)
 		globalConfig.On("Delete", "maintenance").Return(nil)
 		err := ActivateMaintenanceMode("test message", reg)
@@ -101,3 +131,254 @@ func TestDeactivateMaintenanceMode(t *testing.T) {
 		require.Nil(t, err)
 	})
 }
+
+func TestSetMaintenanceMode(t *testing.T) {
+	t.Run("should store all fields", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		start := time.Now().Add(-time.Minute).Truncate(time.Second)
+		plannedEnd := time.Now().Add(time.Hour).Truncate(time.Second)
+		mode := MaintenanceMode{
+			Title:         "Upgrade",
+			Text:          "The ldap is upgraded",
+			Start:         start,
+			PlannedEnd:    &plannedEnd,
+			Initiator:     "admin",
+			AffectedDogus: []string{"ldap", "cas"},
+		}
+
+		// when
+		err := SetMaintenanceMode(mode, reg)
+		require.NoError(t, err)
+
+		// then
+		actual, active, err := GetMaintenanceMode(reg)
+		require.NoError(t, err)
+		require.True(t, active)
+		assert.True(t, start.Equal(actual.Start))
+		require.NotNil(t, actual.PlannedEnd)
+		assert.True(t, plannedEnd.Equal(*actual.PlannedEnd))
+		assert.Equal(t, "admin", actual.Initiator)
+		assert.Equal(t, []string{"ldap", "cas"}, actual.AffectedDogus)
+	})
+
+	t.Run("should set the start to now", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+
+		// when
+		err := SetMaintenanceMode(MaintenanceMode{Title: "Maintenance", Text: "test"}, reg)
+		require.NoError(t, err)
+
+		// then
+		actual, _, err := GetMaintenanceMode(reg)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), actual.Start, time.Minute)
+	})
+
+	t.Run("should expire at the planned end", func(t *testing.T) {
+		// given
+		reg := mocks.NewRegistry(t)
+		globalConfig := mocks.NewConfigurationContext(t)
+		reg.On("GlobalConfig").Return(globalConfig)
+		plannedEnd := time.Now().Add(90 * time.Minute)
+		globalConfig.On("SetWithLifetime", "maintenance", maintenanceModeWith("Maintenance", "test"), 5400).Return(nil)
+
+		// when
+		err := SetMaintenanceMode(MaintenanceMode{Title: "Maintenance", Text: "test", PlannedEnd: &plannedEnd}, reg)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should be removed after the planned end", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		plannedEnd := time.Now().Add(500 * time.Millisecond)
+
+		// when
+		err := SetMaintenanceMode(MaintenanceMode{Title: "Maintenance", Text: "test", PlannedEnd: &plannedEnd}, reg)
+		require.NoError(t, err)
+
+		// then
+		assert.Eventually(t, func() bool {
+			_, active, err := GetMaintenanceMode(reg)
+			return err == nil && !active
+		}, 3*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("should fail if the planned end is in the past", func(t *testing.T) {
+		// given
+		plannedEnd := time.Now().Add(-time.Minute)
+		mode := MaintenanceMode{Title: "Maintenance", Text: "test", Start: plannedEnd.Add(-time.Minute), PlannedEnd: &plannedEnd}
+
+		// when
+		err := SetMaintenanceMode(mode, nil)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is in the past")
+	})
+
+	t.Run("should fail if the planned end is before the start", func(t *testing.T) {
+		// given
+		plannedEnd := time.Now().Add(-time.Minute)
+
+		// when
+		err := SetMaintenanceMode(MaintenanceMode{Title: "Maintenance", Text: "test", PlannedEnd: &plannedEnd}, nil)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "The planned end has to be after the start")
+	})
+
+	t.Run("should fail if the maintenance mode starts in the future", func(t *testing.T) {
+		// given
+		mode := MaintenanceMode{Title: "Maintenance", Text: "test", Start: time.Now().Add(time.Hour)}
+
+		// when
+		err := SetMaintenanceMode(mode, nil)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "use ScheduleMaintenanceMode instead")
+	})
+}
+
+func TestScheduleMaintenanceMode(t *testing.T) {
+	t.Run("should activate the maintenance mode at the start", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		start := time.Now().Add(200 * time.Millisecond)
+
+		// when
+		err := ScheduleMaintenanceMode(context.Background(), MaintenanceMode{Title: "Maintenance", Text: "test", Start: start}, reg)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, time.Now().Before(start))
+		actual, active, err := GetMaintenanceMode(reg)
+		require.NoError(t, err)
+		require.True(t, active)
+		assert.True(t, start.Equal(actual.Start))
+	})
+
+	t.Run("should not activate the maintenance mode if the context is done", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		// when
+		err := ScheduleMaintenanceMode(ctx, MaintenanceMode{Title: "Maintenance", Text: "test", Start: time.Now().Add(time.Hour)}, reg)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		_, active, err := GetMaintenanceMode(reg)
+		require.NoError(t, err)
+		assert.False(t, active)
+	})
+
+	t.Run("should fail on invalid maintenance mode", func(t *testing.T) {
+		err := ScheduleMaintenanceMode(context.Background(), MaintenanceMode{Title: "Maintenance"}, nil)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Message text is missing")
+	})
+}
+
+func TestGetMaintenanceMode(t *testing.T) {
+	t.Run("should return false if the maintenance mode is not active", func(t *testing.T) {
+		_, active, err := GetMaintenanceMode(registry.NewInMemoryRegistry())
+
+		require.NoError(t, err)
+		assert.False(t, active)
+	})
+
+	t.Run("should read maintenance modes of older versions", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		require.NoError(t, reg.GlobalConfig().Set("maintenance", `{"title": "Maintenance", "text": "ipsum lorem"}`))
+
+		// when
+		mode, active, err := GetMaintenanceMode(reg)
+
+		// then
+		require.NoError(t, err)
+		require.True(t, active)
+		assert.Equal(t, "Maintenance", mode.Title)
+		assert.Equal(t, "ipsum lorem", mode.Text)
+		assert.True(t, mode.Start.IsZero())
+	})
+
+	t.Run("should fail on invalid json", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		require.NoError(t, reg.GlobalConfig().Set("maintenance", "ipsum lorem"))
+
+		// when
+		_, _, err := GetMaintenanceMode(reg)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse maintenance mode")
+	})
+}
+
+func TestWatchMaintenanceMode(t *testing.T) {
+	// given
+	reg := registry.NewInMemoryRegistry()
+	ctx, cancel := context.WithCancel(context.Background())
+	events, errs := WatchMaintenanceMode(ctx, reg)
+	// give the watcher time to subscribe
+	time.Sleep(10 * time.Millisecond)
+	nextEvent := func() MaintenanceModeEvent {
+		select {
+		case event := <-events:
+			return event
+		case err := <-errs:
+			t.Fatalf("unexpected error: %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("no event received")
+		}
+		return MaintenanceModeEvent{}
+	}
+
+	// when
+	require.NoError(t, ActivateMaintenanceMode("ipsum lorem", reg))
+
+	// then
+	event := nextEvent()
+	assert.True(t, event.Active)
+	require.NotNil(t, event.Mode)
+	assert.Equal(t, "ipsum lorem", event.Mode.Text)
+
+	// when
+	require.NoError(t, reg.GlobalConfig().Set("maintenance", "ipsum lorem"))
+
+	// then
+	select {
+	case err := <-errs:
+		assert.Contains(t, err.Error(), "failed to parse maintenance mode")
+	case <-time.After(2 * time.Second):
+		t.Fatal("no error received")
+	}
+
+	// when
+	require.NoError(t, DeactivateMaintenanceMode(reg))
+
+	// then
+	event = nextEvent()
+	assert.False(t, event.Active)
+	assert.Nil(t, event.Mode)
+
+	// when
+	cancel()
+
+	// then
+	for range events {
+	}
+	for range errs {
+	}
+}
@@ -1,16 +1,31 @@
 package tasks
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/cloudogu/cesapp-lib/core"
 	"github.com/cloudogu/cesapp-lib/registry"
 )
 
-// CreateProxySettings reads the proxy related settings from registry.
+const (
+	proxyEnabledKey  = "proxy/enabled"
+	proxyServerKey   = "proxy/server"
+	proxyPortKey     = "proxy/port"
+	proxyUsernameKey = "proxy/username"
+	proxyPasswordKey = "proxy/password"
+	proxySchemeKey   = "proxy/scheme"
+	proxyNoProxyKey  = "proxy/no_proxy"
+)
+
+// CreateProxySettings reads the proxy related settings from registry. Servers of older versions which contain the
+// scheme, e.g. https://proxy.example.com, are split into scheme and server.
 func CreateProxySettings(reg registry.Registry) (core.ProxySettings, error) {
 	settings := core.ProxySettings{}
 
 	configReader := registry.NewConfigurationReader(reg.GlobalConfig())
-	enabled, err := configReader.GetBool("proxy/enabled")
+	enabled, err := configReader.GetBool(proxyEnabledKey)
 	if err != nil {
 		return settings, err
 	}
@@ -18,30 +33,126 @@ func CreateProxySettings(reg registry.Registry) (core.ProxySettings, error) {
 	if enabled {
 		settings.Enabled = true
 
-		server, err := configReader.GetString("proxy/server")
+		server, err := configReader.GetString(proxyServerKey)
 		if err != nil {
 			return settings, err
 		}
 		settings.Server = server
 
-		port, err := configReader.GetInt("proxy/port")
+		port, err := configReader.GetInt(proxyPortKey)
 		if err != nil {
 			return settings, err
 		}
 		settings.Port = port
 
-		username, err := configReader.GetString("proxy/username")
+		username, err := configReader.GetString(proxyUsernameKey)
 		if err != nil {
 			return settings, err
 		}
 		settings.Username = username
 
-		password, err := configReader.GetString("proxy/password")
+		password, err := configReader.GetString(proxyPasswordKey)
 		if err != nil {
 			return settings, err
 		}
 		settings.Password = password
+
+		scheme, err := configReader.GetString(proxySchemeKey)
+		if err != nil {
+			return settings, err
+		}
+		settings.Scheme = scheme
+
+		noProxy, err := configReader.GetStringSlice(proxyNoProxyKey)
+		if err != nil {
+			return settings, err
+		}
+		if len(noProxy) > 0 {
+			settings.NoProxy = noProxy
+		}
+
+		migrateProxyServer(&settings)
 	}
 
 	return settings, nil
 }
+
+// migrateProxyServer moves the scheme of servers of older versions into the scheme of the settings.
+func migrateProxyServer(settings *core.ProxySettings) {
+	for _, scheme := range []string{"http", "https"} {
+		server, found := strings.CutPrefix(settings.Server, scheme+"://")
+		if found {
+			settings.Server = strings.TrimSuffix(server, "/")
+			if settings.Scheme == "" {
+				settings.Scheme = scheme
+			}
+			return
+		}
+	}
+}
+
+// StoreProxySettings validates the proxy settings and writes them to the registry, so that they can be read with
+// CreateProxySettings. The keys are written with a single batch, so that with etcd v3, the memory and the filesystem
+// registry either all keys are written or none of them. With etcd v2, other clients may read partially written
+// settings, see ConfigurationContext.ApplyBatch. The other keys are kept if the proxy is disabled.
+func StoreProxySettings(settings core.ProxySettings, reg registry.Registry) error {
+	err := settings.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to store proxy settings: %w", err)
+	}
+
+	changes := []registry.ConfigurationChange{
+		{Key: proxyEnabledKey, Value: strconv.FormatBool(settings.Enabled)},
+	}
+	if settings.Enabled {
+		changes = append(changes,
+			registry.ConfigurationChange{Key: proxyServerKey, Value: settings.Server},
+			registry.ConfigurationChange{Key: proxyPortKey, Value: strconv.Itoa(settings.Port)},
+			optionalProxyChange(proxyUsernameKey, settings.Username),
+			optionalProxyChange(proxyPasswordKey, settings.Password),
+			optionalProxyChange(proxySchemeKey, settings.Scheme),
+			optionalProxyChange(proxyNoProxyKey, strings.Join(settings.NoProxy, ",")),
+		)
+	}
+
+	err = reg.GlobalConfig().ApplyBatch(changes)
+	if err != nil {
+		return fmt.Errorf("failed to store proxy settings: %w", err)
+	}
+	return nil
+}
+
+// optionalProxyChange removes keys with empty values instead of storing empty values.
+func optionalProxyChange(key string, value string) registry.ConfigurationChange {
+	if value == "" {
+		return registry.ConfigurationChange{Key: key, Delete: true}
+	}
+	return registry.ConfigurationChange{Key: key, Value: value}
+}
+
+// MigrateProxySettings reads the proxy settings and stores them again in the format of this version, e.g. with the
+// scheme of the server in its own key. It returns false if the settings already had the current format.
+func MigrateProxySettings(reg registry.Registry) (bool, error) {
+	settings, err := CreateProxySettings(reg)
+	if err != nil {
+		return false, fmt.Errorf("failed to read proxy settings: %w", err)
+	}
+
+	if !settings.Enabled {
+		return false, nil
+	}
+
+	exists, stored, err := reg.GlobalConfig().GetOrFalse(proxyServerKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to read proxy server: %w", err)
+	}
+	if !exists || stored == settings.Server {
+		return false, nil
+	}
+
+	err = StoreProxySettings(settings, reg)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -1,6 +1,8 @@
 package tasks_test
 
 import (
+	"github.com/cloudogu/cesapp-lib/core"
+	"github.com/cloudogu/cesapp-lib/registry"
 	"github.com/cloudogu/cesapp-lib/registry/mocks"
 	"github.com/cloudogu/cesapp-lib/tasks"
 	"github.com/stretchr/testify/require"
@@ -24,6 +26,10 @@ func TestCreateProxySettings(t *testing.T) {
 		globalConfig.On("Get", "proxy/username").Return("bob", nil)
 		globalConfig.On("Exists", "proxy/password").Return(true, nil)
 		globalConfig.On("Get", "proxy/password").Return("bob123", nil)
+		globalConfig.On("Exists", "proxy/scheme").Return(true, nil)
+		globalConfig.On("Get", "proxy/scheme").Return("https", nil)
+		globalConfig.On("Exists", "proxy/no_proxy").Return(true, nil)
+		globalConfig.On("Get", "proxy/no_proxy").Return("localhost, .cloudogu.com", nil)
 
 		proxySettings, err := tasks.CreateProxySettings(reg)
 		assert.Nil(t, err)
@@ -32,6 +38,25 @@ func TestCreateProxySettings(t *testing.T) {
 		assert.Equal(t, 3864, proxySettings.Port)
 		assert.Equal(t, "bob", proxySettings.Username)
 		assert.Equal(t, "bob123", proxySettings.Password)
+		assert.Equal(t, "https", proxySettings.Scheme)
+		assert.Equal(t, []string{"localhost", ".cloudogu.com"}, proxySettings.NoProxy)
+	})
+
+	t.Run("should split the scheme of servers of older versions", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		require.NoError(t, reg.GlobalConfig().Set("proxy/enabled", "true"))
+		require.NoError(t, reg.GlobalConfig().Set("proxy/server", "https://proxy.cloudogu.com/"))
+		require.NoError(t, reg.GlobalConfig().Set("proxy/port", "3864"))
+
+		// when
+		proxySettings, err := tasks.CreateProxySettings(reg)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "proxy.cloudogu.com", proxySettings.Server)
+		assert.Equal(t, "https", proxySettings.Scheme)
+		assert.Nil(t, proxySettings.NoProxy)
 	})
 
 	t.Run("should return error on missing proxy server", func(t *testing.T) {
@@ -132,3 +157,139 @@ func TestCreateProxySettings(t *testing.T) {
 		assert.Contains(t, err.Error(), "assert.AnError general error for testing")
 	})
 }
+
+func TestStoreProxySettings(t *testing.T) {
+	t.Run("should store settings which can be read again", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		settings := core.ProxySettings{
+			Enabled:  true,
+			Server:   "proxy.cloudogu.com",
+			Port:     3864,
+			Username: "bob",
+			Password: "bob123",
+			Scheme:   "https",
+			NoProxy:  []string{"localhost", ".cloudogu.com"},
+		}
+
+		// when
+		err := tasks.StoreProxySettings(settings, reg)
+		require.NoError(t, err)
+
+		// then
+		actual, err := tasks.CreateProxySettings(reg)
+		require.NoError(t, err)
+		assert.Equal(t, settings, actual)
+	})
+
+	t.Run("should remove empty optional settings", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		require.NoError(t, reg.GlobalConfig().Set("proxy/username", "bob"))
+		require.NoError(t, reg.GlobalConfig().Set("proxy/password", "bob123"))
+		settings := core.ProxySettings{Enabled: true, Server: "proxy.cloudogu.com", Port: 3864}
+
+		// when
+		err := tasks.StoreProxySettings(settings, reg)
+		require.NoError(t, err)
+
+		// then
+		actual, err := tasks.CreateProxySettings(reg)
+		require.NoError(t, err)
+		assert.Equal(t, settings, actual)
+		exists, err := reg.GlobalConfig().Exists("proxy/password")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should only disable the proxy", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		require.NoError(t, tasks.StoreProxySettings(core.ProxySettings{Enabled: true, Server: "proxy.cloudogu.com", Port: 3864}, reg))
+
+		// when
+		err := tasks.StoreProxySettings(core.ProxySettings{}, reg)
+		require.NoError(t, err)
+
+		// then
+		actual, err := tasks.CreateProxySettings(reg)
+		require.NoError(t, err)
+		assert.False(t, actual.Enabled)
+		server, err := reg.GlobalConfig().Get("proxy/server")
+		require.NoError(t, err)
+		assert.Equal(t, "proxy.cloudogu.com", server)
+	})
+
+	t.Run("should not store invalid settings", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+
+		// when
+		err := tasks.StoreProxySettings(core.ProxySettings{Enabled: true, Server: "proxy.cloudogu.com"}, reg)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid proxy port 0")
+		exists, err := reg.GlobalConfig().Exists("proxy/enabled")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should return error on failing batch", func(t *testing.T) {
+		// given
+		reg := mocks.NewRegistry(t)
+		globalConfig := mocks.NewConfigurationContext(t)
+		reg.On("GlobalConfig").Return(globalConfig)
+		globalConfig.On("ApplyBatch", []registry.ConfigurationChange{{Key: "proxy/enabled", Value: "false"}}).Return(assert.AnError)
+
+		// when
+		err := tasks.StoreProxySettings(core.ProxySettings{}, reg)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "assert.AnError general error for testing")
+	})
+}
+
+func TestMigrateProxySettings(t *testing.T) {
+	t.Run("should store the scheme of the server in its own key", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		require.NoError(t, reg.GlobalConfig().Set("proxy/enabled", "true"))
+		require.NoError(t, reg.GlobalConfig().Set("proxy/server", "http://proxy.cloudogu.com"))
+		require.NoError(t, reg.GlobalConfig().Set("proxy/port", "3864"))
+
+		// when
+		migrated, err := tasks.MigrateProxySettings(reg)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, migrated)
+		server, err := reg.GlobalConfig().Get("proxy/server")
+		require.NoError(t, err)
+		assert.Equal(t, "proxy.cloudogu.com", server)
+		scheme, err := reg.GlobalConfig().Get("proxy/scheme")
+		require.NoError(t, err)
+		assert.Equal(t, "http", scheme)
+	})
+
+	t.Run("should not change settings of the current format", func(t *testing.T) {
+		// given
+		reg := registry.NewInMemoryRegistry()
+		require.NoError(t, tasks.StoreProxySettings(core.ProxySettings{Enabled: true, Server: "proxy.cloudogu.com", Port: 3864}, reg))
+
+		// when
+		migrated, err := tasks.MigrateProxySettings(reg)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, migrated)
+	})
+
+	t.Run("should not change disabled settings", func(t *testing.T) {
+		migrated, err := tasks.MigrateProxySettings(registry.NewInMemoryRegistry())
+
+		require.NoError(t, err)
+		assert.False(t, migrated)
+	})
+}